@@ -0,0 +1,252 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+)
+
+// New creates a new datapath API client.
+func New(transport runtime.ClientTransport, formats strfmt.Registry) ClientService {
+	return &Client{transport: transport, formats: formats}
+}
+
+/*
+Client for datapath API
+*/
+type Client struct {
+	transport runtime.ClientTransport
+	formats   strfmt.Registry
+}
+
+// ClientOption is the option for Client methods
+type ClientOption func(*runtime.ClientOperation)
+
+// ClientService is the interface for Client methods
+type ClientService interface {
+	GetDatapathIpsets(params *GetDatapathIpsetsParams, opts ...ClientOption) (*GetDatapathIpsetsOK, error)
+
+	GetDatapathIptables(params *GetDatapathIptablesParams, opts ...ClientOption) (*GetDatapathIptablesOK, error)
+
+	GetDatapathLayer2(params *GetDatapathLayer2Params, opts ...ClientOption) (*GetDatapathLayer2OK, error)
+
+	GetDatapathRoutes(params *GetDatapathRoutesParams, opts ...ClientOption) (*GetDatapathRoutesOK, error)
+
+	GetDatapathVxlan(params *GetDatapathVxlanParams, opts ...ClientOption) (*GetDatapathVxlanOK, error)
+
+	SetTransport(transport runtime.ClientTransport)
+}
+
+/*
+GetDatapathIpsets gets the ipsets
+
+the egress ipsets of the agent and their members
+*/
+func (a *Client) GetDatapathIpsets(params *GetDatapathIpsetsParams, opts ...ClientOption) (*GetDatapathIpsetsOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetDatapathIpsetsParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "GetDatapathIpsets",
+		Method:             "GET",
+		PathPattern:        "/datapath/ipsets",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetDatapathIpsetsReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*GetDatapathIpsetsOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for GetDatapathIpsets: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
+/*
+GetDatapathIptables gets the iptables chains
+
+the iptables chains and rules of the agent as expected by the agent and as read back from the node
+*/
+func (a *Client) GetDatapathIptables(params *GetDatapathIptablesParams, opts ...ClientOption) (*GetDatapathIptablesOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetDatapathIptablesParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "GetDatapathIptables",
+		Method:             "GET",
+		PathPattern:        "/datapath/iptables",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetDatapathIptablesReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*GetDatapathIptablesOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for GetDatapathIptables: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
+/*
+GetDatapathLayer2 gets the layer2 announcements
+
+the EIPs announced by the agent with ARP and NDP
+*/
+func (a *Client) GetDatapathLayer2(params *GetDatapathLayer2Params, opts ...ClientOption) (*GetDatapathLayer2OK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetDatapathLayer2Params()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "GetDatapathLayer2",
+		Method:             "GET",
+		PathPattern:        "/datapath/layer2",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetDatapathLayer2Reader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*GetDatapathLayer2OK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for GetDatapathLayer2: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
+/*
+GetDatapathRoutes gets the policy routes
+
+the ip rules of the egress marks and the routes in their tables
+*/
+func (a *Client) GetDatapathRoutes(params *GetDatapathRoutesParams, opts ...ClientOption) (*GetDatapathRoutesOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetDatapathRoutesParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "GetDatapathRoutes",
+		Method:             "GET",
+		PathPattern:        "/datapath/routes",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetDatapathRoutesReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*GetDatapathRoutesOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for GetDatapathRoutes: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
+/*
+GetDatapathVxlan gets the vxlan peers
+
+the vxlan peers known by the agent, and the FDB and neighbor entries of the vxlan device
+*/
+func (a *Client) GetDatapathVxlan(params *GetDatapathVxlanParams, opts ...ClientOption) (*GetDatapathVxlanOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetDatapathVxlanParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "GetDatapathVxlan",
+		Method:             "GET",
+		PathPattern:        "/datapath/vxlan",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetDatapathVxlanReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*GetDatapathVxlanOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for GetDatapathVxlan: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
+// SetTransport changes the transport on the client
+func (a *Client) SetTransport(transport runtime.ClientTransport) {
+	a.transport = transport
+}
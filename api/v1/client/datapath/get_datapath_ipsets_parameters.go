@@ -0,0 +1,131 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
+	cr "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+)
+
+// NewGetDatapathIpsetsParams creates a new GetDatapathIpsetsParams object,
+// with the default timeout for this client.
+//
+// Default values are not hydrated, since defaults are normally applied by the API server side.
+//
+// To enforce default values in parameter, use SetDefaults or WithDefaults.
+func NewGetDatapathIpsetsParams() *GetDatapathIpsetsParams {
+	return &GetDatapathIpsetsParams{
+		timeout: cr.DefaultTimeout,
+	}
+}
+
+// NewGetDatapathIpsetsParamsWithTimeout creates a new GetDatapathIpsetsParams object
+// with the ability to set a timeout on a request.
+func NewGetDatapathIpsetsParamsWithTimeout(timeout time.Duration) *GetDatapathIpsetsParams {
+	return &GetDatapathIpsetsParams{
+		timeout: timeout,
+	}
+}
+
+// NewGetDatapathIpsetsParamsWithContext creates a new GetDatapathIpsetsParams object
+// with the ability to set a context for a request.
+func NewGetDatapathIpsetsParamsWithContext(ctx context.Context) *GetDatapathIpsetsParams {
+	return &GetDatapathIpsetsParams{
+		Context: ctx,
+	}
+}
+
+// NewGetDatapathIpsetsParamsWithHTTPClient creates a new GetDatapathIpsetsParams object
+// with the ability to set a custom HTTPClient for a request.
+func NewGetDatapathIpsetsParamsWithHTTPClient(client *http.Client) *GetDatapathIpsetsParams {
+	return &GetDatapathIpsetsParams{
+		HTTPClient: client,
+	}
+}
+
+/*
+GetDatapathIpsetsParams contains all the parameters to send to the API endpoint
+
+	for the get datapath ipsets operation.
+
+	Typically these are written to a http.Request.
+*/
+type GetDatapathIpsetsParams struct {
+	timeout    time.Duration
+	Context    context.Context
+	HTTPClient *http.Client
+}
+
+// WithDefaults hydrates default values in the get datapath ipsets params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathIpsetsParams) WithDefaults() *GetDatapathIpsetsParams {
+	o.SetDefaults()
+	return o
+}
+
+// SetDefaults hydrates default values in the get datapath ipsets params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathIpsetsParams) SetDefaults() {
+	// no default values defined for this parameter
+}
+
+// WithTimeout adds the timeout to the get datapath ipsets params
+func (o *GetDatapathIpsetsParams) WithTimeout(timeout time.Duration) *GetDatapathIpsetsParams {
+	o.SetTimeout(timeout)
+	return o
+}
+
+// SetTimeout adds the timeout to the get datapath ipsets params
+func (o *GetDatapathIpsetsParams) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// WithContext adds the context to the get datapath ipsets params
+func (o *GetDatapathIpsetsParams) WithContext(ctx context.Context) *GetDatapathIpsetsParams {
+	o.SetContext(ctx)
+	return o
+}
+
+// SetContext adds the context to the get datapath ipsets params
+func (o *GetDatapathIpsetsParams) SetContext(ctx context.Context) {
+	o.Context = ctx
+}
+
+// WithHTTPClient adds the HTTPClient to the get datapath ipsets params
+func (o *GetDatapathIpsetsParams) WithHTTPClient(client *http.Client) *GetDatapathIpsetsParams {
+	o.SetHTTPClient(client)
+	return o
+}
+
+// SetHTTPClient adds the HTTPClient to the get datapath ipsets params
+func (o *GetDatapathIpsetsParams) SetHTTPClient(client *http.Client) {
+	o.HTTPClient = client
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *GetDatapathIpsetsParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+
+	if err := r.SetTimeout(o.timeout); err != nil {
+		return err
+	}
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathIpsetsReader is a Reader for the GetDatapathIpsets structure.
+type GetDatapathIpsetsReader struct {
+	formats strfmt.Registry
+}
+
+// ReadResponse reads a server response into the received o.
+func (o *GetDatapathIpsetsReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	switch response.Code() {
+	case 200:
+		result := NewGetDatapathIpsetsOK()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case 500:
+		result := NewGetDatapathIpsetsInternalServerError()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+	default:
+		return nil, runtime.NewAPIError("response status code does not match any response statuses defined for this endpoint in the swagger spec", response, response.Code())
+	}
+}
+
+// NewGetDatapathIpsetsOK creates a GetDatapathIpsetsOK with default headers values
+func NewGetDatapathIpsetsOK() *GetDatapathIpsetsOK {
+	return &GetDatapathIpsetsOK{}
+}
+
+/*
+GetDatapathIpsetsOK describes a response with status code 200, with default header values.
+
+Success
+*/
+type GetDatapathIpsetsOK struct {
+	Payload []*models.IPSet
+}
+
+// IsSuccess returns true when this get datapath ipsets o k response has a 2xx status code
+func (o *GetDatapathIpsetsOK) IsSuccess() bool {
+	return true
+}
+
+// IsRedirect returns true when this get datapath ipsets o k response has a 3xx status code
+func (o *GetDatapathIpsetsOK) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath ipsets o k response has a 4xx status code
+func (o *GetDatapathIpsetsOK) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath ipsets o k response has a 5xx status code
+func (o *GetDatapathIpsetsOK) IsServerError() bool {
+	return false
+}
+
+// IsCode returns true when this get datapath ipsets o k response a status code equal to that given
+func (o *GetDatapathIpsetsOK) IsCode(code int) bool {
+	return code == 200
+}
+
+// Code gets the status code for the get datapath ipsets o k response
+func (o *GetDatapathIpsetsOK) Code() int {
+	return 200
+}
+
+func (o *GetDatapathIpsetsOK) Error() string {
+	return fmt.Sprintf("[GET /datapath/ipsets][%d] getDatapathIpsetsOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathIpsetsOK) String() string {
+	return fmt.Sprintf("[GET /datapath/ipsets][%d] getDatapathIpsetsOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathIpsetsOK) GetPayload() []*models.IPSet {
+	return o.Payload
+}
+
+func (o *GetDatapathIpsetsOK) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewGetDatapathIpsetsInternalServerError creates a GetDatapathIpsetsInternalServerError with default headers values
+func NewGetDatapathIpsetsInternalServerError() *GetDatapathIpsetsInternalServerError {
+	return &GetDatapathIpsetsInternalServerError{}
+}
+
+/*
+GetDatapathIpsetsInternalServerError describes a response with status code 500, with default header values.
+
+Failed
+*/
+type GetDatapathIpsetsInternalServerError struct {
+	Payload string
+}
+
+// IsSuccess returns true when this get datapath ipsets internal server error response has a 2xx status code
+func (o *GetDatapathIpsetsInternalServerError) IsSuccess() bool {
+	return false
+}
+
+// IsRedirect returns true when this get datapath ipsets internal server error response has a 3xx status code
+func (o *GetDatapathIpsetsInternalServerError) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath ipsets internal server error response has a 4xx status code
+func (o *GetDatapathIpsetsInternalServerError) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath ipsets internal server error response has a 5xx status code
+func (o *GetDatapathIpsetsInternalServerError) IsServerError() bool {
+	return true
+}
+
+// IsCode returns true when this get datapath ipsets internal server error response a status code equal to that given
+func (o *GetDatapathIpsetsInternalServerError) IsCode(code int) bool {
+	return code == 500
+}
+
+// Code gets the status code for the get datapath ipsets internal server error response
+func (o *GetDatapathIpsetsInternalServerError) Code() int {
+	return 500
+}
+
+func (o *GetDatapathIpsetsInternalServerError) Error() string {
+	return fmt.Sprintf("[GET /datapath/ipsets][%d] getDatapathIpsetsInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathIpsetsInternalServerError) String() string {
+	return fmt.Sprintf("[GET /datapath/ipsets][%d] getDatapathIpsetsInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathIpsetsInternalServerError) GetPayload() string {
+	return o.Payload
+}
+
+func (o *GetDatapathIpsetsInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
+	cr "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+)
+
+// NewGetDatapathIptablesParams creates a new GetDatapathIptablesParams object,
+// with the default timeout for this client.
+//
+// Default values are not hydrated, since defaults are normally applied by the API server side.
+//
+// To enforce default values in parameter, use SetDefaults or WithDefaults.
+func NewGetDatapathIptablesParams() *GetDatapathIptablesParams {
+	return &GetDatapathIptablesParams{
+		timeout: cr.DefaultTimeout,
+	}
+}
+
+// NewGetDatapathIptablesParamsWithTimeout creates a new GetDatapathIptablesParams object
+// with the ability to set a timeout on a request.
+func NewGetDatapathIptablesParamsWithTimeout(timeout time.Duration) *GetDatapathIptablesParams {
+	return &GetDatapathIptablesParams{
+		timeout: timeout,
+	}
+}
+
+// NewGetDatapathIptablesParamsWithContext creates a new GetDatapathIptablesParams object
+// with the ability to set a context for a request.
+func NewGetDatapathIptablesParamsWithContext(ctx context.Context) *GetDatapathIptablesParams {
+	return &GetDatapathIptablesParams{
+		Context: ctx,
+	}
+}
+
+// NewGetDatapathIptablesParamsWithHTTPClient creates a new GetDatapathIptablesParams object
+// with the ability to set a custom HTTPClient for a request.
+func NewGetDatapathIptablesParamsWithHTTPClient(client *http.Client) *GetDatapathIptablesParams {
+	return &GetDatapathIptablesParams{
+		HTTPClient: client,
+	}
+}
+
+/*
+GetDatapathIptablesParams contains all the parameters to send to the API endpoint
+
+	for the get datapath iptables operation.
+
+	Typically these are written to a http.Request.
+*/
+type GetDatapathIptablesParams struct {
+	timeout    time.Duration
+	Context    context.Context
+	HTTPClient *http.Client
+}
+
+// WithDefaults hydrates default values in the get datapath iptables params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathIptablesParams) WithDefaults() *GetDatapathIptablesParams {
+	o.SetDefaults()
+	return o
+}
+
+// SetDefaults hydrates default values in the get datapath iptables params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathIptablesParams) SetDefaults() {
+	// no default values defined for this parameter
+}
+
+// WithTimeout adds the timeout to the get datapath iptables params
+func (o *GetDatapathIptablesParams) WithTimeout(timeout time.Duration) *GetDatapathIptablesParams {
+	o.SetTimeout(timeout)
+	return o
+}
+
+// SetTimeout adds the timeout to the get datapath iptables params
+func (o *GetDatapathIptablesParams) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// WithContext adds the context to the get datapath iptables params
+func (o *GetDatapathIptablesParams) WithContext(ctx context.Context) *GetDatapathIptablesParams {
+	o.SetContext(ctx)
+	return o
+}
+
+// SetContext adds the context to the get datapath iptables params
+func (o *GetDatapathIptablesParams) SetContext(ctx context.Context) {
+	o.Context = ctx
+}
+
+// WithHTTPClient adds the HTTPClient to the get datapath iptables params
+func (o *GetDatapathIptablesParams) WithHTTPClient(client *http.Client) *GetDatapathIptablesParams {
+	o.SetHTTPClient(client)
+	return o
+}
+
+// SetHTTPClient adds the HTTPClient to the get datapath iptables params
+func (o *GetDatapathIptablesParams) SetHTTPClient(client *http.Client) {
+	o.HTTPClient = client
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *GetDatapathIptablesParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+
+	if err := r.SetTimeout(o.timeout); err != nil {
+		return err
+	}
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
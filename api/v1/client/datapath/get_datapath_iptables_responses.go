@@ -0,0 +1,176 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathIptablesReader is a Reader for the GetDatapathIptables structure.
+type GetDatapathIptablesReader struct {
+	formats strfmt.Registry
+}
+
+// ReadResponse reads a server response into the received o.
+func (o *GetDatapathIptablesReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	switch response.Code() {
+	case 200:
+		result := NewGetDatapathIptablesOK()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case 500:
+		result := NewGetDatapathIptablesInternalServerError()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+	default:
+		return nil, runtime.NewAPIError("response status code does not match any response statuses defined for this endpoint in the swagger spec", response, response.Code())
+	}
+}
+
+// NewGetDatapathIptablesOK creates a GetDatapathIptablesOK with default headers values
+func NewGetDatapathIptablesOK() *GetDatapathIptablesOK {
+	return &GetDatapathIptablesOK{}
+}
+
+/*
+GetDatapathIptablesOK describes a response with status code 200, with default header values.
+
+Success
+*/
+type GetDatapathIptablesOK struct {
+	Payload []*models.IptablesChain
+}
+
+// IsSuccess returns true when this get datapath iptables o k response has a 2xx status code
+func (o *GetDatapathIptablesOK) IsSuccess() bool {
+	return true
+}
+
+// IsRedirect returns true when this get datapath iptables o k response has a 3xx status code
+func (o *GetDatapathIptablesOK) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath iptables o k response has a 4xx status code
+func (o *GetDatapathIptablesOK) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath iptables o k response has a 5xx status code
+func (o *GetDatapathIptablesOK) IsServerError() bool {
+	return false
+}
+
+// IsCode returns true when this get datapath iptables o k response a status code equal to that given
+func (o *GetDatapathIptablesOK) IsCode(code int) bool {
+	return code == 200
+}
+
+// Code gets the status code for the get datapath iptables o k response
+func (o *GetDatapathIptablesOK) Code() int {
+	return 200
+}
+
+func (o *GetDatapathIptablesOK) Error() string {
+	return fmt.Sprintf("[GET /datapath/iptables][%d] getDatapathIptablesOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathIptablesOK) String() string {
+	return fmt.Sprintf("[GET /datapath/iptables][%d] getDatapathIptablesOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathIptablesOK) GetPayload() []*models.IptablesChain {
+	return o.Payload
+}
+
+func (o *GetDatapathIptablesOK) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewGetDatapathIptablesInternalServerError creates a GetDatapathIptablesInternalServerError with default headers values
+func NewGetDatapathIptablesInternalServerError() *GetDatapathIptablesInternalServerError {
+	return &GetDatapathIptablesInternalServerError{}
+}
+
+/*
+GetDatapathIptablesInternalServerError describes a response with status code 500, with default header values.
+
+Failed
+*/
+type GetDatapathIptablesInternalServerError struct {
+	Payload string
+}
+
+// IsSuccess returns true when this get datapath iptables internal server error response has a 2xx status code
+func (o *GetDatapathIptablesInternalServerError) IsSuccess() bool {
+	return false
+}
+
+// IsRedirect returns true when this get datapath iptables internal server error response has a 3xx status code
+func (o *GetDatapathIptablesInternalServerError) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath iptables internal server error response has a 4xx status code
+func (o *GetDatapathIptablesInternalServerError) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath iptables internal server error response has a 5xx status code
+func (o *GetDatapathIptablesInternalServerError) IsServerError() bool {
+	return true
+}
+
+// IsCode returns true when this get datapath iptables internal server error response a status code equal to that given
+func (o *GetDatapathIptablesInternalServerError) IsCode(code int) bool {
+	return code == 500
+}
+
+// Code gets the status code for the get datapath iptables internal server error response
+func (o *GetDatapathIptablesInternalServerError) Code() int {
+	return 500
+}
+
+func (o *GetDatapathIptablesInternalServerError) Error() string {
+	return fmt.Sprintf("[GET /datapath/iptables][%d] getDatapathIptablesInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathIptablesInternalServerError) String() string {
+	return fmt.Sprintf("[GET /datapath/iptables][%d] getDatapathIptablesInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathIptablesInternalServerError) GetPayload() string {
+	return o.Payload
+}
+
+func (o *GetDatapathIptablesInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
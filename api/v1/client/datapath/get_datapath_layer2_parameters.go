@@ -0,0 +1,131 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
+	cr "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+)
+
+// NewGetDatapathLayer2Params creates a new GetDatapathLayer2Params object,
+// with the default timeout for this client.
+//
+// Default values are not hydrated, since defaults are normally applied by the API server side.
+//
+// To enforce default values in parameter, use SetDefaults or WithDefaults.
+func NewGetDatapathLayer2Params() *GetDatapathLayer2Params {
+	return &GetDatapathLayer2Params{
+		timeout: cr.DefaultTimeout,
+	}
+}
+
+// NewGetDatapathLayer2ParamsWithTimeout creates a new GetDatapathLayer2Params object
+// with the ability to set a timeout on a request.
+func NewGetDatapathLayer2ParamsWithTimeout(timeout time.Duration) *GetDatapathLayer2Params {
+	return &GetDatapathLayer2Params{
+		timeout: timeout,
+	}
+}
+
+// NewGetDatapathLayer2ParamsWithContext creates a new GetDatapathLayer2Params object
+// with the ability to set a context for a request.
+func NewGetDatapathLayer2ParamsWithContext(ctx context.Context) *GetDatapathLayer2Params {
+	return &GetDatapathLayer2Params{
+		Context: ctx,
+	}
+}
+
+// NewGetDatapathLayer2ParamsWithHTTPClient creates a new GetDatapathLayer2Params object
+// with the ability to set a custom HTTPClient for a request.
+func NewGetDatapathLayer2ParamsWithHTTPClient(client *http.Client) *GetDatapathLayer2Params {
+	return &GetDatapathLayer2Params{
+		HTTPClient: client,
+	}
+}
+
+/*
+GetDatapathLayer2Params contains all the parameters to send to the API endpoint
+
+	for the get datapath layer2 operation.
+
+	Typically these are written to a http.Request.
+*/
+type GetDatapathLayer2Params struct {
+	timeout    time.Duration
+	Context    context.Context
+	HTTPClient *http.Client
+}
+
+// WithDefaults hydrates default values in the get datapath layer2 params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathLayer2Params) WithDefaults() *GetDatapathLayer2Params {
+	o.SetDefaults()
+	return o
+}
+
+// SetDefaults hydrates default values in the get datapath layer2 params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathLayer2Params) SetDefaults() {
+	// no default values defined for this parameter
+}
+
+// WithTimeout adds the timeout to the get datapath layer2 params
+func (o *GetDatapathLayer2Params) WithTimeout(timeout time.Duration) *GetDatapathLayer2Params {
+	o.SetTimeout(timeout)
+	return o
+}
+
+// SetTimeout adds the timeout to the get datapath layer2 params
+func (o *GetDatapathLayer2Params) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// WithContext adds the context to the get datapath layer2 params
+func (o *GetDatapathLayer2Params) WithContext(ctx context.Context) *GetDatapathLayer2Params {
+	o.SetContext(ctx)
+	return o
+}
+
+// SetContext adds the context to the get datapath layer2 params
+func (o *GetDatapathLayer2Params) SetContext(ctx context.Context) {
+	o.Context = ctx
+}
+
+// WithHTTPClient adds the HTTPClient to the get datapath layer2 params
+func (o *GetDatapathLayer2Params) WithHTTPClient(client *http.Client) *GetDatapathLayer2Params {
+	o.SetHTTPClient(client)
+	return o
+}
+
+// SetHTTPClient adds the HTTPClient to the get datapath layer2 params
+func (o *GetDatapathLayer2Params) SetHTTPClient(client *http.Client) {
+	o.HTTPClient = client
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *GetDatapathLayer2Params) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+
+	if err := r.SetTimeout(o.timeout); err != nil {
+		return err
+	}
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
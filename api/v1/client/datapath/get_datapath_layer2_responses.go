@@ -0,0 +1,178 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathLayer2Reader is a Reader for the GetDatapathLayer2 structure.
+type GetDatapathLayer2Reader struct {
+	formats strfmt.Registry
+}
+
+// ReadResponse reads a server response into the received o.
+func (o *GetDatapathLayer2Reader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	switch response.Code() {
+	case 200:
+		result := NewGetDatapathLayer2OK()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case 500:
+		result := NewGetDatapathLayer2InternalServerError()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+	default:
+		return nil, runtime.NewAPIError("response status code does not match any response statuses defined for this endpoint in the swagger spec", response, response.Code())
+	}
+}
+
+// NewGetDatapathLayer2OK creates a GetDatapathLayer2OK with default headers values
+func NewGetDatapathLayer2OK() *GetDatapathLayer2OK {
+	return &GetDatapathLayer2OK{}
+}
+
+/*
+GetDatapathLayer2OK describes a response with status code 200, with default header values.
+
+Success
+*/
+type GetDatapathLayer2OK struct {
+	Payload *models.Layer2State
+}
+
+// IsSuccess returns true when this get datapath layer2 o k response has a 2xx status code
+func (o *GetDatapathLayer2OK) IsSuccess() bool {
+	return true
+}
+
+// IsRedirect returns true when this get datapath layer2 o k response has a 3xx status code
+func (o *GetDatapathLayer2OK) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath layer2 o k response has a 4xx status code
+func (o *GetDatapathLayer2OK) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath layer2 o k response has a 5xx status code
+func (o *GetDatapathLayer2OK) IsServerError() bool {
+	return false
+}
+
+// IsCode returns true when this get datapath layer2 o k response a status code equal to that given
+func (o *GetDatapathLayer2OK) IsCode(code int) bool {
+	return code == 200
+}
+
+// Code gets the status code for the get datapath layer2 o k response
+func (o *GetDatapathLayer2OK) Code() int {
+	return 200
+}
+
+func (o *GetDatapathLayer2OK) Error() string {
+	return fmt.Sprintf("[GET /datapath/layer2][%d] getDatapathLayer2OK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathLayer2OK) String() string {
+	return fmt.Sprintf("[GET /datapath/layer2][%d] getDatapathLayer2OK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathLayer2OK) GetPayload() *models.Layer2State {
+	return o.Payload
+}
+
+func (o *GetDatapathLayer2OK) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.Layer2State)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewGetDatapathLayer2InternalServerError creates a GetDatapathLayer2InternalServerError with default headers values
+func NewGetDatapathLayer2InternalServerError() *GetDatapathLayer2InternalServerError {
+	return &GetDatapathLayer2InternalServerError{}
+}
+
+/*
+GetDatapathLayer2InternalServerError describes a response with status code 500, with default header values.
+
+Failed
+*/
+type GetDatapathLayer2InternalServerError struct {
+	Payload string
+}
+
+// IsSuccess returns true when this get datapath layer2 internal server error response has a 2xx status code
+func (o *GetDatapathLayer2InternalServerError) IsSuccess() bool {
+	return false
+}
+
+// IsRedirect returns true when this get datapath layer2 internal server error response has a 3xx status code
+func (o *GetDatapathLayer2InternalServerError) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath layer2 internal server error response has a 4xx status code
+func (o *GetDatapathLayer2InternalServerError) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath layer2 internal server error response has a 5xx status code
+func (o *GetDatapathLayer2InternalServerError) IsServerError() bool {
+	return true
+}
+
+// IsCode returns true when this get datapath layer2 internal server error response a status code equal to that given
+func (o *GetDatapathLayer2InternalServerError) IsCode(code int) bool {
+	return code == 500
+}
+
+// Code gets the status code for the get datapath layer2 internal server error response
+func (o *GetDatapathLayer2InternalServerError) Code() int {
+	return 500
+}
+
+func (o *GetDatapathLayer2InternalServerError) Error() string {
+	return fmt.Sprintf("[GET /datapath/layer2][%d] getDatapathLayer2InternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathLayer2InternalServerError) String() string {
+	return fmt.Sprintf("[GET /datapath/layer2][%d] getDatapathLayer2InternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathLayer2InternalServerError) GetPayload() string {
+	return o.Payload
+}
+
+func (o *GetDatapathLayer2InternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
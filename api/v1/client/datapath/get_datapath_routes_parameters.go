@@ -0,0 +1,131 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
+	cr "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+)
+
+// NewGetDatapathRoutesParams creates a new GetDatapathRoutesParams object,
+// with the default timeout for this client.
+//
+// Default values are not hydrated, since defaults are normally applied by the API server side.
+//
+// To enforce default values in parameter, use SetDefaults or WithDefaults.
+func NewGetDatapathRoutesParams() *GetDatapathRoutesParams {
+	return &GetDatapathRoutesParams{
+		timeout: cr.DefaultTimeout,
+	}
+}
+
+// NewGetDatapathRoutesParamsWithTimeout creates a new GetDatapathRoutesParams object
+// with the ability to set a timeout on a request.
+func NewGetDatapathRoutesParamsWithTimeout(timeout time.Duration) *GetDatapathRoutesParams {
+	return &GetDatapathRoutesParams{
+		timeout: timeout,
+	}
+}
+
+// NewGetDatapathRoutesParamsWithContext creates a new GetDatapathRoutesParams object
+// with the ability to set a context for a request.
+func NewGetDatapathRoutesParamsWithContext(ctx context.Context) *GetDatapathRoutesParams {
+	return &GetDatapathRoutesParams{
+		Context: ctx,
+	}
+}
+
+// NewGetDatapathRoutesParamsWithHTTPClient creates a new GetDatapathRoutesParams object
+// with the ability to set a custom HTTPClient for a request.
+func NewGetDatapathRoutesParamsWithHTTPClient(client *http.Client) *GetDatapathRoutesParams {
+	return &GetDatapathRoutesParams{
+		HTTPClient: client,
+	}
+}
+
+/*
+GetDatapathRoutesParams contains all the parameters to send to the API endpoint
+
+	for the get datapath routes operation.
+
+	Typically these are written to a http.Request.
+*/
+type GetDatapathRoutesParams struct {
+	timeout    time.Duration
+	Context    context.Context
+	HTTPClient *http.Client
+}
+
+// WithDefaults hydrates default values in the get datapath routes params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathRoutesParams) WithDefaults() *GetDatapathRoutesParams {
+	o.SetDefaults()
+	return o
+}
+
+// SetDefaults hydrates default values in the get datapath routes params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathRoutesParams) SetDefaults() {
+	// no default values defined for this parameter
+}
+
+// WithTimeout adds the timeout to the get datapath routes params
+func (o *GetDatapathRoutesParams) WithTimeout(timeout time.Duration) *GetDatapathRoutesParams {
+	o.SetTimeout(timeout)
+	return o
+}
+
+// SetTimeout adds the timeout to the get datapath routes params
+func (o *GetDatapathRoutesParams) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// WithContext adds the context to the get datapath routes params
+func (o *GetDatapathRoutesParams) WithContext(ctx context.Context) *GetDatapathRoutesParams {
+	o.SetContext(ctx)
+	return o
+}
+
+// SetContext adds the context to the get datapath routes params
+func (o *GetDatapathRoutesParams) SetContext(ctx context.Context) {
+	o.Context = ctx
+}
+
+// WithHTTPClient adds the HTTPClient to the get datapath routes params
+func (o *GetDatapathRoutesParams) WithHTTPClient(client *http.Client) *GetDatapathRoutesParams {
+	o.SetHTTPClient(client)
+	return o
+}
+
+// SetHTTPClient adds the HTTPClient to the get datapath routes params
+func (o *GetDatapathRoutesParams) SetHTTPClient(client *http.Client) {
+	o.HTTPClient = client
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *GetDatapathRoutesParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+
+	if err := r.SetTimeout(o.timeout); err != nil {
+		return err
+	}
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathRoutesReader is a Reader for the GetDatapathRoutes structure.
+type GetDatapathRoutesReader struct {
+	formats strfmt.Registry
+}
+
+// ReadResponse reads a server response into the received o.
+func (o *GetDatapathRoutesReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	switch response.Code() {
+	case 200:
+		result := NewGetDatapathRoutesOK()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case 500:
+		result := NewGetDatapathRoutesInternalServerError()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+	default:
+		return nil, runtime.NewAPIError("response status code does not match any response statuses defined for this endpoint in the swagger spec", response, response.Code())
+	}
+}
+
+// NewGetDatapathRoutesOK creates a GetDatapathRoutesOK with default headers values
+func NewGetDatapathRoutesOK() *GetDatapathRoutesOK {
+	return &GetDatapathRoutesOK{}
+}
+
+/*
+GetDatapathRoutesOK describes a response with status code 200, with default header values.
+
+Success
+*/
+type GetDatapathRoutesOK struct {
+	Payload *models.RouteState
+}
+
+// IsSuccess returns true when this get datapath routes o k response has a 2xx status code
+func (o *GetDatapathRoutesOK) IsSuccess() bool {
+	return true
+}
+
+// IsRedirect returns true when this get datapath routes o k response has a 3xx status code
+func (o *GetDatapathRoutesOK) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath routes o k response has a 4xx status code
+func (o *GetDatapathRoutesOK) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath routes o k response has a 5xx status code
+func (o *GetDatapathRoutesOK) IsServerError() bool {
+	return false
+}
+
+// IsCode returns true when this get datapath routes o k response a status code equal to that given
+func (o *GetDatapathRoutesOK) IsCode(code int) bool {
+	return code == 200
+}
+
+// Code gets the status code for the get datapath routes o k response
+func (o *GetDatapathRoutesOK) Code() int {
+	return 200
+}
+
+func (o *GetDatapathRoutesOK) Error() string {
+	return fmt.Sprintf("[GET /datapath/routes][%d] getDatapathRoutesOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathRoutesOK) String() string {
+	return fmt.Sprintf("[GET /datapath/routes][%d] getDatapathRoutesOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathRoutesOK) GetPayload() *models.RouteState {
+	return o.Payload
+}
+
+func (o *GetDatapathRoutesOK) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.RouteState)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewGetDatapathRoutesInternalServerError creates a GetDatapathRoutesInternalServerError with default headers values
+func NewGetDatapathRoutesInternalServerError() *GetDatapathRoutesInternalServerError {
+	return &GetDatapathRoutesInternalServerError{}
+}
+
+/*
+GetDatapathRoutesInternalServerError describes a response with status code 500, with default header values.
+
+Failed
+*/
+type GetDatapathRoutesInternalServerError struct {
+	Payload string
+}
+
+// IsSuccess returns true when this get datapath routes internal server error response has a 2xx status code
+func (o *GetDatapathRoutesInternalServerError) IsSuccess() bool {
+	return false
+}
+
+// IsRedirect returns true when this get datapath routes internal server error response has a 3xx status code
+func (o *GetDatapathRoutesInternalServerError) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath routes internal server error response has a 4xx status code
+func (o *GetDatapathRoutesInternalServerError) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath routes internal server error response has a 5xx status code
+func (o *GetDatapathRoutesInternalServerError) IsServerError() bool {
+	return true
+}
+
+// IsCode returns true when this get datapath routes internal server error response a status code equal to that given
+func (o *GetDatapathRoutesInternalServerError) IsCode(code int) bool {
+	return code == 500
+}
+
+// Code gets the status code for the get datapath routes internal server error response
+func (o *GetDatapathRoutesInternalServerError) Code() int {
+	return 500
+}
+
+func (o *GetDatapathRoutesInternalServerError) Error() string {
+	return fmt.Sprintf("[GET /datapath/routes][%d] getDatapathRoutesInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathRoutesInternalServerError) String() string {
+	return fmt.Sprintf("[GET /datapath/routes][%d] getDatapathRoutesInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathRoutesInternalServerError) GetPayload() string {
+	return o.Payload
+}
+
+func (o *GetDatapathRoutesInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
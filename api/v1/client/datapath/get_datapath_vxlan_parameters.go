@@ -0,0 +1,131 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
+	cr "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+)
+
+// NewGetDatapathVxlanParams creates a new GetDatapathVxlanParams object,
+// with the default timeout for this client.
+//
+// Default values are not hydrated, since defaults are normally applied by the API server side.
+//
+// To enforce default values in parameter, use SetDefaults or WithDefaults.
+func NewGetDatapathVxlanParams() *GetDatapathVxlanParams {
+	return &GetDatapathVxlanParams{
+		timeout: cr.DefaultTimeout,
+	}
+}
+
+// NewGetDatapathVxlanParamsWithTimeout creates a new GetDatapathVxlanParams object
+// with the ability to set a timeout on a request.
+func NewGetDatapathVxlanParamsWithTimeout(timeout time.Duration) *GetDatapathVxlanParams {
+	return &GetDatapathVxlanParams{
+		timeout: timeout,
+	}
+}
+
+// NewGetDatapathVxlanParamsWithContext creates a new GetDatapathVxlanParams object
+// with the ability to set a context for a request.
+func NewGetDatapathVxlanParamsWithContext(ctx context.Context) *GetDatapathVxlanParams {
+	return &GetDatapathVxlanParams{
+		Context: ctx,
+	}
+}
+
+// NewGetDatapathVxlanParamsWithHTTPClient creates a new GetDatapathVxlanParams object
+// with the ability to set a custom HTTPClient for a request.
+func NewGetDatapathVxlanParamsWithHTTPClient(client *http.Client) *GetDatapathVxlanParams {
+	return &GetDatapathVxlanParams{
+		HTTPClient: client,
+	}
+}
+
+/*
+GetDatapathVxlanParams contains all the parameters to send to the API endpoint
+
+	for the get datapath vxlan operation.
+
+	Typically these are written to a http.Request.
+*/
+type GetDatapathVxlanParams struct {
+	timeout    time.Duration
+	Context    context.Context
+	HTTPClient *http.Client
+}
+
+// WithDefaults hydrates default values in the get datapath vxlan params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathVxlanParams) WithDefaults() *GetDatapathVxlanParams {
+	o.SetDefaults()
+	return o
+}
+
+// SetDefaults hydrates default values in the get datapath vxlan params (not the query body).
+//
+// All values with no default are reset to their zero value.
+func (o *GetDatapathVxlanParams) SetDefaults() {
+	// no default values defined for this parameter
+}
+
+// WithTimeout adds the timeout to the get datapath vxlan params
+func (o *GetDatapathVxlanParams) WithTimeout(timeout time.Duration) *GetDatapathVxlanParams {
+	o.SetTimeout(timeout)
+	return o
+}
+
+// SetTimeout adds the timeout to the get datapath vxlan params
+func (o *GetDatapathVxlanParams) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// WithContext adds the context to the get datapath vxlan params
+func (o *GetDatapathVxlanParams) WithContext(ctx context.Context) *GetDatapathVxlanParams {
+	o.SetContext(ctx)
+	return o
+}
+
+// SetContext adds the context to the get datapath vxlan params
+func (o *GetDatapathVxlanParams) SetContext(ctx context.Context) {
+	o.Context = ctx
+}
+
+// WithHTTPClient adds the HTTPClient to the get datapath vxlan params
+func (o *GetDatapathVxlanParams) WithHTTPClient(client *http.Client) *GetDatapathVxlanParams {
+	o.SetHTTPClient(client)
+	return o
+}
+
+// SetHTTPClient adds the HTTPClient to the get datapath vxlan params
+func (o *GetDatapathVxlanParams) SetHTTPClient(client *http.Client) {
+	o.HTTPClient = client
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *GetDatapathVxlanParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+
+	if err := r.SetTimeout(o.timeout); err != nil {
+		return err
+	}
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
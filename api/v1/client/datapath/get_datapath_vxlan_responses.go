@@ -0,0 +1,178 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathVxlanReader is a Reader for the GetDatapathVxlan structure.
+type GetDatapathVxlanReader struct {
+	formats strfmt.Registry
+}
+
+// ReadResponse reads a server response into the received o.
+func (o *GetDatapathVxlanReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	switch response.Code() {
+	case 200:
+		result := NewGetDatapathVxlanOK()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case 500:
+		result := NewGetDatapathVxlanInternalServerError()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+	default:
+		return nil, runtime.NewAPIError("response status code does not match any response statuses defined for this endpoint in the swagger spec", response, response.Code())
+	}
+}
+
+// NewGetDatapathVxlanOK creates a GetDatapathVxlanOK with default headers values
+func NewGetDatapathVxlanOK() *GetDatapathVxlanOK {
+	return &GetDatapathVxlanOK{}
+}
+
+/*
+GetDatapathVxlanOK describes a response with status code 200, with default header values.
+
+Success
+*/
+type GetDatapathVxlanOK struct {
+	Payload *models.VxlanState
+}
+
+// IsSuccess returns true when this get datapath vxlan o k response has a 2xx status code
+func (o *GetDatapathVxlanOK) IsSuccess() bool {
+	return true
+}
+
+// IsRedirect returns true when this get datapath vxlan o k response has a 3xx status code
+func (o *GetDatapathVxlanOK) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath vxlan o k response has a 4xx status code
+func (o *GetDatapathVxlanOK) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath vxlan o k response has a 5xx status code
+func (o *GetDatapathVxlanOK) IsServerError() bool {
+	return false
+}
+
+// IsCode returns true when this get datapath vxlan o k response a status code equal to that given
+func (o *GetDatapathVxlanOK) IsCode(code int) bool {
+	return code == 200
+}
+
+// Code gets the status code for the get datapath vxlan o k response
+func (o *GetDatapathVxlanOK) Code() int {
+	return 200
+}
+
+func (o *GetDatapathVxlanOK) Error() string {
+	return fmt.Sprintf("[GET /datapath/vxlan][%d] getDatapathVxlanOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathVxlanOK) String() string {
+	return fmt.Sprintf("[GET /datapath/vxlan][%d] getDatapathVxlanOK  %+v", 200, o.Payload)
+}
+
+func (o *GetDatapathVxlanOK) GetPayload() *models.VxlanState {
+	return o.Payload
+}
+
+func (o *GetDatapathVxlanOK) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.VxlanState)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewGetDatapathVxlanInternalServerError creates a GetDatapathVxlanInternalServerError with default headers values
+func NewGetDatapathVxlanInternalServerError() *GetDatapathVxlanInternalServerError {
+	return &GetDatapathVxlanInternalServerError{}
+}
+
+/*
+GetDatapathVxlanInternalServerError describes a response with status code 500, with default header values.
+
+Failed
+*/
+type GetDatapathVxlanInternalServerError struct {
+	Payload string
+}
+
+// IsSuccess returns true when this get datapath vxlan internal server error response has a 2xx status code
+func (o *GetDatapathVxlanInternalServerError) IsSuccess() bool {
+	return false
+}
+
+// IsRedirect returns true when this get datapath vxlan internal server error response has a 3xx status code
+func (o *GetDatapathVxlanInternalServerError) IsRedirect() bool {
+	return false
+}
+
+// IsClientError returns true when this get datapath vxlan internal server error response has a 4xx status code
+func (o *GetDatapathVxlanInternalServerError) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns true when this get datapath vxlan internal server error response has a 5xx status code
+func (o *GetDatapathVxlanInternalServerError) IsServerError() bool {
+	return true
+}
+
+// IsCode returns true when this get datapath vxlan internal server error response a status code equal to that given
+func (o *GetDatapathVxlanInternalServerError) IsCode(code int) bool {
+	return code == 500
+}
+
+// Code gets the status code for the get datapath vxlan internal server error response
+func (o *GetDatapathVxlanInternalServerError) Code() int {
+	return 500
+}
+
+func (o *GetDatapathVxlanInternalServerError) Error() string {
+	return fmt.Sprintf("[GET /datapath/vxlan][%d] getDatapathVxlanInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathVxlanInternalServerError) String() string {
+	return fmt.Sprintf("[GET /datapath/vxlan][%d] getDatapathVxlanInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetDatapathVxlanInternalServerError) GetPayload() string {
+	return o.Payload
+}
+
+func (o *GetDatapathVxlanInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
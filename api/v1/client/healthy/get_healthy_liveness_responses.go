@@ -10,6 +10,7 @@ package healthy
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
@@ -107,6 +108,7 @@ GetHealthyLivenessInternalServerError describes a response with status code 500,
 Failed
 */
 type GetHealthyLivenessInternalServerError struct {
+	Payload string
 }
 
 // IsSuccess returns true when this get healthy liveness internal server error response has a 2xx status code
@@ -140,14 +142,23 @@ func (o *GetHealthyLivenessInternalServerError) Code() int {
 }
 
 func (o *GetHealthyLivenessInternalServerError) Error() string {
-	return fmt.Sprintf("[GET /healthy/liveness][%d] getHealthyLivenessInternalServerError ", 500)
+	return fmt.Sprintf("[GET /healthy/liveness][%d] getHealthyLivenessInternalServerError  %+v", 500, o.Payload)
 }
 
 func (o *GetHealthyLivenessInternalServerError) String() string {
-	return fmt.Sprintf("[GET /healthy/liveness][%d] getHealthyLivenessInternalServerError ", 500)
+	return fmt.Sprintf("[GET /healthy/liveness][%d] getHealthyLivenessInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetHealthyLivenessInternalServerError) GetPayload() string {
+	return o.Payload
 }
 
 func (o *GetHealthyLivenessInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
 
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
 	return nil
 }
@@ -10,6 +10,7 @@ package healthy
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
@@ -107,6 +108,7 @@ GetHealthyReadinessInternalServerError describes a response with status code 500
 Failed
 */
 type GetHealthyReadinessInternalServerError struct {
+	Payload string
 }
 
 // IsSuccess returns true when this get healthy readiness internal server error response has a 2xx status code
@@ -140,14 +142,23 @@ func (o *GetHealthyReadinessInternalServerError) Code() int {
 }
 
 func (o *GetHealthyReadinessInternalServerError) Error() string {
-	return fmt.Sprintf("[GET /healthy/readiness][%d] getHealthyReadinessInternalServerError ", 500)
+	return fmt.Sprintf("[GET /healthy/readiness][%d] getHealthyReadinessInternalServerError  %+v", 500, o.Payload)
 }
 
 func (o *GetHealthyReadinessInternalServerError) String() string {
-	return fmt.Sprintf("[GET /healthy/readiness][%d] getHealthyReadinessInternalServerError ", 500)
+	return fmt.Sprintf("[GET /healthy/readiness][%d] getHealthyReadinessInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetHealthyReadinessInternalServerError) GetPayload() string {
+	return o.Payload
 }
 
 func (o *GetHealthyReadinessInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
 
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
 	return nil
 }
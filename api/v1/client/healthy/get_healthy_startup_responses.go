@@ -10,6 +10,7 @@ package healthy
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
@@ -107,6 +108,7 @@ GetHealthyStartupInternalServerError describes a response with status code 500,
 Failed
 */
 type GetHealthyStartupInternalServerError struct {
+	Payload string
 }
 
 // IsSuccess returns true when this get healthy startup internal server error response has a 2xx status code
@@ -140,14 +142,23 @@ func (o *GetHealthyStartupInternalServerError) Code() int {
 }
 
 func (o *GetHealthyStartupInternalServerError) Error() string {
-	return fmt.Sprintf("[GET /healthy/startup][%d] getHealthyStartupInternalServerError ", 500)
+	return fmt.Sprintf("[GET /healthy/startup][%d] getHealthyStartupInternalServerError  %+v", 500, o.Payload)
 }
 
 func (o *GetHealthyStartupInternalServerError) String() string {
-	return fmt.Sprintf("[GET /healthy/startup][%d] getHealthyStartupInternalServerError ", 500)
+	return fmt.Sprintf("[GET /healthy/startup][%d] getHealthyStartupInternalServerError  %+v", 500, o.Payload)
+}
+
+func (o *GetHealthyStartupInternalServerError) GetPayload() string {
+	return o.Payload
 }
 
 func (o *GetHealthyStartupInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
 
+	// response payload
+	if err := consumer.Consume(response.Body(), &o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
 	return nil
 }
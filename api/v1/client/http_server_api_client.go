@@ -13,6 +13,7 @@ import (
 	httptransport "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/strfmt"
 
+	"github.com/spidernet-io/egressgateway/api/v1/client/datapath"
 	"github.com/spidernet-io/egressgateway/api/v1/client/healthy"
 )
 
@@ -58,6 +59,7 @@ func New(transport runtime.ClientTransport, formats strfmt.Registry) *HTTPServer
 
 	cli := new(HTTPServerAPI)
 	cli.Transport = transport
+	cli.Datapath = datapath.New(transport, formats)
 	cli.Healthy = healthy.New(transport, formats)
 	return cli
 }
@@ -103,6 +105,8 @@ func (cfg *TransportConfig) WithSchemes(schemes []string) *TransportConfig {
 
 // HTTPServerAPI is a client for HTTP server API
 type HTTPServerAPI struct {
+	Datapath datapath.ClientService
+
 	Healthy healthy.ClientService
 
 	Transport runtime.ClientTransport
@@ -111,5 +115,6 @@ type HTTPServerAPI struct {
 // SetTransport changes the transport on the client and all its subresources
 func (c *HTTPServerAPI) SetTransport(transport runtime.ClientTransport) {
 	c.Transport = transport
+	c.Datapath.SetTransport(transport)
 	c.Healthy.SetTransport(transport)
 }
@@ -0,0 +1,56 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// Announcement EIP announced for an EgressGateway
+//
+// swagger:model Announcement
+type Announcement struct {
+
+	// egress gateway
+	EgressGateway string `json:"egressGateway,omitempty"`
+
+	// ip
+	IP string `json:"ip,omitempty"`
+}
+
+// Validate validates this announcement
+func (m *Announcement) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this announcement based on context it is used
+func (m *Announcement) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Announcement) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Announcement) UnmarshalBinary(b []byte) error {
+	var res Announcement
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
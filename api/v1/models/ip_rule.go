@@ -0,0 +1,62 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// IPRule ip rule of an egress mark
+//
+// swagger:model IPRule
+type IPRule struct {
+
+	// family
+	Family string `json:"family,omitempty"`
+
+	// mark
+	Mark string `json:"mark,omitempty"`
+
+	// priority
+	Priority int64 `json:"priority,omitempty"`
+
+	// table
+	Table int64 `json:"table,omitempty"`
+}
+
+// Validate validates this IP rule
+func (m *IPRule) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this IP rule based on context it is used
+func (m *IPRule) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *IPRule) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *IPRule) UnmarshalBinary(b []byte) error {
+	var res IPRule
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,56 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// IPSet ipset and its members
+//
+// swagger:model IPSet
+type IPSet struct {
+
+	// members
+	Members []string `json:"members"`
+
+	// name
+	Name string `json:"name,omitempty"`
+}
+
+// Validate validates this IP set
+func (m *IPSet) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this IP set based on context it is used
+func (m *IPSet) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *IPSet) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *IPSet) UnmarshalBinary(b []byte) error {
+	var res IPSet
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
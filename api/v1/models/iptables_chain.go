@@ -0,0 +1,68 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// IptablesChain iptables chain of the agent, rules are rendered in the iptables-save format
+//
+// swagger:model IptablesChain
+type IptablesChain struct {
+
+	// actual
+	Actual []string `json:"actual"`
+
+	// chain
+	Chain string `json:"chain,omitempty"`
+
+	// expected
+	Expected []string `json:"expected"`
+
+	// the rules read back match the expected rules
+	InSync bool `json:"inSync"`
+
+	// ip version
+	IPVersion int64 `json:"ipVersion,omitempty"`
+
+	// table
+	Table string `json:"table,omitempty"`
+}
+
+// Validate validates this iptables chain
+func (m *IptablesChain) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this iptables chain based on context it is used
+func (m *IptablesChain) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *IptablesChain) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *IptablesChain) UnmarshalBinary(b []byte) error {
+	var res IptablesChain
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
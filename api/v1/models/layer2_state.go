@@ -0,0 +1,122 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// Layer2State EIPs announced by the agent
+//
+// swagger:model Layer2State
+type Layer2State struct {
+
+	// announcements
+	Announcements []*Announcement `json:"announcements"`
+
+	// interfaces
+	Interfaces []string `json:"interfaces"`
+}
+
+// Validate validates this layer2 state
+func (m *Layer2State) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateAnnouncements(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Layer2State) validateAnnouncements(formats strfmt.Registry) error {
+	if swag.IsZero(m.Announcements) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Announcements); i++ {
+		if swag.IsZero(m.Announcements[i]) { // not required
+			continue
+		}
+
+		if m.Announcements[i] != nil {
+			if err := m.Announcements[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("announcements" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("announcements" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ContextValidate validate this layer2 state based on the context it is used
+func (m *Layer2State) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateAnnouncements(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Layer2State) contextValidateAnnouncements(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Announcements); i++ {
+
+		if m.Announcements[i] != nil {
+			if err := m.Announcements[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("announcements" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("announcements" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Layer2State) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Layer2State) UnmarshalBinary(b []byte) error {
+	var res Layer2State
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,59 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// NeighEntry FDB or neighbor entry
+//
+// swagger:model NeighEntry
+type NeighEntry struct {
+
+	// ip
+	IP string `json:"ip,omitempty"`
+
+	// mac
+	Mac string `json:"mac,omitempty"`
+
+	// state
+	State string `json:"state,omitempty"`
+}
+
+// Validate validates this neigh entry
+func (m *NeighEntry) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this neigh entry based on context it is used
+func (m *NeighEntry) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *NeighEntry) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *NeighEntry) UnmarshalBinary(b []byte) error {
+	var res NeighEntry
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
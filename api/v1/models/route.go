@@ -0,0 +1,62 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// Route route in the table of an egress mark
+//
+// swagger:model Route
+type Route struct {
+
+	// device
+	Device string `json:"device,omitempty"`
+
+	// family
+	Family string `json:"family,omitempty"`
+
+	// gateway
+	Gateway string `json:"gateway,omitempty"`
+
+	// table
+	Table int64 `json:"table,omitempty"`
+}
+
+// Validate validates this route
+func (m *Route) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this route based on context it is used
+func (m *Route) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Route) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Route) UnmarshalBinary(b []byte) error {
+	var res Route
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
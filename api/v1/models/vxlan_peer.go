@@ -0,0 +1,68 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// VxlanPeer vxlan tunnel endpoint of an EgressNode
+//
+// swagger:model VxlanPeer
+type VxlanPeer struct {
+
+	// ipv4
+	IPV4 string `json:"ipv4,omitempty"`
+
+	// ipv6
+	IPV6 string `json:"ipv6,omitempty"`
+
+	// mac
+	Mac string `json:"mac,omitempty"`
+
+	// mark
+	Mark string `json:"mark,omitempty"`
+
+	// node
+	Node string `json:"node,omitempty"`
+
+	// parent
+	Parent string `json:"parent,omitempty"`
+}
+
+// Validate validates this vxlan peer
+func (m *VxlanPeer) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this vxlan peer based on context it is used
+func (m *VxlanPeer) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *VxlanPeer) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *VxlanPeer) UnmarshalBinary(b []byte) error {
+	var res VxlanPeer
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,233 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// VxlanState vxlan peers and the entries of the vxlan device
+//
+// swagger:model VxlanState
+type VxlanState struct {
+
+	// fdb
+	Fdb []*NeighEntry `json:"fdb"`
+
+	// neigh
+	Neigh []*NeighEntry `json:"neigh"`
+
+	// peers
+	Peers []*VxlanPeer `json:"peers"`
+}
+
+// Validate validates this vxlan state
+func (m *VxlanState) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateFdb(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateNeigh(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validatePeers(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *VxlanState) validateFdb(formats strfmt.Registry) error {
+	if swag.IsZero(m.Fdb) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Fdb); i++ {
+		if swag.IsZero(m.Fdb[i]) { // not required
+			continue
+		}
+
+		if m.Fdb[i] != nil {
+			if err := m.Fdb[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("fdb" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("fdb" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *VxlanState) validateNeigh(formats strfmt.Registry) error {
+	if swag.IsZero(m.Neigh) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Neigh); i++ {
+		if swag.IsZero(m.Neigh[i]) { // not required
+			continue
+		}
+
+		if m.Neigh[i] != nil {
+			if err := m.Neigh[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("neigh" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("neigh" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *VxlanState) validatePeers(formats strfmt.Registry) error {
+	if swag.IsZero(m.Peers) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Peers); i++ {
+		if swag.IsZero(m.Peers[i]) { // not required
+			continue
+		}
+
+		if m.Peers[i] != nil {
+			if err := m.Peers[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("peers" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("peers" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ContextValidate validate this vxlan state based on the context it is used
+func (m *VxlanState) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateFdb(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateNeigh(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidatePeers(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *VxlanState) contextValidateFdb(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Fdb); i++ {
+
+		if m.Fdb[i] != nil {
+			if err := m.Fdb[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("fdb" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("fdb" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *VxlanState) contextValidateNeigh(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Neigh); i++ {
+
+		if m.Neigh[i] != nil {
+			if err := m.Neigh[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("neigh" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("neigh" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *VxlanState) contextValidatePeers(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Peers); i++ {
+
+		if m.Peers[i] != nil {
+			if err := m.Peers[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("peers" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("peers" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *VxlanState) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *VxlanState) UnmarshalBinary(b []byte) error {
+	var res VxlanState
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/go-openapi/runtime/middleware"
 
 	"github.com/spidernet-io/egressgateway/api/v1/server/restapi"
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi/datapath"
 	"github.com/spidernet-io/egressgateway/api/v1/server/restapi/healthy"
 )
 
@@ -41,6 +42,31 @@ func configureAPI(api *restapi.HTTPServerAPIAPI) http.Handler {
 
 	api.JSONProducer = runtime.JSONProducer()
 
+	if api.DatapathGetDatapathIpsetsHandler == nil {
+		api.DatapathGetDatapathIpsetsHandler = datapath.GetDatapathIpsetsHandlerFunc(func(params datapath.GetDatapathIpsetsParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathIpsets has not yet been implemented")
+		})
+	}
+	if api.DatapathGetDatapathIptablesHandler == nil {
+		api.DatapathGetDatapathIptablesHandler = datapath.GetDatapathIptablesHandlerFunc(func(params datapath.GetDatapathIptablesParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathIptables has not yet been implemented")
+		})
+	}
+	if api.DatapathGetDatapathLayer2Handler == nil {
+		api.DatapathGetDatapathLayer2Handler = datapath.GetDatapathLayer2HandlerFunc(func(params datapath.GetDatapathLayer2Params) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathLayer2 has not yet been implemented")
+		})
+	}
+	if api.DatapathGetDatapathRoutesHandler == nil {
+		api.DatapathGetDatapathRoutesHandler = datapath.GetDatapathRoutesHandlerFunc(func(params datapath.GetDatapathRoutesParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathRoutes has not yet been implemented")
+		})
+	}
+	if api.DatapathGetDatapathVxlanHandler == nil {
+		api.DatapathGetDatapathVxlanHandler = datapath.GetDatapathVxlanHandlerFunc(func(params datapath.GetDatapathVxlanParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathVxlan has not yet been implemented")
+		})
+	}
 	if api.HealthyGetHealthyLivenessHandler == nil {
 		api.HealthyGetHealthyLivenessHandler = healthy.GetHealthyLivenessHandlerFunc(func(params healthy.GetHealthyLivenessParams) middleware.Responder {
 			return middleware.NotImplemented("operation healthy.GetHealthyLiveness has not yet been implemented")
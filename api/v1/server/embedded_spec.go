@@ -35,6 +35,127 @@ func init() {
   },
   "basePath": "/v1",
   "paths": {
+    "/datapath/ipsets": {
+      "get": {
+        "description": "the egress ipsets of the agent and their members",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the ipsets",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "type": "array",
+              "items": {
+                "$ref": "#/definitions/IPSet"
+              }
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/iptables": {
+      "get": {
+        "description": "the iptables chains and rules of the agent as expected by the agent and as read back from the node",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the iptables chains",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "type": "array",
+              "items": {
+                "$ref": "#/definitions/IptablesChain"
+              }
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/layer2": {
+      "get": {
+        "description": "the EIPs announced by the agent with ARP and NDP",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the layer2 announcements",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/Layer2State"
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/routes": {
+      "get": {
+        "description": "the ip rules of the egress marks and the routes in their tables",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the policy routes",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/RouteState"
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/vxlan": {
+      "get": {
+        "description": "the vxlan peers known by the agent, and the FDB and neighbor entries of the vxlan device",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the vxlan peers",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/VxlanState"
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
     "/healthy/liveness": {
       "get": {
         "description": "pod liveness probe for agent and controller pod",
@@ -47,7 +168,10 @@ func init() {
             "description": "Success"
           },
           "500": {
-            "description": "Failed"
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
           }
         }
       }
@@ -64,7 +188,10 @@ func init() {
             "description": "Success"
           },
           "500": {
-            "description": "Failed"
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
           }
         }
       }
@@ -81,7 +208,206 @@ func init() {
             "description": "Success"
           },
           "500": {
-            "description": "Failed"
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    }
+  },
+  "definitions": {
+    "Announcement": {
+      "description": "EIP announced for an EgressGateway",
+      "type": "object",
+      "properties": {
+        "egressGateway": {
+          "type": "string"
+        },
+        "ip": {
+          "type": "string"
+        }
+      }
+    },
+    "IPRule": {
+      "description": "ip rule of an egress mark",
+      "type": "object",
+      "properties": {
+        "family": {
+          "type": "string"
+        },
+        "mark": {
+          "type": "string"
+        },
+        "priority": {
+          "type": "integer"
+        },
+        "table": {
+          "type": "integer"
+        }
+      }
+    },
+    "IPSet": {
+      "description": "ipset and its members",
+      "type": "object",
+      "properties": {
+        "members": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "name": {
+          "type": "string"
+        }
+      }
+    },
+    "IptablesChain": {
+      "description": "iptables chain of the agent, rules are rendered in the iptables-save format",
+      "type": "object",
+      "properties": {
+        "actual": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "chain": {
+          "type": "string"
+        },
+        "expected": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "inSync": {
+          "description": "the rules read back match the expected rules",
+          "type": "boolean",
+          "x-omitempty": false
+        },
+        "ipVersion": {
+          "type": "integer"
+        },
+        "table": {
+          "type": "string"
+        }
+      }
+    },
+    "Layer2State": {
+      "description": "EIPs announced by the agent",
+      "type": "object",
+      "properties": {
+        "announcements": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/Announcement"
+          }
+        },
+        "interfaces": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        }
+      }
+    },
+    "NeighEntry": {
+      "description": "FDB or neighbor entry",
+      "type": "object",
+      "properties": {
+        "ip": {
+          "type": "string"
+        },
+        "mac": {
+          "type": "string"
+        },
+        "state": {
+          "type": "string"
+        }
+      }
+    },
+    "Route": {
+      "description": "route in the table of an egress mark",
+      "type": "object",
+      "properties": {
+        "device": {
+          "type": "string"
+        },
+        "family": {
+          "type": "string"
+        },
+        "gateway": {
+          "type": "string"
+        },
+        "table": {
+          "type": "integer"
+        }
+      }
+    },
+    "RouteState": {
+      "description": "ip rules of the egress marks and the routes of their tables",
+      "type": "object",
+      "properties": {
+        "routes": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/Route"
+          }
+        },
+        "rules": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/IPRule"
+          }
+        }
+      }
+    },
+    "VxlanPeer": {
+      "description": "vxlan tunnel endpoint of an EgressNode",
+      "type": "object",
+      "properties": {
+        "ipv4": {
+          "type": "string"
+        },
+        "ipv6": {
+          "type": "string"
+        },
+        "mac": {
+          "type": "string"
+        },
+        "mark": {
+          "type": "string"
+        },
+        "node": {
+          "type": "string"
+        },
+        "parent": {
+          "type": "string"
+        }
+      }
+    },
+    "VxlanState": {
+      "description": "vxlan peers and the entries of the vxlan device",
+      "type": "object",
+      "properties": {
+        "fdb": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/NeighEntry"
+          }
+        },
+        "neigh": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/NeighEntry"
+          }
+        },
+        "peers": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/VxlanPeer"
           }
         }
       }
@@ -106,6 +432,127 @@ func init() {
   },
   "basePath": "/v1",
   "paths": {
+    "/datapath/ipsets": {
+      "get": {
+        "description": "the egress ipsets of the agent and their members",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the ipsets",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "type": "array",
+              "items": {
+                "$ref": "#/definitions/IPSet"
+              }
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/iptables": {
+      "get": {
+        "description": "the iptables chains and rules of the agent as expected by the agent and as read back from the node",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the iptables chains",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "type": "array",
+              "items": {
+                "$ref": "#/definitions/IptablesChain"
+              }
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/layer2": {
+      "get": {
+        "description": "the EIPs announced by the agent with ARP and NDP",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the layer2 announcements",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/Layer2State"
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/routes": {
+      "get": {
+        "description": "the ip rules of the egress marks and the routes in their tables",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the policy routes",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/RouteState"
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
+    "/datapath/vxlan": {
+      "get": {
+        "description": "the vxlan peers known by the agent, and the FDB and neighbor entries of the vxlan device",
+        "tags": [
+          "datapath"
+        ],
+        "summary": "Get the vxlan peers",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/VxlanState"
+            }
+          },
+          "500": {
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    },
     "/healthy/liveness": {
       "get": {
         "description": "pod liveness probe for agent and controller pod",
@@ -118,7 +565,10 @@ func init() {
             "description": "Success"
           },
           "500": {
-            "description": "Failed"
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
           }
         }
       }
@@ -135,7 +585,10 @@ func init() {
             "description": "Success"
           },
           "500": {
-            "description": "Failed"
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
           }
         }
       }
@@ -152,7 +605,206 @@ func init() {
             "description": "Success"
           },
           "500": {
-            "description": "Failed"
+            "description": "Failed",
+            "schema": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    }
+  },
+  "definitions": {
+    "Announcement": {
+      "description": "EIP announced for an EgressGateway",
+      "type": "object",
+      "properties": {
+        "egressGateway": {
+          "type": "string"
+        },
+        "ip": {
+          "type": "string"
+        }
+      }
+    },
+    "IPRule": {
+      "description": "ip rule of an egress mark",
+      "type": "object",
+      "properties": {
+        "family": {
+          "type": "string"
+        },
+        "mark": {
+          "type": "string"
+        },
+        "priority": {
+          "type": "integer"
+        },
+        "table": {
+          "type": "integer"
+        }
+      }
+    },
+    "IPSet": {
+      "description": "ipset and its members",
+      "type": "object",
+      "properties": {
+        "members": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "name": {
+          "type": "string"
+        }
+      }
+    },
+    "IptablesChain": {
+      "description": "iptables chain of the agent, rules are rendered in the iptables-save format",
+      "type": "object",
+      "properties": {
+        "actual": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "chain": {
+          "type": "string"
+        },
+        "expected": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "inSync": {
+          "description": "the rules read back match the expected rules",
+          "type": "boolean",
+          "x-omitempty": false
+        },
+        "ipVersion": {
+          "type": "integer"
+        },
+        "table": {
+          "type": "string"
+        }
+      }
+    },
+    "Layer2State": {
+      "description": "EIPs announced by the agent",
+      "type": "object",
+      "properties": {
+        "announcements": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/Announcement"
+          }
+        },
+        "interfaces": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        }
+      }
+    },
+    "NeighEntry": {
+      "description": "FDB or neighbor entry",
+      "type": "object",
+      "properties": {
+        "ip": {
+          "type": "string"
+        },
+        "mac": {
+          "type": "string"
+        },
+        "state": {
+          "type": "string"
+        }
+      }
+    },
+    "Route": {
+      "description": "route in the table of an egress mark",
+      "type": "object",
+      "properties": {
+        "device": {
+          "type": "string"
+        },
+        "family": {
+          "type": "string"
+        },
+        "gateway": {
+          "type": "string"
+        },
+        "table": {
+          "type": "integer"
+        }
+      }
+    },
+    "RouteState": {
+      "description": "ip rules of the egress marks and the routes of their tables",
+      "type": "object",
+      "properties": {
+        "routes": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/Route"
+          }
+        },
+        "rules": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/IPRule"
+          }
+        }
+      }
+    },
+    "VxlanPeer": {
+      "description": "vxlan tunnel endpoint of an EgressNode",
+      "type": "object",
+      "properties": {
+        "ipv4": {
+          "type": "string"
+        },
+        "ipv6": {
+          "type": "string"
+        },
+        "mac": {
+          "type": "string"
+        },
+        "mark": {
+          "type": "string"
+        },
+        "node": {
+          "type": "string"
+        },
+        "parent": {
+          "type": "string"
+        }
+      }
+    },
+    "VxlanState": {
+      "description": "vxlan peers and the entries of the vxlan device",
+      "type": "object",
+      "properties": {
+        "fdb": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/NeighEntry"
+          }
+        },
+        "neigh": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/NeighEntry"
+          }
+        },
+        "peers": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/VxlanPeer"
           }
         }
       }
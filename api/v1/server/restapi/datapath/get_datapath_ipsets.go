@@ -0,0 +1,61 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+)
+
+// GetDatapathIpsetsHandlerFunc turns a function with the right signature into a get datapath ipsets handler
+type GetDatapathIpsetsHandlerFunc func(GetDatapathIpsetsParams) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetDatapathIpsetsHandlerFunc) Handle(params GetDatapathIpsetsParams) middleware.Responder {
+	return fn(params)
+}
+
+// GetDatapathIpsetsHandler interface for that can handle valid get datapath ipsets params
+type GetDatapathIpsetsHandler interface {
+	Handle(GetDatapathIpsetsParams) middleware.Responder
+}
+
+// NewGetDatapathIpsets creates a new http.Handler for the get datapath ipsets operation
+func NewGetDatapathIpsets(ctx *middleware.Context, handler GetDatapathIpsetsHandler) *GetDatapathIpsets {
+	return &GetDatapathIpsets{Context: ctx, Handler: handler}
+}
+
+/*
+	GetDatapathIpsets swagger:route GET /datapath/ipsets datapath getDatapathIpsets
+
+# Get the ipsets
+
+the egress ipsets of the agent and their members
+*/
+type GetDatapathIpsets struct {
+	Context *middleware.Context
+	Handler GetDatapathIpsetsHandler
+}
+
+func (o *GetDatapathIpsets) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		*r = *rCtx
+	}
+	var Params = NewGetDatapathIpsetsParams()
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
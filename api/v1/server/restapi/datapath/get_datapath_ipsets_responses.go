@@ -0,0 +1,108 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathIpsetsOKCode is the HTTP code returned for type GetDatapathIpsetsOK
+const GetDatapathIpsetsOKCode int = 200
+
+/*
+GetDatapathIpsetsOK Success
+
+swagger:response getDatapathIpsetsOK
+*/
+type GetDatapathIpsetsOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload []*models.IPSet `json:"body,omitempty"`
+}
+
+// NewGetDatapathIpsetsOK creates GetDatapathIpsetsOK with default headers values
+func NewGetDatapathIpsetsOK() *GetDatapathIpsetsOK {
+
+	return &GetDatapathIpsetsOK{}
+}
+
+// WithPayload adds the payload to the get datapath ipsets o k response
+func (o *GetDatapathIpsetsOK) WithPayload(payload []*models.IPSet) *GetDatapathIpsetsOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath ipsets o k response
+func (o *GetDatapathIpsetsOK) SetPayload(payload []*models.IPSet) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathIpsetsOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	payload := o.Payload
+	if payload == nil {
+		// return empty array
+		payload = make([]*models.IPSet, 0, 50)
+	}
+
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
+
+// GetDatapathIpsetsInternalServerErrorCode is the HTTP code returned for type GetDatapathIpsetsInternalServerError
+const GetDatapathIpsetsInternalServerErrorCode int = 500
+
+/*
+GetDatapathIpsetsInternalServerError Failed
+
+swagger:response getDatapathIpsetsInternalServerError
+*/
+type GetDatapathIpsetsInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
+}
+
+// NewGetDatapathIpsetsInternalServerError creates GetDatapathIpsetsInternalServerError with default headers values
+func NewGetDatapathIpsetsInternalServerError() *GetDatapathIpsetsInternalServerError {
+
+	return &GetDatapathIpsetsInternalServerError{}
+}
+
+// WithPayload adds the payload to the get datapath ipsets internal server error response
+func (o *GetDatapathIpsetsInternalServerError) WithPayload(payload string) *GetDatapathIpsetsInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath ipsets internal server error response
+func (o *GetDatapathIpsetsInternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathIpsetsInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
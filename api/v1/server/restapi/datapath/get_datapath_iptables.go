@@ -0,0 +1,61 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+)
+
+// GetDatapathIptablesHandlerFunc turns a function with the right signature into a get datapath iptables handler
+type GetDatapathIptablesHandlerFunc func(GetDatapathIptablesParams) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetDatapathIptablesHandlerFunc) Handle(params GetDatapathIptablesParams) middleware.Responder {
+	return fn(params)
+}
+
+// GetDatapathIptablesHandler interface for that can handle valid get datapath iptables params
+type GetDatapathIptablesHandler interface {
+	Handle(GetDatapathIptablesParams) middleware.Responder
+}
+
+// NewGetDatapathIptables creates a new http.Handler for the get datapath iptables operation
+func NewGetDatapathIptables(ctx *middleware.Context, handler GetDatapathIptablesHandler) *GetDatapathIptables {
+	return &GetDatapathIptables{Context: ctx, Handler: handler}
+}
+
+/*
+	GetDatapathIptables swagger:route GET /datapath/iptables datapath getDatapathIptables
+
+# Get the iptables chains
+
+the iptables chains and rules of the agent as expected by the agent and as read back from the node
+*/
+type GetDatapathIptables struct {
+	Context *middleware.Context
+	Handler GetDatapathIptablesHandler
+}
+
+func (o *GetDatapathIptables) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		*r = *rCtx
+	}
+	var Params = NewGetDatapathIptablesParams()
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
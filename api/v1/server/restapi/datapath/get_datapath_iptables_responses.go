@@ -0,0 +1,108 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathIptablesOKCode is the HTTP code returned for type GetDatapathIptablesOK
+const GetDatapathIptablesOKCode int = 200
+
+/*
+GetDatapathIptablesOK Success
+
+swagger:response getDatapathIptablesOK
+*/
+type GetDatapathIptablesOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload []*models.IptablesChain `json:"body,omitempty"`
+}
+
+// NewGetDatapathIptablesOK creates GetDatapathIptablesOK with default headers values
+func NewGetDatapathIptablesOK() *GetDatapathIptablesOK {
+
+	return &GetDatapathIptablesOK{}
+}
+
+// WithPayload adds the payload to the get datapath iptables o k response
+func (o *GetDatapathIptablesOK) WithPayload(payload []*models.IptablesChain) *GetDatapathIptablesOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath iptables o k response
+func (o *GetDatapathIptablesOK) SetPayload(payload []*models.IptablesChain) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathIptablesOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	payload := o.Payload
+	if payload == nil {
+		// return empty array
+		payload = make([]*models.IptablesChain, 0, 50)
+	}
+
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
+
+// GetDatapathIptablesInternalServerErrorCode is the HTTP code returned for type GetDatapathIptablesInternalServerError
+const GetDatapathIptablesInternalServerErrorCode int = 500
+
+/*
+GetDatapathIptablesInternalServerError Failed
+
+swagger:response getDatapathIptablesInternalServerError
+*/
+type GetDatapathIptablesInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
+}
+
+// NewGetDatapathIptablesInternalServerError creates GetDatapathIptablesInternalServerError with default headers values
+func NewGetDatapathIptablesInternalServerError() *GetDatapathIptablesInternalServerError {
+
+	return &GetDatapathIptablesInternalServerError{}
+}
+
+// WithPayload adds the payload to the get datapath iptables internal server error response
+func (o *GetDatapathIptablesInternalServerError) WithPayload(payload string) *GetDatapathIptablesInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath iptables internal server error response
+func (o *GetDatapathIptablesInternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathIptablesInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
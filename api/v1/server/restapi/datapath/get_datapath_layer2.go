@@ -0,0 +1,61 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+)
+
+// GetDatapathLayer2HandlerFunc turns a function with the right signature into a get datapath layer2 handler
+type GetDatapathLayer2HandlerFunc func(GetDatapathLayer2Params) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetDatapathLayer2HandlerFunc) Handle(params GetDatapathLayer2Params) middleware.Responder {
+	return fn(params)
+}
+
+// GetDatapathLayer2Handler interface for that can handle valid get datapath layer2 params
+type GetDatapathLayer2Handler interface {
+	Handle(GetDatapathLayer2Params) middleware.Responder
+}
+
+// NewGetDatapathLayer2 creates a new http.Handler for the get datapath layer2 operation
+func NewGetDatapathLayer2(ctx *middleware.Context, handler GetDatapathLayer2Handler) *GetDatapathLayer2 {
+	return &GetDatapathLayer2{Context: ctx, Handler: handler}
+}
+
+/*
+	GetDatapathLayer2 swagger:route GET /datapath/layer2 datapath getDatapathLayer2
+
+# Get the layer2 announcements
+
+the EIPs announced by the agent with ARP and NDP
+*/
+type GetDatapathLayer2 struct {
+	Context *middleware.Context
+	Handler GetDatapathLayer2Handler
+}
+
+func (o *GetDatapathLayer2) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		*r = *rCtx
+	}
+	var Params = NewGetDatapathLayer2Params()
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
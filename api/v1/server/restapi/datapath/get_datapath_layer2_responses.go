@@ -0,0 +1,105 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathLayer2OKCode is the HTTP code returned for type GetDatapathLayer2OK
+const GetDatapathLayer2OKCode int = 200
+
+/*
+GetDatapathLayer2OK Success
+
+swagger:response getDatapathLayer2OK
+*/
+type GetDatapathLayer2OK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Layer2State `json:"body,omitempty"`
+}
+
+// NewGetDatapathLayer2OK creates GetDatapathLayer2OK with default headers values
+func NewGetDatapathLayer2OK() *GetDatapathLayer2OK {
+
+	return &GetDatapathLayer2OK{}
+}
+
+// WithPayload adds the payload to the get datapath layer2 o k response
+func (o *GetDatapathLayer2OK) WithPayload(payload *models.Layer2State) *GetDatapathLayer2OK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath layer2 o k response
+func (o *GetDatapathLayer2OK) SetPayload(payload *models.Layer2State) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathLayer2OK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// GetDatapathLayer2InternalServerErrorCode is the HTTP code returned for type GetDatapathLayer2InternalServerError
+const GetDatapathLayer2InternalServerErrorCode int = 500
+
+/*
+GetDatapathLayer2InternalServerError Failed
+
+swagger:response getDatapathLayer2InternalServerError
+*/
+type GetDatapathLayer2InternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
+}
+
+// NewGetDatapathLayer2InternalServerError creates GetDatapathLayer2InternalServerError with default headers values
+func NewGetDatapathLayer2InternalServerError() *GetDatapathLayer2InternalServerError {
+
+	return &GetDatapathLayer2InternalServerError{}
+}
+
+// WithPayload adds the payload to the get datapath layer2 internal server error response
+func (o *GetDatapathLayer2InternalServerError) WithPayload(payload string) *GetDatapathLayer2InternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath layer2 internal server error response
+func (o *GetDatapathLayer2InternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathLayer2InternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
@@ -0,0 +1,61 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+)
+
+// GetDatapathRoutesHandlerFunc turns a function with the right signature into a get datapath routes handler
+type GetDatapathRoutesHandlerFunc func(GetDatapathRoutesParams) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetDatapathRoutesHandlerFunc) Handle(params GetDatapathRoutesParams) middleware.Responder {
+	return fn(params)
+}
+
+// GetDatapathRoutesHandler interface for that can handle valid get datapath routes params
+type GetDatapathRoutesHandler interface {
+	Handle(GetDatapathRoutesParams) middleware.Responder
+}
+
+// NewGetDatapathRoutes creates a new http.Handler for the get datapath routes operation
+func NewGetDatapathRoutes(ctx *middleware.Context, handler GetDatapathRoutesHandler) *GetDatapathRoutes {
+	return &GetDatapathRoutes{Context: ctx, Handler: handler}
+}
+
+/*
+	GetDatapathRoutes swagger:route GET /datapath/routes datapath getDatapathRoutes
+
+# Get the policy routes
+
+the ip rules of the egress marks and the routes in their tables
+*/
+type GetDatapathRoutes struct {
+	Context *middleware.Context
+	Handler GetDatapathRoutesHandler
+}
+
+func (o *GetDatapathRoutes) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		*r = *rCtx
+	}
+	var Params = NewGetDatapathRoutesParams()
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,105 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathRoutesOKCode is the HTTP code returned for type GetDatapathRoutesOK
+const GetDatapathRoutesOKCode int = 200
+
+/*
+GetDatapathRoutesOK Success
+
+swagger:response getDatapathRoutesOK
+*/
+type GetDatapathRoutesOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.RouteState `json:"body,omitempty"`
+}
+
+// NewGetDatapathRoutesOK creates GetDatapathRoutesOK with default headers values
+func NewGetDatapathRoutesOK() *GetDatapathRoutesOK {
+
+	return &GetDatapathRoutesOK{}
+}
+
+// WithPayload adds the payload to the get datapath routes o k response
+func (o *GetDatapathRoutesOK) WithPayload(payload *models.RouteState) *GetDatapathRoutesOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath routes o k response
+func (o *GetDatapathRoutesOK) SetPayload(payload *models.RouteState) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathRoutesOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// GetDatapathRoutesInternalServerErrorCode is the HTTP code returned for type GetDatapathRoutesInternalServerError
+const GetDatapathRoutesInternalServerErrorCode int = 500
+
+/*
+GetDatapathRoutesInternalServerError Failed
+
+swagger:response getDatapathRoutesInternalServerError
+*/
+type GetDatapathRoutesInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
+}
+
+// NewGetDatapathRoutesInternalServerError creates GetDatapathRoutesInternalServerError with default headers values
+func NewGetDatapathRoutesInternalServerError() *GetDatapathRoutesInternalServerError {
+
+	return &GetDatapathRoutesInternalServerError{}
+}
+
+// WithPayload adds the payload to the get datapath routes internal server error response
+func (o *GetDatapathRoutesInternalServerError) WithPayload(payload string) *GetDatapathRoutesInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath routes internal server error response
+func (o *GetDatapathRoutesInternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathRoutesInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
@@ -0,0 +1,61 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+)
+
+// GetDatapathVxlanHandlerFunc turns a function with the right signature into a get datapath vxlan handler
+type GetDatapathVxlanHandlerFunc func(GetDatapathVxlanParams) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetDatapathVxlanHandlerFunc) Handle(params GetDatapathVxlanParams) middleware.Responder {
+	return fn(params)
+}
+
+// GetDatapathVxlanHandler interface for that can handle valid get datapath vxlan params
+type GetDatapathVxlanHandler interface {
+	Handle(GetDatapathVxlanParams) middleware.Responder
+}
+
+// NewGetDatapathVxlan creates a new http.Handler for the get datapath vxlan operation
+func NewGetDatapathVxlan(ctx *middleware.Context, handler GetDatapathVxlanHandler) *GetDatapathVxlan {
+	return &GetDatapathVxlan{Context: ctx, Handler: handler}
+}
+
+/*
+	GetDatapathVxlan swagger:route GET /datapath/vxlan datapath getDatapathVxlan
+
+# Get the vxlan peers
+
+the vxlan peers known by the agent, and the FDB and neighbor entries of the vxlan device
+*/
+type GetDatapathVxlan struct {
+	Context *middleware.Context
+	Handler GetDatapathVxlanHandler
+}
+
+func (o *GetDatapathVxlan) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		*r = *rCtx
+	}
+	var Params = NewGetDatapathVxlanParams()
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,105 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+)
+
+// GetDatapathVxlanOKCode is the HTTP code returned for type GetDatapathVxlanOK
+const GetDatapathVxlanOKCode int = 200
+
+/*
+GetDatapathVxlanOK Success
+
+swagger:response getDatapathVxlanOK
+*/
+type GetDatapathVxlanOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.VxlanState `json:"body,omitempty"`
+}
+
+// NewGetDatapathVxlanOK creates GetDatapathVxlanOK with default headers values
+func NewGetDatapathVxlanOK() *GetDatapathVxlanOK {
+
+	return &GetDatapathVxlanOK{}
+}
+
+// WithPayload adds the payload to the get datapath vxlan o k response
+func (o *GetDatapathVxlanOK) WithPayload(payload *models.VxlanState) *GetDatapathVxlanOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath vxlan o k response
+func (o *GetDatapathVxlanOK) SetPayload(payload *models.VxlanState) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathVxlanOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// GetDatapathVxlanInternalServerErrorCode is the HTTP code returned for type GetDatapathVxlanInternalServerError
+const GetDatapathVxlanInternalServerErrorCode int = 500
+
+/*
+GetDatapathVxlanInternalServerError Failed
+
+swagger:response getDatapathVxlanInternalServerError
+*/
+type GetDatapathVxlanInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
+}
+
+// NewGetDatapathVxlanInternalServerError creates GetDatapathVxlanInternalServerError with default headers values
+func NewGetDatapathVxlanInternalServerError() *GetDatapathVxlanInternalServerError {
+
+	return &GetDatapathVxlanInternalServerError{}
+}
+
+// WithPayload adds the payload to the get datapath vxlan internal server error response
+func (o *GetDatapathVxlanInternalServerError) WithPayload(payload string) *GetDatapathVxlanInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get datapath vxlan internal server error response
+func (o *GetDatapathVxlanInternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetDatapathVxlanInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
@@ -48,6 +48,11 @@ GetHealthyLivenessInternalServerError Failed
 swagger:response getHealthyLivenessInternalServerError
 */
 type GetHealthyLivenessInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
 }
 
 // NewGetHealthyLivenessInternalServerError creates GetHealthyLivenessInternalServerError with default headers values
@@ -56,10 +61,23 @@ func NewGetHealthyLivenessInternalServerError() *GetHealthyLivenessInternalServe
 	return &GetHealthyLivenessInternalServerError{}
 }
 
+// WithPayload adds the payload to the get healthy liveness internal server error response
+func (o *GetHealthyLivenessInternalServerError) WithPayload(payload string) *GetHealthyLivenessInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get healthy liveness internal server error response
+func (o *GetHealthyLivenessInternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
 // WriteResponse to the client
 func (o *GetHealthyLivenessInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
 
-	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
-
 	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
 }
@@ -48,6 +48,11 @@ GetHealthyReadinessInternalServerError Failed
 swagger:response getHealthyReadinessInternalServerError
 */
 type GetHealthyReadinessInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
 }
 
 // NewGetHealthyReadinessInternalServerError creates GetHealthyReadinessInternalServerError with default headers values
@@ -56,10 +61,23 @@ func NewGetHealthyReadinessInternalServerError() *GetHealthyReadinessInternalSer
 	return &GetHealthyReadinessInternalServerError{}
 }
 
+// WithPayload adds the payload to the get healthy readiness internal server error response
+func (o *GetHealthyReadinessInternalServerError) WithPayload(payload string) *GetHealthyReadinessInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get healthy readiness internal server error response
+func (o *GetHealthyReadinessInternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
 // WriteResponse to the client
 func (o *GetHealthyReadinessInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
 
-	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
-
 	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
 }
@@ -48,6 +48,11 @@ GetHealthyStartupInternalServerError Failed
 swagger:response getHealthyStartupInternalServerError
 */
 type GetHealthyStartupInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
 }
 
 // NewGetHealthyStartupInternalServerError creates GetHealthyStartupInternalServerError with default headers values
@@ -56,10 +61,23 @@ func NewGetHealthyStartupInternalServerError() *GetHealthyStartupInternalServerE
 	return &GetHealthyStartupInternalServerError{}
 }
 
+// WithPayload adds the payload to the get healthy startup internal server error response
+func (o *GetHealthyStartupInternalServerError) WithPayload(payload string) *GetHealthyStartupInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get healthy startup internal server error response
+func (o *GetHealthyStartupInternalServerError) SetPayload(payload string) {
+	o.Payload = payload
+}
+
 // WriteResponse to the client
 func (o *GetHealthyStartupInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
 
-	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
-
 	rw.WriteHeader(500)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
 }
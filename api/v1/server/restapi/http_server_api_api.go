@@ -22,6 +22,7 @@ import (
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi/datapath"
 	"github.com/spidernet-io/egressgateway/api/v1/server/restapi/healthy"
 )
 
@@ -47,6 +48,21 @@ func NewHTTPServerAPIAPI(spec *loads.Document) *HTTPServerAPIAPI {
 
 		JSONProducer: runtime.JSONProducer(),
 
+		DatapathGetDatapathIpsetsHandler: datapath.GetDatapathIpsetsHandlerFunc(func(params datapath.GetDatapathIpsetsParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathIpsets has not yet been implemented")
+		}),
+		DatapathGetDatapathIptablesHandler: datapath.GetDatapathIptablesHandlerFunc(func(params datapath.GetDatapathIptablesParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathIptables has not yet been implemented")
+		}),
+		DatapathGetDatapathLayer2Handler: datapath.GetDatapathLayer2HandlerFunc(func(params datapath.GetDatapathLayer2Params) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathLayer2 has not yet been implemented")
+		}),
+		DatapathGetDatapathRoutesHandler: datapath.GetDatapathRoutesHandlerFunc(func(params datapath.GetDatapathRoutesParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathRoutes has not yet been implemented")
+		}),
+		DatapathGetDatapathVxlanHandler: datapath.GetDatapathVxlanHandlerFunc(func(params datapath.GetDatapathVxlanParams) middleware.Responder {
+			return middleware.NotImplemented("operation datapath.GetDatapathVxlan has not yet been implemented")
+		}),
 		HealthyGetHealthyLivenessHandler: healthy.GetHealthyLivenessHandlerFunc(func(params healthy.GetHealthyLivenessParams) middleware.Responder {
 			return middleware.NotImplemented("operation healthy.GetHealthyLiveness has not yet been implemented")
 		}),
@@ -92,6 +108,16 @@ type HTTPServerAPIAPI struct {
 	//   - application/json
 	JSONProducer runtime.Producer
 
+	// DatapathGetDatapathIpsetsHandler sets the operation handler for the get datapath ipsets operation
+	DatapathGetDatapathIpsetsHandler datapath.GetDatapathIpsetsHandler
+	// DatapathGetDatapathIptablesHandler sets the operation handler for the get datapath iptables operation
+	DatapathGetDatapathIptablesHandler datapath.GetDatapathIptablesHandler
+	// DatapathGetDatapathLayer2Handler sets the operation handler for the get datapath layer2 operation
+	DatapathGetDatapathLayer2Handler datapath.GetDatapathLayer2Handler
+	// DatapathGetDatapathRoutesHandler sets the operation handler for the get datapath routes operation
+	DatapathGetDatapathRoutesHandler datapath.GetDatapathRoutesHandler
+	// DatapathGetDatapathVxlanHandler sets the operation handler for the get datapath vxlan operation
+	DatapathGetDatapathVxlanHandler datapath.GetDatapathVxlanHandler
 	// HealthyGetHealthyLivenessHandler sets the operation handler for the get healthy liveness operation
 	HealthyGetHealthyLivenessHandler healthy.GetHealthyLivenessHandler
 	// HealthyGetHealthyReadinessHandler sets the operation handler for the get healthy readiness operation
@@ -175,6 +201,21 @@ func (o *HTTPServerAPIAPI) Validate() error {
 		unregistered = append(unregistered, "JSONProducer")
 	}
 
+	if o.DatapathGetDatapathIpsetsHandler == nil {
+		unregistered = append(unregistered, "datapath.GetDatapathIpsetsHandler")
+	}
+	if o.DatapathGetDatapathIptablesHandler == nil {
+		unregistered = append(unregistered, "datapath.GetDatapathIptablesHandler")
+	}
+	if o.DatapathGetDatapathLayer2Handler == nil {
+		unregistered = append(unregistered, "datapath.GetDatapathLayer2Handler")
+	}
+	if o.DatapathGetDatapathRoutesHandler == nil {
+		unregistered = append(unregistered, "datapath.GetDatapathRoutesHandler")
+	}
+	if o.DatapathGetDatapathVxlanHandler == nil {
+		unregistered = append(unregistered, "datapath.GetDatapathVxlanHandler")
+	}
 	if o.HealthyGetHealthyLivenessHandler == nil {
 		unregistered = append(unregistered, "healthy.GetHealthyLivenessHandler")
 	}
@@ -272,6 +313,26 @@ func (o *HTTPServerAPIAPI) initHandlerCache() {
 		o.handlers = make(map[string]map[string]http.Handler)
 	}
 
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/datapath/ipsets"] = datapath.NewGetDatapathIpsets(o.context, o.DatapathGetDatapathIpsetsHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/datapath/iptables"] = datapath.NewGetDatapathIptables(o.context, o.DatapathGetDatapathIptablesHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/datapath/layer2"] = datapath.NewGetDatapathLayer2(o.context, o.DatapathGetDatapathLayer2Handler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/datapath/routes"] = datapath.NewGetDatapathRoutes(o.context, o.DatapathGetDatapathRoutesHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/datapath/vxlan"] = datapath.NewGetDatapathVxlan(o.context, o.DatapathGetDatapathVxlanHandler)
 	if o.handlers["GET"] == nil {
 		o.handlers["GET"] = make(map[string]http.Handler)
 	}
@@ -59,20 +59,21 @@ var rootCmd = &cobra.Command{
 func run(ctx context.Context, log *zap.Logger, config *config.Config) error {
 	setupUtility(log.Named("debug"), config)
 
-	ctl, err := controller.New(config, log)
-	if err != nil {
-		return err
-	}
+	for {
+		// a manager can not be started twice, a new controller rebuilds the
+		// caches and the allocators before it campaigns for the leader again
+		ctl, err := controller.New(config, log)
+		if err != nil {
+			return err
+		}
 
-restart:
-	err = ctl.Start(ctx)
-	if err != nil {
-		if err.Error() == "leader election lost" && config.LeaderElectionLostRestart {
-			goto restart
+		err = ctl.Start(ctx)
+		if err != nil && err.Error() == "leader election lost" && config.LeaderElectionLostRestart && ctx.Err() == nil {
+			log.Warn("leader election lost, restart the controller")
+			continue
 		}
 		return err
 	}
-	return nil
 }
 
 func setupUtility(log *zap.Logger, config *config.Config) {
@@ -0,0 +1,53 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+var binName = filepath.Base(os.Args[0])
+
+// rootCmd represents the base command, it also works as a kubectl plugin
+// when the binary is named kubectl-egress
+var rootCmd = &cobra.Command{
+	Use:          binName,
+	Short:        "inspect the egress gateway of the cluster",
+	SilenceUsage: true,
+}
+
+func init() {
+	// --kubeconfig is registered by controller-runtime
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+}
+
+func newClient() (client.Client, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+	}
+	cli, err := client.New(cfg, client.Options{Scheme: schema.GetScheme()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %v", err)
+	}
+	return cli, nil
+}
+
+// Execute adds all child commands to the root command sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spidernet-io/egressgateway/pkg/trace"
+)
+
+type traceFlags struct {
+	namespace   string
+	dest        string
+	protocol    string
+	port        int32
+	ignoreCIDRs []string
+	output      string
+	timeout     time.Duration
+}
+
+func newTraceCmd() *cobra.Command {
+	f := new(traceFlags)
+	cmd := &cobra.Command{
+		Use:   "trace POD --dest IP",
+		Short: "explain how the traffic of a pod to a destination egresses",
+		Long: `Explain how the traffic of a pod to a destination egresses: the policies
+selecting the pod and why, the effective one by priority, the gateway nodes
+with their EIPs, and the state of the EgressNodes on the path.`,
+		Example: fmt.Sprintf("  %s trace -n default nginx --dest 1.1.1.1 --protocol tcp --port 443", binName),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrace(cmd.Context(), cmd.OutOrStdout(), f, args[0])
+		},
+	}
+	cmd.Flags().StringVarP(&f.namespace, "namespace", "n", "default", "namespace of the pod")
+	cmd.Flags().StringVar(&f.dest, "dest", "", "destination IP of the traffic")
+	cmd.Flags().StringVar(&f.protocol, "protocol", "", "protocol of the traffic, checked against destPorts with --port")
+	cmd.Flags().Int32Var(&f.port, "port", 0, "destination port of the traffic")
+	cmd.Flags().StringSliceVar(&f.ignoreCIDRs, "ignore-cidr", nil, "egressIgnoreCIDR.custom of the agent configuration")
+	cmd.Flags().StringVarP(&f.output, "output", "o", "text", "output format, text or json")
+	cmd.Flags().DurationVar(&f.timeout, "timeout", 30*time.Second, "timeout of the requests to the API server")
+	_ = cmd.MarkFlagRequired("dest")
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(newTraceCmd())
+}
+
+func runTrace(ctx context.Context, w io.Writer, f *traceFlags, pod string) error {
+	dest := net.ParseIP(f.dest)
+	if dest == nil {
+		return fmt.Errorf("invalid destination IP %s", f.dest)
+	}
+	if f.output != "text" && f.output != "json" {
+		return fmt.Errorf("invalid output format %s", f.output)
+	}
+	if f.protocol != "" && (f.port <= 0 || f.port > 65535) {
+		return fmt.Errorf("--port is required with --protocol")
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	res, err := trace.Trace(ctx, cli, trace.Options{
+		Namespace:   f.namespace,
+		Pod:         pod,
+		Dest:        dest,
+		Protocol:    strings.ToUpper(f.protocol),
+		Port:        f.port,
+		IgnoreCIDRs: f.ignoreCIDRs,
+	})
+	if err != nil {
+		return err
+	}
+
+	if f.output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	printTrace(w, res)
+	return nil
+}
+
+func printTrace(w io.Writer, res *trace.Result) {
+	fmt.Fprintf(w, "Pod:         %s %v\n", res.Pod, res.PodIPs)
+	fmt.Fprintf(w, "Destination: %s\n", res.Destination)
+	if res.SourceNode != nil {
+		fmt.Fprintf(w, "Source node: %s\n", formatNode(*res.SourceNode))
+	}
+
+	fmt.Fprintf(w, "\nPolicies:\n")
+	if len(res.Policies) == 0 {
+		fmt.Fprintf(w, "  no policy\n")
+	}
+	for _, p := range res.Policies {
+		state := "not selected"
+		switch {
+		case p.Matched:
+			state = "matched"
+		case p.Selected:
+			state = "selected, destination not matched"
+		}
+		fmt.Fprintf(w, "  %s %s (priority %d): %s\n", p.Kind, policyName(p), p.Priority, state)
+		for _, reason := range p.Reasons {
+			fmt.Fprintf(w, "    - %s\n", reason)
+		}
+	}
+
+	fmt.Fprintf(w, "\nResult:\n")
+	if res.Effective == nil {
+		fmt.Fprintf(w, "  no policy matches, the traffic egresses from the source node\n")
+		return
+	}
+	fmt.Fprintf(w, "  effective policy: %s %s\n", res.Effective.Kind, policyName(*res.Effective))
+	fmt.Fprintf(w, "  EgressGateway:    %s\n", res.Gateway.EgressGateway)
+	if len(res.Gateway.Nodes) == 0 {
+		fmt.Fprintf(w, "  no gateway node is assigned to the policy, the traffic is not forwarded\n")
+		return
+	}
+	for _, node := range res.Gateway.Nodes {
+		eip := strings.Trim(node.EIPv4+" "+node.EIPv6, " ")
+		if res.Gateway.UseNodeIP {
+			eip = "node IP"
+		}
+		fmt.Fprintf(w, "  gateway node:     %s, EIP: %s\n", formatNode(node), eip)
+	}
+}
+
+func policyName(p trace.PolicyResult) string {
+	if p.Namespace == "" {
+		return p.Name
+	}
+	return p.Namespace + "/" + p.Name
+}
+
+func formatNode(node trace.Node) string {
+	res := fmt.Sprintf("%s [phase: %s", node.Name, node.Phase)
+	if node.Mark != "" {
+		res += ", mark: " + node.Mark
+	}
+	tunnel := strings.Trim(node.TunnelIPv4+" "+node.TunnelIPv6, " ")
+	if tunnel != "" {
+		res += ", tunnel: " + tunnel
+	}
+	if !node.Ready {
+		res += ", not ready"
+	}
+	return res + "]"
+}
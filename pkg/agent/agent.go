@@ -12,10 +12,11 @@ import (
 	"go.uber.org/zap"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/spidernet-io/egressgateway/pkg/agent/metrics"
+	"github.com/spidernet-io/egressgateway/pkg/apiserver"
 	"github.com/spidernet-io/egressgateway/pkg/config"
 	"github.com/spidernet-io/egressgateway/pkg/logger"
 	"github.com/spidernet-io/egressgateway/pkg/schema"
@@ -48,32 +49,48 @@ func New(cfg *config.Config, log *zap.Logger) (types.Service, error) {
 		return nil, fmt.Errorf("failed to create manager: %w", err)
 	}
 
-	err = mgr.AddHealthzCheck("healthz", healthz.Ping)
-	if err != nil {
-		return nil, fmt.Errorf("failed to AddHealthzCheck: %w", err)
-	}
-	err = mgr.AddReadyzCheck("readyz", healthz.Ping)
-	if err != nil {
-		return nil, fmt.Errorf("failed to AddReadyzCheck: %w", err)
-	}
-
 	metrics.RegisterMetricCollectors()
 
-	err = newEgressNodeController(mgr, cfg, log)
+	vxlanR, err := newEgressNodeController(mgr, cfg, log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create node controller: %w", err)
 	}
 
-	err = newPolicyController(mgr, log, cfg)
+	police, err := newPolicyController(mgr, log, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create egress gateway policy controller: %w", err)
 	}
 
-	err = newEipCtrl(mgr, log, cfg)
+	if err := ctrlmetrics.Registry.Register(newTrafficCollector(police, log.Named("traffic"))); err != nil {
+		return nil, fmt.Errorf("failed to register traffic collector: %w", err)
+	}
+
+	eipR, err := newEipCtrl(mgr, log, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to eip controller: %w", err)
 	}
 
+	err = newLeaseKeeper(mgr, log, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lease keeper: %w", err)
+	}
+
+	probes := newProbes(vxlanR, police)
+	if err := probes.AddToManager(mgr); err != nil {
+		return nil, err
+	}
+
+	if cfg.APIBindAddress != "" {
+		dp := &datapathAPI{police: police, vxlan: vxlanR, eip: eipR}
+		srv, err := apiserver.New(cfg.APIBindAddress, log.Named("api"), probes.Register, dp.register)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create api server: %w", err)
+		}
+		if err := mgr.Add(srv); err != nil {
+			return nil, fmt.Errorf("failed to add api server: %w", err)
+		}
+	}
+
 	return &Agent{
 		client:  mgr.GetClient(),
 		manager: mgr,
@@ -0,0 +1,141 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/ipset"
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+	"github.com/spidernet-io/egressgateway/pkg/nftables"
+)
+
+// ruleTable is a table programming the rules of the policies, it is
+// implemented by the iptables and the nftables datapaths
+type ruleTable interface {
+	UpdateChain(chain *iptables.Chain)
+	InsertOrAppendRules(chainName string, rules []iptables.Rule)
+	Apply() (time.Duration, error)
+	Dump() ([]iptables.ChainState, error)
+	ReadCounters() ([]iptables.RuleCounter, error)
+	TableName() string
+	TableIPVersion() uint8
+}
+
+// backend holds the tables and the sets of the datapath mode
+type backend struct {
+	mangleTables []ruleTable
+	filterTables []ruleTable
+	natTables    []ruleTable
+	ipset        ipset.Interface
+}
+
+func newBackend(cfg *config.Config, log *zap.Logger) (*backend, error) {
+	if cfg.FileConfig.DatapathMode == config.DatapathModeNFTables {
+		return newNFTablesBackend(cfg, log)
+	}
+	return newIPTablesBackend(cfg, log)
+}
+
+// newIPTablesBackend programs the rules with iptables-restore and the sets
+// with ipset
+func newIPTablesBackend(cfg *config.Config, log *zap.Logger) (*backend, error) {
+	iptablesCfg := cfg.FileConfig.IPTables
+	opt := iptables.Options{
+		HistoricChainPrefixes:    []string{"egw"},
+		BackendMode:              cfg.FileConfig.IPTables.BackendMode,
+		InsertMode:               "insert",
+		RefreshInterval:          time.Second * time.Duration(iptablesCfg.RefreshIntervalSecond),
+		LockTimeout:              time.Second * time.Duration(iptablesCfg.LockTimeoutSecond),
+		LockProbeInterval:        time.Millisecond * time.Duration(iptablesCfg.LockProbeIntervalMillis),
+		InitialPostWriteInterval: time.Second * time.Duration(iptablesCfg.InitialPostWriteIntervalSecond),
+		RestoreSupportsLock:      iptablesCfg.RestoreSupportsLock,
+	}
+	var lock sync.Locker
+	if cfg.FileConfig.IPTables.RestoreSupportsLock {
+		log.Info("iptables-restore has built-in lock implementation")
+		lock = iptables.DummyLock{}
+	} else {
+		log.Info("iptables-restore use shared lock")
+		lock = iptables.NewSharedLock(iptablesCfg.LockFilePath, opt.LockTimeout, opt.LockProbeInterval)
+	}
+	opt.XTablesLock = lock
+
+	b := &backend{ipset: ipset.New(exec.New())}
+	if cfg.FileConfig.EnableIPv4 {
+		mangleTable, err := iptables.NewTable("mangle", 4, "egw:", opt, log)
+		if err != nil {
+			return nil, err
+		}
+		b.mangleTables = append(b.mangleTables, mangleTable)
+
+		natTable, err := iptables.NewTable("nat", 4, "egw:", opt, log)
+		if err != nil {
+			return nil, err
+		}
+		b.natTables = append(b.natTables, natTable)
+
+		filterTable, err := iptables.NewTable("filter", 4, "egw:", opt, log)
+		if err != nil {
+			return nil, err
+		}
+		b.filterTables = append(b.filterTables, filterTable)
+	}
+	if cfg.FileConfig.EnableIPv6 {
+		mangle, err := iptables.NewTable("mangle", 6, "egw:-", opt, log)
+		if err != nil {
+			return nil, err
+		}
+		b.mangleTables = append(b.mangleTables, mangle)
+		nat, err := iptables.NewTable("nat", 6, "egw:", opt, log)
+		if err != nil {
+			return nil, err
+		}
+		b.natTables = append(b.natTables, nat)
+		filter, err := iptables.NewTable("filter", 6, "egw:", opt, log)
+		if err != nil {
+			return nil, err
+		}
+		b.filterTables = append(b.filterTables, filter)
+	}
+	return b, nil
+}
+
+// newNFTablesBackend programs the rules and the sets in the egressgateway
+// tables of nftables through netlink
+func newNFTablesBackend(cfg *config.Config, log *zap.Logger) (*backend, error) {
+	log.Info("program the datapath with nftables")
+	rs := nftables.New(log)
+	b := &backend{ipset: rs.Sets()}
+	versions := make([]uint8, 0)
+	if cfg.FileConfig.EnableIPv4 {
+		versions = append(versions, 4)
+	}
+	if cfg.FileConfig.EnableIPv6 {
+		versions = append(versions, 6)
+	}
+	for _, version := range versions {
+		mangle, err := rs.NewTable("mangle", version)
+		if err != nil {
+			return nil, err
+		}
+		b.mangleTables = append(b.mangleTables, mangle)
+		nat, err := rs.NewTable("nat", version)
+		if err != nil {
+			return nil, err
+		}
+		b.natTables = append(b.natTables, nat)
+		filter, err := rs.NewTable("filter", version)
+		if err != nil {
+			return nil, err
+		}
+		b.filterTables = append(b.filterTables, filter)
+	}
+	return b, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// policyPath is how the node sends out the traffic of a policy
+type policyPath struct {
+	// marks are the marks of the gateway nodes the traffic of the pods of
+	// the node is sent to
+	marks []uint32
+	// snat is set when the node is a gateway node of the policy, the traffic
+	// is SNATed to eip, it is the IP of the node when the policy uses the
+	// node IP
+	snat bool
+	eip  IP
+}
+
+// movedFlowFilter matches the conntrack entries of the sources of a policy
+// still using its old gateway: the entries marked for a removed gateway node
+// and the entries SNATed by the node when it is no longer the gateway node
+type movedFlowFilter struct {
+	sources []*net.IPNet
+	// marks are matched with the bits of mask of the ct mark, the mark rules
+	// of the policies save the mark of the gateway node in them
+	marks map[uint32]struct{}
+	mask  uint32
+	snat  bool
+	// eips are the old addresses the entries are SNATed to
+	eips map[string]struct{}
+}
+
+func (f movedFlowFilter) MatchConntrackFlow(flow *netlink.ConntrackFlow) bool {
+	if !f.fromSources(flow.Forward.SrcIP) {
+		return false
+	}
+	if _, ok := f.marks[flow.Mark&f.mask]; ok {
+		return true
+	}
+	if !f.snat {
+		return false
+	}
+	// the reply of a SNATed connection is sent to the SNAT address
+	_, ok := f.eips[flow.Reverse.DstIP.String()]
+	return ok
+}
+
+func (f movedFlowFilter) fromSources(ip net.IP) bool {
+	for _, source := range f.sources {
+		if source.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// newMovedFlowFilter returns the filter of the entries of the policy moved
+// from old to cur, ok is false when nothing is moved
+func newMovedFlowFilter(old, cur policyPath) (movedFlowFilter, bool) {
+	res := movedFlowFilter{marks: make(map[uint32]struct{}), mask: connMarkMask(old.marks...)}
+	for _, mark := range old.marks {
+		res.marks[mark] = struct{}{}
+	}
+	for _, mark := range cur.marks {
+		delete(res.marks, mark)
+	}
+	if old.snat && (!cur.snat || old.eip != cur.eip) {
+		res.snat = true
+		res.eips = make(map[string]struct{})
+		for _, ip := range []string{old.eip.V4, old.eip.V6} {
+			if ip != "" {
+				res.eips[ip] = struct{}{}
+			}
+		}
+	}
+	return res, len(res.marks) > 0 || res.snat
+}
+
+// parseSources parses the entries of the source ipsets of a policy, they are
+// IP addresses or CIDRs
+func parseSources(entries []string) []*net.IPNet {
+	res := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		res = append(res, ipNet)
+	}
+	return res
+}
+
+// flushMovedPolicies deletes the conntrack entries of the policies whose
+// gateway nodes or EIPs are changed since the last apply, so that the
+// connections are set up again through the new gateway node instead of
+// being sent to the old one until they time out. The entries of the removed
+// policies are deleted too. paths are the paths of the policies applied just
+// now.
+func (r *policeReconciler) flushMovedPolicies(paths map[egressv1.Policy]policyPath) {
+	old := r.policyPaths
+	r.policyPaths = paths
+	for policy, prev := range old {
+		cur, ok := paths[policy]
+		if !ok {
+			// the sources of the removed policy are not needed any more
+			defer r.policySources.Delete(policy)
+		}
+		filter, moved := newMovedFlowFilter(prev, cur)
+		if !moved {
+			continue
+		}
+
+		entries, _ := r.policySources.Load(policy)
+		sources := parseSources(entries)
+		for _, family := range []netlink.InetFamily{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			filter.sources = sourcesOfFamily(sources, family)
+			if len(filter.sources) == 0 {
+				continue
+			}
+			n, err := r.deleteFlows(family, filter)
+			if err != nil {
+				r.log.Warn("failed to delete the conntrack entries of the moved policy",
+					zap.String("policy", policy.Namespace+"/"+policy.Name), zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				r.log.Sugar().Infof("deleted %d conntrack entries of the moved policy %s/%s", n, policy.Namespace, policy.Name)
+			}
+		}
+	}
+}
+
+func sourcesOfFamily(sources []*net.IPNet, family netlink.InetFamily) []*net.IPNet {
+	res := make([]*net.IPNet, 0, len(sources))
+	for _, source := range sources {
+		if (source.IP.To4() != nil) == (family == netlink.FAMILY_V4) {
+			res = append(res, source)
+		}
+	}
+	return res
+}
+
+// nodeIP returns the IP of the parent interface of the tunnel of the node,
+// the traffic of the policies using the node IP is SNATed to it
+func (r *policeReconciler) nodeIP(ctx context.Context) IP {
+	node := new(egressv1.EgressNode)
+	if err := r.client.Get(ctx, types.NamespacedName{Name: r.cfg.EnvConfig.NodeName}, node); err != nil {
+		r.log.Warn("failed to get the IP of the node", zap.Error(err))
+		return IP{}
+	}
+	return IP{V4: node.Status.Tunnel.Parent.IPv4, V6: node.Status.Tunnel.Parent.IPv6}
+}
+
+func deleteConntrackFlows(family netlink.InetFamily, filter netlink.CustomConntrackFilter) (uint, error) {
+	return netlink.ConntrackDeleteFilter(netlink.ConntrackTable, family, filter)
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/ipset"
+	ipsettest "github.com/spidernet-io/egressgateway/pkg/ipset/testing"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+func newFlow(src, replyDst string, mark uint32) *netlink.ConntrackFlow {
+	flow := &netlink.ConntrackFlow{Mark: mark}
+	flow.Forward.SrcIP = net.ParseIP(src)
+	flow.Reverse.DstIP = net.ParseIP(replyDst)
+	return flow
+}
+
+func TestMovedFlowFilter(t *testing.T) {
+	cases := []struct {
+		name  string
+		old   policyPath
+		cur   policyPath
+		moved bool
+		match []*netlink.ConntrackFlow
+		skip  []*netlink.ConntrackFlow
+	}{
+		{
+			name: "unchanged",
+			old:  policyPath{marks: []uint32{0x26000001}},
+			cur:  policyPath{marks: []uint32{0x26000001}},
+		},
+		{
+			name:  "gateway node removed",
+			old:   policyPath{marks: []uint32{0x26000001, 0x26000002}},
+			cur:   policyPath{marks: []uint32{0x26000002}},
+			moved: true,
+			match: []*netlink.ConntrackFlow{
+				newFlow("172.30.0.2", "1.1.1.1", 0x26000001),
+				// the bits of the other users of the ct mark
+				newFlow("172.30.0.2", "1.1.1.1", 0x26000101),
+			},
+			skip: []*netlink.ConntrackFlow{
+				newFlow("172.30.0.2", "1.1.1.1", 0x26000002),
+				newFlow("172.31.0.2", "1.1.1.1", 0x26000001),
+			},
+		},
+		{
+			name:  "eip changed",
+			old:   policyPath{snat: true, eip: IP{V4: "10.6.1.21"}},
+			cur:   policyPath{snat: true, eip: IP{V4: "10.6.1.22"}},
+			moved: true,
+			match: []*netlink.ConntrackFlow{newFlow("172.30.0.2", "10.6.1.21", 0)},
+			skip:  []*netlink.ConntrackFlow{newFlow("172.30.0.2", "10.6.1.22", 0)},
+		},
+		{
+			name:  "node ip of the old gateway node",
+			old:   policyPath{snat: true, eip: IP{V4: "10.6.1.1"}},
+			cur:   policyPath{marks: []uint32{0x26000002}},
+			moved: true,
+			match: []*netlink.ConntrackFlow{newFlow("172.30.0.2", "10.6.1.1", 0)},
+			skip: []*netlink.ConntrackFlow{
+				newFlow("172.30.0.2", "172.30.0.2", 0),
+				// NATed to another address, e.g. by the CNI
+				newFlow("172.30.0.2", "10.244.0.1", 0),
+			},
+		},
+		{
+			name:  "policy removed",
+			old:   policyPath{marks: []uint32{0x26000001}},
+			moved: true,
+			match: []*netlink.ConntrackFlow{newFlow("172.30.0.2", "1.1.1.1", 0x26000001)},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			filter, moved := newMovedFlowFilter(c.old, c.cur)
+			assert.Equal(t, c.moved, moved)
+			filter.sources = parseSources([]string{"172.30.0.0/24", "fd00::1", "bad"})
+			for _, flow := range c.match {
+				assert.True(t, filter.MatchConntrackFlow(flow), "%+v", flow)
+			}
+			for _, flow := range c.skip {
+				assert.False(t, filter.MatchConntrackFlow(flow), "%+v", flow)
+			}
+		})
+	}
+}
+
+func TestFlushMovedPolicies(t *testing.T) {
+	var families []netlink.InetFamily
+	r := &policeReconciler{
+		log: zap.NewNop(),
+		cfg: &config.Config{
+			FileConfig: config.FileConfig{EnableIPv4: true, EnableIPv6: true},
+		},
+		ipsetMap:      utils.NewSyncMap[string, *ipset.IPSet](),
+		policySources: utils.NewSyncMap[egressv1.Policy, []string](),
+		ipset:         ipsettest.NewFake("7.1"),
+		policyPaths:   make(map[egressv1.Policy]policyPath),
+		deleteFlows: func(family netlink.InetFamily, filter netlink.CustomConntrackFilter) (uint, error) {
+			families = append(families, family)
+			return 1, nil
+		},
+	}
+	moved := egressv1.Policy{Namespace: "default", Name: "moved"}
+	kept := egressv1.Policy{Namespace: "default", Name: "kept"}
+	for _, policy := range []egressv1.Policy{moved, kept} {
+		err := r.updatePolicyIPSet(policy.Namespace, policy.Name, false, &PolicyCommon{PodSubnet: []string{"172.30.0.0/16"}})
+		assert.NoError(t, err)
+	}
+
+	// nothing is flushed on the first apply
+	r.flushMovedPolicies(map[egressv1.Policy]policyPath{
+		moved: {marks: []uint32{0x26000001}},
+		kept:  {marks: []uint32{0x26000001}},
+	})
+	assert.Empty(t, families)
+
+	r.flushMovedPolicies(map[egressv1.Policy]policyPath{
+		moved: {marks: []uint32{0x26000002}},
+		kept:  {marks: []uint32{0x26000001}},
+	})
+	// the policy has no IPv6 source
+	assert.Equal(t, []netlink.InetFamily{netlink.FAMILY_V4}, families)
+	assert.Equal(t, policyPath{marks: []uint32{0x26000002}}, r.policyPaths[moved])
+
+	// the entries of the removed policy are deleted and its sources are forgotten
+	families = nil
+	r.flushMovedPolicies(map[egressv1.Policy]policyPath{
+		moved: {marks: []uint32{0x26000002}},
+	})
+	assert.Equal(t, []netlink.InetFamily{netlink.FAMILY_V4}, families)
+	_, ok := r.policySources.Load(kept)
+	assert.False(t, ok)
+	_, ok = r.policySources.Load(moved)
+	assert.True(t, ok)
+}
@@ -0,0 +1,242 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/vishvananda/netlink"
+
+	"github.com/spidernet-io/egressgateway/api/v1/models"
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi"
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi/datapath"
+	"github.com/spidernet-io/egressgateway/pkg/agent/vxlan"
+)
+
+// egressIPSetPrefix is the prefix of the ipsets created by the agent
+const egressIPSetPrefix = "egress-"
+
+// datapathAPI dumps the live datapath state of the agent
+type datapathAPI struct {
+	police *policeReconciler
+	vxlan  *vxlanReconciler
+	eip    *eip
+}
+
+func (d *datapathAPI) register(api *restapi.HTTPServerAPIAPI) {
+	api.DatapathGetDatapathIpsetsHandler = datapath.GetDatapathIpsetsHandlerFunc(
+		func(params datapath.GetDatapathIpsetsParams) middleware.Responder {
+			res, err := d.ipsets()
+			if err != nil {
+				return datapath.NewGetDatapathIpsetsInternalServerError().WithPayload(err.Error())
+			}
+			return datapath.NewGetDatapathIpsetsOK().WithPayload(res)
+		})
+	api.DatapathGetDatapathIptablesHandler = datapath.GetDatapathIptablesHandlerFunc(
+		func(params datapath.GetDatapathIptablesParams) middleware.Responder {
+			res, err := d.iptables()
+			if err != nil {
+				return datapath.NewGetDatapathIptablesInternalServerError().WithPayload(err.Error())
+			}
+			return datapath.NewGetDatapathIptablesOK().WithPayload(res)
+		})
+	api.DatapathGetDatapathVxlanHandler = datapath.GetDatapathVxlanHandlerFunc(
+		func(params datapath.GetDatapathVxlanParams) middleware.Responder {
+			res, err := d.vxlanState()
+			if err != nil {
+				return datapath.NewGetDatapathVxlanInternalServerError().WithPayload(err.Error())
+			}
+			return datapath.NewGetDatapathVxlanOK().WithPayload(res)
+		})
+	api.DatapathGetDatapathRoutesHandler = datapath.GetDatapathRoutesHandlerFunc(
+		func(params datapath.GetDatapathRoutesParams) middleware.Responder {
+			res, err := d.routes()
+			if err != nil {
+				return datapath.NewGetDatapathRoutesInternalServerError().WithPayload(err.Error())
+			}
+			return datapath.NewGetDatapathRoutesOK().WithPayload(res)
+		})
+	api.DatapathGetDatapathLayer2Handler = datapath.GetDatapathLayer2HandlerFunc(
+		func(params datapath.GetDatapathLayer2Params) middleware.Responder {
+			return datapath.NewGetDatapathLayer2OK().WithPayload(d.layer2())
+		})
+}
+
+func (d *datapathAPI) ipsets() ([]*models.IPSet, error) {
+	names, err := d.police.ipset.ListSets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ipsets: %v", err)
+	}
+	sort.Strings(names)
+
+	res := make([]*models.IPSet, 0)
+	for _, name := range names {
+		if !strings.HasPrefix(name, egressIPSetPrefix) {
+			continue
+		}
+		members, err := d.police.ipset.ListEntries(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the members of ipset %s: %v", name, err)
+		}
+		sort.Strings(members)
+		res = append(res, &models.IPSet{Name: name, Members: members})
+	}
+	return res, nil
+}
+
+func (d *datapathAPI) iptables() ([]*models.IptablesChain, error) {
+	tables := make([]ruleTable, 0)
+	tables = append(tables, d.police.mangleTables...)
+	tables = append(tables, d.police.filterTables...)
+	tables = append(tables, d.police.natTables...)
+
+	res := make([]*models.IptablesChain, 0)
+	for _, table := range tables {
+		chains, err := table.Dump()
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump table %s of IPv%d: %v", table.TableName(), table.TableIPVersion(), err)
+		}
+		for _, chain := range chains {
+			res = append(res, &models.IptablesChain{
+				Table:     table.TableName(),
+				IPVersion: int64(table.TableIPVersion()),
+				Chain:     chain.Name,
+				InSync:    chain.InSync,
+				Expected:  chain.Expected,
+				Actual:    chain.Actual,
+			})
+		}
+	}
+	return res, nil
+}
+
+func (d *datapathAPI) vxlanState() (*models.VxlanState, error) {
+	res := &models.VxlanState{
+		Peers: make([]*models.VxlanPeer, 0),
+		Fdb:   make([]*models.NeighEntry, 0),
+		Neigh: make([]*models.NeighEntry, 0),
+	}
+	d.vxlan.peerMap.Range(func(node string, peer vxlan.Peer) bool {
+		item := &models.VxlanPeer{Node: node, Parent: ipString(peer.Parent), Mac: peer.MAC.String()}
+		if peer.IPv4 != nil {
+			item.IPV4 = peer.IPv4.String()
+		}
+		if peer.IPv6 != nil {
+			item.IPV6 = peer.IPv6.String()
+		}
+		if peer.Mark != 0 {
+			item.Mark = fmt.Sprintf("%#x", peer.Mark)
+		}
+		res.Peers = append(res.Peers, item)
+		return true
+	})
+	sort.Slice(res.Peers, func(i, j int) bool { return res.Peers[i].Node < res.Peers[j].Node })
+
+	fdb, neigh, err := d.vxlan.vxlan.ListEntries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the entries of the vxlan device: %v", err)
+	}
+	for _, item := range fdb {
+		res.Fdb = append(res.Fdb, neighEntry(item))
+	}
+	for _, item := range neigh {
+		res.Neigh = append(res.Neigh, neighEntry(item))
+	}
+	return res, nil
+}
+
+func (d *datapathAPI) routes() (*models.RouteState, error) {
+	rules, routes, err := d.vxlan.ruleRoute.List(d.vxlan.cfg.FileConfig.Mark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the rules and routes: %v", err)
+	}
+
+	res := &models.RouteState{
+		Rules:  make([]*models.IPRule, 0, len(rules)),
+		Routes: make([]*models.Route, 0, len(routes)),
+	}
+	for _, rule := range rules {
+		res.Rules = append(res.Rules, &models.IPRule{
+			Family:   familyString(rule.Family),
+			Priority: int64(rule.Priority),
+			Mark:     fmt.Sprintf("%#x", rule.Mark),
+			Table:    int64(rule.Table),
+		})
+	}
+	for _, route := range routes {
+		item := &models.Route{
+			Family:  familyString(route.Family),
+			Table:   int64(route.Table),
+			Gateway: ipString(route.Gw),
+		}
+		if link, err := netlink.LinkByIndex(route.LinkIndex); err == nil {
+			item.Device = link.Attrs().Name
+		}
+		res.Routes = append(res.Routes, item)
+	}
+	return res, nil
+}
+
+func (d *datapathAPI) layer2() *models.Layer2State {
+	res := &models.Layer2State{
+		Interfaces:    d.eip.announce.GetInterfaces(),
+		Announcements: make([]*models.Announcement, 0),
+	}
+	for name, ips := range d.eip.announce.GetBalancers() {
+		for _, ip := range ips {
+			res.Announcements = append(res.Announcements, &models.Announcement{EgressGateway: name, IP: ip.String()})
+		}
+	}
+	sort.Slice(res.Announcements, func(i, j int) bool {
+		a, b := res.Announcements[i], res.Announcements[j]
+		if a.EgressGateway != b.EgressGateway {
+			return a.EgressGateway < b.EgressGateway
+		}
+		return a.IP < b.IP
+	})
+	return res
+}
+
+func neighEntry(neigh netlink.Neigh) *models.NeighEntry {
+	return &models.NeighEntry{
+		IP:    ipString(neigh.IP),
+		Mac:   neigh.HardwareAddr.String(),
+		State: neighState(neigh.State),
+	}
+}
+
+func neighState(state int) string {
+	switch state {
+	case netlink.NUD_PERMANENT:
+		return "PERMANENT"
+	case netlink.NUD_REACHABLE:
+		return "REACHABLE"
+	case netlink.NUD_STALE:
+		return "STALE"
+	case netlink.NUD_FAILED:
+		return "FAILED"
+	case netlink.NUD_NOARP:
+		return "NOARP"
+	default:
+		return fmt.Sprintf("%#x", state)
+	}
+}
+
+func familyString(family int) string {
+	if family == netlink.FAMILY_V6 {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+func ipString(ip net.IP) string {
+	if len(ip) == 0 {
+		return ""
+	}
+	return ip.String()
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/spidernet-io/egressgateway/api/v1/client"
+	"github.com/spidernet-io/egressgateway/api/v1/client/datapath"
+	"github.com/spidernet-io/egressgateway/pkg/apiserver"
+	"github.com/spidernet-io/egressgateway/pkg/ipset"
+	ipsettest "github.com/spidernet-io/egressgateway/pkg/ipset/testing"
+	"github.com/spidernet-io/egressgateway/pkg/logger"
+)
+
+func TestDatapathAPIIPSets(t *testing.T) {
+	fakeIPSet := ipsettest.NewFake("7.1")
+	src := &ipset.IPSet{Name: "egress-src-v4-test", SetType: ipset.HashNet, HashFamily: "inet"}
+	other := &ipset.IPSet{Name: "other", SetType: ipset.HashNet, HashFamily: "inet"}
+	assert.NoError(t, fakeIPSet.CreateSet(src, true))
+	assert.NoError(t, fakeIPSet.CreateSet(other, true))
+	assert.NoError(t, fakeIPSet.AddEntry("10.244.0.11", src, true))
+	assert.NoError(t, fakeIPSet.AddEntry("10.244.0.10", src, true))
+	assert.NoError(t, fakeIPSet.AddEntry("10.244.0.12", other, true))
+
+	dp := &datapathAPI{police: &policeReconciler{ipset: fakeIPSet}}
+	srv, err := apiserver.New(":0", logger.NewStdoutLogger(os.Getenv("LOG_LEVEL")), dp.register)
+	assert.NoError(t, err)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	cli := client.NewHTTPClientWithConfig(strfmt.Default, client.DefaultTransportConfig().WithHost(u.Host))
+	res, err := cli.Datapath.GetDatapathIpsets(datapath.NewGetDatapathIpsetsParams())
+	assert.NoError(t, err)
+
+	// only the ipsets of the agent are listed
+	assert.Len(t, res.Payload, 1)
+	assert.Equal(t, "egress-src-v4-test", res.Payload[0].Name)
+	assert.Equal(t, []string{"10.244.0.10", "10.244.0.11"}, res.Payload[0].Members)
+}
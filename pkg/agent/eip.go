@@ -75,11 +75,11 @@ func (r *eip) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.R
 }
 
 // newEipCtrl return a new egress ip controller
-func newEipCtrl(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
+func newEipCtrl(mgr manager.Manager, log *zap.Logger, cfg *config.Config) (*eip, error) {
 	lw := logWrapper{log: log.Named("layer2")}
 	an, err := layer2.New(lw, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	eip := &eip{
@@ -91,15 +91,15 @@ func newEipCtrl(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error
 
 	c, err := controller.New("eip", mgr, controller.Options{Reconciler: eip})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err = c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressGateway{}),
 		&handler.EnqueueRequestForObject{}); err != nil {
-		return fmt.Errorf("failed to watch EgressGateway: %v", err)
+		return nil, fmt.Errorf("failed to watch EgressGateway: %v", err)
 	}
 
-	return nil
+	return eip, nil
 }
 
 type logWrapper struct {
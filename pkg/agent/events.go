@@ -0,0 +1,96 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+// the reasons of the Events recorded by the agent
+const (
+	// eventDatapathSyncFailed is recorded on a policy when its ipsets or rules fail to apply
+	eventDatapathSyncFailed = "DatapathSyncFailed"
+	// eventPolicySyncFailed is recorded on the EgressNode when the rules of the policies fail to apply
+	eventPolicySyncFailed = "PolicySyncFailed"
+	// eventVXLANSyncFailed is recorded on the EgressNode when the vxlan device fails to set up
+	eventVXLANSyncFailed = "VXLANSyncFailed"
+	// eventRouteSyncFailed is recorded on the EgressNode when the routes of the peers fail to set up
+	eventRouteSyncFailed = "RouteSyncFailed"
+)
+
+// eventRecorder records the failures of the datapath of the node. The
+// datapath is applied again and again, so an Event is only recorded when
+// the error of an object is changed, the recorder of the manager aggregates
+// and rate limits the rest.
+type eventRecorder struct {
+	recorder record.EventRecorder
+	client   client.Client
+	nodeName string
+	// last is the last message of each object and reason
+	last *utils.SyncMap[string, string]
+}
+
+func newEventRecorder(mgr manager.Manager, nodeName string) *eventRecorder {
+	return &eventRecorder{
+		recorder: mgr.GetEventRecorderFor("egressgateway-agent"),
+		client:   mgr.GetClient(),
+		nodeName: nodeName,
+		last:     utils.NewSyncMap[string, string](),
+	}
+}
+
+// changed reports whether the message of the key is changed, an empty
+// message clears the key
+func (r *eventRecorder) changed(key, msg string) bool {
+	if msg == "" {
+		r.last.Delete(key)
+		return false
+	}
+	if old, ok := r.last.Load(key); ok && old == msg {
+		return false
+	}
+	r.last.Store(key, msg)
+	return true
+}
+
+// warning records a Warning Event on obj when err is not nil
+func (r *eventRecorder) warning(obj client.Object, reason string, err error, what string) {
+	key := reason + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	msg := ""
+	if err != nil {
+		msg = fmt.Sprintf("%s on node %s: %v", what, r.nodeName, err)
+	}
+	if r.changed(key, msg) {
+		r.recorder.Event(obj, corev1.EventTypeWarning, reason, msg)
+	}
+}
+
+// nodeWarning records a Warning Event on the EgressNode of the node when err is not nil
+func (r *eventRecorder) nodeWarning(reason string, err error, what string) {
+	key := reason + "/" + r.nodeName
+	msg := ""
+	if err != nil {
+		msg = fmt.Sprintf("%s: %v", what, err)
+	}
+	if !r.changed(key, msg) {
+		return
+	}
+	node := new(egressv1.EgressNode)
+	if err := r.client.Get(context.Background(), types.NamespacedName{Name: r.nodeName}, node); err != nil {
+		// recorded again in the next failure
+		r.last.Delete(key)
+		return
+	}
+	r.recorder.Event(node, corev1.EventTypeWarning, reason, msg)
+}
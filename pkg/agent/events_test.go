@@ -0,0 +1,62 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+func TestEventRecorder(t *testing.T) {
+	node := &egressv1.EgressNode{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	recorder := record.NewFakeRecorder(10)
+	r := &eventRecorder{
+		recorder: recorder,
+		client:   fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(node).Build(),
+		nodeName: "node1",
+		last:     utils.NewSyncMap[string, string](),
+	}
+	events := func() []string {
+		var res []string
+		for len(recorder.Events) > 0 {
+			res = append(res, <-recorder.Events)
+		}
+		return res
+	}
+
+	// the same error is recorded once
+	r.nodeWarning(eventVXLANSyncFailed, fmt.Errorf("link down"), "Failed to set up the vxlan device")
+	r.nodeWarning(eventVXLANSyncFailed, fmt.Errorf("link down"), "Failed to set up the vxlan device")
+	assert.Equal(t, []string{"Warning VXLANSyncFailed Failed to set up the vxlan device: link down"}, events())
+
+	// a changed error is recorded
+	r.nodeWarning(eventVXLANSyncFailed, fmt.Errorf("no parent"), "Failed to set up the vxlan device")
+	assert.Equal(t, []string{"Warning VXLANSyncFailed Failed to set up the vxlan device: no parent"}, events())
+
+	// the error is recorded again after it is cleared
+	r.nodeWarning(eventVXLANSyncFailed, nil, "Failed to set up the vxlan device")
+	r.nodeWarning(eventVXLANSyncFailed, fmt.Errorf("no parent"), "Failed to set up the vxlan device")
+	assert.Len(t, events(), 1)
+
+	policy := &egressv1.EgressPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"}}
+	r.warning(policy, eventDatapathSyncFailed, fmt.Errorf("ipset failed"), "Failed to apply the policy")
+	r.warning(policy, eventDatapathSyncFailed, fmt.Errorf("ipset failed"), "Failed to apply the policy")
+	assert.Equal(t, []string{"Warning DatapathSyncFailed Failed to apply the policy on node node1: ipset failed"}, events())
+
+	// the EgressNode of another node is not found
+	r.nodeName = "node2"
+	r.nodeWarning(eventRouteSyncFailed, fmt.Errorf("failed"), "Failed to set up the routes of the peers")
+	assert.Empty(t, events())
+	_, ok := r.last.Load(eventRouteSyncFailed + "/node2")
+	assert.False(t, ok)
+}
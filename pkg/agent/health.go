@@ -0,0 +1,31 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	"github.com/spidernet-io/egressgateway/pkg/health"
+)
+
+// newProbes returns the checks of the agent, it is ready when the vxlan
+// device is up, the last setup of the routes of the peers succeeded, the
+// policies were applied once and no apply of the iptables rules and ipsets
+// failed since the last full apply. The checks only depend on the datapath
+// of the node, not on the objects in the apiserver.
+func newProbes(vxlanR *vxlanReconciler, police *policeReconciler) health.Probes {
+	return health.Probes{
+		Readiness: health.Checks{
+			"vxlan":       func(_ *http.Request) error { return vxlanR.vxlan.Check() },
+			"vxlan-route": vxlanR.routeState.Check,
+			"policy-init": police.initState.Check,
+			"policy-sync": police.syncState.Check,
+		},
+		Liveness: health.Checks{
+			"ping": healthz.Ping,
+		},
+	}
+}
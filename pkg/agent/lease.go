@@ -0,0 +1,175 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// leaseKeeper renews the lease of the node while it is a gateway node, the
+// controller marks the EgressNode failed and moves its policies when the
+// lease expires
+type leaseKeeper struct {
+	client client.Client
+	// reader reads the lease from the apiserver, the leases are not cached by the agent
+	reader client.Reader
+	log    *zap.Logger
+	cfg    *config.Config
+	clock  clock.WithTicker
+	lease  *coordinationv1.Lease
+}
+
+// Start renews the lease until ctx is done
+func (k *leaseKeeper) Start(ctx context.Context) error {
+	interval := time.Duration(k.cfg.FileConfig.NodeLease.RenewIntervalMillis) * time.Millisecond
+	ticker := k.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	k.log.Sugar().Infof("renew the lease of the node every %v", interval)
+	for {
+		if err := k.renew(ctx); err != nil {
+			k.log.Warn("failed to renew the lease of the node", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C():
+		}
+	}
+}
+
+// renew creates the lease of the node or updates its renew time, the nodes
+// of no EgressGateway do not renew the lease
+func (k *leaseKeeper) renew(ctx context.Context) error {
+	nodeName := k.cfg.EnvConfig.NodeName
+	isGateway, err := k.isGatewayNode(ctx)
+	if err != nil {
+		return err
+	}
+	if !isGateway {
+		return nil
+	}
+
+	now := metav1.NewMicroTime(k.clock.Now())
+	key := types.NamespacedName{
+		Namespace: k.cfg.EnvConfig.PodNamespace,
+		Name:      egressv1.EgressNodeLeasePrefix + nodeName,
+	}
+
+	if k.lease == nil {
+		lease := new(coordinationv1.Lease)
+		err := k.reader.Get(ctx, key, lease)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to get lease %v: %v", key, err)
+			}
+			lease, err = k.newLease(ctx, key, now)
+			if err != nil {
+				return err
+			}
+			if err := k.client.Create(ctx, lease); err != nil {
+				return fmt.Errorf("failed to create lease %v: %v", key, err)
+			}
+			k.lease = lease
+			return nil
+		}
+		k.lease = lease
+	}
+
+	k.lease.Spec.HolderIdentity = &nodeName
+	k.lease.Spec.LeaseDurationSeconds = leaseDurationSeconds(k.cfg.FileConfig.NodeLease.LeaseDurationMillis)
+	k.lease.Spec.RenewTime = &now
+	if err := k.client.Update(ctx, k.lease); err != nil {
+		// get the lease again on the next renewal
+		k.lease = nil
+		return fmt.Errorf("failed to update lease %v: %v", key, err)
+	}
+	return nil
+}
+
+// isGatewayNode reports whether the node is a node of an EgressGateway
+func (k *leaseKeeper) isGatewayNode(ctx context.Context) (bool, error) {
+	gateways := new(egressv1.EgressGatewayList)
+	if err := k.client.List(ctx, gateways); err != nil {
+		return false, fmt.Errorf("failed to list EgressGateway: %v", err)
+	}
+	for _, gateway := range gateways.Items {
+		for _, node := range gateway.Status.NodeList {
+			if node.Name == k.cfg.EnvConfig.NodeName {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// newLease returns the lease of the node, it is owned by the Node, so it is
+// removed with the Node
+func (k *leaseKeeper) newLease(ctx context.Context, key types.NamespacedName, now metav1.MicroTime) (*coordinationv1.Lease, error) {
+	nodeName := k.cfg.EnvConfig.NodeName
+	node := new(corev1.Node)
+	if err := k.reader.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			Labels:    map[string]string{egressv1.LabelEgressNodeLease: nodeName},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Node",
+				Name:       node.Name,
+				UID:        node.UID,
+			}},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &nodeName,
+			LeaseDurationSeconds: leaseDurationSeconds(k.cfg.FileConfig.NodeLease.LeaseDurationMillis),
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}, nil
+}
+
+// leaseDurationSeconds rounds the lease duration up to seconds, the
+// controller checks the lease with the duration in milliseconds
+func leaseDurationSeconds(millis int) *int32 {
+	res := int32((millis + 999) / 1000)
+	return &res
+}
+
+func newLeaseKeeper(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
+	if !cfg.FileConfig.NodeLease.Enable {
+		log.Info("node lease is disabled")
+		return nil
+	}
+	if cfg.FileConfig.NodeLease.RenewIntervalMillis <= 0 {
+		return fmt.Errorf("invalid nodeLease.renewIntervalMillis %d", cfg.FileConfig.NodeLease.RenewIntervalMillis)
+	}
+
+	return mgr.Add(&leaseKeeper{
+		client: mgr.GetClient(),
+		reader: mgr.GetAPIReader(),
+		log:    log.Named("lease"),
+		cfg:    cfg,
+		clock:  clock.RealClock{},
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestLeaseKeeperRenew(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakeClock(start)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", UID: "uid1"}}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(node).Build()
+	k := &leaseKeeper{
+		client: cli,
+		reader: cli,
+		log:    zap.NewNop(),
+		cfg: &config.Config{
+			EnvConfig: config.EnvConfig{NodeName: "node1", PodNamespace: "kube-system"},
+			FileConfig: config.FileConfig{NodeLease: config.NodeLease{
+				Enable:              true,
+				LeaseDurationMillis: 1500,
+				RenewIntervalMillis: 500,
+			}},
+		},
+		clock: fakeClock,
+	}
+	key := types.NamespacedName{Namespace: "kube-system", Name: egressv1.EgressNodeLeasePrefix + "node1"}
+
+	// the node of no EgressGateway does not renew the lease
+	assert.NoError(t, k.renew(ctx))
+	lease := new(coordinationv1.Lease)
+	assert.True(t, errors.IsNotFound(cli.Get(ctx, key, lease)))
+
+	eg := &egressv1.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Status:     egressv1.EgressGatewayStatus{NodeList: []egressv1.EgressIPStatus{{Name: "node1"}}},
+	}
+	assert.NoError(t, cli.Create(ctx, eg))
+	assert.NoError(t, k.renew(ctx))
+	assert.NoError(t, cli.Get(ctx, key, lease))
+	assert.Equal(t, "node1", lease.Labels[egressv1.LabelEgressNodeLease])
+	assert.Equal(t, "node1", *lease.Spec.HolderIdentity)
+	assert.Equal(t, int32(2), *lease.Spec.LeaseDurationSeconds)
+	assert.Equal(t, types.UID("uid1"), lease.OwnerReferences[0].UID)
+	assert.True(t, lease.Spec.RenewTime.Time.Equal(start))
+
+	fakeClock.Step(500 * time.Millisecond)
+	assert.NoError(t, k.renew(ctx))
+	assert.NoError(t, cli.Get(ctx, key, lease))
+	assert.True(t, lease.Spec.RenewTime.Time.Equal(start.Add(500*time.Millisecond)))
+
+	// the lease is read again after it is changed by others
+	lease.Spec.HolderIdentity = nil
+	assert.NoError(t, cli.Update(ctx, lease))
+	fakeClock.Step(500 * time.Millisecond)
+	assert.Error(t, k.renew(ctx))
+	assert.NoError(t, k.renew(ctx))
+	assert.NoError(t, cli.Get(ctx, key, lease))
+	assert.Equal(t, "node1", *lease.Spec.HolderIdentity)
+	assert.True(t, lease.Spec.RenewTime.Time.Equal(start.Add(time.Second)))
+}
@@ -9,17 +9,18 @@ import (
 	"fmt"
 	"net"
 	"path"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"time"
 
+	"github.com/vishvananda/netlink"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/utils/exec"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -29,9 +30,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/health"
 	"github.com/spidernet-io/egressgateway/pkg/ipset"
 	"github.com/spidernet-io/egressgateway/pkg/iptables"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/policymatch"
 	"github.com/spidernet-io/egressgateway/pkg/utils"
 )
 
@@ -50,10 +53,29 @@ type policeReconciler struct {
 
 	ruleV4Map     *utils.SyncMap[string, iptables.Rule]
 	ruleV6Map     *utils.SyncMap[string, iptables.Rule]
-	mangleTables  []*iptables.Table
-	filterTables  []*iptables.Table
-	natTables     []*iptables.Table
+	mangleTables  []ruleTable
+	filterTables  []ruleTable
+	natTables     []ruleTable
 	policyMapNode *utils.SyncMap[egressv1.Policy, string]
+	// policyRules records the spec each policy rule was built with
+	policyRules *utils.SyncMap[egressv1.Policy, policyRuleSpec]
+	// programmed records the policies handled by the agent, it is reported
+	// in the status of the EgressNode
+	programmed *utils.SyncMap[egressv1.Policy, egressv1.ProgrammedPolicy]
+	// initState records the first apply of the policies, syncState records
+	// the failures of the iptables rules and the ipsets of the policies since
+	// the last full apply
+	initState health.SyncState
+	syncState health.SyncState
+	events    *eventRecorder
+	// policyPaths records the path of each policy of the last apply, the
+	// conntrack entries of a policy are deleted by deleteFlows when its
+	// path is changed
+	policyPaths map[egressv1.Policy]policyPath
+	// policySources records the sources of the policies, the entries of the
+	// sources are deleted when the policy is moved or removed
+	policySources *utils.SyncMap[egressv1.Policy, []string]
+	deleteFlows   func(family netlink.InetFamily, filter netlink.CustomConntrackFilter) (uint, error)
 }
 
 func (r *policeReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
@@ -61,6 +83,7 @@ func (r *policeReconciler) Reconcile(ctx context.Context, req reconcile.Request)
 		r.log.Sugar().Info("first reconcile of policy controller, init apply policy")
 	redo:
 		err := r.initApplyPolicy()
+		r.initState.Record(err)
 		if err != nil {
 			r.log.Sugar().Error("first reconcile of policy controller, init apply policy, with error:", err)
 			goto redo
@@ -78,25 +101,69 @@ func (r *policeReconciler) Reconcile(ctx context.Context, req reconcile.Request)
 	switch kind {
 	case "EgressGateway":
 		res, err = r.reconcileGateway(ctx, newReq, log)
-		return res, err
 	case "EgressClusterPolicy":
 		res, err = r.reconcileClusterPolicy(ctx, newReq, log)
-		return res, err
 	case "EgressPolicy":
 		res, err = r.reconcilePolicy(ctx, newReq, log)
-		return res, err
 	case "EgressClusterInfo":
 		res, err = r.reconcileClusterInfo(ctx, newReq, log)
 	default:
 		return reconcile.Result{}, nil
 	}
+
+	if reportErr := r.reportProgrammed(ctx); reportErr != nil {
+		log.Warn("failed to report programmed policies", zap.Error(reportErr))
+		res.Requeue = true
+	}
 	return res, err
 }
 
 type PolicyCommon struct {
-	NodeName   string
-	DestSubnet []string
-	IP         IP
+	// NodeNames are the gateway nodes of the policy
+	NodeNames         []string
+	DestSubnet        []string
+	ExcludeDestSubnet []string
+	DestDomains       []string
+	// DestDomainIPs are the addresses of DestDomains resolved by the controller
+	DestDomainIPs egressv1.IPListPair
+	PodSubnet     []string
+	IP            IP
+	DestPorts     []egressv1.PolicyPort
+	Priority      uint64
+	Generation    int64
+	// Gateway is the EgressGateway of the policy
+	Gateway string
+	// EIPs are the EIPs of the policy by gateway node
+	EIPs map[string]IP
+}
+
+// isIgnoreInternalCIDR reports whether the policy matches all destinations
+// outside the cluster, instead of the destination ipset
+func (p *PolicyCommon) isIgnoreInternalCIDR() bool {
+	return policymatch.MatchAllDest(p.DestSubnet, p.DestDomains)
+}
+
+// isExcludeDest reports whether the rules of the policy skip the destinations
+// in the exclusion ipset
+func (p *PolicyCommon) isExcludeDest() bool {
+	return len(p.ExcludeDestSubnet) > 0
+}
+
+// policyRuleSpec is the part of the policy spec the iptables rules are built from
+type policyRuleSpec struct {
+	Priority           uint64
+	DestPorts          string
+	IgnoreInternalCIDR bool
+	ExcludeDest        bool
+}
+
+func newPolicyRuleSpec(val *PolicyCommon) policyRuleSpec {
+	return policyRuleSpec{
+		Priority:           val.Priority,
+		DestPorts:          fmt.Sprint(val.DestPorts),
+		IgnoreInternalCIDR: val.isIgnoreInternalCIDR(),
+		ExcludeDest:        val.isExcludeDest(),
+	}
 }
 
 type IP struct {
@@ -104,6 +171,14 @@ type IP struct {
 	V6 string
 }
 
+// version returns the IP of the IP version
+func (ip IP) version(version uint8) string {
+	if version == 6 {
+		return ip.V6
+	}
+	return ip.V4
+}
+
 // initApplyPolicy init applies the given policy
 // list egress gateway
 // list policy/cluster-policy
@@ -111,12 +186,16 @@ type IP struct {
 // build ipset
 // build route table rule
 // build iptables
-func (r *policeReconciler) initApplyPolicy() error {
+func (r *policeReconciler) initApplyPolicy() (err error) {
+	defer func() {
+		r.syncState.Record(err)
+		r.events.nodeWarning(eventPolicySyncFailed, err, "Failed to apply the policies")
+	}()
 	r.log.Info("apply policy")
 	ctx := context.Background()
 
 	gateways := new(egressv1.EgressGatewayList)
-	err := r.client.List(ctx, gateways)
+	err = r.client.List(ctx, gateways)
 	if err != nil {
 		return fmt.Errorf("failed to list gateway: %v", err)
 	}
@@ -133,38 +212,54 @@ func (r *policeReconciler) initApplyPolicy() error {
 				for _, eip := range list.Eips {
 					for _, policy := range eip.Policies {
 						snatPolicies[policy] = &PolicyCommon{
-							NodeName: list.Name,
-							IP:       IP{V4: eip.IPv4, V6: eip.IPv6},
+							NodeNames: []string{list.Name},
+							IP:        IP{V4: eip.IPv4, V6: eip.IPv6},
+							Gateway:   item.Name,
 						}
 					}
 				}
 			} else {
 				for _, eip := range list.Eips {
 					for _, policy := range eip.Policies {
-						unSnatPolicies[policy] = &PolicyCommon{NodeName: list.Name}
+						if val, ok := unSnatPolicies[policy]; ok {
+							val.NodeNames = append(val.NodeNames, list.Name)
+							val.EIPs[list.Name] = IP{V4: eip.IPv4, V6: eip.IPv6}
+							continue
+						}
+						unSnatPolicies[policy] = &PolicyCommon{
+							NodeNames: []string{list.Name},
+							Gateway:   item.Name,
+							EIPs:      map[string]IP{list.Name: {V4: eip.IPv4, V6: eip.IPv6}},
+						}
 					}
 				}
 			}
 		}
 	}
 
+	// the traffic of a policy spread across several gateway nodes is sent
+	// out by this node directly when it is one of them
+	for policy := range snatPolicies {
+		delete(unSnatPolicies, policy)
+	}
+
 	for policy, val := range unSnatPolicies {
-		val.DestSubnet, err = r.getPolicySubnet(policy.Namespace, policy.Name)
+		err = r.getPolicySpec(policy.Namespace, policy.Name, val)
 		if err != nil {
 			return err
 		}
-		err := r.updatePolicyIPSet(policy.Namespace, policy.Name, false, val.DestSubnet)
+		err := r.updatePolicyIPSet(policy.Namespace, policy.Name, false, val)
 		if err != nil {
 			return err
 		}
 	}
 
 	for policy, val := range snatPolicies {
-		val.DestSubnet, err = r.getPolicySubnet(policy.Namespace, policy.Name)
+		err = r.getPolicySpec(policy.Namespace, policy.Name, val)
 		if err != nil {
 			return err
 		}
-		err := r.updatePolicyIPSet(policy.Namespace, policy.Name, true, val.DestSubnet)
+		err := r.updatePolicyIPSet(policy.Namespace, policy.Name, true, val)
 		if err != nil {
 			return err
 		}
@@ -190,32 +285,34 @@ func (r *policeReconciler) initApplyPolicy() error {
 		}
 	}
 
+	paths := make(map[egressv1.Policy]policyPath)
+	skipped := make(map[egressv1.Policy]error)
 	for _, table := range r.mangleTables {
 		rules := make([]iptables.Rule, 0)
-		for policy, val := range unSnatPolicies {
-			node := new(egressv1.EgressNode)
-			err := r.client.Get(context.Background(), types.NamespacedName{Name: val.NodeName}, node)
+		for _, policy := range sortPolicyByPriority(unSnatPolicies) {
+			val := unSnatPolicies[policy]
+			marks, err := r.getNodeMarks(val.NodeNames)
 			if err != nil {
 				r.log.Warn("failed to get eip node information of policy, skip building rule of policy")
+				skipped[policy] = err
+				if old, ok := r.policyPaths[policy]; ok {
+					paths[policy] = old
+				}
 				continue
 			}
+			paths[policy] = policyPath{marks: marks}
 			policyName := policy.Name
 			if policy.Namespace != "" {
 				policyName = fmt.Sprintf("%s-%s", policy.Namespace, policy.Name)
 			}
 
-			mark, err := parseMark(node.Status.Mark)
-			if err != nil {
-				return err
-			}
-
-			isIgnoreInternalCIDR := false
-			if len(val.DestSubnet) <= 0 {
-				isIgnoreInternalCIDR = true
+			var policyRules []iptables.Rule
+			if len(marks) == 1 {
+				policyRules = r.buildPolicyRule(policyName, marks[0], baseMark, table.TableIPVersion(), val.isIgnoreInternalCIDR(), val.isExcludeDest(), val.DestPorts)
+			} else {
+				policyRules = buildBalancedPolicyRule(policyName, marks, baseMark, table.TableIPVersion(), val.isIgnoreInternalCIDR(), val.isExcludeDest(), val.DestPorts)
 			}
-
-			rule := r.buildPolicyRule(policyName, mark, table.IPVersion, isIgnoreInternalCIDR)
-			rules = append(rules, *rule)
+			rules = append(rules, markRuleComments(policyRules, policy, val, marks, table.TableIPVersion())...)
 		}
 		table.UpdateChain(&iptables.Chain{
 			Name:  "EGRESSGATEWAY-MARK-REQUEST",
@@ -223,23 +320,28 @@ func (r *policeReconciler) initApplyPolicy() error {
 		})
 	}
 
+	var nodeIP IP
+	if len(snatPolicies) > 0 {
+		nodeIP = r.nodeIP(ctx)
+	}
 	for _, table := range r.natTables {
 		rules := make([]iptables.Rule, 0)
-		for policy, val := range snatPolicies {
+		for _, policy := range sortPolicyByPriority(snatPolicies) {
+			val := snatPolicies[policy]
+			paths[policy] = policyPath{snat: true, eip: val.IP}
+			if val.IP.V4 == "" && val.IP.V6 == "" {
+				paths[policy] = policyPath{snat: true, eip: nodeIP}
+			}
 			policyName := policy.Name
 			if policy.Namespace != "" {
 				policyName = fmt.Sprintf("%s-%s", policy.Namespace, policy.Name)
 			}
 
-			isIgnoreInternalCIDR := false
-			if len(val.DestSubnet) <= 0 {
-				isIgnoreInternalCIDR = true
-			}
-
-			rule := buildEipRule(policyName, val.IP, table.IPVersion, isIgnoreInternalCIDR)
-			if rule != nil {
-				rules = append(rules, *rule)
+			eipRules := buildEipRule(policyName, val.IP, table.TableIPVersion(), val.isIgnoreInternalCIDR(), val.isExcludeDest(), val.DestPorts)
+			for i := range eipRules {
+				eipRules[i].Comment = trafficComments(policy, val.Gateway, val.IP.version(table.TableIPVersion()))
 			}
+			rules = append(rules, eipRules...)
 		}
 
 		table.UpdateChain(&iptables.Chain{Name: "EGRESSGATEWAY-SNAT-EIP", Rules: rules})
@@ -254,26 +356,83 @@ func (r *policeReconciler) initApplyPolicy() error {
 	for _, table := range allTables {
 		_, err := table.Apply()
 		if err != nil {
-			return fmt.Errorf("failed to apply rule %v: %v", table.Name, err)
+			return fmt.Errorf("failed to apply rule %v: %v", table.TableName(), err)
 		}
 	}
+	r.flushMovedPolicies(paths)
+
+	for policy, val := range unSnatPolicies {
+		r.policyRules.Store(policy, newPolicyRuleSpec(val))
+		r.ackPolicy(policy, val.Generation, skipped[policy])
+	}
+	for policy, val := range snatPolicies {
+		r.policyRules.Store(policy, newPolicyRuleSpec(val))
+		r.ackPolicy(policy, val.Generation, nil)
+	}
 
 	return nil
 }
 
-func (r *policeReconciler) getPolicySubnet(ns, name string) ([]string, error) {
-	var obj client.Object
-	key := types.NamespacedName{Namespace: ns, Name: name}
-	getSubnet := func(obj client.Object) []string {
-		switch obj := obj.(type) {
-		case *egressv1.EgressPolicy:
-			return obj.Spec.DestSubnet
-		case *egressv1.EgressClusterPolicy:
-			return obj.Spec.DestSubnet
-		default:
-			return nil
+// getNodeMarks returns the marks of the gateway nodes, sorted by the node name
+func (r *policeReconciler) getNodeMarks(nodeNames []string) ([]uint32, error) {
+	names := append([]string{}, nodeNames...)
+	sort.Strings(names)
+	marks := make([]uint32, 0, len(names))
+	for _, name := range names {
+		node := new(egressv1.EgressNode)
+		err := r.client.Get(context.Background(), types.NamespacedName{Name: name}, node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get EgressNode %s: %v", name, err)
+		}
+		mark, err := parseMark(node.Status.Mark)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse mark of EgressNode %s: %v", name, err)
 		}
+		marks = append(marks, mark)
 	}
+	return marks, nil
+}
+
+// sortPolicyByPriority returns the policies ordered from the highest priority
+// to the lowest, the rule of a higher priority policy is matched first
+func sortPolicyByPriority(policies map[egressv1.Policy]*PolicyCommon) []egressv1.Policy {
+	res := make([]egressv1.Policy, 0, len(policies))
+	for policy := range policies {
+		res = append(res, policy)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return egressv1.PolicyLess(res[i], policies[res[i]].Priority, res[j], policies[res[j]].Priority)
+	})
+	return res
+}
+
+// setPolicySpec fills val with the spec of the EgressPolicy or EgressClusterPolicy obj
+func setPolicySpec(val *PolicyCommon, obj client.Object) {
+	switch obj := obj.(type) {
+	case *egressv1.EgressPolicy:
+		val.DestSubnet = obj.Spec.DestSubnet
+		val.ExcludeDestSubnet = obj.Spec.ExcludeDestSubnet
+		val.DestDomains = obj.Spec.DestDomains
+		val.DestDomainIPs = obj.Status.DestDomainIPs
+		val.PodSubnet = obj.Spec.AppliedTo.PodSubnet
+		val.DestPorts = obj.Spec.DestPorts
+		val.Priority = obj.GetPriority()
+		val.Generation = obj.Generation
+	case *egressv1.EgressClusterPolicy:
+		val.DestSubnet = obj.Spec.DestSubnet
+		val.ExcludeDestSubnet = obj.Spec.ExcludeDestSubnet
+		val.DestDomains = obj.Spec.DestDomains
+		val.DestDomainIPs = obj.Status.DestDomainIPs
+		val.PodSubnet = getClusterPodSubnet(obj)
+		val.DestPorts = obj.Spec.DestPorts
+		val.Priority = obj.GetPriority()
+		val.Generation = obj.Generation
+	}
+}
+
+func (r *policeReconciler) getPolicySpec(ns, name string, val *PolicyCommon) error {
+	var obj client.Object
+	key := types.NamespacedName{Namespace: ns, Name: name}
 	if ns != "" {
 		obj = new(egressv1.EgressPolicy)
 	} else {
@@ -282,30 +441,58 @@ func (r *policeReconciler) getPolicySubnet(ns, name string) ([]string, error) {
 	err := r.client.Get(context.Background(), key, obj)
 	if err != nil {
 		if !errors.IsNotFound(err) {
-			return nil, err
+			return err
 		}
 	}
-	return getSubnet(obj), nil
+	setPolicySpec(val, obj)
+	return nil
 }
 
-func (r *policeReconciler) updatePolicyIPSet(policyNs string, policyName string, isEipNodeSet bool, destSubnet []string) error {
-	// calculate src ip list
-	srcIPv4List, srcIPv6List, err := r.getPolicySrcIPs(policyNs, policyName, func(e egressv1.EgressEndpoint) bool {
-		if e.Node == r.cfg.EnvConfig.NodeName {
-			return true
-		}
-		if isEipNodeSet {
-			return true
-		}
-		return false
-	})
+func getClusterPodSubnet(policy *egressv1.EgressClusterPolicy) []string {
+	if policy.Spec.AppliedTo.PodSubnet == nil {
+		return nil
+	}
+	return *policy.Spec.AppliedTo.PodSubnet
+}
 
+// updatePolicyIPSet update the src and dst ipset of the policy, the src ipset
+// is built from podSubnet when it is set, otherwise from the endpoint slices,
+// the dst ipset is built from destSubnet and the addresses of destDomains
+func (r *policeReconciler) updatePolicyIPSet(policyNs string, policyName string, isEipNodeSet bool, spec *PolicyCommon) error {
+	// calculate src ip list
+	var srcIPv4List, srcIPv6List []string
+	var err error
+	if len(spec.PodSubnet) > 0 {
+		srcIPv4List, srcIPv6List, err = r.getDstCIDR(spec.PodSubnet)
+	} else {
+		srcIPv4List, srcIPv6List, err = r.getPolicySrcIPs(policyNs, policyName, func(e egressv1.EgressEndpoint) bool {
+			if e.Node == r.cfg.EnvConfig.NodeName {
+				return true
+			}
+			if isEipNodeSet {
+				return true
+			}
+			return false
+		})
+	}
 	if err != nil {
 		return err
 	}
+	r.policySources.Store(egressv1.Policy{Namespace: policyNs, Name: policyName},
+		append(append([]string{}, srcIPv4List...), srcIPv6List...))
 
 	// calculate dst ip list
-	dstIPv4List, dstIPv6List, err := r.getDstCIDR(destSubnet)
+	dstIPv4List, dstIPv6List, err := r.getDstCIDR(spec.DestSubnet)
+	if err != nil {
+		return err
+	}
+	// the domains are resolved by the controller, so that the gateway node
+	// matches the same addresses as the other nodes
+	if len(spec.DestDomains) > 0 {
+		dstIPv4List = append(dstIPv4List, spec.DestDomainIPs.IPv4...)
+		dstIPv6List = append(dstIPv6List, spec.DestDomainIPs.IPv6...)
+	}
+	excludeIPv4List, excludeIPv6List, err := r.getDstCIDR(spec.ExcludeDestSubnet)
 	if err != nil {
 		return err
 	}
@@ -343,6 +530,12 @@ func (r *policeReconciler) updatePolicyIPSet(policyNs string, policyName string,
 			} else if r.cfg.FileConfig.EnableIPv6 {
 				toAddList[set.Name], toDelList[set.Name] = findDiff(oldIPList, dstIPv6List)
 			}
+		case IPExcludeDst:
+			if set.Stack == IPv4 && r.cfg.FileConfig.EnableIPv4 {
+				toAddList[set.Name], toDelList[set.Name] = findDiff(oldIPList, excludeIPv4List)
+			} else if r.cfg.FileConfig.EnableIPv6 {
+				toAddList[set.Name], toDelList[set.Name] = findDiff(oldIPList, excludeIPv6List)
+			}
 		}
 		return nil
 	})
@@ -426,7 +619,7 @@ func (r *policeReconciler) getPolicySrcIPs(policyNs, policyName string, filter f
 	return ipv4List, ipv6List, nil
 }
 
-func buildEipRule(policyName string, eip IP, version uint8, isIgnoreInternalCIDR bool) *iptables.Rule {
+func buildEipRule(policyName string, eip IP, version uint8, isIgnoreInternalCIDR, isExcludeDest bool, ports []egressv1.PolicyPort) []iptables.Rule {
 	if eip.V4 == "" && eip.V6 == "" {
 		return nil
 	}
@@ -449,10 +642,37 @@ func buildEipRule(policyName string, eip IP, version uint8, isIgnoreInternalCIDR
 		matchCriteria = iptables.MatchCriteria{}.SourceIPSet(srcName).NotDestIPSet(ignoreName).
 			CTDirectionOriginal(iptables.DirectionOriginal)
 	}
+	if isExcludeDest {
+		matchCriteria = matchCriteria.NotDestIPSet(formatIPSetName("egress-exdst-"+tmp, policyName))
+	}
 
 	action := iptables.SNATAction{ToAddr: ip}
-	rule := &iptables.Rule{Match: matchCriteria, Action: action, Comment: []string{}}
-	return rule
+	rules := make([]iptables.Rule, 0)
+	for _, match := range matchDestPorts(matchCriteria, ports) {
+		rules = append(rules, iptables.Rule{Match: match, Action: action, Comment: []string{}})
+	}
+	return rules
+}
+
+// matchDestPorts returns a match for each of the destination port rules,
+// match itself is returned when there is no port rule
+func matchDestPorts(match iptables.MatchCriteria, ports []egressv1.PolicyPort) []iptables.MatchCriteria {
+	if len(ports) == 0 {
+		return []iptables.MatchCriteria{match}
+	}
+	res := make([]iptables.MatchCriteria, 0, len(ports))
+	for _, port := range ports {
+		protocol := policymatch.DestPortProtocol(port)
+		item := append(iptables.MatchCriteria{}, match...).Protocol(strings.ToLower(protocol))
+		switch {
+		case port.Port != 0 && port.EndPort > port.Port:
+			item = item.DestPortRanges([]*iptables.PortRange{{First: port.Port, Last: port.EndPort}})
+		case port.Port != 0:
+			item = item.DestPorts(uint16(port.Port))
+		}
+		res = append(res, item)
+	}
+	return res
 }
 
 func parseMark(mark string) (uint32, error) {
@@ -475,7 +695,82 @@ func parseMarkToInt(mark string) (int, error) {
 	return i32, nil
 }
 
-func (r *policeReconciler) buildPolicyRule(policyName string, mark uint32, version uint8, isIgnoreInternalCIDR bool) *iptables.Rule {
+// buildPolicyRule build the mark rules of the policy, packets already marked by
+// a higher priority policy are skipped, so the first matched rule wins
+func (r *policeReconciler) buildPolicyRule(policyName string, mark, base uint32, version uint8, isIgnoreInternalCIDR, isExcludeDest bool, ports []egressv1.PolicyPort) []iptables.Rule {
+	matchCriteria := policyMatch(policyName, version, isIgnoreInternalCIDR, isExcludeDest).
+		NotMarkMatchesWithMask(base, 0xff000000)
+
+	action := iptables.SetMaskedMarkAction{Mark: mark, Mask: 0xffffffff}
+	rules := make([]iptables.Rule, 0)
+	for _, match := range matchDestPorts(matchCriteria, ports) {
+		rules = append(rules, iptables.Rule{Match: match, Action: action, Comment: []string{}})
+	}
+	// the mark is saved in the conntrack entry, so that the entries of the
+	// policy are found by the mark when the policy is moved
+	for _, match := range matchDestPorts(policyMatch(policyName, version, isIgnoreInternalCIDR, isExcludeDest), ports) {
+		rules = append(rules, iptables.Rule{
+			Match:   match.MarkMatchesWithMask(base, 0xff000000),
+			Action:  iptables.SaveConnMarkAction{SaveMask: connMarkMask(mark)},
+			Comment: []string{},
+		})
+	}
+	return rules
+}
+
+// buildBalancedPolicyRule build the mark rules of a policy spread across
+// several gateway nodes. The new connections are split randomly between the
+// marks of the gateway nodes, the mark is saved in the conntrack entry, so
+// the packets of an existing connection keep going to the same gateway node.
+func buildBalancedPolicyRule(policyName string, marks []uint32, base uint32, version uint8, isIgnoreInternalCIDR, isExcludeDest bool, ports []egressv1.PolicyPort) []iptables.Rule {
+	policyMatchCriteria := policyMatch(policyName, version, isIgnoreInternalCIDR, isExcludeDest)
+	matchCriteria := append(iptables.MatchCriteria{}, policyMatchCriteria...).NotMarkMatchesWithMask(base, 0xff000000)
+	mask := connMarkMask(marks...)
+
+	rules := make([]iptables.Rule, 0)
+	for _, match := range matchDestPorts(matchCriteria, ports) {
+		for _, mark := range marks {
+			rules = append(rules, iptables.Rule{
+				Match:   append(iptables.MatchCriteria{}, match...).ConnMarkMatchesWithMask(mark, mask),
+				Action:  iptables.SetMaskedMarkAction{Mark: mark, Mask: 0xffffffff},
+				Comment: []string{},
+			})
+		}
+		for i, mark := range marks {
+			item := append(iptables.MatchCriteria{}, match...)
+			if i != len(marks)-1 {
+				item = item.StatisticRandom(1 / float64(len(marks)-i))
+			}
+			rules = append(rules, iptables.Rule{
+				Match:   item,
+				Action:  iptables.SetMaskedMarkAction{Mark: mark, Mask: 0xffffffff},
+				Comment: []string{},
+			})
+		}
+	}
+	for _, match := range matchDestPorts(policyMatchCriteria, ports) {
+		rules = append(rules, iptables.Rule{
+			Match:   match.MarkMatchesWithMask(base, 0xff000000),
+			Action:  iptables.SaveConnMarkAction{SaveMask: mask},
+			Comment: []string{},
+		})
+	}
+	return rules
+}
+
+// connMarkMask returns the bits of the conntrack mark used by the policy, the
+// egress bits of the top byte and the bits of the marks of its gateway
+// nodes, the other bits are kept for the other users of the conntrack mark
+func connMarkMask(marks ...uint32) uint32 {
+	mask := uint32(0xff000000)
+	for _, mark := range marks {
+		mask |= mark
+	}
+	return mask
+}
+
+// policyMatch matches the traffic from the sources to the destinations of the policy
+func policyMatch(policyName string, version uint8, isIgnoreInternalCIDR, isExcludeDest bool) iptables.MatchCriteria {
 	tmp := "v4-"
 	ignoreInternalCIDRName := EgressClusterCIDRIPv4
 	if version == 6 {
@@ -492,10 +787,10 @@ func (r *policeReconciler) buildPolicyRule(policyName string, mark uint32, versi
 		matchCriteria = iptables.MatchCriteria{}.SourceIPSet(srcName).NotDestIPSet(ignoreInternalCIDRName).
 			CTDirectionOriginal(iptables.DirectionOriginal)
 	}
-
-	action := iptables.SetMaskedMarkAction{Mark: mark, Mask: 0xffffffff}
-	rule := &iptables.Rule{Match: matchCriteria, Action: action, Comment: []string{}}
-	return rule
+	if isExcludeDest {
+		matchCriteria = matchCriteria.NotDestIPSet(formatIPSetName("egress-exdst-"+tmp, policyName))
+	}
+	return matchCriteria
 }
 
 func buildNatStaticRule(base uint32) map[string][]iptables.Rule {
@@ -526,44 +821,7 @@ func (r *policeReconciler) reconcileClusterInfo(ctx context.Context, req reconci
 		return reconcile.Result{}, nil
 	}
 
-	ipv4 := make([]string, 0)
-	ipv6 := make([]string, 0)
-
-	addIP := func(items ...string) {
-		for _, ip := range items {
-			ip := net.ParseIP(ip)
-			if ip.To4() != nil {
-				ipv4 = append(ipv4, ip.String())
-			} else if ip.To16() != nil {
-				ipv6 = append(ipv6, ip.String())
-			}
-		}
-	}
-
-	addIP(info.Status.EgressIgnoreCIDR.NodeIP.IPv4...)
-	addIP(info.Status.EgressIgnoreCIDR.NodeIP.IPv6...)
-
-	addCIDR := func(items ...string) {
-		for _, item := range items {
-			ip, cidr, err := net.ParseCIDR(item)
-			if err != nil {
-				continue
-			}
-			if ip.To4() != nil {
-				ipv4 = append(ipv4, cidr.String())
-			} else if ip.To16() != nil {
-				ipv6 = append(ipv6, cidr.String())
-			}
-		}
-	}
-
-	addCIDR(info.Status.EgressIgnoreCIDR.PodCIDR.IPv4...)
-	addCIDR(info.Status.EgressIgnoreCIDR.PodCIDR.IPv6...)
-
-	addCIDR(info.Status.EgressIgnoreCIDR.ClusterIP.IPv4...)
-	addCIDR(info.Status.EgressIgnoreCIDR.ClusterIP.IPv6...)
-
-	addCIDR(r.cfg.FileConfig.EgressIgnoreCIDR.Custom...)
+	ipv4, ipv6 := policymatch.IgnoreCIDRs(info, r.cfg.FileConfig.EgressIgnoreCIDR.Custom)
 
 	process := func(gotList []string, expList []string, toAdd, toDel func(item string) error) error {
 		got := sets.NewString(gotList...)
@@ -705,6 +963,8 @@ func (r *policeReconciler) reconcilePolicy(ctx context.Context, req reconcile.Re
 			r.removeIPSet(log, set.Name)
 			return nil
 		})
+		r.policyRules.Delete(egressv1.Policy{Namespace: req.Namespace, Name: req.Name})
+		r.programmed.Delete(egressv1.Policy{Namespace: req.Namespace, Name: req.Name})
 		return reconcile.Result{}, nil
 	}
 
@@ -717,24 +977,41 @@ func (r *policeReconciler) reconcilePolicy(ctx context.Context, req reconcile.Re
 		return reconcile.Result{}, nil
 	}
 
+	// flag is set when this node is one of the gateway nodes of the policy
 	nodeName := ""
+	flag := false
 	for _, node := range gateway.Status.NodeList {
 		for _, eip := range node.Eips {
 			for _, p := range eip.Policies {
 				if p.Name == policy.Name && p.Namespace == policy.Namespace {
 					nodeName = node.Name
+					if node.Name == r.cfg.EnvConfig.NodeName {
+						flag = true
+					}
 				}
 			}
 		}
 	}
 
-	flag := false
-	if nodeName == r.cfg.EnvConfig.NodeName {
-		flag = true
-	}
-
 	// update event
-	err = r.updatePolicyIPSet(policy.Namespace, policy.Name, flag, policy.Spec.DestSubnet)
+	key := egressv1.Policy{Namespace: policy.Namespace, Name: policy.Name}
+	spec := new(PolicyCommon)
+	setPolicySpec(spec, policy)
+	err = r.updatePolicyIPSet(policy.Namespace, policy.Name, flag, spec)
+	if err == nil {
+		err = r.updatePolicyRule(key, newPolicyRuleSpec(spec), log)
+	}
+	if err == nil && r.syncState.Check(nil) != nil {
+		// the failure of an earlier apply is only cleared by a full apply
+		err = r.initApplyPolicy()
+	} else {
+		r.syncState.RecordFailure(err)
+	}
+	r.events.warning(policy, eventDatapathSyncFailed, err, "Failed to apply the policy")
+	// the rules of the policy are built after a gateway node is selected
+	if nodeName != "" {
+		r.ackPolicy(key, policy.Generation, err)
+	}
 	if err != nil {
 		return reconcile.Result{Requeue: true}, err
 	}
@@ -764,6 +1041,8 @@ func (r *policeReconciler) reconcileClusterPolicy(ctx context.Context, req recon
 			r.removeIPSet(log, set.Name)
 			return nil
 		})
+		r.policyRules.Delete(egressv1.Policy{Namespace: req.Namespace, Name: req.Name})
+		r.programmed.Delete(egressv1.Policy{Namespace: req.Namespace, Name: req.Name})
 		return reconcile.Result{}, nil
 	}
 
@@ -776,30 +1055,96 @@ func (r *policeReconciler) reconcileClusterPolicy(ctx context.Context, req recon
 		return reconcile.Result{Requeue: false}, nil
 	}
 
+	// flag is set when this node is one of the gateway nodes of the policy
 	nodeName := ""
+	flag := false
 	for _, node := range gateway.Status.NodeList {
 		for _, eip := range node.Eips {
 			for _, p := range eip.Policies {
 				if p.Name == policy.Name && p.Namespace == policy.Namespace {
 					nodeName = node.Name
+					if node.Name == r.cfg.EnvConfig.NodeName {
+						flag = true
+					}
 				}
 			}
 		}
 	}
 
-	flag := false
-	if nodeName == r.cfg.EnvConfig.NodeName {
-		flag = true
-	}
-
 	// update event
-	err = r.updatePolicyIPSet(policy.Namespace, policy.Name, flag, policy.Spec.DestSubnet)
+	key := egressv1.Policy{Namespace: policy.Namespace, Name: policy.Name}
+	spec := new(PolicyCommon)
+	setPolicySpec(spec, policy)
+	err = r.updatePolicyIPSet(policy.Namespace, policy.Name, flag, spec)
+	if err == nil {
+		err = r.updatePolicyRule(key, newPolicyRuleSpec(spec), log)
+	}
+	if err == nil && r.syncState.Check(nil) != nil {
+		// the failure of an earlier apply is only cleared by a full apply
+		err = r.initApplyPolicy()
+	} else {
+		r.syncState.RecordFailure(err)
+	}
+	r.events.warning(policy, eventDatapathSyncFailed, err, "Failed to apply the policy")
+	// the rules of the policy are built after a gateway node is selected
+	if nodeName != "" {
+		r.ackPolicy(key, policy.Generation, err)
+	}
 	if err != nil {
 		return reconcile.Result{Requeue: true}, err
 	}
 	return reconcile.Result{}, nil
 }
 
+// updatePolicyRule rebuilds the policy rules when the priority or the
+// destination ports of the policy are changed after the rules were built
+func (r *policeReconciler) updatePolicyRule(policy egressv1.Policy, spec policyRuleSpec, log *zap.Logger) error {
+	old, ok := r.policyRules.Load(policy)
+	if !ok || old == spec {
+		return nil
+	}
+	log.Sugar().Infof("policy rule spec changed from %+v to %+v, rebuild rules", old, spec)
+	return r.initApplyPolicy()
+}
+
+// ackPolicy records the generation of the policy handled by the agent
+func (r *policeReconciler) ackPolicy(policy egressv1.Policy, generation int64, err error) {
+	item := egressv1.ProgrammedPolicy{Name: policy.Name, Namespace: policy.Namespace, Generation: generation}
+	if err != nil {
+		item.Error = err.Error()
+	}
+	r.programmed.Store(policy, item)
+}
+
+// reportProgrammed updates the programmed policies in the status of the EgressNode
+func (r *policeReconciler) reportProgrammed(ctx context.Context) error {
+	list := make([]egressv1.ProgrammedPolicy, 0)
+	r.programmed.Range(func(_ egressv1.Policy, item egressv1.ProgrammedPolicy) bool {
+		list = append(list, item)
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Namespace != list[j].Namespace {
+			return list[i].Namespace < list[j].Namespace
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	node := new(egressv1.EgressNode)
+	err := r.client.Get(ctx, types.NamespacedName{Name: r.cfg.EnvConfig.NodeName}, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if len(list) == 0 && len(node.Status.Policies) == 0 || reflect.DeepEqual(list, node.Status.Policies) {
+		return nil
+	}
+	node.Status.Policies = list
+	return r.client.Status().Update(ctx, node)
+}
+
 func findDiff(oldList, newList []string) (toAdd, toDel []string) {
 	oldCopy := make([]string, len(oldList))
 	copy(oldCopy, oldList)
@@ -908,100 +1253,50 @@ func (r *policeReconciler) createIPSet(log *zap.Logger, set SetName) error {
 	return nil
 }
 
-func newPolicyController(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
-	iptablesCfg := cfg.FileConfig.IPTables
-	opt := iptables.Options{
-		HistoricChainPrefixes:    []string{"egw"},
-		BackendMode:              cfg.FileConfig.IPTables.BackendMode,
-		InsertMode:               "insert",
-		RefreshInterval:          time.Second * time.Duration(iptablesCfg.RefreshIntervalSecond),
-		LockTimeout:              time.Second * time.Duration(iptablesCfg.LockTimeoutSecond),
-		LockProbeInterval:        time.Millisecond * time.Duration(iptablesCfg.LockProbeIntervalMillis),
-		InitialPostWriteInterval: time.Second * time.Duration(iptablesCfg.InitialPostWriteIntervalSecond),
-		RestoreSupportsLock:      iptablesCfg.RestoreSupportsLock,
-	}
-	var lock sync.Locker
-	if cfg.FileConfig.IPTables.RestoreSupportsLock {
-		log.Info("iptables-restore has built-in lock implementation")
-		lock = iptables.DummyLock{}
-	} else {
-		log.Info("iptables-restore use shared lock")
-		lock = iptables.NewSharedLock(iptablesCfg.LockFilePath, opt.LockTimeout, opt.LockProbeInterval)
-	}
-	opt.XTablesLock = lock
-
-	mangleTables := make([]*iptables.Table, 0)
-	filterTables := make([]*iptables.Table, 0)
-	natTables := make([]*iptables.Table, 0)
-	if cfg.FileConfig.EnableIPv4 {
-		mangleTable, err := iptables.NewTable("mangle", 4, "egw:", opt, log)
-		if err != nil {
-			return err
-		}
-		mangleTables = append(mangleTables, mangleTable)
-
-		natTable, err := iptables.NewTable("nat", 4, "egw:", opt, log)
-		if err != nil {
-			return err
-		}
-		natTables = append(natTables, natTable)
-
-		filterTable, err := iptables.NewTable("filter", 4, "egw:", opt, log)
-		if err != nil {
-			return err
-		}
-		filterTables = append(filterTables, filterTable)
-	}
-	if cfg.FileConfig.EnableIPv6 {
-		mangle, err := iptables.NewTable("mangle", 6, "egw:-", opt, log)
-		if err != nil {
-			return err
-		}
-		mangleTables = append(mangleTables, mangle)
-		nat, err := iptables.NewTable("nat", 6, "egw:", opt, log)
-		if err != nil {
-			return err
-		}
-		natTables = append(natTables, nat)
-		filter, err := iptables.NewTable("filter", 6, "egw:", opt, log)
-		if err != nil {
-			return err
-		}
-		filterTables = append(filterTables, filter)
+func newPolicyController(mgr manager.Manager, log *zap.Logger, cfg *config.Config) (*policeReconciler, error) {
+	b, err := newBackend(cfg, log)
+	if err != nil {
+		return nil, err
 	}
 
-	e := exec.New()
 	r := &policeReconciler{
 		client:       mgr.GetClient(),
 		ipsetMap:     utils.NewSyncMap[string, *ipset.IPSet](),
 		log:          log,
-		ipset:        ipset.New(e),
+		ipset:        b.ipset,
 		cfg:          cfg,
-		mangleTables: mangleTables,
-		filterTables: filterTables,
-		natTables:    natTables,
+		mangleTables: b.mangleTables,
+		filterTables: b.filterTables,
+		natTables:    b.natTables,
 		ruleV4Map:    utils.NewSyncMap[string, iptables.Rule](),
 		ruleV6Map:    utils.NewSyncMap[string, iptables.Rule](),
+
+		policyRules:   utils.NewSyncMap[egressv1.Policy, policyRuleSpec](),
+		programmed:    utils.NewSyncMap[egressv1.Policy, egressv1.ProgrammedPolicy](),
+		events:        newEventRecorder(mgr, cfg.EnvConfig.NodeName),
+		policyPaths:   make(map[egressv1.Policy]policyPath),
+		policySources: utils.NewSyncMap[egressv1.Policy, []string](),
+		deleteFlows:   deleteConntrackFlows,
 	}
 
 	c, err := controller.New("policy", mgr, controller.Options{Reconciler: r})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressGateway{}),
 		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressGateway"))); err != nil {
-		return fmt.Errorf("failed to watch EgressGateway: %w", err)
+		return nil, fmt.Errorf("failed to watch EgressGateway: %w", err)
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressPolicy{}),
 		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressPolicy")), policyPredicate{}); err != nil {
-		return fmt.Errorf("failed to watch EgressPolicy: %w", err)
+		return nil, fmt.Errorf("failed to watch EgressPolicy: %w", err)
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressClusterPolicy{}),
 		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressClusterPolicy")), policyPredicate{}); err != nil {
-		return fmt.Errorf("failed to watch EgressClusterPolicy: %w", err)
+		return nil, fmt.Errorf("failed to watch EgressClusterPolicy: %w", err)
 	}
 
 	if err := c.Watch(
@@ -1009,7 +1304,7 @@ func newPolicyController(mgr manager.Manager, log *zap.Logger, cfg *config.Confi
 		handler.EnqueueRequestsFromMapFunc(enqueueEndpointSlice()),
 		epSlicePredicate{},
 	); err != nil {
-		return fmt.Errorf("failed to watch EgressEndpointSlice: %w", err)
+		return nil, fmt.Errorf("failed to watch EgressEndpointSlice: %w", err)
 	}
 
 	if err := c.Watch(
@@ -1017,15 +1312,15 @@ func newPolicyController(mgr manager.Manager, log *zap.Logger, cfg *config.Confi
 		handler.EnqueueRequestsFromMapFunc(enqueueEndpointSlice()),
 		epSlicePredicate{},
 	); err != nil {
-		return fmt.Errorf("failed to watch EgressClusterEndpointSlice: %w", err)
+		return nil, fmt.Errorf("failed to watch EgressClusterEndpointSlice: %w", err)
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressClusterInfo{}),
 		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressClusterInfo"))); err != nil {
-		return fmt.Errorf("failed to watch EgressClusterInfo: %w", err)
+		return nil, fmt.Errorf("failed to watch EgressClusterInfo: %w", err)
 	}
 
-	return nil
+	return r, nil
 }
 
 func buildIPSetNamesByPolicy(ns, name string, enableIPv4, enableIPv6 bool) SetNames {
@@ -1038,12 +1333,14 @@ func buildIPSetNamesByPolicy(ns, name string, enableIPv4, enableIPv6 bool) SetNa
 		res = append(res, []SetName{
 			{Name: formatIPSetName("egress-src-v4-", name), Stack: IPv4, Kind: IPSrc},
 			{Name: formatIPSetName("egress-dst-v4-", name), Stack: IPv4, Kind: IPDst},
+			{Name: formatIPSetName("egress-exdst-v4-", name), Stack: IPv4, Kind: IPExcludeDst},
 		}...)
 	}
 	if enableIPv6 {
 		res = append(res, []SetName{
 			{Name: formatIPSetName("egress-src-v6-", name), Stack: IPv6, Kind: IPSrc},
 			{Name: formatIPSetName("egress-dst-v6-", name), Stack: IPv6, Kind: IPDst},
+			{Name: formatIPSetName("egress-exdst-v6-", name), Stack: IPv6, Kind: IPExcludeDst},
 		}...)
 	}
 	return res
@@ -1078,6 +1375,8 @@ type IPKind int
 const (
 	IPSrc IPKind = iota
 	IPDst
+	// IPExcludeDst is the destinations excluded by the policy
+	IPExcludeDst
 )
 
 type IPStack int
@@ -2,3 +2,369 @@
 // SPDX-License-Identifier: Apache-2.0
 
 package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/ipset"
+	ipsettest "github.com/spidernet-io/egressgateway/pkg/ipset/testing"
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/nftables"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+func TestSortPolicyByPriority(t *testing.T) {
+	cases := map[string]struct {
+		policies map[egressv1.Policy]*PolicyCommon
+		expect   []egressv1.Policy
+	}{
+		"smaller priority first": {
+			policies: map[egressv1.Policy]*PolicyCommon{
+				{Namespace: "default", Name: "a"}: {Priority: 300},
+				{Namespace: "default", Name: "b"}: {Priority: 100},
+				{Namespace: "default", Name: "c"}: {Priority: 200},
+			},
+			expect: []egressv1.Policy{
+				{Namespace: "default", Name: "b"},
+				{Namespace: "default", Name: "c"},
+				{Namespace: "default", Name: "a"},
+			},
+		},
+		"namespaced policy before cluster policy on tie": {
+			policies: map[egressv1.Policy]*PolicyCommon{
+				{Name: "a"}:                       {Priority: 100},
+				{Namespace: "default", Name: "z"}: {Priority: 100},
+			},
+			expect: []egressv1.Policy{
+				{Namespace: "default", Name: "z"},
+				{Name: "a"},
+			},
+		},
+		"name order on tie": {
+			policies: map[egressv1.Policy]*PolicyCommon{
+				{Namespace: "ns2", Name: "a"}: {Priority: 100},
+				{Namespace: "ns1", Name: "b"}: {Priority: 100},
+				{Namespace: "ns1", Name: "a"}: {Priority: 100},
+			},
+			expect: []egressv1.Policy{
+				{Namespace: "ns1", Name: "a"},
+				{Namespace: "ns1", Name: "b"},
+				{Namespace: "ns2", Name: "a"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				assert.Equal(t, c.expect, sortPolicyByPriority(c.policies))
+			}
+		})
+	}
+}
+
+func TestBuildPolicyRuleSkipMarked(t *testing.T) {
+	r := &policeReconciler{}
+	rules := r.buildPolicyRule("default-policy", 0x26000001, 0x26000000, 4, false, false, nil)
+	assert.Len(t, rules, 2)
+	assert.Contains(t, rules[0].Match.Render(), "-m mark ! --mark 0x26000000/0xff000000")
+
+	// the mark is saved in the conntrack entry
+	assert.Contains(t, rules[1].Match.Render(), "-m mark --mark 0x26000000/0xff000000")
+	// only the egress bits and the node bits are saved
+	assert.Equal(t, iptables.SaveConnMarkAction{SaveMask: 0xff000001}, rules[1].Action)
+}
+
+func TestBuildRuleWithDestPorts(t *testing.T) {
+	ports := []egressv1.PolicyPort{
+		{Protocol: "TCP", Port: 443},
+		{Protocol: "UDP", Port: 53},
+		{Protocol: "TCP", Port: 8000, EndPort: 8080},
+		{Protocol: "SCTP"},
+		{Port: 80},
+	}
+	expect := []string{
+		"-p tcp -m multiport --destination-ports 443",
+		"-p udp -m multiport --destination-ports 53",
+		"-p tcp -m multiport --destination-ports 8000:8080",
+		"-p sctp",
+		"-p tcp -m multiport --destination-ports 80",
+	}
+
+	r := &policeReconciler{}
+	rules := r.buildPolicyRule("default-policy", 0x26000001, 0x26000000, 4, false, false, ports)
+	// the mark rules and the save rules
+	assert.Len(t, rules, 2*len(expect))
+	for i, rule := range rules[:len(expect)] {
+		assert.True(t, strings.HasSuffix(rule.Match.Render(), expect[i]), rule.Match.Render())
+		assert.Equal(t, 1, strings.Count(rule.Match.Render(), "-p "), rule.Match.Render())
+	}
+
+	eipRules := buildEipRule("default-policy", IP{V4: "10.6.1.21"}, 4, false, false, ports)
+	assert.Len(t, eipRules, len(expect))
+	for i, rule := range eipRules {
+		assert.True(t, strings.HasSuffix(rule.Match.Render(), expect[i]), rule.Match.Render())
+	}
+
+	assert.Len(t, buildEipRule("default-policy", IP{V4: "10.6.1.21"}, 4, false, false, nil), 1)
+	assert.Len(t, buildEipRule("default-policy", IP{}, 4, false, false, ports), 0)
+}
+
+// TestRenderPolicyRulesNFTables renders every rule built for the policies
+// into nftables, so that a new match of the builders cannot break nft mode
+func TestRenderPolicyRulesNFTables(t *testing.T) {
+	ports := []egressv1.PolicyPort{
+		{Protocol: "TCP", Port: 443},
+		{Protocol: "UDP", Port: 53},
+		{Protocol: "TCP", Port: 8000, EndPort: 8080},
+		{Protocol: "SCTP"},
+	}
+	marks := []uint32{0x26000001, 0x26000002}
+	eip := IP{V4: "10.6.1.21", V6: "fd00::21"}
+
+	for _, version := range []uint8{4, 6} {
+		rs := nftables.New(zap.NewNop())
+		sets := rs.Sets()
+		for _, item := range buildIPSetNamesByPolicy("default", "policy", version == 4, version == 6) {
+			set := &ipset.IPSet{Name: item.Name, SetType: ipset.HashNet, HashFamily: item.Stack.HashFamily()}
+			assert.NoError(t, sets.CreateSet(set, true))
+		}
+		cluster := &ipset.IPSet{Name: EgressClusterCIDRIPv4, SetType: ipset.HashNet, HashFamily: IPv4.HashFamily()}
+		if version == 6 {
+			cluster = &ipset.IPSet{Name: EgressClusterCIDRIPv6, SetType: ipset.HashNet, HashFamily: IPv6.HashFamily()}
+		}
+		assert.NoError(t, sets.CreateSet(cluster, true))
+
+		mangle, err := rs.NewTable("mangle", version)
+		assert.NoError(t, err)
+		nat, err := rs.NewTable("nat", version)
+		assert.NoError(t, err)
+		filter, err := rs.NewTable("filter", version)
+		assert.NoError(t, err)
+
+		r := &policeReconciler{}
+		markRules := make([]iptables.Rule, 0)
+		eipRules := make([]iptables.Rule, 0)
+		for _, ignoreInternalCIDR := range []bool{false, true} {
+			for _, excludeDest := range []bool{false, true} {
+				for _, policyPorts := range [][]egressv1.PolicyPort{nil, ports} {
+					markRules = append(markRules, r.buildPolicyRule("default-policy", marks[0], 0x26000000,
+						version, ignoreInternalCIDR, excludeDest, policyPorts)...)
+					markRules = append(markRules, buildBalancedPolicyRule("default-policy", marks, 0x26000000,
+						version, ignoreInternalCIDR, excludeDest, policyPorts)...)
+					eipRules = append(eipRules, buildEipRule("default-policy", eip,
+						version, ignoreInternalCIDR, excludeDest, policyPorts)...)
+				}
+			}
+		}
+		mangle.UpdateChain(&iptables.Chain{Name: "EGRESSGATEWAY-MARK-REQUEST", Rules: markRules})
+		nat.UpdateChain(&iptables.Chain{Name: "EGRESSGATEWAY-SNAT-EIP", Rules: eipRules})
+		for table, static := range map[*nftables.Table]map[string][]iptables.Rule{
+			mangle: buildMangleStaticRule(0x26000000),
+			nat:    buildNatStaticRule(0x26000000),
+			filter: buildFilterStaticRule(0x26000000),
+		} {
+			for chain, rules := range static {
+				table.InsertOrAppendRules(chain, rules)
+			}
+		}
+
+		for _, table := range []*nftables.Table{mangle, nat, filter} {
+			rendered, err := table.Render()
+			if !assert.NoError(t, err, "IPv%d table %s", version, table.Name) {
+				t.FailNow()
+			}
+			assert.NotEmpty(t, rendered)
+		}
+	}
+}
+
+func TestUpdatePolicyIPSetWithPodSubnet(t *testing.T) {
+	fakeIPSet := ipsettest.NewFake("7.1")
+	r := &policeReconciler{
+		client: fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build(),
+		log:    zap.NewNop(),
+		cfg: &config.Config{
+			FileConfig: config.FileConfig{EnableIPv4: true, EnableIPv6: true},
+		},
+		ipsetMap:      utils.NewSyncMap[string, *ipset.IPSet](),
+		policySources: utils.NewSyncMap[egressv1.Policy, []string](),
+		ipset:         fakeIPSet,
+	}
+
+	podSubnet := []string{"172.30.0.0/16", "10.6.1.21/32", "fd00:1::/112"}
+	err := r.updatePolicyIPSet("default", "policy", false, &PolicyCommon{DestSubnet: []string{"1.1.1.0/24"}, PodSubnet: podSubnet})
+	assert.NoError(t, err)
+
+	srcV4, err := fakeIPSet.ListEntries(formatIPSetName("egress-src-v4-", "default-policy"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"172.30.0.0/16", "10.6.1.21"}, srcV4)
+
+	srcV6, err := fakeIPSet.ListEntries(formatIPSetName("egress-src-v6-", "default-policy"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"fd00:1::/112"}, srcV6)
+
+	// shrink the subnet list, stale entries are removed
+	err = r.updatePolicyIPSet("default", "policy", false, &PolicyCommon{DestSubnet: []string{"1.1.1.0/24"}, PodSubnet: podSubnet[:1]})
+	assert.NoError(t, err)
+
+	srcV4, err = fakeIPSet.ListEntries(formatIPSetName("egress-src-v4-", "default-policy"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"172.30.0.0/16"}, srcV4)
+}
+
+func TestPolicyExcludeDest(t *testing.T) {
+	fakeIPSet := ipsettest.NewFake("7.1")
+	r := &policeReconciler{
+		client: fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build(),
+		log:    zap.NewNop(),
+		cfg: &config.Config{
+			FileConfig: config.FileConfig{EnableIPv4: true, EnableIPv6: true},
+		},
+		ipsetMap:      utils.NewSyncMap[string, *ipset.IPSet](),
+		policySources: utils.NewSyncMap[egressv1.Policy, []string](),
+		ipset:         fakeIPSet,
+	}
+
+	spec := &PolicyCommon{
+		ExcludeDestSubnet: []string{"10.0.0.0/8", "fd00:2::/64"},
+		PodSubnet:         []string{"172.30.0.0/16"},
+	}
+	assert.True(t, spec.isIgnoreInternalCIDR())
+	assert.True(t, spec.isExcludeDest())
+	err := r.updatePolicyIPSet("default", "policy", false, spec)
+	assert.NoError(t, err)
+
+	excludeV4, err := fakeIPSet.ListEntries(formatIPSetName("egress-exdst-v4-", "default-policy"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"10.0.0.0/8"}, excludeV4)
+
+	excludeV6, err := fakeIPSet.ListEntries(formatIPSetName("egress-exdst-v6-", "default-policy"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"fd00:2::/64"}, excludeV6)
+
+	exclude := "! --match-set " + formatIPSetName("egress-exdst-v4-", "default-policy") + " dst"
+	rules := r.buildPolicyRule("default-policy", 0x26000001, 0x26000000, 4, true, true, nil)
+	assert.Len(t, rules, 2)
+	for _, rule := range rules {
+		assert.Contains(t, rule.Match.Render(), exclude)
+	}
+
+	eipRules := buildEipRule("default-policy", IP{V4: "10.6.1.21"}, 4, true, true, nil)
+	assert.Len(t, eipRules, 1)
+	assert.Contains(t, eipRules[0].Match.Render(), exclude)
+
+	rules = r.buildPolicyRule("default-policy", 0x26000001, 0x26000000, 4, true, false, nil)
+	assert.NotContains(t, rules[0].Match.Render(), "egress-exdst")
+}
+
+func TestBuildBalancedPolicyRule(t *testing.T) {
+	marks := []uint32{0x26000001, 0x26000002, 0x26000003}
+	rules := buildBalancedPolicyRule("default-policy", marks, 0x26000000, 4, true, false, nil)
+	// restore, split and save rules
+	assert.Len(t, rules, 7)
+
+	for i, mark := range marks {
+		match := rules[i].Match.Render()
+		assert.Contains(t, match, fmt.Sprintf("-m connmark --mark %#x/0xff000003", mark))
+		assert.Contains(t, match, "-m mark ! --mark 0x26000000/0xff000000")
+		assert.Equal(t, iptables.SetMaskedMarkAction{Mark: mark, Mask: 0xffffffff}, rules[i].Action)
+	}
+
+	assert.Contains(t, rules[3].Match.Render(), "--probability 0.33333")
+	assert.Contains(t, rules[4].Match.Render(), "--probability 0.50000")
+	assert.NotContains(t, rules[5].Match.Render(), "statistic")
+	for i, mark := range marks {
+		assert.Equal(t, iptables.SetMaskedMarkAction{Mark: mark, Mask: 0xffffffff}, rules[3+i].Action)
+	}
+
+	save := rules[6].Match.Render()
+	assert.Contains(t, save, "-m mark --mark 0x26000000/0xff000000")
+	assert.NotContains(t, save, "connmark")
+	// the other bits of the conntrack mark are kept
+	assert.Equal(t, iptables.SaveConnMarkAction{SaveMask: 0xff000003}, rules[6].Action)
+
+	// the rules are built for each of the destination ports
+	ports := []egressv1.PolicyPort{{Protocol: "TCP", Port: 443}, {Protocol: "UDP", Port: 53}}
+	rules = buildBalancedPolicyRule("default-policy", marks[:2], 0x26000000, 4, true, false, ports)
+	assert.Len(t, rules, 10)
+}
+
+func TestReportProgrammed(t *testing.T) {
+	ctx := context.Background()
+	node := &egressv1.EgressNode{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).
+		WithObjects(node).WithStatusSubresource(node).Build()
+	r := &policeReconciler{
+		client:     cli,
+		log:        zap.NewNop(),
+		cfg:        &config.Config{EnvConfig: config.EnvConfig{NodeName: "node1"}},
+		programmed: utils.NewSyncMap[egressv1.Policy, egressv1.ProgrammedPolicy](),
+	}
+
+	r.ackPolicy(egressv1.Policy{Namespace: "default", Name: "b"}, 2, nil)
+	r.ackPolicy(egressv1.Policy{Namespace: "default", Name: "a"}, 1, fmt.Errorf("failed"))
+	r.ackPolicy(egressv1.Policy{Name: "c"}, 3, nil)
+	assert.NoError(t, r.reportProgrammed(ctx))
+
+	res := new(egressv1.EgressNode)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "node1"}, res))
+	assert.Equal(t, []egressv1.ProgrammedPolicy{
+		{Name: "c", Generation: 3},
+		{Namespace: "default", Name: "a", Generation: 1, Error: "failed"},
+		{Namespace: "default", Name: "b", Generation: 2},
+	}, res.Status.Policies)
+
+	// nothing changed, no update
+	version := res.ResourceVersion
+	assert.NoError(t, r.reportProgrammed(ctx))
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "node1"}, res))
+	assert.Equal(t, version, res.ResourceVersion)
+}
+
+func TestUpdatePolicyIPSetWithDestDomains(t *testing.T) {
+	fakeIPSet := ipsettest.NewFake("7.1")
+	r := &policeReconciler{
+		client: fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build(),
+		log:    zap.NewNop(),
+		cfg: &config.Config{
+			FileConfig: config.FileConfig{EnableIPv4: true, EnableIPv6: true},
+		},
+		ipsetMap:      utils.NewSyncMap[string, *ipset.IPSet](),
+		policySources: utils.NewSyncMap[egressv1.Policy, []string](),
+		ipset:         fakeIPSet,
+	}
+
+	spec := &PolicyCommon{
+		DestSubnet:  []string{"1.1.1.0/24"},
+		DestDomains: []string{"api.example.com"},
+		DestDomainIPs: egressv1.IPListPair{
+			IPv4: []string{"10.6.1.92"},
+			IPv6: []string{"fd00::92"},
+		},
+		PodSubnet: []string{"172.30.0.0/16"},
+	}
+	assert.False(t, spec.isIgnoreInternalCIDR())
+	err := r.updatePolicyIPSet("default", "policy", false, spec)
+	assert.NoError(t, err)
+
+	dstV4, err := fakeIPSet.ListEntries(formatIPSetName("egress-dst-v4-", "default-policy"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1.1.1.0/24", "10.6.1.92"}, dstV4)
+
+	dstV6, err := fakeIPSet.ListEntries(formatIPSetName("egress-dst-v6-", "default-policy"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"fd00::92"}, dstV6)
+}
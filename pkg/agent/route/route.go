@@ -63,6 +63,44 @@ func (r *RuleRoute) PurgeStaleRules(marks map[int]struct{}, baseMark string) err
 	return nil
 }
 
+// List returns the ip rules of the marks in the range of baseMark, and the
+// routes in their tables
+func (r *RuleRoute) List(baseMark string) ([]netlink.Rule, []netlink.Route, error) {
+	start, end, err := markallocator.RangeSize(baseMark)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rules := make([]netlink.Rule, 0)
+	routes := make([]netlink.Route, 0)
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		list, err := netlink.RuleList(family)
+		if err != nil {
+			return nil, nil, err
+		}
+		tables := make(map[int]struct{})
+		for _, rule := range list {
+			if int(start) > rule.Mark || int(end) < rule.Mark {
+				continue
+			}
+			rule.Family = family
+			rules = append(rules, rule)
+			tables[rule.Table] = struct{}{}
+		}
+		for table := range tables {
+			list, err := netlink.RouteListFiltered(family, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, route := range list {
+				route.Family = family
+				routes = append(routes, route)
+			}
+		}
+	}
+	return rules, routes, nil
+}
+
 func (r *RuleRoute) Ensure(linkName string, ipv4, ipv6 *net.IP, table int, mark int) error {
 	if mark == 0 {
 		return nil
@@ -0,0 +1,256 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// the comments of the policy rules, they are read back with the counters of
+// the rules to label the traffic metrics
+const (
+	commentPolicy  = "policy="
+	commentGateway = "gateway="
+	commentEIP     = "eip="
+)
+
+// trafficCacheInterval is how long the traffic read back is reused, reading
+// the counters and the conntrack table on every scrape is expensive on busy
+// nodes
+const trafficCacheInterval = 30 * time.Second
+
+var trafficLabels = []string{"policy", "namespace", "gateway", "eip"}
+
+var (
+	policyPacketsDesc = prometheus.NewDesc(
+		"egress_policy_packets_total",
+		"Total number of packets of the pods of the node sent to the gateway nodes by the policy",
+		trafficLabels, nil)
+	policyBytesDesc = prometheus.NewDesc(
+		"egress_policy_bytes_total",
+		"Total number of bytes of the pods of the node sent to the gateway nodes by the policy",
+		trafficLabels, nil)
+	policyConnectionsDesc = prometheus.NewDesc(
+		"egress_policy_snat_connections_total",
+		"Total number of connections of the policy SNATed to the EIP on the gateway node",
+		trafficLabels, nil)
+	eipConntrackDesc = prometheus.NewDesc(
+		"egress_eip_conntrack_entries",
+		"Number of conntrack entries of the connections SNATed to the EIP on the gateway node",
+		[]string{"gateway", "eip"}, nil)
+)
+
+// trafficComments returns the comments of a rule of the policy
+func trafficComments(policy egressv1.Policy, gateway, eip string) []string {
+	res := []string{commentPolicy + policy.Namespace + "/" + policy.Name, commentGateway + gateway}
+	if eip != "" {
+		res = append(res, commentEIP+eip)
+	}
+	return res
+}
+
+// markRuleComments sets the comments of the mark rules of the policy by the
+// mark they set, marks are the marks of the gateway nodes sorted by the node
+// name. The rules saving the mark have no comment, so that each packet is
+// counted by a single rule.
+func markRuleComments(rules []iptables.Rule, policy egressv1.Policy, val *PolicyCommon, marks []uint32, version uint8) []iptables.Rule {
+	names := append([]string{}, val.NodeNames...)
+	sort.Strings(names)
+	eips := make(map[uint32]string, len(marks))
+	for i, mark := range marks {
+		eips[mark] = val.EIPs[names[i]].version(version)
+	}
+	for i, rule := range rules {
+		action, ok := rule.Action.(iptables.SetMaskedMarkAction)
+		if !ok {
+			continue
+		}
+		rules[i].Comment = trafficComments(policy, val.Gateway, eips[action.Mark])
+	}
+	return rules
+}
+
+type trafficKey struct {
+	policy    string
+	namespace string
+	gateway   string
+	eip       string
+}
+
+type trafficValue struct {
+	packets uint64
+	bytes   uint64
+}
+
+// parseTrafficComments returns the labels of a rule of a policy
+func parseTrafficComments(comments []string) (trafficKey, bool) {
+	key := trafficKey{}
+	isPolicy := false
+	for _, comment := range comments {
+		switch {
+		case strings.HasPrefix(comment, commentPolicy):
+			ns, name, ok := strings.Cut(strings.TrimPrefix(comment, commentPolicy), "/")
+			if !ok {
+				return key, false
+			}
+			key.namespace, key.policy = ns, name
+			isPolicy = true
+		case strings.HasPrefix(comment, commentGateway):
+			key.gateway = strings.TrimPrefix(comment, commentGateway)
+		case strings.HasPrefix(comment, commentEIP):
+			key.eip = strings.TrimPrefix(comment, commentEIP)
+		}
+	}
+	return key, isPolicy
+}
+
+// sumTraffic sums the counters of the rules of the policies in the chain,
+// the rules of a policy are split by the destination ports, the gateway
+// nodes and the IP versions
+func sumTraffic(counters []iptables.RuleCounter, chain string, res map[trafficKey]*trafficValue) {
+	for _, counter := range counters {
+		if counter.Chain != chain {
+			continue
+		}
+		key, ok := parseTrafficComments(counter.Comments)
+		if !ok {
+			continue
+		}
+		val, ok := res[key]
+		if !ok {
+			val = new(trafficValue)
+			res[key] = val
+		}
+		val.packets += counter.Packets
+		val.bytes += counter.Bytes
+	}
+}
+
+// trafficCollector exports the traffic of the policies and the EIPs, it is
+// read back from the counters of the policy rules and the conntrack table
+// when the metrics are scraped, at most once per interval
+type trafficCollector struct {
+	police    *policeReconciler
+	log       *zap.Logger
+	listFlows func(family netlink.InetFamily) ([]*netlink.ConntrackFlow, error)
+	interval  time.Duration
+	now       func() time.Time
+
+	mutex   sync.Mutex
+	metrics []prometheus.Metric
+	updated time.Time
+}
+
+func newTrafficCollector(police *policeReconciler, log *zap.Logger) *trafficCollector {
+	return &trafficCollector{
+		police: police,
+		log:    log,
+		listFlows: func(family netlink.InetFamily) ([]*netlink.ConntrackFlow, error) {
+			return netlink.ConntrackTableList(netlink.ConntrackTable, family)
+		},
+		interval: trafficCacheInterval,
+		now:      time.Now,
+	}
+}
+
+func (c *trafficCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- policyPacketsDesc
+	ch <- policyBytesDesc
+	ch <- policyConnectionsDesc
+	ch <- eipConntrackDesc
+}
+
+// Collect sends the metrics read back within the interval, the concurrent
+// scrapes wait for a single read
+func (c *trafficCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	now := c.now()
+	if c.updated.IsZero() || now.Sub(c.updated) >= c.interval {
+		c.metrics = c.collect()
+		c.updated = now
+	}
+	metrics := c.metrics
+	c.mutex.Unlock()
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+}
+
+func (c *trafficCollector) collect() []prometheus.Metric {
+	res := make([]prometheus.Metric, 0)
+	marked := c.readTraffic(c.police.mangleTables, "EGRESSGATEWAY-MARK-REQUEST")
+	for key, val := range marked {
+		labels := []string{key.policy, key.namespace, key.gateway, key.eip}
+		res = append(res,
+			prometheus.MustNewConstMetric(policyPacketsDesc, prometheus.CounterValue, float64(val.packets), labels...),
+			prometheus.MustNewConstMetric(policyBytesDesc, prometheus.CounterValue, float64(val.bytes), labels...))
+	}
+
+	// the nat rules only see the first packet of the connections
+	snat := c.readTraffic(c.police.natTables, "EGRESSGATEWAY-SNAT-EIP")
+	eips := make(map[string]string)
+	for key, val := range snat {
+		labels := []string{key.policy, key.namespace, key.gateway, key.eip}
+		res = append(res, prometheus.MustNewConstMetric(policyConnectionsDesc, prometheus.CounterValue, float64(val.packets), labels...))
+		if key.eip != "" {
+			eips[key.eip] = key.gateway
+		}
+	}
+	if len(eips) == 0 {
+		return res
+	}
+
+	entries, err := c.countEntries(eips)
+	if err != nil {
+		c.log.Warn("failed to list the conntrack entries", zap.Error(err))
+		return res
+	}
+	for eip, gateway := range eips {
+		res = append(res, prometheus.MustNewConstMetric(eipConntrackDesc, prometheus.GaugeValue, float64(entries[eip]), gateway, eip))
+	}
+	return res
+}
+
+func (c *trafficCollector) readTraffic(tables []ruleTable, chain string) map[trafficKey]*trafficValue {
+	res := make(map[trafficKey]*trafficValue)
+	for _, table := range tables {
+		counters, err := table.ReadCounters()
+		if err != nil {
+			c.log.Warn("failed to read the counters of the policy rules", zap.String("table", table.TableName()), zap.Error(err))
+			continue
+		}
+		sumTraffic(counters, chain, res)
+	}
+	return res
+}
+
+// countEntries counts the conntrack entries by the EIP, the reply of a
+// connection SNATed to an EIP is sent to the EIP
+func (c *trafficCollector) countEntries(eips map[string]string) (map[string]int, error) {
+	res := make(map[string]int)
+	for _, family := range []netlink.InetFamily{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		flows, err := c.listFlows(family)
+		if err != nil {
+			return nil, err
+		}
+		for _, flow := range flows {
+			ip := flow.Reverse.DstIP.String()
+			if _, ok := eips[ip]; ok {
+				res[ip]++
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+func TestMarkRuleComments(t *testing.T) {
+	policy := egressv1.Policy{Namespace: "default", Name: "policy"}
+	val := &PolicyCommon{
+		NodeNames: []string{"node2", "node1"},
+		Gateway:   "gateway",
+		EIPs: map[string]IP{
+			"node1": {V4: "10.6.1.21"},
+			"node2": {V4: "10.6.1.22"},
+		},
+	}
+	// the marks are sorted by the node name
+	marks := []uint32{0x26000001, 0x26000002}
+	rules := buildBalancedPolicyRule("default-policy", marks, 0x26000000, 4, true, false, nil)
+	rules = markRuleComments(rules, policy, val, marks, 4)
+
+	counted := 0
+	for _, rule := range rules {
+		action, ok := rule.Action.(iptables.SetMaskedMarkAction)
+		if !ok {
+			assert.Empty(t, rule.Comment)
+			continue
+		}
+		counted++
+		eip := "10.6.1.21"
+		if action.Mark == 0x26000002 {
+			eip = "10.6.1.22"
+		}
+		assert.Equal(t, []string{"policy=default/policy", "gateway=gateway", "eip=" + eip}, rule.Comment)
+	}
+	assert.Equal(t, 4, counted)
+}
+
+func TestSumTraffic(t *testing.T) {
+	counters := []iptables.RuleCounter{
+		{Chain: "EGRESSGATEWAY-MARK-REQUEST", Comments: []string{"policy=default/p1", "gateway=gw", "eip=10.6.1.21"}, Packets: 1, Bytes: 100},
+		{Chain: "EGRESSGATEWAY-MARK-REQUEST", Comments: []string{"policy=default/p1", "gateway=gw", "eip=10.6.1.21"}, Packets: 2, Bytes: 200},
+		{Chain: "EGRESSGATEWAY-MARK-REQUEST", Comments: []string{"policy=/cluster", "gateway=gw"}, Packets: 3, Bytes: 300},
+		{Chain: "EGRESSGATEWAY-MARK-REQUEST", Comments: []string{}, Packets: 4, Bytes: 400},
+		{Chain: "PREROUTING", Comments: []string{"policy=default/p1", "gateway=gw"}, Packets: 5, Bytes: 500},
+	}
+	res := make(map[trafficKey]*trafficValue)
+	sumTraffic(counters, "EGRESSGATEWAY-MARK-REQUEST", res)
+	assert.Equal(t, map[trafficKey]*trafficValue{
+		{policy: "p1", namespace: "default", gateway: "gw", eip: "10.6.1.21"}: {packets: 3, bytes: 300},
+		{policy: "cluster", gateway: "gw"}:                                    {packets: 3, bytes: 300},
+	}, res)
+}
+
+func TestCountEntries(t *testing.T) {
+	flow := func(replyDst string) *netlink.ConntrackFlow {
+		res := new(netlink.ConntrackFlow)
+		res.Reverse.DstIP = net.ParseIP(replyDst)
+		return res
+	}
+	c := &trafficCollector{listFlows: func(family netlink.InetFamily) ([]*netlink.ConntrackFlow, error) {
+		if family == netlink.FAMILY_V6 {
+			return []*netlink.ConntrackFlow{flow("fd00::21")}, nil
+		}
+		return []*netlink.ConntrackFlow{flow("10.6.1.21"), flow("10.6.1.21"), flow("10.6.1.30")}, nil
+	}}
+	res, err := c.countEntries(map[string]string{"10.6.1.21": "gw", "fd00::21": "gw", "10.6.1.22": "gw"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"10.6.1.21": 2, "fd00::21": 1}, res)
+}
+
+// countedTable counts how many times the counters are read
+type countedTable struct {
+	ruleTable
+	counters []iptables.RuleCounter
+	reads    int
+}
+
+func (t *countedTable) ReadCounters() ([]iptables.RuleCounter, error) {
+	t.reads++
+	return t.counters, nil
+}
+
+func TestTrafficCollectorCache(t *testing.T) {
+	table := &countedTable{counters: []iptables.RuleCounter{{
+		Chain:    "EGRESSGATEWAY-MARK-REQUEST",
+		Comments: []string{"policy=default/p1", "gateway=gw"},
+		Packets:  10,
+		Bytes:    1000,
+	}}}
+	now := time.Unix(1000, 0)
+	c := newTrafficCollector(&policeReconciler{mangleTables: []ruleTable{table}}, zap.NewNop())
+	c.now = func() time.Time { return now }
+
+	collect := func() int {
+		ch := make(chan prometheus.Metric, 10)
+		c.Collect(ch)
+		close(ch)
+		return len(ch)
+	}
+	// packets and bytes
+	assert.Equal(t, 2, collect())
+	assert.Equal(t, 1, table.reads)
+
+	// read from the cache within the interval
+	now = now.Add(trafficCacheInterval / 2)
+	assert.Equal(t, 2, collect())
+	assert.Equal(t, 1, table.reads)
+
+	now = now.Add(trafficCacheInterval)
+	assert.Equal(t, 2, collect())
+	assert.Equal(t, 2, table.reads)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/spidernet-io/egressgateway/pkg/agent/route"
 	"github.com/spidernet-io/egressgateway/pkg/agent/vxlan"
 	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/health"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
 	"github.com/spidernet-io/egressgateway/pkg/utils"
 )
@@ -40,6 +41,11 @@ type vxlanReconciler struct {
 
 	ruleRoute      *route.RuleRoute
 	ruleRouteCache *utils.SyncMap[string, []net.IP]
+
+	events *eventRecorder
+	// routeState records the last setup of the vxlan device and the routes
+	// of the peers
+	routeState health.SyncState
 }
 
 type VTEP struct {
@@ -339,7 +345,9 @@ func (r *vxlanReconciler) keepVXLAN() {
 		}
 
 		err = r.vxlan.EnsureLink(name, vni, port, mac, 0, ipv4, ipv6, disableChecksumOffload)
+		r.events.nodeWarning(eventVXLANSyncFailed, err, "Failed to set up the vxlan device")
 		if err != nil {
+			r.routeState.Record(fmt.Errorf("vxlan device: %v", err))
 			r.log.Sugar().Errorf("ensure vxlan link with error: %v", err)
 			reduce = false
 			time.Sleep(time.Second)
@@ -351,6 +359,8 @@ func (r *vxlanReconciler) keepVXLAN() {
 		err = r.ensureRoute()
 		if err != nil {
 			r.log.Sugar().Errorf("ensure route with error: %v", err)
+			r.events.nodeWarning(eventRouteSyncFailed, err, "Failed to set up the routes of the peers")
+			r.routeState.Record(fmt.Errorf("routes: %v", err))
 			reduce = false
 			time.Sleep(time.Second)
 			continue
@@ -358,6 +368,8 @@ func (r *vxlanReconciler) keepVXLAN() {
 
 		r.log.Sugar().Debugf("route ensure has completed")
 
+		// routeErr is the first failure of the policy routes of the peers
+		var routeErr error
 		markMap := make(map[int]struct{})
 		r.peerMap.Range(func(key string, val vxlan.Peer) bool {
 			if val.Mark != 0 {
@@ -367,6 +379,9 @@ func (r *vxlanReconciler) keepVXLAN() {
 			if err != nil {
 				r.log.Sugar().Errorf("ensure vxlan link with error: %v", err)
 				reduce = false
+				if routeErr == nil {
+					routeErr = fmt.Errorf("peer %s: %v", key, err)
+				}
 			}
 			return true
 		})
@@ -374,7 +389,12 @@ func (r *vxlanReconciler) keepVXLAN() {
 		if err != nil {
 			r.log.Sugar().Errorf("purge stale rules error: %v", err)
 			reduce = false
+			if routeErr == nil {
+				routeErr = fmt.Errorf("purge stale rules: %v", err)
+			}
 		}
+		r.events.nodeWarning(eventRouteSyncFailed, routeErr, "Failed to set up the routes of the peers")
+		r.routeState.Record(routeErr)
 
 		r.log.Sugar().Debugf("route rule ensure has completed")
 
@@ -426,7 +446,7 @@ func (r *vxlanReconciler) ensureRoute() error {
 	return nil
 }
 
-func newEgressNodeController(mgr manager.Manager, cfg *config.Config, log *zap.Logger) error {
+func newEgressNodeController(mgr manager.Manager, cfg *config.Config, log *zap.Logger) (*vxlanReconciler, error) {
 	ruleRoute := route.NewRuleRoute(log)
 
 	r := &vxlanReconciler{
@@ -437,6 +457,7 @@ func newEgressNodeController(mgr manager.Manager, cfg *config.Config, log *zap.L
 		vxlan:          vxlan.New(),
 		ruleRoute:      ruleRoute,
 		ruleRouteCache: utils.NewSyncMap[string, []net.IP](),
+		events:         newEventRecorder(mgr, cfg.EnvConfig.NodeName),
 	}
 	netLink := vxlan.NetLink{
 		RouteListFiltered: netlink.RouteListFiltered,
@@ -453,15 +474,15 @@ func newEgressNodeController(mgr manager.Manager, cfg *config.Config, log *zap.L
 
 	c, err := controller.New("vxlan", mgr, controller.Options{Reconciler: r})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressNode{}),
 		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressNode"))); err != nil {
-		return fmt.Errorf("failed to watch EgressNode: %w", err)
+		return nil, fmt.Errorf("failed to watch EgressNode: %w", err)
 	}
 
 	go r.keepVXLAN()
 
-	return nil
+	return r, nil
 }
@@ -181,6 +181,29 @@ func (dev *Device) ListNeigh() ([]netlink.Neigh, error) {
 	return existingNeigh, nil
 }
 
+// ListEntries returns the FDB entries and the IPv4 and IPv6 neighbor entries
+// of the vxlan device
+func (dev *Device) ListEntries() (fdb []netlink.Neigh, neigh []netlink.Neigh, err error) {
+	dev.lock.RLock()
+	defer dev.lock.RUnlock()
+
+	if dev.notReady() {
+		return nil, nil, nil
+	}
+	fdb, err = netlink.NeighList(dev.link.Index, syscall.AF_BRIDGE)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		list, err := netlink.NeighList(dev.link.Index, family)
+		if err != nil {
+			return nil, nil, err
+		}
+		neigh = append(neigh, list...)
+	}
+	return fdb, neigh, nil
+}
+
 func (dev *Device) Add(peer Peer) error {
 	dev.lock.RLock()
 	defer dev.lock.RUnlock()
@@ -324,6 +347,24 @@ func (dev *Device) ensureAddr(ipn *net.IPNet, link netlink.Link, family int) err
 	return nil
 }
 
+// Check returns an error when the vxlan device is not created or is down
+func (dev *Device) Check() error {
+	dev.lock.RLock()
+	defer dev.lock.RUnlock()
+
+	if dev.notReady() {
+		return fmt.Errorf("vxlan device is not created")
+	}
+	link, err := netlink.LinkByName(dev.link.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get vxlan device %s: %v", dev.link.Name, err)
+	}
+	if link.Attrs().Flags&net.FlagUp == 0 {
+		return fmt.Errorf("vxlan device %s is down", dev.link.Name)
+	}
+	return nil
+}
+
 func (dev *Device) notReady() bool {
 	return dev.link == nil
 }
@@ -0,0 +1,80 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package apiserver serves the http server API of api/v1 for the agent and
+// the controller.
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/loads"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/egressgateway/api/v1/server"
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi"
+)
+
+// Server is a runnable of the manager serving the API on addr, the handlers
+// not registered respond with NotImplemented
+type Server struct {
+	addr    string
+	log     *zap.Logger
+	handler http.Handler
+}
+
+// New returns the server of the API, each register function sets some of the
+// handlers of the API
+func New(addr string, log *zap.Logger, register ...func(api *restapi.HTTPServerAPIAPI)) (*Server, error) {
+	spec, err := loads.Analyzed(server.SwaggerJSON, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the spec of the API: %v", err)
+	}
+	api := restapi.NewHTTPServerAPIAPI(spec)
+	api.Logger = log.Sugar().Debugf
+	for _, f := range register {
+		f(api)
+	}
+
+	srv := server.NewServer(api)
+	srv.ConfigureAPI()
+	return &Server{addr: addr, log: log, handler: srv.GetHandler()}, nil
+}
+
+// Handler returns the handler of the API
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Start serves the API until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			s.log.Warn("failed to shutdown the API server", zap.Error(err))
+		}
+	}()
+
+	s.log.Sugar().Infof("serve the API on %s", s.addr)
+	err := httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve the API: %v", err)
+	}
+	return nil
+}
+
+// NeedLeaderElection makes the API served by all the replicas
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
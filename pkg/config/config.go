@@ -18,6 +18,12 @@ import (
 	"github.com/spidernet-io/egressgateway/pkg/iptables"
 )
 
+// the datapath modes of the agent
+const (
+	DatapathModeIPTables = "iptables"
+	DatapathModeNFTables = "nftables"
+)
+
 type Config struct {
 	// From environment
 	EnvConfig
@@ -49,8 +55,10 @@ type EnvConfig struct {
 	LeaderElectionLostRestart bool   `mapstructure:"LEADER_ELECTION_LOST_RESTART"`
 	MetricsBindAddress        string `mapstructure:"METRICS_BIND_ADDRESS"`
 	HealthProbeBindAddress    string `mapstructure:"HEALTH_PROBE_BIND_ADDRESS"`
+	APIBindAddress            string `mapstructure:"API_BIND_ADDRESS"`
 	GopsPort                  int    `mapstructure:"GOPS_PORT"`
 	WebhookPort               int    `mapstructure:"WEBHOOK_PORT"`
+	WebhookServiceName        string `mapstructure:"WEBHOOK_SERVICE_NAME"`
 	PyroscopeServerAddr       string `mapstructure:"PYROSCOPE_SERVER_ADDR"`
 	PodName                   string `mapstructure:"POD_NAME"`
 	PodNamespace              string `mapstructure:"POD_NAMESPACE"`
@@ -73,6 +81,8 @@ type FileConfig struct {
 	EgressIgnoreCIDR          EgressIgnoreCIDR `yaml:"egressIgnoreCIDR"`
 	MaxNumberEndpointPerSlice int              `yaml:"maxNumberEndpointPerSlice"`
 	Mark                      string           `yaml:"mark"`
+	NodeLease                 NodeLease        `yaml:"nodeLease"`
+	Auditor                   Auditor          `yaml:"auditor"`
 }
 
 const TunnelInterfaceDefaultRoute = "defaultRouteInterface"
@@ -85,6 +95,22 @@ type VXLAN struct {
 	DisableChecksumOffload bool   `yaml:"disableChecksumOffload"`
 }
 
+// NodeLease configures the lease renewed by the agent, the controller fails
+// over the policies of the gateway node when its lease expires
+type NodeLease struct {
+	Enable              bool `yaml:"enable"`
+	LeaseDurationMillis int  `yaml:"leaseDurationMillis"`
+	RenewIntervalMillis int  `yaml:"renewIntervalMillis"`
+}
+
+// Auditor configures the periodic check of the EIPs, marks and tunnel IPs
+// allocations, the conflicts are only reported in dry-run mode
+type Auditor struct {
+	Enable         bool `yaml:"enable"`
+	IntervalSecond int  `yaml:"intervalSecond"`
+	DryRun         bool `yaml:"dryRun"`
+}
+
 type IPTables struct {
 	BackendMode                    string `yaml:"backendMode"`
 	RefreshIntervalSecond          int    `yaml:"refreshIntervalSecond"`
@@ -153,6 +179,16 @@ func LoadConfig(isAgent bool) (*Config, error) {
 				Custom: []string{},
 			},
 			Mark: "0x26000000",
+			NodeLease: NodeLease{
+				Enable:              true,
+				LeaseDurationMillis: 2000,
+				RenewIntervalMillis: 500,
+			},
+			Auditor: Auditor{
+				Enable:         true,
+				IntervalSecond: 60,
+				DryRun:         false,
+			},
 		},
 	}
 
@@ -197,6 +233,15 @@ func LoadConfig(isAgent bool) (*Config, error) {
 		}
 	}
 
+	switch config.FileConfig.DatapathMode {
+	case "":
+		config.FileConfig.DatapathMode = DatapathModeIPTables
+	case DatapathModeIPTables, DatapathModeNFTables:
+	default:
+		return nil, fmt.Errorf("unsupported datapathMode %s, it should be %s or %s",
+			config.FileConfig.DatapathMode, DatapathModeIPTables, DatapathModeNFTables)
+	}
+
 	if config.FileConfig.IPTables.BackendMode == "auto" {
 		config.FileConfig.IPTables.BackendMode = ver.BackendMode
 	}
@@ -0,0 +1,364 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// the kinds of the conflicts, they are the reasons of the Events
+const (
+	conflictDuplicateEIP      = "DuplicateEIP"
+	conflictDanglingPolicy    = "DanglingPolicy"
+	conflictDuplicateMark     = "DuplicateMark"
+	conflictDuplicateTunnelIP = "DuplicateTunnelIP"
+)
+
+var conflictKinds = []string{
+	conflictDuplicateEIP,
+	conflictDanglingPolicy,
+	conflictDuplicateMark,
+	conflictDuplicateTunnelIP,
+}
+
+// auditor cross-checks the EIPs, marks and tunnel IPs kept in the status of
+// the EgressGateways and the EgressNodes. The conflicts are reported with
+// Events and metrics, and repaired unless the auditor runs in dry-run mode.
+type auditor struct {
+	client client.Client
+	// reader confirms a policy is deleted, the cache may lag behind
+	reader   client.Reader
+	log      *zap.Logger
+	config   *config.Config
+	recorder record.EventRecorder
+}
+
+// Start audits the allocations every interval until ctx is done
+func (a *auditor) Start(ctx context.Context) error {
+	interval := time.Duration(a.config.FileConfig.Auditor.IntervalSecond) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	a.log.Sugar().Infof("audit the allocations every %v, dry run: %v", interval, a.config.FileConfig.Auditor.DryRun)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := a.audit(ctx); err != nil {
+			a.log.Warn("failed to audit the allocations", zap.Error(err))
+		}
+	}
+}
+
+// NeedLeaderElection makes the auditor run on the leader only
+func (a *auditor) NeedLeaderElection() bool {
+	return true
+}
+
+func (a *auditor) audit(ctx context.Context) error {
+	counts := make(map[string]int)
+	if err := a.auditEgressGateways(ctx, counts); err != nil {
+		return err
+	}
+	if err := a.auditEgressNodes(ctx, counts); err != nil {
+		return err
+	}
+	for _, kind := range conflictKinds {
+		metrics.AuditConflicts.WithLabelValues(kind).Set(float64(counts[kind]))
+	}
+	return nil
+}
+
+// auditEgressGateways drops the policies which are deleted from the node list,
+// and merges an EIP bound to several gateway nodes into the first one
+func (a *auditor) auditEgressGateways(ctx context.Context, counts map[string]int) error {
+	egs := new(egressv1.EgressGatewayList)
+	if err := a.client.List(ctx, egs); err != nil {
+		return fmt.Errorf("failed to list EgressGateway: %v", err)
+	}
+	policies, err := a.listPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the EgressGateway holding the EIP
+	eipOwners := make(map[string]string)
+	for i := range egs.Items {
+		eg := &egs.Items[i]
+		newEG := eg.DeepCopy()
+		var repaired []string
+
+		for j := range newEG.Status.NodeList {
+			node := &newEG.Status.NodeList[j]
+			eips := make([]egressv1.Eips, 0, len(node.Eips))
+			for _, eip := range node.Eips {
+				alive := make([]egressv1.Policy, 0, len(eip.Policies))
+				for _, policy := range eip.Policies {
+					exists, err := a.policyExists(ctx, policies, policy)
+					if err != nil {
+						return err
+					}
+					if exists {
+						alive = append(alive, policy)
+						continue
+					}
+					a.report(counts, eg, conflictDanglingPolicy, true,
+						"gateway node %s holds the deleted policy %s", node.Name, policy)
+					repaired = append(repaired, conflictDanglingPolicy)
+				}
+				if len(eip.Policies) > 0 && len(alive) == 0 {
+					continue
+				}
+				eip.Policies = alive
+				eips = append(eips, eip)
+			}
+			node.Eips = eips
+		}
+
+		// the gateway node holding the EIP first
+		owners := make(map[string]int)
+		for j := range newEG.Status.NodeList {
+			node := &newEG.Status.NodeList[j]
+			eips := make([]egressv1.Eips, 0, len(node.Eips))
+			for _, eip := range node.Eips {
+				owner, ok := eipOwner(owners, eip)
+				if !ok {
+					for _, ip := range []string{eip.IPv4, eip.IPv6} {
+						if ip != "" {
+							owners[ip] = j
+						}
+					}
+					eips = append(eips, eip)
+					continue
+				}
+				a.report(counts, eg, conflictDuplicateEIP, true,
+					"EIP %s of gateway node %s is held by gateway node %s", eipKey(eip), node.Name, newEG.Status.NodeList[owner].Name)
+				repaired = append(repaired, conflictDuplicateEIP)
+				if owner == j {
+					eips = mergeEip(eips, eip)
+				} else {
+					newEG.Status.NodeList[owner].Eips = mergeEip(newEG.Status.NodeList[owner].Eips, eip)
+				}
+			}
+			node.Eips = eips
+		}
+
+		// the ippools of the EgressGateways should not overlap, it is left
+		// to the user to fix them
+		for ip := range owners {
+			if owner, ok := eipOwners[ip]; ok && owner != eg.Name {
+				a.report(counts, eg, conflictDuplicateEIP, false, "EIP %s is also held by EgressGateway %s", ip, owner)
+				continue
+			}
+			eipOwners[ip] = eg.Name
+		}
+
+		a.repair(ctx, newEG, repaired)
+	}
+	return nil
+}
+
+// auditEgressNodes clears the duplicated mark and tunnel IPs of the newer
+// EgressNodes, the EgressNode controller allocates new ones for them
+func (a *auditor) auditEgressNodes(ctx context.Context, counts map[string]int) error {
+	nodes := new(egressv1.EgressNodeList)
+	if err := a.client.List(ctx, nodes); err != nil {
+		return fmt.Errorf("failed to list EgressNode: %v", err)
+	}
+	items := nodes.Items
+	sort.Slice(items, func(i, j int) bool {
+		ti, tj := items[i].CreationTimestamp, items[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	marks := make(map[string]string)
+	tunnelIPs := make(map[string]string)
+	for i := range items {
+		node := &items[i]
+		newNode := node.DeepCopy()
+		var repaired []string
+
+		if mark := node.Status.Mark; mark != "" {
+			if owner, ok := marks[mark]; ok {
+				a.report(counts, node, conflictDuplicateMark, true,
+					"mark %s is also held by EgressNode %s, reallocate it", mark, owner)
+				newNode.Status.Mark = ""
+				repaired = append(repaired, conflictDuplicateMark)
+			} else {
+				marks[mark] = node.Name
+			}
+		}
+
+		for _, ip := range []*string{&newNode.Status.Tunnel.IPv4, &newNode.Status.Tunnel.IPv6} {
+			if *ip == "" {
+				continue
+			}
+			if owner, ok := tunnelIPs[*ip]; ok {
+				a.report(counts, node, conflictDuplicateTunnelIP, true,
+					"tunnel IP %s is also held by EgressNode %s, reallocate it", *ip, owner)
+				*ip = ""
+				repaired = append(repaired, conflictDuplicateTunnelIP)
+				continue
+			}
+			tunnelIPs[*ip] = node.Name
+		}
+
+		a.repair(ctx, newNode, repaired)
+	}
+	return nil
+}
+
+// report counts the conflict, it is logged and recorded as an Event of obj
+func (a *auditor) report(counts map[string]int, obj client.Object, kind string, repairable bool, format string, args ...interface{}) {
+	counts[kind]++
+	msg := fmt.Sprintf(format, args...)
+	switch {
+	case !repairable:
+		msg += ", it can not be repaired automatically"
+	case a.config.FileConfig.Auditor.DryRun:
+		msg += ", it is not repaired in dry-run mode"
+	}
+	a.log.Sugar().Warnf("%s of %s: %s", kind, obj.GetName(), msg)
+	a.recorder.Event(obj, corev1.EventTypeWarning, kind, msg)
+}
+
+// repair updates the status of obj with the repaired allocations, the
+// conflicts are checked again in the next audit if it fails
+func (a *auditor) repair(ctx context.Context, obj client.Object, repaired []string) {
+	if len(repaired) == 0 || a.config.FileConfig.Auditor.DryRun {
+		return
+	}
+	if err := a.client.Status().Update(ctx, obj); err != nil {
+		a.log.Sugar().Warnf("failed to repair %s: %v", obj.GetName(), err)
+		return
+	}
+	for _, kind := range repaired {
+		metrics.AuditRepairs.WithLabelValues(kind).Inc()
+	}
+}
+
+func (a *auditor) listPolicies(ctx context.Context) (map[egressv1.Policy]struct{}, error) {
+	res := make(map[egressv1.Policy]struct{})
+	policies := new(egressv1.EgressPolicyList)
+	if err := a.client.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("failed to list EgressPolicy: %v", err)
+	}
+	for _, item := range policies.Items {
+		res[egressv1.Policy{Name: item.Name, Namespace: item.Namespace}] = struct{}{}
+	}
+	clusterPolicies := new(egressv1.EgressClusterPolicyList)
+	if err := a.client.List(ctx, clusterPolicies); err != nil {
+		return nil, fmt.Errorf("failed to list EgressClusterPolicy: %v", err)
+	}
+	for _, item := range clusterPolicies.Items {
+		res[egressv1.Policy{Name: item.Name}] = struct{}{}
+	}
+	return res, nil
+}
+
+// policyExists looks up the policy in the listed policies, a missing policy is
+// confirmed with the reader
+func (a *auditor) policyExists(ctx context.Context, policies map[egressv1.Policy]struct{}, policy egressv1.Policy) (bool, error) {
+	if _, ok := policies[policy]; ok {
+		return true, nil
+	}
+	var obj client.Object = &egressv1.EgressPolicy{}
+	if policy.Namespace == "" {
+		obj = &egressv1.EgressClusterPolicy{}
+	}
+	err := a.reader.Get(ctx, types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}, obj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get policy %s: %v", policy, err)
+	}
+	return true, nil
+}
+
+func eipOwner(owners map[string]int, eip egressv1.Eips) (int, bool) {
+	for _, ip := range []string{eip.IPv4, eip.IPv6} {
+		if ip == "" {
+			continue
+		}
+		if owner, ok := owners[ip]; ok {
+			return owner, true
+		}
+	}
+	return 0, false
+}
+
+// mergeEip merges the policies of eip into the entry of eips sharing an IP
+func mergeEip(eips []egressv1.Eips, eip egressv1.Eips) []egressv1.Eips {
+	for i, item := range eips {
+		if (eip.IPv4 == "" || item.IPv4 != eip.IPv4) && (eip.IPv6 == "" || item.IPv6 != eip.IPv6) {
+			continue
+		}
+		for _, policy := range eip.Policies {
+			found := false
+			for _, p := range item.Policies {
+				if p == policy {
+					found = true
+					break
+				}
+			}
+			if !found {
+				eips[i].Policies = append(eips[i].Policies, policy)
+			}
+		}
+		return eips
+	}
+	return append(eips, eip)
+}
+
+func eipKey(eip egressv1.Eips) string {
+	if eip.IPv4 != "" && eip.IPv6 != "" {
+		return eip.IPv4 + "/" + eip.IPv6
+	}
+	return eip.IPv4 + eip.IPv6
+}
+
+func newAuditor(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
+	if log == nil {
+		return fmt.Errorf("log can not be nil")
+	}
+	if cfg == nil {
+		return fmt.Errorf("cfg can not be nil")
+	}
+	if !cfg.FileConfig.Auditor.Enable {
+		log.Info("auditor is disabled")
+		return nil
+	}
+	if cfg.FileConfig.Auditor.IntervalSecond <= 0 {
+		return fmt.Errorf("invalid auditor.intervalSecond %d", cfg.FileConfig.Auditor.IntervalSecond)
+	}
+
+	return mgr.Add(&auditor{
+		client:   mgr.GetClient(),
+		reader:   mgr.GetAPIReader(),
+		log:      log.Named("auditor"),
+		config:   cfg,
+		recorder: mgr.GetEventRecorderFor("egressgateway-auditor"),
+	})
+}
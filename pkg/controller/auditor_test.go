@@ -0,0 +1,150 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/logger"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestAudit(t *testing.T) {
+	now := time.Now()
+	policy := egressv1.Policy{Name: "p1", Namespace: "default"}
+	deleted := egressv1.Policy{Name: "p2", Namespace: "default"}
+	clusterPolicy := egressv1.Policy{Name: "cp1"}
+
+	newObjects := func() []client.Object {
+		return []client.Object{
+			&egressv1.EgressPolicy{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"}},
+			&egressv1.EgressClusterPolicy{ObjectMeta: metav1.ObjectMeta{Name: "cp1"}},
+			&egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg1"},
+				Status: egressv1.EgressGatewayStatus{
+					NodeList: []egressv1.EgressIPStatus{
+						{Name: "node1", Eips: []egressv1.Eips{
+							{IPv4: "10.6.1.21", Policies: []egressv1.Policy{policy}},
+							{IPv4: "10.6.1.22", Policies: []egressv1.Policy{deleted}},
+						}},
+						{Name: "node2", Eips: []egressv1.Eips{
+							{IPv4: "10.6.1.21", Policies: []egressv1.Policy{clusterPolicy}},
+						}},
+					},
+				},
+			},
+			&egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg2"},
+				Status: egressv1.EgressGatewayStatus{
+					NodeList: []egressv1.EgressIPStatus{
+						{Name: "node3", Eips: []egressv1.Eips{
+							{IPv4: "10.6.1.21", Policies: []egressv1.Policy{clusterPolicy}},
+						}},
+					},
+				},
+			},
+			&egressv1.EgressNode{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1", CreationTimestamp: metav1.NewTime(now)},
+				Status: egressv1.EgressNodeStatus{
+					Mark:   "0x26000000",
+					Tunnel: egressv1.Tunnel{IPv4: "172.31.0.10"},
+				},
+			},
+			&egressv1.EgressNode{
+				ObjectMeta: metav1.ObjectMeta{Name: "node2", CreationTimestamp: metav1.NewTime(now.Add(time.Minute))},
+				Status: egressv1.EgressNodeStatus{
+					Mark:   "0x26000000",
+					Tunnel: egressv1.Tunnel{IPv4: "172.31.0.10"},
+				},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		dryRun   bool
+		expNode1 []egressv1.Eips
+		expNode2 []egressv1.Eips
+		expMark  string
+		expIPv4  string
+	}{
+		"repair": {
+			expNode1: []egressv1.Eips{
+				{IPv4: "10.6.1.21", Policies: []egressv1.Policy{policy, clusterPolicy}},
+			},
+			expNode2: []egressv1.Eips{},
+			expMark:  "",
+			expIPv4:  "",
+		},
+		"dry run": {
+			dryRun: true,
+			expNode1: []egressv1.Eips{
+				{IPv4: "10.6.1.21", Policies: []egressv1.Policy{policy}},
+				{IPv4: "10.6.1.22", Policies: []egressv1.Policy{deleted}},
+			},
+			expNode2: []egressv1.Eips{
+				{IPv4: "10.6.1.21", Policies: []egressv1.Policy{clusterPolicy}},
+			},
+			expMark: "0x26000000",
+			expIPv4: "172.31.0.10",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			objs := newObjects()
+			cli := fake.NewClientBuilder().
+				WithScheme(schema.GetScheme()).
+				WithObjects(objs...).
+				WithStatusSubresource(objs...).
+				Build()
+			recorder := record.NewFakeRecorder(100)
+			a := &auditor{
+				client: cli,
+				reader: cli,
+				log:    logger.NewStdoutLogger(os.Getenv("LOG_LEVEL")),
+				config: &config.Config{
+					FileConfig: config.FileConfig{
+						Auditor: config.Auditor{Enable: true, IntervalSecond: 60, DryRun: c.dryRun},
+					},
+				},
+				recorder: recorder,
+			}
+
+			ctx := context.Background()
+			assert.NoError(t, a.audit(ctx))
+
+			// a dangling policy, a duplicated EIP in eg1 and one across the
+			// EgressGateways, a duplicated mark and a duplicated tunnel IP
+			assert.Len(t, recorder.Events, 5)
+
+			eg := new(egressv1.EgressGateway)
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg1"}, eg))
+			assert.Equal(t, c.expNode1, eg.Status.NodeList[0].Eips)
+			assert.Equal(t, len(c.expNode2), len(eg.Status.NodeList[1].Eips))
+			if len(c.expNode2) > 0 {
+				assert.Equal(t, c.expNode2, eg.Status.NodeList[1].Eips)
+			}
+
+			node := new(egressv1.EgressNode)
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "node1"}, node))
+			assert.Equal(t, "0x26000000", node.Status.Mark)
+			assert.Equal(t, "172.31.0.10", node.Status.Tunnel.IPv4)
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "node2"}, node))
+			assert.Equal(t, c.expMark, node.Status.Mark)
+			assert.Equal(t, c.expIPv4, node.Status.Tunnel.IPv4)
+		})
+	}
+}
@@ -14,7 +14,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -28,6 +27,7 @@ import (
 	"github.com/spidernet-io/egressgateway/pkg/coalescing"
 	"github.com/spidernet-io/egressgateway/pkg/config"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/policymatch"
 )
 
 type endpointClusterReconciler struct {
@@ -198,12 +198,7 @@ func (r *endpointClusterReconciler) Reconcile(ctx context.Context, req reconcile
 }
 
 func newClusterEndpointSlice(policy *egressv1.EgressClusterPolicy) *egressv1.EgressClusterEndpointSlice {
-	// TODO: change it on release v1
-	gvk := schema.GroupVersionKind{
-		Group:   "egressgateway.spidernet.io",
-		Version: "v1beta1",
-		Kind:    "EgressClusterPolicy",
-	}
+	gvk := egressv1.GroupVersion.WithKind("EgressClusterPolicy")
 	ownerRef := metav1.NewControllerRef(policy, gvk)
 
 	return &egressv1.EgressClusterEndpointSlice{
@@ -219,6 +214,10 @@ func newClusterEndpointSlice(policy *egressv1.EgressClusterPolicy) *egressv1.Egr
 }
 
 func listPodsByClusterPolicy(ctx context.Context, cli client.Client, policy *egressv1.EgressClusterPolicy) ([]corev1.Pod, error) {
+	// policy with podSubnet matches source addresses directly, no pods are selected
+	if policy.Spec.AppliedTo.PodSelector == nil {
+		return make([]corev1.Pod, 0), nil
+	}
 	if policy.Spec.AppliedTo.NamespaceSelector == nil {
 		pods := new(corev1.PodList)
 		selector, err := metav1.LabelSelectorAsSelector(policy.Spec.AppliedTo.PodSelector)
@@ -232,7 +231,7 @@ func listPodsByClusterPolicy(ctx context.Context, cli client.Client, policy *egr
 		if err != nil {
 			return nil, err
 		}
-		return pods.Items, nil
+		return excludePods(pods.Items, policy.Spec.AppliedTo.ExcludePodSelector)
 	}
 
 	nsList := new(corev1.NamespaceList)
@@ -268,7 +267,7 @@ func listPodsByClusterPolicy(ctx context.Context, cli client.Client, policy *egr
 		res = append(res, pods.Items...)
 	}
 
-	return res, nil
+	return excludePods(res, policy.Spec.AppliedTo.ExcludePodSelector)
 }
 
 func listClusterEndpointSlices(ctx context.Context, cli client.Client, policyName string) (*egressv1.EgressClusterEndpointSliceList, error) {
@@ -424,11 +423,14 @@ func enqueueEGCP(cli client.Client) handler.MapFunc {
 		}
 
 		for _, policy := range policyList.Items {
-			selPods, err := metav1.LabelSelectorAsSelector(policy.Spec.AppliedTo.PodSelector)
+			// policy with podSubnet selects no pods
+			if policy.Spec.AppliedTo.PodSelector == nil {
+				continue
+			}
+			match, err := policymatch.SelectsLabels(policy.Spec.AppliedTo.PodSelector, pod.Labels)
 			if err != nil {
 				return nil
 			}
-			match := selPods.Matches(labels.Set(pod.Labels))
 			if match {
 				if policy.Spec.AppliedTo.NamespaceSelector != nil {
 					ns := new(corev1.Namespace)
@@ -436,11 +438,10 @@ func enqueueEGCP(cli client.Client) handler.MapFunc {
 					if err != nil {
 						return nil
 					}
-					selNS, err := metav1.LabelSelectorAsSelector(policy.Spec.AppliedTo.NamespaceSelector)
+					match, err := policymatch.SelectsNamespace(policy.Spec.AppliedTo.NamespaceSelector, ns.Labels)
 					if err != nil {
 						return nil
 					}
-					match := selNS.Matches(labels.Set(ns.Labels))
 					if !match {
 						continue
 					}
@@ -9,16 +9,21 @@ import (
 
 	"github.com/go-logr/logr"
 	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	runtimeWebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 
+	"github.com/spidernet-io/egressgateway/pkg/apiserver"
 	"github.com/spidernet-io/egressgateway/pkg/config"
 	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
 	"github.com/spidernet-io/egressgateway/pkg/controller/webhook"
 	"github.com/spidernet-io/egressgateway/pkg/egressgateway"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
 	"github.com/spidernet-io/egressgateway/pkg/logger"
 	"github.com/spidernet-io/egressgateway/pkg/schema"
 	"github.com/spidernet-io/egressgateway/pkg/types"
@@ -30,17 +35,32 @@ type Controller struct {
 }
 
 func New(cfg *config.Config, log *zap.Logger) (types.Service, error) {
+	// only the leases of the EgressNodes are cached
+	leaseSelector, err := labels.Parse(egressv1.LabelEgressNodeLease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lease selector: %w", err)
+	}
+	// the controllers run on the leader only, the webhooks are served by all
+	// the replicas. The lease is released on shutdown to hand off quickly.
+	leaderElectionNamespace := cfg.LeaderElectionNamespace
+	if leaderElectionNamespace == "" {
+		leaderElectionNamespace = cfg.PodNamespace
+	}
 	mgrOpts := manager.Options{
-		Scheme:                  schema.GetScheme(),
-		Logger:                  logr.New(logger.NewLogSink(log, cfg.KLOGLevel)),
-		LeaderElection:          false,
-		HealthProbeBindAddress:  cfg.HealthProbeBindAddress,
-		LeaderElectionID:        cfg.LeaderElectionID,
-		LeaderElectionNamespace: cfg.LeaderElectionNamespace,
+		Scheme:                        schema.GetScheme(),
+		Logger:                        logr.New(logger.NewLogSink(log, cfg.KLOGLevel)),
+		LeaderElection:                cfg.LeaderElection,
+		LeaderElectionReleaseOnCancel: true,
+		HealthProbeBindAddress:        cfg.HealthProbeBindAddress,
+		LeaderElectionID:              cfg.LeaderElectionID,
+		LeaderElectionNamespace:       leaderElectionNamespace,
 		WebhookServer: runtimeWebhook.NewServer(runtimeWebhook.Options{
 			Port:    cfg.WebhookPort,
 			CertDir: cfg.TLSCertDir,
 		}),
+		Cache: cache.Options{ByObject: map[client.Object]cache.ByObject{
+			&coordinationv1.Lease{}: {Label: leaseSelector},
+		}},
 	}
 
 	if cfg.MetricsBindAddress != "" {
@@ -55,16 +75,19 @@ func New(cfg *config.Config, log *zap.Logger) (types.Service, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create manager: %w", err)
 	}
-	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
-		return nil, fmt.Errorf("failed to AddHealthzCheck: %w", err)
-	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
-		return nil, fmt.Errorf("failed to AddReadyzCheck: %w", err)
+	probes := newProbes(mgr, cfg)
+	if err := probes.AddToManager(mgr); err != nil {
+		return nil, err
 	}
 	mgr.GetWebhookServer().Register("/validate", webhook.ValidateHook(mgr.GetClient(), cfg))
 	mgr.GetWebhookServer().Register("/mutate", webhook.MutateHook(mgr.GetClient(), cfg))
+	mgr.GetWebhookServer().Register(conversionPath, conversion.NewWebhookHandler(mgr.GetScheme()))
+	err = mgr.Add(&crdConversion{client: mgr.GetClient(), reader: mgr.GetAPIReader(), log: log, cfg: cfg})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add crd conversion: %w", err)
+	}
 
-	metrics.RegisterMetricCollectors()
+	metrics.RegisterMetricCollectors(egressNodeControllerMetricCollectors()...)
 
 	err = egressgateway.NewEgressGatewayController(mgr, log, cfg)
 	if err != nil {
@@ -100,19 +123,46 @@ func New(cfg *config.Config, log *zap.Logger) (types.Service, error) {
 		return nil, fmt.Errorf("failed to create cluster endpoint slice controller: %w", err)
 	}
 
+	err = newPolicyStatusController(mgr, log, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy status controller: %w", err)
+	}
+
+	err = newDestDomainController(mgr, log, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dest domain controller: %w", err)
+	}
+
+	err = newDefaultPolicyController(mgr, log, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create default policy controller: %w", err)
+	}
+
+	err = newNodeLeaseController(mgr, log, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node lease controller: %w", err)
+	}
+
+	err = newAuditor(mgr, log, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auditor: %w", err)
+	}
+
+	if cfg.APIBindAddress != "" {
+		srv, err := apiserver.New(cfg.APIBindAddress, log.Named("api"), probes.Register)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create api server: %w", err)
+		}
+		if err := mgr.Add(srv); err != nil {
+			return nil, fmt.Errorf("failed to add api server: %w", err)
+		}
+	}
+
 	return &Controller{client: mgr.GetClient(), manager: mgr}, err
 }
 
+// Start runs the manager until ctx is done or the leader election is lost,
+// it waits for the manager to stop so that the leader lease is released
 func (c *Controller) Start(ctx context.Context) error {
-	errChan := make(chan error)
-	go func() {
-		errChan <- c.manager.Start(ctx)
-	}()
-
-	select {
-	case <-ctx.Done():
-		return nil
-	case err := <-errChan:
-		return err
-	}
+	return c.manager.Start(ctx)
 }
@@ -0,0 +1,106 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+)
+
+// conversionPath is the path of the conversion webhook in the webhook server
+const conversionPath = "/convert"
+
+// conversionCRDs are the CRDs whose schema differs between v1beta1 and v1,
+// the other CRDs keep the None conversion strategy
+var conversionCRDs = []string{
+	"egressgateways.egressgateway.spidernet.io",
+	"egresspolicies.egressgateway.spidernet.io",
+	"egressclusterpolicies.egressgateway.spidernet.io",
+}
+
+// crdConversion points the conversion webhook of the CRDs to the webhook
+// server of the controller. The CRDs of the chart are not templated, so the
+// service and the CA bundle are set when the controller starts.
+type crdConversion struct {
+	client client.Client
+	reader client.Reader
+	log    *zap.Logger
+	cfg    *config.Config
+}
+
+func (c *crdConversion) Start(ctx context.Context) error {
+	if c.cfg.WebhookServiceName == "" {
+		c.log.Info("WEBHOOK_SERVICE_NAME is not set, skip setting the conversion webhook of the CRDs")
+		return nil
+	}
+	caBundle, err := os.ReadFile(filepath.Join(c.cfg.TLSCertDir, "ca.crt"))
+	if err != nil {
+		c.log.Sugar().Errorf("failed to read the CA bundle of the conversion webhook: %v", err)
+		return nil
+	}
+
+	backoff := wait.Backoff{Duration: time.Second, Factor: 2, Steps: 8, Cap: time.Minute}
+	for _, name := range conversionCRDs {
+		err := wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+			if err := c.setConversion(ctx, name, caBundle); err != nil {
+				c.log.Sugar().Warnf("failed to set the conversion webhook of CRD %s: %v", name, err)
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			c.log.Sugar().Errorf("give up setting the conversion webhook of CRD %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (c *crdConversion) setConversion(ctx context.Context, name string, caBundle []byte) error {
+	crd := new(apiextensionsv1.CustomResourceDefinition)
+	if err := c.reader.Get(ctx, types.NamespacedName{Name: name}, crd); err != nil {
+		return err
+	}
+	conversion := webhookConversion(c.cfg, caBundle)
+	if reflect.DeepEqual(crd.Spec.Conversion, conversion) {
+		return nil
+	}
+	crd.Spec.Conversion = conversion
+	if err := c.client.Update(ctx, crd); err != nil {
+		return fmt.Errorf("failed to update CRD: %v", err)
+	}
+	c.log.Sugar().Infof("set the conversion webhook of CRD %s", name)
+	return nil
+}
+
+func webhookConversion(cfg *config.Config, caBundle []byte) *apiextensionsv1.CustomResourceConversion {
+	path := conversionPath
+	port := int32(cfg.WebhookPort)
+	return &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: cfg.PodNamespace,
+					Name:      cfg.WebhookServiceName,
+					Path:      &path,
+					Port:      &port,
+				},
+				CABundle: caBundle,
+			},
+			ConversionReviewVersions: []string{"v1"},
+		},
+	}
+}
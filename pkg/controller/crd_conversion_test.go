@@ -0,0 +1,186 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressgatewayv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+// convert sends a ConversionReview of obj to the conversion webhook
+func convert(t *testing.T, obj interface{}, desiredAPIVersion string) map[string]interface{} {
+	raw, err := json.Marshal(obj)
+	assert.NoError(t, err)
+	review := apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               "uid",
+			DesiredAPIVersion: desiredAPIVersion,
+			Objects:           []runtime.RawExtension{{Raw: raw}},
+		},
+	}
+	body, err := json.Marshal(review)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, conversionPath, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	conversion.NewWebhookHandler(schema.GetScheme()).ServeHTTP(rec, req)
+
+	res := apiextensionsv1.ConversionReview{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, metav1.StatusSuccess, res.Response.Result.Status, res.Response.Result.Message)
+	assert.Len(t, res.Response.ConvertedObjects, 1)
+	converted := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(res.Response.ConvertedObjects[0].Raw, &converted))
+	return converted
+}
+
+func TestConversionWebhook(t *testing.T) {
+	eg := &egressv1.EgressGateway{
+		TypeMeta:   metav1.TypeMeta{APIVersion: egressv1.GroupVersion.String(), Kind: "EgressGateway"},
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Spec: egressv1.EgressGatewaySpec{
+			Ippools:              egressv1.Ippools{IPv4: []string{"10.6.1.21-10.6.1.22"}, Ipv4DefaultEIP: "10.6.1.21"},
+			NodeSelector:         egressv1.NodeSelector{Policy: egressv1.NodeSelectRR},
+			FailbackPolicy:       egressv1.FailbackAfterDelay,
+			FailbackDelaySeconds: 10,
+		},
+		Status: egressv1.EgressGatewayStatus{
+			NodeList: []egressv1.EgressIPStatus{
+				{Name: "node1", Eips: []egressv1.Eips{{IPv4: "10.6.1.21", Policies: []egressv1.Policy{{Name: "p", Namespace: "default"}}}}},
+				{Name: "node2"},
+			},
+			PreferredNodes: []egressv1.PreferredNode{
+				{Name: "node2", Policies: []egressv1.Policy{{Name: "p", Namespace: "default"}}},
+			},
+		},
+	}
+	res := convert(t, eg, egressgatewayv1.GroupVersion.String())
+	assert.Equal(t, "egressgateway.spidernet.io/v1", res["apiVersion"])
+	node := res["status"].(map[string]interface{})["nodeList"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, node, "eips")
+	assert.NotContains(t, node, "epis")
+	assert.Equal(t, "rr", res["spec"].(map[string]interface{})["nodeSelector"].(map[string]interface{})["policy"])
+
+	// converted back without losing fields
+	back := convert(t, res, egressv1.GroupVersion.String())
+	raw, err := json.Marshal(back)
+	assert.NoError(t, err)
+	roundTrip := new(egressv1.EgressGateway)
+	assert.NoError(t, json.Unmarshal(raw, roundTrip))
+	assert.Equal(t, eg.Spec, roundTrip.Spec)
+	assert.Equal(t, eg.Status, roundTrip.Status)
+
+	podSubnet := []string{"172.30.0.0/16"}
+	policy := &egressv1.EgressClusterPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: egressv1.GroupVersion.String(), Kind: "EgressClusterPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+		Spec: egressv1.EgressClusterPolicySpec{
+			EgressGatewayName: "eg",
+			EgressIP:          egressv1.EgressIP{AllocatorPolicy: egressv1.EipAllocatorRR},
+			GatewayReplicas:   2,
+			AppliedTo: egressv1.ClusterAppliedTo{
+				PodSubnet:          &podSubnet,
+				ExcludePodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			},
+			ExcludeDestSubnet: []string{"10.0.0.0/8"},
+			DestPorts:         []egressv1.PolicyPort{{Protocol: "TCP", Port: 443}},
+			Priority:          pointer.Uint64(10),
+		},
+		Status: egressv1.EgressPolicyStatus{
+			Eip:  egressv1.Eip{Ipv4: "10.6.1.21"},
+			Node: "node1",
+			Gateways: []egressv1.PolicyGateway{
+				{Node: "node1", Ipv4: "10.6.1.21"},
+				{Node: "node2", Ipv4: "10.6.1.22"},
+			},
+		},
+	}
+	res = convert(t, policy, egressgatewayv1.GroupVersion.String())
+	raw, err = json.Marshal(res)
+	assert.NoError(t, err)
+	hub := new(egressgatewayv1.EgressClusterPolicy)
+	assert.NoError(t, json.Unmarshal(raw, hub))
+	assert.Equal(t, podSubnet, hub.Spec.AppliedTo.PodSubnet)
+	assert.Equal(t, []string{"10.0.0.0/8"}, hub.Spec.ExcludeDestSubnet)
+	assert.Equal(t, egressgatewayv1.EipAllocatorRR, hub.Spec.EgressIP.AllocatorPolicy)
+	assert.Equal(t, "10.6.1.21", hub.Status.Eip.IPv4)
+	assert.Equal(t, int32(2), hub.Spec.GatewayReplicas)
+	assert.Equal(t, "10.6.1.22", hub.Status.Gateways[1].IPv4)
+
+	back = convert(t, res, egressv1.GroupVersion.String())
+	raw, err = json.Marshal(back)
+	assert.NoError(t, err)
+	policyBack := new(egressv1.EgressClusterPolicy)
+	assert.NoError(t, json.Unmarshal(raw, policyBack))
+	assert.Equal(t, policy.Spec, policyBack.Spec)
+	assert.Equal(t, policy.Status, policyBack.Status)
+}
+
+func TestCRDConversion(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("ca"), 0600))
+	cfg := &config.Config{EnvConfig: config.EnvConfig{
+		WebhookPort:        5822,
+		WebhookServiceName: "egressgateway-controller",
+		PodNamespace:       "kube-system",
+		TLSCertDir:         dir,
+	}}
+
+	builder := fake.NewClientBuilder().WithScheme(schema.GetScheme())
+	for _, name := range conversionCRDs {
+		builder.WithObjects(&apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Conversion: &apiextensionsv1.CustomResourceConversion{Strategy: apiextensionsv1.NoneConverter},
+			},
+		})
+	}
+	cli := builder.Build()
+	c := &crdConversion{client: cli, reader: cli, log: zap.NewNop(), cfg: cfg}
+	ctx := context.Background()
+	assert.NoError(t, c.Start(ctx))
+
+	for _, name := range conversionCRDs {
+		crd := new(apiextensionsv1.CustomResourceDefinition)
+		assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: name}, crd))
+		assert.Equal(t, apiextensionsv1.WebhookConverter, crd.Spec.Conversion.Strategy)
+		clientConfig := crd.Spec.Conversion.Webhook.ClientConfig
+		assert.Equal(t, []byte("ca"), clientConfig.CABundle)
+		assert.Equal(t, "kube-system", clientConfig.Service.Namespace)
+		assert.Equal(t, "egressgateway-controller", clientConfig.Service.Name)
+		assert.Equal(t, conversionPath, *clientConfig.Service.Path)
+		assert.Equal(t, int32(5822), *clientConfig.Service.Port)
+	}
+
+	// not updated again when unchanged
+	crd := new(apiextensionsv1.CustomResourceDefinition)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: conversionCRDs[0]}, crd))
+	assert.NoError(t, c.Start(ctx))
+	res := new(apiextensionsv1.CustomResourceDefinition)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: conversionCRDs[0]}, res))
+	assert.Equal(t, crd.ResourceVersion, res.ResourceVersion)
+}
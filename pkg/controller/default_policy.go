@@ -0,0 +1,168 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+const (
+	// defaultPolicyName is the name of the EgressPolicy managed for the
+	// default EgressGateway of the namespace
+	defaultPolicyName = "default-egressgateway"
+	// defaultPolicyPriority is the lowest priority, the apiserver stores the
+	// integers as int64, so the other policies selecting the same pods take
+	// precedence
+	defaultPolicyPriority uint64 = math.MaxInt64
+)
+
+// defaultPolicyReconciler turns the default EgressGateway annotation of the
+// namespaces into an EgressPolicy selecting all pods of the namespace
+type defaultPolicyReconciler struct {
+	client client.Client
+	log    *zap.Logger
+	config *config.Config
+}
+
+func (r *defaultPolicyReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.With(zap.String("namespace", req.Name))
+	log.Debug("default policy controller: reconciling")
+
+	gateway := ""
+	ns := new(corev1.Namespace)
+	err := r.client.Get(ctx, types.NamespacedName{Name: req.Name}, ns)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{Requeue: true}, err
+		}
+	} else if ns.DeletionTimestamp.IsZero() {
+		gateway = ns.Annotations[egressv1.AnnotationDefaultEgressGateway]
+	}
+
+	policy := new(egressv1.EgressPolicy)
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: req.Name, Name: defaultPolicyName}, policy)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{Requeue: true}, err
+		}
+		policy = nil
+	}
+	if policy != nil && policy.Labels[egressv1.LabelDefaultPolicy] != "true" {
+		log.Sugar().Warnf("EgressPolicy %s/%s is not managed by the controller, skip it", req.Name, defaultPolicyName)
+		return reconcile.Result{}, nil
+	}
+
+	if gateway == "" {
+		if policy == nil {
+			return reconcile.Result{}, nil
+		}
+		log.Info("delete the default policy of the namespace")
+		err = r.client.Delete(ctx, policy)
+		if err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{Requeue: true}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	spec := defaultPolicySpec(gateway)
+	if policy == nil {
+		policy = &egressv1.EgressPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: req.Name,
+				Name:      defaultPolicyName,
+				Labels:    map[string]string{egressv1.LabelDefaultPolicy: "true"},
+			},
+			Spec: spec,
+		}
+		log.Sugar().Infof("create the default policy of the namespace with EgressGateway %s", gateway)
+		err = r.client.Create(ctx, policy)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return reconcile.Result{Requeue: true}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// the egressIP defaulted by the apiserver is kept when the gateway is unchanged
+	if policy.Spec.EgressGatewayName == gateway {
+		spec.EgressIP = policy.Spec.EgressIP
+	}
+	if reflect.DeepEqual(policy.Spec, spec) {
+		return reconcile.Result{}, nil
+	}
+	policy.Spec = spec
+	log.Sugar().Infof("update the default policy of the namespace with EgressGateway %s", gateway)
+	err = r.client.Update(ctx, policy)
+	if err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// defaultPolicySpec returns the spec of the default policy of the namespace
+func defaultPolicySpec(gateway string) egressv1.EgressPolicySpec {
+	return egressv1.EgressPolicySpec{
+		EgressGatewayName: gateway,
+		AppliedTo:         egressv1.AppliedTo{PodSelector: &metav1.LabelSelector{}},
+		Priority:          pointer.Uint64(defaultPolicyPriority),
+	}
+}
+
+// enqueueDefaultPolicyNamespace maps the managed default policy to its namespace
+func enqueueDefaultPolicyNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetLabels()[egressv1.LabelDefaultPolicy] != "true" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+}
+
+func newDefaultPolicyController(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
+	if log == nil {
+		return fmt.Errorf("log can not be nil")
+	}
+	if cfg == nil {
+		return fmt.Errorf("cfg can not be nil")
+	}
+
+	r := &defaultPolicyReconciler{
+		client: mgr.GetClient(),
+		log:    log,
+		config: cfg,
+	}
+
+	log.Sugar().Infof("new default policy controller")
+	c, err := controller.New("defaultpolicy", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &corev1.Namespace{}), &handler.EnqueueRequestForObject{}); err != nil {
+		return fmt.Errorf("failed to watch Namespace: %w", err)
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressPolicy{}),
+		handler.EnqueueRequestsFromMapFunc(enqueueDefaultPolicyNamespace)); err != nil {
+		return fmt.Errorf("failed to watch EgressPolicy: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestDefaultPolicyReconcile(t *testing.T) {
+	ctx := context.Background()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "shop",
+		Annotations: map[string]string{egressv1.AnnotationDefaultEgressGateway: "eg1"},
+	}}
+	userPolicy := &egressv1.EgressPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: defaultPolicyName}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "other",
+		Annotations: map[string]string{egressv1.AnnotationDefaultEgressGateway: "eg1"},
+	}}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(ns, other, userPolicy).Build()
+	r := &defaultPolicyReconciler{client: cli, log: zap.NewNop()}
+	key := types.NamespacedName{Namespace: "shop", Name: defaultPolicyName}
+	reconcileNs := func(name string) {
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+		assert.NoError(t, err)
+	}
+
+	// created for the annotation
+	reconcileNs("shop")
+	policy := new(egressv1.EgressPolicy)
+	assert.NoError(t, cli.Get(ctx, key, policy))
+	assert.Equal(t, "true", policy.Labels[egressv1.LabelDefaultPolicy])
+	assert.Equal(t, defaultPolicySpec("eg1"), policy.Spec)
+
+	// follows the annotation
+	ns.Annotations[egressv1.AnnotationDefaultEgressGateway] = "eg2"
+	assert.NoError(t, cli.Update(ctx, ns))
+	reconcileNs("shop")
+	assert.NoError(t, cli.Get(ctx, key, policy))
+	assert.Equal(t, "eg2", policy.Spec.EgressGatewayName)
+
+	// deleted with the annotation
+	delete(ns.Annotations, egressv1.AnnotationDefaultEgressGateway)
+	assert.NoError(t, cli.Update(ctx, ns))
+	reconcileNs("shop")
+	assert.True(t, errors.IsNotFound(cli.Get(ctx, key, policy)))
+
+	// the policy created by the user is not touched
+	reconcileNs("other")
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Namespace: "other", Name: defaultPolicyName}, policy))
+	assert.Equal(t, "", policy.Spec.EgressGatewayName)
+	assert.Empty(t, policy.Labels)
+
+	assert.Len(t, enqueueDefaultPolicyNamespace(ctx, userPolicy), 0)
+	userPolicy.Labels = map[string]string{egressv1.LabelDefaultPolicy: "true"}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "other"}}},
+		enqueueDefaultPolicyNamespace(ctx, userPolicy))
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+const (
+	// destDomainTimeout bounds the queries of the domains of a policy, the
+	// domains which are not answered in time are queried again after
+	// fqdnRetryInterval
+	destDomainTimeout = 10 * time.Second
+	// destDomainWorkers is the number of policies resolved at the same time
+	destDomainWorkers = 4
+)
+
+// destDomainReconciler resolves the destDomains of the policies and publishes
+// the addresses in the policy status, so that the agents of all nodes match
+// the same addresses
+type destDomainReconciler struct {
+	client client.Client
+	log    *zap.Logger
+	fqdn   *fqdnCache
+}
+
+func (r *destDomainReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	kind, newReq, err := utils.ParseKindWithReq(req)
+	if err != nil {
+		r.log.Sugar().Errorf("parse req(%v) with error: %v", req, err)
+		return reconcile.Result{}, err
+	}
+
+	log := r.log.With(zap.String("namespace", newReq.Namespace), zap.String("name", newReq.Name), zap.String("kind", kind))
+	log.Debug("dest domain controller: reconciling")
+	var policy client.Object
+	switch kind {
+	case "EgressPolicy":
+		policy = new(egressv1.EgressPolicy)
+	case "EgressClusterPolicy":
+		policy = new(egressv1.EgressClusterPolicy)
+	default:
+		return reconcile.Result{}, nil
+	}
+
+	key := egressv1.Policy{Namespace: newReq.Namespace, Name: newReq.Name}
+	err = r.client.Get(ctx, newReq.NamespacedName, policy)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			r.fqdn.Forget(key)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{Requeue: true}, err
+	}
+
+	var domains []string
+	var status *egressv1.EgressPolicyStatus
+	switch policy := policy.(type) {
+	case *egressv1.EgressPolicy:
+		domains, status = policy.Spec.DestDomains, &policy.Status
+	case *egressv1.EgressClusterPolicy:
+		domains, status = policy.Spec.DestDomains, &policy.Status
+	}
+
+	ips := egressv1.IPListPair{}
+	if len(domains) > 0 {
+		resolveCtx, cancel := context.WithTimeout(ctx, destDomainTimeout)
+		ips.IPv4, ips.IPv6 = r.fqdn.Resolve(resolveCtx, key, domains)
+		cancel()
+	} else {
+		r.fqdn.Forget(key)
+	}
+	// keep the empty lists out of the status
+	if len(ips.IPv4) == 0 {
+		ips.IPv4 = nil
+	}
+	if len(ips.IPv6) == 0 {
+		ips.IPv6 = nil
+	}
+
+	if !reflect.DeepEqual(ips, status.DestDomainIPs) {
+		status.DestDomainIPs = ips
+		log.Debug("update dest domain addresses", zap.Strings("ipv4", ips.IPv4), zap.Strings("ipv6", ips.IPv6))
+		err = r.client.Status().Update(ctx, policy)
+		if err != nil {
+			return reconcile.Result{Requeue: true}, err
+		}
+	}
+	if len(domains) > 0 {
+		// resolve the domains again when the TTL expires
+		return reconcile.Result{RequeueAfter: r.fqdn.NextRefresh(key)}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+func newDestDomainController(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
+	if log == nil {
+		return fmt.Errorf("log can not be nil")
+	}
+	if cfg == nil {
+		return fmt.Errorf("cfg can not be nil")
+	}
+
+	r := &destDomainReconciler{
+		client: mgr.GetClient(),
+		log:    log,
+	}
+	resolver, err := newUDPResolver("/etc/resolv.conf", cfg.FileConfig.EnableIPv4, cfg.FileConfig.EnableIPv6)
+	if err != nil {
+		log.Warn("failed to get nameserver, destDomains of policies will not be resolved", zap.Error(err))
+		r.fqdn = newFQDNCache(nil, log)
+	} else {
+		r.fqdn = newFQDNCache(resolver, log)
+	}
+
+	log.Sugar().Infof("new dest domain controller")
+	c, err := controller.New("destdomain", mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: destDomainWorkers,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the status updates of the policies do not change the domains
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressPolicy{}),
+		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressPolicy")),
+		predicate.GenerationChangedPredicate{}); err != nil {
+		return fmt.Errorf("failed to watch EgressPolicy: %w", err)
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressClusterPolicy{}),
+		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressClusterPolicy")),
+		predicate.GenerationChangedPredicate{}); err != nil {
+		return fmt.Errorf("failed to watch EgressClusterPolicy: %w", err)
+	}
+
+	return nil
+}
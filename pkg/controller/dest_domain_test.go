@@ -0,0 +1,73 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestDestDomainReconcile(t *testing.T) {
+	ctx := context.Background()
+	policy := &egressv1.EgressPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+		Spec: egressv1.EgressPolicySpec{
+			EgressGatewayName: "eg",
+			DestDomains:       []string{"api.example.com"},
+		},
+	}
+	clusterPolicy := &egressv1.EgressClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-policy"},
+		Spec:       egressv1.EgressClusterPolicySpec{EgressGatewayName: "eg"},
+		Status: egressv1.EgressPolicyStatus{
+			DestDomainIPs: egressv1.IPListPair{IPv4: []string{"10.6.1.100"}},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).
+		WithObjects(policy, clusterPolicy).WithStatusSubresource(policy, clusterPolicy).Build()
+	resolver := &fakeResolver{records: map[string][]dnsAnswer{
+		"api.example.com": {
+			{IP: net.ParseIP("10.6.1.92"), TTL: 60 * time.Second},
+			{IP: net.ParseIP("fd00::92"), TTL: 60 * time.Second},
+		},
+	}}
+	r := &destDomainReconciler{client: cli, log: zap.NewNop(), fqdn: newFQDNCache(resolver, zap.NewNop())}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "EgressPolicy/default", Name: "policy"}}
+	res, err := r.Reconcile(ctx, req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, time.Minute, res.RequeueAfter.Round(time.Second))
+	got := new(egressv1.EgressPolicy)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Namespace: "default", Name: "policy"}, got))
+	assert.Equal(t, []string{"10.6.1.92"}, got.Status.DestDomainIPs.IPv4)
+	assert.Equal(t, []string{"fd00::92"}, got.Status.DestDomainIPs.IPv6)
+
+	// cached until the TTL expires
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resolver.queries)
+
+	// the addresses are removed with the domains
+	req = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "EgressClusterPolicy/", Name: "cluster-policy"}}
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), res.RequeueAfter)
+	gotCluster := new(egressv1.EgressClusterPolicy)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "cluster-policy"}, gotCluster))
+	assert.Equal(t, egressv1.IPListPair{}, gotCluster.Status.DestDomainIPs)
+}
@@ -72,20 +72,18 @@ func (r *egcpReconciler) reconcileEG(ctx context.Context, req reconcile.Request,
 
 	for _, item := range egcpList.Items {
 		policy := egressv1.Policy{Name: item.Name, Namespace: item.Namespace}
-		eipStatus, isExist := egressgateway.GetEIPStatusByPolicy(policy, *egw)
-		if !isExist {
+		gateways := egressgateway.GetPolicyGateways(policy, *egw)
+		if len(gateways) == 0 {
 			continue
 		}
 
 		newEGCP := item.DeepCopy()
-		for _, eip := range eipStatus.Eips {
-			for _, p := range eip.Policies {
-				if p == policy {
-					newEGCP.Status.Eip.Ipv4 = eip.IPv4
-					newEGCP.Status.Eip.Ipv6 = eip.IPv6
-					newEGCP.Status.Node = eipStatus.Name
-				}
-			}
+		newEGCP.Status.Eip.Ipv4 = gateways[0].Ipv4
+		newEGCP.Status.Eip.Ipv6 = gateways[0].Ipv6
+		newEGCP.Status.Node = gateways[0].Node
+		newEGCP.Status.Gateways = nil
+		if len(gateways) > 1 {
+			newEGCP.Status.Gateways = gateways
 		}
 
 		r.log.Sugar().Debugf("update egressclusterpolicy status\n%v", newEGCP.Status)
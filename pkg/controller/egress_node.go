@@ -7,16 +7,16 @@ import (
 	"context"
 	"crypto/sha1"
 	"fmt"
+	"math/big"
 	"net"
-	"sync"
 	"time"
 
-	"github.com/cilium/ipam/service/ipallocator"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -25,6 +25,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
+	"github.com/spidernet-io/egressgateway/pkg/ipallocator"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
 	"github.com/spidernet-io/egressgateway/pkg/markallocator"
 	"github.com/spidernet-io/egressgateway/pkg/utils"
@@ -61,6 +63,12 @@ var (
 	egressNodeFinalizers = "egressgateway.spidernet.io/egressnode"
 )
 
+// the reasons of the Events of the EgressNodes
+const (
+	eventMarkAllocationFailed     = "MarkAllocationFailed"
+	eventTunnelIPAllocationFailed = "TunnelIPAllocationFailed"
+)
+
 func egressNodeControllerMetricCollectors() []prometheus.Collector {
 	return []prometheus.Collector{
 		countNumIPAllocateNextCalls,
@@ -71,10 +79,12 @@ func egressNodeControllerMetricCollectors() []prometheus.Collector {
 }
 
 type egReconciler struct {
-	client      client.Client
-	log         *zap.Logger
-	config      *config.Config
-	doOnce      sync.Once
+	client   client.Client
+	log      *zap.Logger
+	config   *config.Config
+	recorder record.EventRecorder
+	// synced is closed when the allocators are rebuilt from the EgressNodes
+	synced      chan struct{}
 	mark        markallocator.Interface
 	allocatorV4 *ipallocator.Range
 	allocatorV6 *ipallocator.Range
@@ -87,16 +97,12 @@ func (r *egReconciler) Reconcile(ctx context.Context, req reconcile.Request) (re
 		return reconcile.Result{}, err
 	}
 
-	r.doOnce.Do(func() {
-		r.log.Sugar().Info("first reconcile of egressnode controller, init egressnode")
-	redo:
-		err := r.initEgressNode()
-		if err != nil {
-			r.log.Sugar().Errorf("init egreee node controller with error: %v", err)
-			time.Sleep(time.Second)
-			goto redo
-		}
-	})
+	select {
+	case <-r.synced:
+	case <-ctx.Done():
+		return reconcile.Result{Requeue: true}, ctx.Err()
+	}
+	defer r.recordPoolMetrics()
 
 	log := r.log.With(zap.String("name", newReq.Name), zap.String("kind", kind))
 	log.Info("reconciling")
@@ -110,6 +116,21 @@ func (r *egReconciler) Reconcile(ctx context.Context, req reconcile.Request) (re
 	}
 }
 
+// recordPoolMetrics records the usage of the mark range and the tunnel CIDRs
+func (r *egReconciler) recordPoolMetrics() {
+	metrics.MarkAllocated.Set(float64(r.mark.Used()))
+	metrics.MarkTotal.Set(float64(r.mark.Size()))
+	for version, allocator := range map[string]*ipallocator.Range{"ipv4": r.allocatorV4, "ipv6": r.allocatorV6} {
+		if allocator == nil {
+			continue
+		}
+		used := allocator.Used()
+		total, _ := new(big.Float).SetInt(new(big.Int).Add(allocator.Free(), big.NewInt(int64(used)))).Float64()
+		metrics.TunnelIPAllocated.WithLabelValues(version).Set(float64(used))
+		metrics.TunnelIPTotal.WithLabelValues(version).Set(total)
+	}
+}
+
 // reconcileEN reconcile egress node
 // goal:
 // - update egress node
@@ -419,6 +440,7 @@ func (r *egReconciler) keepEgressNode(node egressv1.EgressNode, log *zap.Logger)
 		log.Debug("try to allocate next mark")
 		newNode.Status.Mark, err = r.mark.AllocateNext()
 		if err != nil {
+			r.recorder.Eventf(&node, corev1.EventTypeWarning, eventMarkAllocationFailed, "Failed to allocate a mark: %v", err)
 			return fmt.Errorf("can't allocate next mark: %v", err)
 		}
 		countNumMarkAllocateNextCalls.Inc()
@@ -435,6 +457,7 @@ func (r *egReconciler) keepEgressNode(node egressv1.EgressNode, log *zap.Logger)
 		log.Debug("try to allocate next ipv4")
 		ip, err := r.allocatorV4.AllocateNext()
 		if err != nil {
+			r.recorder.Eventf(&node, corev1.EventTypeWarning, eventTunnelIPAllocationFailed, "Failed to allocate a tunnel IPv4 address: %v", err)
 			return fmt.Errorf("can't allocate next ipv4: %v", err)
 		}
 		countNumIPAllocateNextCallsIpv4.Inc()
@@ -451,6 +474,7 @@ func (r *egReconciler) keepEgressNode(node egressv1.EgressNode, log *zap.Logger)
 		log.Debug("try to allocate next ipv6")
 		ip, err := r.allocatorV6.AllocateNext()
 		if err != nil {
+			r.recorder.Eventf(&node, corev1.EventTypeWarning, eventTunnelIPAllocationFailed, "Failed to allocate a tunnel IPv6 address: %v", err)
 			return fmt.Errorf("can't allocate next ipv6: %v", err)
 		}
 		countNumIPAllocateNextCallsIpv6.Inc()
@@ -511,6 +535,30 @@ func generateMACAddress(nodeName string) (string, error) {
 	return hw.String(), nil
 }
 
+// Start rebuilds the allocators from the EgressNodes after the replica becomes
+// the leader, the reconciles wait until it is done
+func (r *egReconciler) Start(ctx context.Context) error {
+	r.log.Sugar().Info("became the leader, init egressnode")
+	for {
+		err := r.initEgressNode()
+		if err == nil {
+			close(r.synced)
+			return nil
+		}
+		r.log.Sugar().Errorf("init egreee node controller with error: %v", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// NeedLeaderElection makes Start run on the leader only
+func (r *egReconciler) NeedLeaderElection() bool {
+	return true
+}
+
 func (r *egReconciler) initEgressNode() error {
 	nodes := &egressv1.EgressNodeList{}
 	err := r.client.List(context.Background(), nodes)
@@ -564,8 +612,10 @@ func newEgressNodeController(mgr manager.Manager, log *zap.Logger, cfg *config.C
 		client: mgr.GetClient(),
 		log:    log,
 		config: cfg,
-		doOnce: sync.Once{},
+		synced: make(chan struct{}),
 		mark:   mark,
+
+		recorder: mgr.GetEventRecorderFor("egressnode-controller"),
 	}
 
 	if cfg.FileConfig.EnableIPv4 {
@@ -589,6 +639,10 @@ func newEgressNodeController(mgr manager.Manager, log *zap.Logger, cfg *config.C
 		}
 	}
 
+	if err := mgr.Add(r); err != nil {
+		return fmt.Errorf("failed to add egressnode allocator init: %w", err)
+	}
+
 	log.Sugar().Infof("new egressnode controller")
 	c, err := controller.New("egressnode", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -9,7 +9,6 @@ import (
 	"os"
 	"testing"
 
-	"github.com/cilium/ipam/service/ipallocator"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,6 +18,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/ipallocator"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
 	"github.com/spidernet-io/egressgateway/pkg/logger"
 	"github.com/spidernet-io/egressgateway/pkg/markallocator"
@@ -73,6 +73,7 @@ func TestEgressNodeCtrlForEgressNode(t *testing.T) {
 		mark:        mark,
 		allocatorV4: allocatorV4,
 		allocatorV6: nil,
+		synced:      make(chan struct{}),
 	}
 
 	reqs := []TestNodeReq{
@@ -83,6 +84,7 @@ func TestEgressNodeCtrlForEgressNode(t *testing.T) {
 		},
 	}
 	ctx := context.Background()
+	assert.NoError(t, reconciler.Start(ctx))
 	for _, req := range reqs {
 		res, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: req.nn})
 		if !req.expErr {
@@ -147,6 +149,7 @@ func TestEgressNodeCtrlForNode(t *testing.T) {
 		mark:        mark,
 		allocatorV4: allocatorV4,
 		allocatorV6: allocatorV6,
+		synced:      make(chan struct{}),
 	}
 
 	reqs := []TestNodeReq{
@@ -157,6 +160,7 @@ func TestEgressNodeCtrlForNode(t *testing.T) {
 		},
 	}
 	ctx := context.Background()
+	assert.NoError(t, reconciler.Start(ctx))
 	for _, req := range reqs {
 		res, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: req.nn})
 		if !req.expErr {
@@ -190,3 +194,56 @@ func TestEgressNodeCtrlForNode(t *testing.T) {
 		t.Fatal("expect deleted egress node, but got one")
 	}
 }
+
+func TestEgressNodeStartRebuildCache(t *testing.T) {
+	log := logger.NewStdoutLogger(os.Getenv("LOG_LEVEL"))
+	cfg := &config.Config{
+		FileConfig: config.FileConfig{EnableIPv4: true},
+	}
+
+	initialObjects := []client.Object{
+		&egressv1.EgressNode{
+			ObjectMeta: v1.ObjectMeta{Name: "node1"},
+			Status: egressv1.EgressNodeStatus{
+				Mark:   "0x26000000",
+				Tunnel: egressv1.Tunnel{IPv4: "10.6.0.10"},
+			},
+		},
+	}
+	builder := fake.NewClientBuilder()
+	builder.WithScheme(schema.GetScheme())
+	builder.WithObjects(initialObjects...)
+	builder.WithStatusSubresource(initialObjects...)
+
+	mark, err := markallocator.NewAllocatorMarkRange("0x26000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, cidr, _ := net.ParseCIDR("10.6.0.0/24")
+	allocatorV4, _ := ipallocator.NewCIDRRange(cidr)
+
+	reconciler := &egReconciler{
+		client:      builder.Build(),
+		log:         log,
+		config:      cfg,
+		mark:        mark,
+		allocatorV4: allocatorV4,
+		synced:      make(chan struct{}),
+	}
+	assert.True(t, reconciler.NeedLeaderElection())
+
+	// the reconciles wait until the leader rebuilds the allocators
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "EgressNode/", Name: "node1"}})
+	assert.Error(t, err)
+
+	assert.NoError(t, reconciler.Start(context.Background()))
+	assert.True(t, allocatorV4.Has(net.ParseIP("10.6.0.10")))
+	assert.Equal(t, 1, allocatorV4.Used())
+	select {
+	case <-reconciler.synced:
+	default:
+		t.Fatal("expect synced to be closed")
+	}
+}
@@ -72,20 +72,18 @@ func (r *egpReconciler) reconcileEG(ctx context.Context, req reconcile.Request,
 
 	for _, item := range egpList.Items {
 		policy := egressv1.Policy{Name: item.Name, Namespace: item.Namespace}
-		eipStatus, isExist := egressgateway.GetEIPStatusByPolicy(policy, *egw)
-		if !isExist {
+		gateways := egressgateway.GetPolicyGateways(policy, *egw)
+		if len(gateways) == 0 {
 			continue
 		}
 
 		newEGP := item.DeepCopy()
-		for _, eip := range eipStatus.Eips {
-			for _, p := range eip.Policies {
-				if p == policy {
-					newEGP.Status.Eip.Ipv4 = eip.IPv4
-					newEGP.Status.Eip.Ipv6 = eip.IPv6
-					newEGP.Status.Node = eipStatus.Name
-				}
-			}
+		newEGP.Status.Eip.Ipv4 = gateways[0].Ipv4
+		newEGP.Status.Eip.Ipv6 = gateways[0].Ipv6
+		newEGP.Status.Node = gateways[0].Node
+		newEGP.Status.Gateways = nil
+		if len(gateways) > 1 {
+			newEGP.Status.Gateways = gateways
 		}
 
 		r.log.Sugar().Debugf("update egresspolicy status\n%v", newEGP.Status)
@@ -16,8 +16,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -31,6 +29,7 @@ import (
 	"github.com/spidernet-io/egressgateway/pkg/coalescing"
 	"github.com/spidernet-io/egressgateway/pkg/config"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/policymatch"
 )
 
 type endpointReconciler struct {
@@ -202,12 +201,7 @@ func (r *endpointReconciler) Reconcile(ctx context.Context, req reconcile.Reques
 }
 
 func newEndpointSlice(policy *egressv1.EgressPolicy) *egressv1.EgressEndpointSlice {
-	// TODO: change it on release v1
-	gvk := schema.GroupVersionKind{
-		Group:   "egressgateway.spidernet.io",
-		Version: "v1beta1",
-		Kind:    "EgressPolicy",
-	}
+	gvk := egressv1.GroupVersion.WithKind("EgressPolicy")
 	ownerRef := metav1.NewControllerRef(policy, gvk)
 	return &egressv1.EgressEndpointSlice{
 		ObjectMeta: metav1.ObjectMeta{
@@ -307,6 +301,10 @@ func (r *endpointReconciler) initEndpoint() error {
 
 func listPodsByPolicy(ctx context.Context, cli client.Client, policy *egressv1.EgressPolicy) (*corev1.PodList, error) {
 	pods := new(corev1.PodList)
+	// policy with podSubnet matches source addresses directly, no pods are selected
+	if policy.Spec.AppliedTo.PodSelector == nil {
+		return pods, nil
+	}
 	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.AppliedTo.PodSelector)
 	if err != nil {
 		return pods, err
@@ -316,9 +314,32 @@ func listPodsByPolicy(ctx context.Context, cli client.Client, policy *egressv1.E
 		Namespace:     policy.Namespace,
 	}
 	err = cli.List(ctx, pods, opt)
+	if err != nil {
+		return pods, err
+	}
+	pods.Items, err = excludePods(pods.Items, policy.Spec.AppliedTo.ExcludePodSelector)
 	return pods, err
 }
 
+// excludePods drops the pods matched by the exclude selector of the policy
+func excludePods(pods []corev1.Pod, exclude *metav1.LabelSelector) ([]corev1.Pod, error) {
+	if exclude == nil {
+		return pods, nil
+	}
+	res := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		excluded, err := policymatch.ExcludesPod(exclude, pod.Labels)
+		if err != nil {
+			return nil, err
+		}
+		if excluded {
+			continue
+		}
+		res = append(res, pod)
+	}
+	return res, nil
+}
+
 func listEndpointSlices(ctx context.Context, cli client.Client, namespace, policyName string) (*egressv1.EgressEndpointSliceList, error) {
 	slices := new(egressv1.EgressEndpointSliceList)
 	labelSelector := &metav1.LabelSelector{MatchLabels: map[string]string{
@@ -432,11 +453,14 @@ func enqueuePod(cli client.Client) handler.MapFunc {
 		res := make([]reconcile.Request, 0)
 
 		for _, policy := range policyList.Items {
-			selPods, err := metav1.LabelSelectorAsSelector(policy.Spec.AppliedTo.PodSelector)
+			// policy with podSubnet selects no pods
+			if policy.Spec.AppliedTo.PodSelector == nil {
+				continue
+			}
+			match, err := policymatch.SelectsLabels(policy.Spec.AppliedTo.PodSelector, pod.Labels)
 			if err != nil {
 				return nil
 			}
-			match := selPods.Matches(labels.Set(pod.Labels))
 			if match {
 				res = append(res, reconcile.Request{
 					NamespacedName: types.NamespacedName{
@@ -495,3 +495,44 @@ func caseDeletePod() TestCaseEPS {
 		config:         conf,
 	}
 }
+
+func TestListPodsWithExcludeSelector(t *testing.T) {
+	ctx := context.Background()
+	newPod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(
+		newPod("web", map[string]string{"app": "shop", "tier": "web"}),
+		newPod("db", map[string]string{"app": "shop", "tier": "db"}),
+	).Build()
+
+	appliedTo := egressv1.AppliedTo{
+		PodSelector:        &metav1.LabelSelector{MatchLabels: map[string]string{"app": "shop"}},
+		ExcludePodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "db"}},
+	}
+	policy := &egressv1.EgressPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default"},
+		Spec:       egressv1.EgressPolicySpec{AppliedTo: appliedTo},
+	}
+	pods, err := listPodsByPolicy(ctx, cli, policy)
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 1)
+	assert.Equal(t, "web", pods.Items[0].Name)
+
+	clusterPolicy := &egressv1.EgressClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+		Spec: egressv1.EgressClusterPolicySpec{AppliedTo: egressv1.ClusterAppliedTo{
+			PodSelector:        appliedTo.PodSelector,
+			ExcludePodSelector: appliedTo.ExcludePodSelector,
+		}},
+	}
+	items, err := listPodsByClusterPolicy(ctx, cli, clusterPolicy)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "web", items[0].Name)
+
+	policy.Spec.AppliedTo.ExcludePodSelector = nil
+	pods, err = listPodsByPolicy(ctx, cli, policy)
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 2)
+}
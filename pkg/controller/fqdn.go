@@ -0,0 +1,433 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+const (
+	// fqdnMinTTL avoids querying the nameserver too often for records with a small TTL
+	fqdnMinTTL = 5 * time.Second
+	// fqdnRetryInterval is the interval of querying again after a failure
+	// or when the domain has no address
+	fqdnRetryInterval = 30 * time.Second
+)
+
+// dnsAnswer is an address of a domain and how long it can be cached
+type dnsAnswer struct {
+	IP  net.IP
+	TTL time.Duration
+}
+
+// dnsResolver resolves the A and AAAA records of a domain
+type dnsResolver interface {
+	Resolve(ctx context.Context, domain string) ([]dnsAnswer, error)
+}
+
+// udpResolver queries the A and AAAA records from the nameserver over UDP,
+// and over TCP when the reply is truncated, unlike net.Resolver it returns
+// the TTL of the records
+type udpResolver struct {
+	server  string
+	timeout time.Duration
+	// record types of the enabled IP families
+	qtypes []dnsmessage.Type
+}
+
+// newUDPResolver returns a resolver of the first nameserver in resolvConf,
+// which only queries the records of the enabled IP families
+func newUDPResolver(resolvConf string, enableIPv4, enableIPv6 bool) (*udpResolver, error) {
+	f, err := os.Open(resolvConf)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		return &udpResolver{
+			server:  net.JoinHostPort(fields[1], "53"),
+			timeout: 5 * time.Second,
+			qtypes:  queryTypes(enableIPv4, enableIPv6),
+		}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no nameserver found in %s", resolvConf)
+}
+
+func queryTypes(enableIPv4, enableIPv6 bool) []dnsmessage.Type {
+	res := make([]dnsmessage.Type, 0, 2)
+	if enableIPv4 {
+		res = append(res, dnsmessage.TypeA)
+	}
+	if enableIPv6 {
+		res = append(res, dnsmessage.TypeAAAA)
+	}
+	return res
+}
+
+// Resolve returns the answers of the families which succeed, it only fails
+// when all of them fail, so a nameserver which does not answer AAAA does not
+// drop the A records
+func (r *udpResolver) Resolve(ctx context.Context, domain string) ([]dnsAnswer, error) {
+	res := make([]dnsAnswer, 0)
+	errs := make([]string, 0)
+	for _, qtype := range r.qtypes {
+		answers, err := r.query(ctx, domain, qtype)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		res = append(res, answers...)
+	}
+	if len(errs) > 0 && len(errs) == len(r.qtypes) {
+		return nil, fmt.Errorf("failed to resolve %s: %s", domain, strings.Join(errs, "; "))
+	}
+	return res, nil
+}
+
+func (r *udpResolver) query(ctx context.Context, domain string, qtype dnsmessage.Type) ([]dnsAnswer, error) {
+	if !strings.HasSuffix(domain, ".") {
+		domain += "."
+	}
+	name, err := dnsmessage.NewName(domain)
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Intn(1 << 16))
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.exchange(ctx, "udp", packed, id)
+	if err != nil {
+		return nil, err
+	}
+	var p dnsmessage.Parser
+	header, err := p.Start(resp)
+	if err != nil {
+		return nil, err
+	}
+	// the answers do not fit in the UDP reply
+	if header.Truncated {
+		resp, err = r.exchange(ctx, "tcp", packed, id)
+		if err != nil {
+			return nil, err
+		}
+		header, err = p.Start(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to query %s %v: %v", domain, qtype, header.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, err
+	}
+	return parseAnswers(&p)
+}
+
+// exchange sends the query to the nameserver over network and returns the
+// reply to it, the messages are prefixed by their length over TCP
+func (r *udpResolver) exchange(ctx context.Context, network string, query []byte, id uint16) ([]byte, error) {
+	dialer := net.Dialer{Timeout: r.timeout}
+	conn, err := dialer.DialContext(ctx, network, r.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(r.timeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if network == "tcp" {
+		msg := make([]byte, 2, 2+len(query))
+		binary.BigEndian.PutUint16(msg, uint16(len(query)))
+		if _, err := conn.Write(append(msg, query...)); err != nil {
+			return nil, err
+		}
+		length := make([]byte, 2)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return nil, err
+		}
+		resp := make([]byte, binary.BigEndian.Uint16(length))
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			return nil, err
+		}
+		var p dnsmessage.Parser
+		header, err := p.Start(resp)
+		if err != nil {
+			return nil, err
+		}
+		if header.ID != id || !header.Response {
+			return nil, fmt.Errorf("unexpected reply %d to query %d", header.ID, id)
+		}
+		return resp, nil
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var p dnsmessage.Parser
+		header, err := p.Start(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		// ignore the response of another query
+		if header.ID != id || !header.Response {
+			continue
+		}
+		return buf[:n], nil
+	}
+}
+
+func parseAnswers(p *dnsmessage.Parser) ([]dnsAnswer, error) {
+	res := make([]dnsAnswer, 0)
+	for {
+		header, err := p.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ttl := time.Duration(header.TTL) * time.Second
+		switch header.Type {
+		case dnsmessage.TypeA:
+			a, err := p.AResource()
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, dnsAnswer{IP: net.IP(a.A[:]), TTL: ttl})
+		case dnsmessage.TypeAAAA:
+			aaaa, err := p.AAAAResource()
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, dnsAnswer{IP: net.IP(aaaa.AAAA[:]), TTL: ttl})
+		default:
+			if err := p.SkipAnswer(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// fqdnCache keeps the resolved addresses of the destDomains of the policies,
+// a domain is queried again when its TTL expires, and an address is removed
+// when it is not returned again before it expires
+type fqdnCache struct {
+	resolver dnsResolver
+	log      *zap.Logger
+	now      func() time.Time
+
+	mutex   sync.Mutex
+	entries map[egressv1.Policy]map[string]*domainEntry
+}
+
+type domainEntry struct {
+	// address -> expiry time
+	ips map[string]time.Time
+	// time of the next query
+	refresh time.Time
+}
+
+func newFQDNCache(resolver dnsResolver, log *zap.Logger) *fqdnCache {
+	return &fqdnCache{
+		resolver: resolver,
+		log:      log,
+		now:      time.Now,
+		entries:  make(map[egressv1.Policy]map[string]*domainEntry),
+	}
+}
+
+// Resolve returns the addresses of the domains of the policy, the domains
+// whose TTL is expired are queried again. The domains are queried without
+// holding the lock, so the other workers resolve their policies meanwhile.
+func (c *fqdnCache) Resolve(ctx context.Context, policy egressv1.Policy, domains []string) ([]string, []string) {
+	stale := c.prepare(policy, domains)
+
+	answers := make(map[*domainEntry][]dnsAnswer, len(stale))
+	for domain, entry := range stale {
+		res, ok := c.query(ctx, domain)
+		if ok {
+			answers[entry] = res
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	ipv4 := make(map[string]struct{})
+	ipv6 := make(map[string]struct{})
+	for _, entry := range c.entries[policy] {
+		if res, ok := answers[entry]; ok {
+			entry.merge(res, now)
+		}
+		for ip, expiry := range entry.ips {
+			if !now.Before(expiry) {
+				delete(entry.ips, ip)
+				continue
+			}
+			if net.ParseIP(ip).To4() != nil {
+				ipv4[ip] = struct{}{}
+			} else {
+				ipv6[ip] = struct{}{}
+			}
+		}
+	}
+	return sortedKeys(ipv4), sortedKeys(ipv6)
+}
+
+// prepare updates the domains of the policy and returns the entries to be
+// queried, their next query is delayed by fqdnRetryInterval so that they are
+// not queried again until the answers are merged
+func (c *fqdnCache) prepare(policy egressv1.Policy, domains []string) map[string]*domainEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	cached, ok := c.entries[policy]
+	if !ok {
+		cached = make(map[string]*domainEntry)
+		c.entries[policy] = cached
+	}
+	keep := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		keep[domain] = struct{}{}
+	}
+	for domain := range cached {
+		if _, ok := keep[domain]; !ok {
+			delete(cached, domain)
+		}
+	}
+
+	stale := make(map[string]*domainEntry)
+	for domain := range keep {
+		entry, ok := cached[domain]
+		if !ok {
+			entry = &domainEntry{ips: make(map[string]time.Time)}
+			cached[domain] = entry
+		}
+		if !now.Before(entry.refresh) {
+			entry.refresh = now.Add(fqdnRetryInterval)
+			stale[domain] = entry
+		}
+	}
+	return stale
+}
+
+// query resolves the domain, ok is false if it fails
+func (c *fqdnCache) query(ctx context.Context, domain string) ([]dnsAnswer, bool) {
+	if c.resolver == nil {
+		c.log.Warn("no dns resolver, skip resolving domain", zap.String("domain", domain))
+		return nil, false
+	}
+	answers, err := c.resolver.Resolve(ctx, domain)
+	if err != nil {
+		c.log.Warn("failed to resolve domain", zap.String("domain", domain), zap.Error(err))
+		return nil, false
+	}
+	return answers, true
+}
+
+// merge adds the answers of the domain, the next query is due when the first
+// answer expires
+func (e *domainEntry) merge(answers []dnsAnswer, now time.Time) {
+	e.refresh = now.Add(fqdnRetryInterval)
+	for i, answer := range answers {
+		ttl := answer.TTL
+		if ttl < fqdnMinTTL {
+			ttl = fqdnMinTTL
+		}
+		expiry := now.Add(ttl)
+		if i == 0 || expiry.Before(e.refresh) {
+			e.refresh = expiry
+		}
+		if old, ok := e.ips[answer.IP.String()]; !ok || old.Before(expiry) {
+			e.ips[answer.IP.String()] = expiry
+		}
+	}
+}
+
+// NextRefresh returns the duration until a domain of the policy needs to be
+// queried again, it is 0 if the policy has no domain
+func (c *fqdnCache) NextRefresh(policy egressv1.Policy) time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var next time.Time
+	for _, entry := range c.entries[policy] {
+		if next.IsZero() || entry.refresh.Before(next) {
+			next = entry.refresh
+		}
+	}
+	if next.IsZero() {
+		return 0
+	}
+	if d := next.Sub(c.now()); d > time.Second {
+		return d
+	}
+	return time.Second
+}
+
+// Forget removes the cached addresses of the policy
+func (c *fqdnCache) Forget(policy egressv1.Policy) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, policy)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,289 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// fakeResolver returns the records of the domain, and counts the queries
+type fakeResolver struct {
+	records map[string][]dnsAnswer
+	queries int
+}
+
+func (f *fakeResolver) Resolve(_ context.Context, domain string) ([]dnsAnswer, error) {
+	f.queries++
+	return f.records[domain], nil
+}
+
+// blockingResolver blocks until it is released, and reports each query
+type blockingResolver struct {
+	started chan string
+	release chan struct{}
+}
+
+func (b *blockingResolver) Resolve(_ context.Context, domain string) ([]dnsAnswer, error) {
+	b.started <- domain
+	<-b.release
+	return []dnsAnswer{{IP: net.ParseIP("10.6.1.92"), TTL: time.Minute}}, nil
+}
+
+// dnsReply answers the query from records, the answers are left out of a
+// truncated reply, and the queries of type servfail fail
+func dnsReply(records map[string][]dnsAnswer, query []byte, truncate bool, servfail dnsmessage.Type) []byte {
+	var req dnsmessage.Message
+	if err := req.Unpack(query); err != nil || len(req.Questions) != 1 {
+		return nil
+	}
+	q := req.Questions[0]
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: req.ID, Response: true, RecursionAvailable: true, Truncated: truncate},
+		Questions: req.Questions,
+	}
+	answers, ok := records[q.Name.String()]
+	if !ok {
+		resp.RCode = dnsmessage.RCodeNameError
+	}
+	if q.Type == servfail {
+		resp.RCode = dnsmessage.RCodeServerFailure
+		answers = nil
+	}
+	for _, answer := range answers {
+		if truncate {
+			break
+		}
+		header := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: uint32(answer.TTL / time.Second)}
+		if ip4 := answer.IP.To4(); ip4 != nil && q.Type == dnsmessage.TypeA {
+			res := &dnsmessage.AResource{}
+			copy(res.A[:], ip4)
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: res})
+		} else if ip4 == nil && q.Type == dnsmessage.TypeAAAA {
+			res := &dnsmessage.AAAAResource{}
+			copy(res.AAAA[:], answer.IP.To16())
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: res})
+		}
+	}
+	packed, err := resp.Pack()
+	if err != nil {
+		return nil
+	}
+	return packed
+}
+
+// startDNSServer starts an in-process nameserver which answers from records
+// over UDP and TCP, the UDP replies are truncated if truncate is set
+func startDNSServer(t *testing.T, records map[string][]dnsAnswer, truncate bool, servfail dnsmessage.Type) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	conn, err := net.ListenPacket("udp", listener.Addr().String())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if packed := dnsReply(records, buf[:n], truncate, servfail); packed != nil {
+				_, _ = conn.WriteTo(packed, addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				length := make([]byte, 2)
+				if _, err := io.ReadFull(c, length); err != nil {
+					return
+				}
+				query := make([]byte, binary.BigEndian.Uint16(length))
+				if _, err := io.ReadFull(c, query); err != nil {
+					return
+				}
+				packed := dnsReply(records, query, false, servfail)
+				binary.BigEndian.PutUint16(length, uint16(len(packed)))
+				_, _ = c.Write(append(length, packed...))
+			}()
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestUDPResolver(t *testing.T) {
+	records := map[string][]dnsAnswer{
+		"api.example.com.": {
+			{IP: net.ParseIP("10.6.1.92"), TTL: 60 * time.Second},
+			{IP: net.ParseIP("10.6.1.93"), TTL: 30 * time.Second},
+			{IP: net.ParseIP("fd00::92"), TTL: 60 * time.Second},
+		},
+	}
+	for _, truncate := range []bool{false, true} {
+		r := &udpResolver{
+			server:  startDNSServer(t, records, truncate, 0),
+			timeout: 2 * time.Second,
+			qtypes:  queryTypes(true, true),
+		}
+
+		answers, err := r.Resolve(context.Background(), "api.example.com")
+		assert.NoError(t, err)
+		assert.Len(t, answers, 3, "truncate %v", truncate)
+		for _, answer := range answers {
+			switch answer.IP.String() {
+			case "10.6.1.92", "fd00::92":
+				assert.Equal(t, 60*time.Second, answer.TTL)
+			case "10.6.1.93":
+				assert.Equal(t, 30*time.Second, answer.TTL)
+			default:
+				t.Fatalf("unexpected answer %v", answer)
+			}
+		}
+
+		answers, err = r.Resolve(context.Background(), "unknown.example.com")
+		assert.NoError(t, err)
+		assert.Len(t, answers, 0)
+	}
+}
+
+func TestUDPResolverFamilies(t *testing.T) {
+	records := map[string][]dnsAnswer{
+		"api.example.com.": {
+			{IP: net.ParseIP("10.6.1.92"), TTL: 60 * time.Second},
+			{IP: net.ParseIP("fd00::92"), TTL: 60 * time.Second},
+		},
+	}
+
+	// the A records are kept when AAAA fails
+	r := &udpResolver{
+		server:  startDNSServer(t, records, false, dnsmessage.TypeAAAA),
+		timeout: 2 * time.Second,
+		qtypes:  queryTypes(true, true),
+	}
+	answers, err := r.Resolve(context.Background(), "api.example.com")
+	assert.NoError(t, err)
+	if assert.Len(t, answers, 1) {
+		assert.Equal(t, "10.6.1.92", answers[0].IP.String())
+	}
+
+	// AAAA is not queried when IPv6 is disabled
+	r.qtypes = queryTypes(true, false)
+	answers, err = r.Resolve(context.Background(), "api.example.com")
+	assert.NoError(t, err)
+	assert.Len(t, answers, 1)
+
+	// it fails when all the families fail
+	r.qtypes = queryTypes(false, true)
+	_, err = r.Resolve(context.Background(), "api.example.com")
+	assert.Error(t, err)
+}
+
+func TestNewUDPResolver(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "resolv.conf")
+	assert.NoError(t, os.WriteFile(conf, []byte("search default.svc.cluster.local\nnameserver 10.96.0.10\noptions ndots:5\n"), 0600))
+	r, err := newUDPResolver(conf, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.96.0.10:53", r.server)
+	assert.Equal(t, []dnsmessage.Type{dnsmessage.TypeA}, r.qtypes)
+
+	empty := filepath.Join(dir, "empty.conf")
+	assert.NoError(t, os.WriteFile(empty, []byte("options ndots:5\n"), 0600))
+	_, err = newUDPResolver(empty, true, true)
+	assert.Error(t, err)
+}
+
+func TestFQDNCache(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]dnsAnswer{
+		"a.example.com": {
+			{IP: net.ParseIP("10.6.1.92"), TTL: 60 * time.Second},
+			{IP: net.ParseIP("fd00::92"), TTL: 60 * time.Second},
+		},
+		"b.example.com": {
+			{IP: net.ParseIP("10.6.1.93"), TTL: time.Second},
+		},
+	}}
+	now := time.Unix(1000, 0)
+	c := newFQDNCache(resolver, zap.NewNop())
+	c.now = func() time.Time { return now }
+	policy := egressv1.Policy{Namespace: "default", Name: "policy"}
+	domains := []string{"a.example.com", "b.example.com"}
+
+	ipv4, ipv6 := c.Resolve(context.Background(), policy, domains)
+	assert.Equal(t, []string{"10.6.1.92", "10.6.1.93"}, ipv4)
+	assert.Equal(t, []string{"fd00::92"}, ipv6)
+	assert.Equal(t, 2, resolver.queries)
+	// the small TTL of b.example.com is raised to fqdnMinTTL
+	assert.Equal(t, fqdnMinTTL, c.NextRefresh(policy))
+
+	// cached before the TTL expires
+	now = now.Add(time.Second)
+	c.Resolve(context.Background(), policy, domains)
+	assert.Equal(t, 2, resolver.queries)
+
+	// b.example.com changes its address, the old one expires
+	resolver.records["b.example.com"] = []dnsAnswer{{IP: net.ParseIP("10.6.1.94"), TTL: 30 * time.Second}}
+	now = now.Add(fqdnMinTTL)
+	ipv4, _ = c.Resolve(context.Background(), policy, domains)
+	assert.Equal(t, []string{"10.6.1.92", "10.6.1.94"}, ipv4)
+	assert.Equal(t, 3, resolver.queries)
+
+	// removed domain is dropped
+	ipv4, ipv6 = c.Resolve(context.Background(), policy, domains[1:])
+	assert.Equal(t, []string{"10.6.1.94"}, ipv4)
+	assert.Len(t, ipv6, 0)
+
+	c.Forget(policy)
+	assert.Equal(t, time.Duration(0), c.NextRefresh(policy))
+}
+
+func TestFQDNCacheQueryWithoutLock(t *testing.T) {
+	resolver := &blockingResolver{started: make(chan string), release: make(chan struct{})}
+	c := newFQDNCache(resolver, zap.NewNop())
+	slow := egressv1.Policy{Namespace: "default", Name: "slow"}
+	other := egressv1.Policy{Namespace: "default", Name: "other"}
+
+	done := make(chan []string)
+	go func() {
+		ipv4, _ := c.Resolve(context.Background(), slow, []string{"slow.example.com"})
+		done <- ipv4
+	}()
+	assert.Equal(t, "slow.example.com", <-resolver.started)
+
+	// the cache is usable while the slow domain is queried, and the slow
+	// domain is not queried twice
+	ipv4, _ := c.Resolve(context.Background(), other, nil)
+	assert.Len(t, ipv4, 0)
+	ipv4, _ = c.Resolve(context.Background(), slow, []string{"slow.example.com"})
+	assert.Len(t, ipv4, 0)
+	assert.Equal(t, fqdnRetryInterval, c.NextRefresh(slow).Round(time.Second))
+
+	close(resolver.release)
+	assert.Equal(t, []string{"10.6.1.92"}, <-done)
+	assert.Equal(t, time.Minute, c.NextRefresh(slow).Round(time.Second))
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/health"
+)
+
+// cacheSyncTimeout bounds the wait of the informer check
+const cacheSyncTimeout = time.Second
+
+// newProbes returns the checks of the controller, it is ready when the
+// informers are synced, the webhook certificate is present and valid and the
+// webhook server is started
+func newProbes(mgr manager.Manager, cfg *config.Config) health.Probes {
+	return health.Probes{
+		Readiness: health.Checks{
+			"informers":    informersSynced(mgr),
+			"webhook-cert": webhookCertValid(cfg.TLSCertDir),
+			"webhook":      mgr.GetWebhookServer().StartedChecker(),
+		},
+		Liveness: health.Checks{
+			"ping": healthz.Ping,
+		},
+	}
+}
+
+func informersSynced(mgr manager.Manager) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncTimeout)
+		defer cancel()
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return fmt.Errorf("informers are not synced")
+		}
+		return nil
+	}
+}
+
+func webhookCertValid(dir string) healthz.Checker {
+	return func(_ *http.Request) error {
+		pair, err := tls.LoadX509KeyPair(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+		if err != nil {
+			return fmt.Errorf("failed to load the webhook certificate: %v", err)
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse the webhook certificate: %v", err)
+		}
+		if time.Now().After(cert.NotAfter) {
+			return fmt.Errorf("the webhook certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+		}
+		return nil
+	}
+}
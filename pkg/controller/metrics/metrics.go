@@ -4,13 +4,112 @@
 package metrics
 
 import (
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
-func RegisterMetricCollectors() {
-	var metricCollectors []prometheus.Collector
-	for _, collector := range metricCollectors {
-		metrics.Registry.MustRegister(collector)
-	}
+// the reasons of the reallocations of the policies
+const (
+	ReasonNodeNotReady   = "NodeNotReady"
+	ReasonNodeDeleted    = "NodeDeleted"
+	ReasonNodeUnselected = "NodeUnselected"
+	ReasonFailback       = "Failback"
+)
+
+var (
+	// AuditConflicts is the number of the allocation conflicts found by the last audit
+	AuditConflicts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egress_audit_conflicts",
+		Help: "Number of allocation conflicts found by the last audit",
+	}, []string{"kind"})
+	// AuditRepairs is the number of the allocation conflicts repaired by the auditor
+	AuditRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "egress_audit_repairs_total",
+		Help: "Total number of allocation conflicts repaired by the auditor",
+	}, []string{"kind"})
+
+	// MarkAllocated is the number of the marks allocated to the EgressNodes
+	MarkAllocated = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "egress_mark_allocated",
+		Help: "Number of marks allocated to the EgressNodes",
+	})
+	// MarkTotal is the number of the marks of the mark range
+	MarkTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "egress_mark_total",
+		Help: "Number of marks of the mark range",
+	})
+	// TunnelIPAllocated is the number of the tunnel IPs allocated to the EgressNodes
+	TunnelIPAllocated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egress_tunnel_ip_allocated",
+		Help: "Number of tunnel IPs allocated to the EgressNodes",
+	}, []string{"version"})
+	// TunnelIPTotal is the number of the usable IPs of the tunnel CIDR
+	TunnelIPTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egress_tunnel_ip_total",
+		Help: "Number of usable IPs of the tunnel CIDR",
+	}, []string{"version"})
+
+	// GatewayEIPAllocated is the number of the EIPs of the EgressGateway bound to a gateway node
+	GatewayEIPAllocated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egress_gateway_eip_allocated",
+		Help: "Number of EIPs of the EgressGateway bound to a gateway node",
+	}, []string{"gateway", "version"})
+	// GatewayEIPTotal is the number of the EIPs of the ippools of the EgressGateway
+	GatewayEIPTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egress_gateway_eip_total",
+		Help: "Number of EIPs of the ippools of the EgressGateway",
+	}, []string{"gateway", "version"})
+	// GatewayNodePolicies is the number of the policies assigned to a gateway node
+	GatewayNodePolicies = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egress_gateway_node_policies",
+		Help: "Number of policies assigned to the gateway node of the EgressGateway",
+	}, []string{"gateway", "node"})
+	// PolicyReallocations is the number of the policies moved to another gateway node
+	PolicyReallocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "egress_policy_reallocations_total",
+		Help: "Total number of policies moved to another gateway node of the EgressGateway",
+	}, []string{"gateway", "reason"})
+
+	// WebhookDenials is the number of the requests denied by the webhooks
+	WebhookDenials = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "egress_webhook_denials_total",
+		Help: "Total number of requests denied by the webhooks",
+	}, []string{"webhook", "kind", "operation", "reason"})
+)
+
+// DeleteGateway deletes the metrics of the EgressGateway
+func DeleteGateway(name string) {
+	labels := prometheus.Labels{"gateway": name}
+	GatewayEIPAllocated.DeletePartialMatch(labels)
+	GatewayEIPTotal.DeletePartialMatch(labels)
+	GatewayNodePolicies.DeletePartialMatch(labels)
+}
+
+var registerOnce sync.Once
+
+// RegisterMetricCollectors registers the collectors and the extra collectors
+// of the controllers once, the controller may be created again after the
+// leader election is lost
+func RegisterMetricCollectors(extra ...prometheus.Collector) {
+	registerOnce.Do(func() {
+		metricCollectors := []prometheus.Collector{
+			AuditConflicts,
+			AuditRepairs,
+			MarkAllocated,
+			MarkTotal,
+			TunnelIPAllocated,
+			TunnelIPTotal,
+			GatewayEIPAllocated,
+			GatewayEIPTotal,
+			GatewayNodePolicies,
+			PolicyReallocations,
+			WebhookDenials,
+		}
+		metricCollectors = append(metricCollectors, extra...)
+		for _, collector := range metricCollectors {
+			metrics.Registry.MustRegister(collector)
+		}
+	})
 }
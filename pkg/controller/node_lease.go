@@ -0,0 +1,172 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// nodeLeaseReconciler checks the leases renewed by the agents. The EgressNode
+// is marked failed when its lease expires, then the EgressGateway controller
+// moves the policies of the node to the other gateway nodes. The phase is
+// restored when the agent renews the lease again.
+type nodeLeaseReconciler struct {
+	client client.Client
+	log    *zap.Logger
+	config *config.Config
+	clock  clock.PassiveClock
+
+	mu sync.Mutex
+	// renewals are the last renewals of the leases seen by the controller
+	renewals map[types.NamespacedName]leaseRenewal
+}
+
+// leaseRenewal is a renewal of a lease, the lease expires by the clock of the
+// controller, so the skewed clock of an agent does not fail its node
+type leaseRenewal struct {
+	renewTime metav1.MicroTime
+	seen      time.Time
+}
+
+func (r *nodeLeaseReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.With(zap.String("lease", req.String()))
+
+	lease := new(coordinationv1.Lease)
+	err := r.client.Get(ctx, req.NamespacedName, lease)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{Requeue: true}, err
+		}
+		r.forget(req.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+	nodeName := lease.Labels[egressv1.LabelEgressNodeLease]
+	if nodeName == "" || lease.Spec.RenewTime == nil {
+		return reconcile.Result{}, nil
+	}
+
+	node := new(egressv1.EgressNode)
+	err = r.client.Get(ctx, types.NamespacedName{Name: nodeName}, node)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{Requeue: true}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	now := r.clock.Now()
+	expire := r.lastRenewal(req.NamespacedName, *lease.Spec.RenewTime, now).Add(r.leaseDuration())
+	if now.Before(expire) {
+		if node.Status.Phase == egressv1.EgressNodeFailed {
+			log.Sugar().Infof("the lease of EgressNode %s is renewed", nodeName)
+			if err := r.updatePhase(ctx, node, egressv1.EgressNodeSucceeded); err != nil {
+				return reconcile.Result{Requeue: true}, err
+			}
+		}
+		// check the lease again when it expires
+		return reconcile.Result{RequeueAfter: expire.Sub(now)}, nil
+	}
+
+	if node.Status.Phase != egressv1.EgressNodeSucceeded {
+		return reconcile.Result{}, nil
+	}
+	log.Sugar().Warnf("the lease of EgressNode %s expired at %v, mark it failed", nodeName, expire)
+	if err := r.updatePhase(ctx, node, egressv1.EgressNodeFailed); err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *nodeLeaseReconciler) updatePhase(ctx context.Context, node *egressv1.EgressNode, phase egressv1.EgressNodePhase) error {
+	node.Status.Phase = phase
+	if err := r.client.Status().Update(ctx, node); err != nil {
+		return fmt.Errorf("failed to update the phase of EgressNode %s: %v", node.Name, err)
+	}
+	return nil
+}
+
+// lastRenewal returns when the controller saw the renew time of the lease
+// change, it is now for a lease seen the first time
+func (r *nodeLeaseReconciler) lastRenewal(key types.NamespacedName, renewTime metav1.MicroTime, now time.Time) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.renewals == nil {
+		r.renewals = make(map[types.NamespacedName]leaseRenewal)
+	}
+	last, ok := r.renewals[key]
+	if ok && last.renewTime.Equal(&renewTime) {
+		return last.seen
+	}
+	r.renewals[key] = leaseRenewal{renewTime: renewTime, seen: now}
+	return now
+}
+
+func (r *nodeLeaseReconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.renewals, key)
+}
+
+func (r *nodeLeaseReconciler) leaseDuration() time.Duration {
+	return time.Duration(r.config.FileConfig.NodeLease.LeaseDurationMillis) * time.Millisecond
+}
+
+func newNodeLeaseController(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
+	if log == nil {
+		return fmt.Errorf("log can not be nil")
+	}
+	if cfg == nil {
+		return fmt.Errorf("cfg can not be nil")
+	}
+	if !cfg.FileConfig.NodeLease.Enable {
+		log.Info("node lease is disabled")
+		return nil
+	}
+	if cfg.FileConfig.NodeLease.LeaseDurationMillis <= 0 {
+		return fmt.Errorf("invalid nodeLease.leaseDurationMillis %d", cfg.FileConfig.NodeLease.LeaseDurationMillis)
+	}
+
+	r := &nodeLeaseReconciler{
+		client: mgr.GetClient(),
+		log:    log,
+		config: cfg,
+		clock:  clock.RealClock{},
+	}
+
+	log.Sugar().Infof("new node lease controller")
+	c, err := controller.New("nodelease", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	isNodeLease := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == cfg.PodNamespace && obj.GetLabels()[egressv1.LabelEgressNodeLease] != ""
+	})
+	if err := c.Watch(source.Kind(mgr.GetCache(), &coordinationv1.Lease{}),
+		&handler.EnqueueRequestForObject{}, isNodeLease); err != nil {
+		return fmt.Errorf("failed to watch Lease: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestNodeLeaseReconcile(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakePassiveClock(start)
+
+	renewTime := metav1.NewMicroTime(start)
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kube-system",
+			Name:      egressv1.EgressNodeLeasePrefix + "node1",
+			Labels:    map[string]string{egressv1.LabelEgressNodeLease: "node1"},
+		},
+		Spec: coordinationv1.LeaseSpec{RenewTime: &renewTime},
+	}
+	node := &egressv1.EgressNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status:     egressv1.EgressNodeStatus{Phase: egressv1.EgressNodeSucceeded},
+	}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).
+		WithObjects(lease, node).WithStatusSubresource(node).Build()
+	r := &nodeLeaseReconciler{
+		client: cli,
+		log:    zap.NewNop(),
+		config: &config.Config{FileConfig: config.FileConfig{NodeLease: config.NodeLease{
+			Enable:              true,
+			LeaseDurationMillis: 1500,
+			RenewIntervalMillis: 500,
+		}}},
+		clock: fakeClock,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: lease.Namespace, Name: lease.Name}}
+	phase := func() egressv1.EgressNodePhase {
+		res := new(egressv1.EgressNode)
+		assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "node1"}, res))
+		return res.Status.Phase
+	}
+
+	// the lease expires by the clock of the controller, it is seen renewed
+	// the first time it is checked
+	fakeClock.SetTime(start.Add(time.Second))
+	res, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, res.RequeueAfter)
+
+	// checked again when the lease expires
+	fakeClock.SetTime(start.Add(2 * time.Second))
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, res.RequeueAfter)
+	assert.Equal(t, egressv1.EgressNodeSucceeded, phase())
+
+	fakeClock.SetTime(start.Add(2500 * time.Millisecond))
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Zero(t, res.RequeueAfter)
+	assert.Equal(t, egressv1.EgressNodeFailed, phase())
+
+	// restored when the lease is renewed, even by an agent whose clock is
+	// an hour behind
+	renewTime = metav1.NewMicroTime(start.Add(-time.Hour))
+	assert.NoError(t, cli.Get(ctx, req.NamespacedName, lease))
+	lease.Spec.RenewTime = &renewTime
+	assert.NoError(t, cli.Update(ctx, lease))
+	fakeClock.SetTime(start.Add(3 * time.Second))
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, res.RequeueAfter)
+	assert.Equal(t, egressv1.EgressNodeSucceeded, phase())
+
+	// the node not ready for other reasons is left to the agent
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "node1"}, node))
+	node.Status.Phase = egressv1.EgressNodePending
+	assert.NoError(t, cli.Status().Update(ctx, node))
+	fakeClock.SetTime(start.Add(time.Minute))
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, egressv1.EgressNodePending, phase())
+}
@@ -0,0 +1,460 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/spidernet-io/egressgateway/pkg/coalescing"
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/egressgateway"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+// policyStatusReconciler rolls up the programmed policies reported by the
+// agents in the EgressNode status into the conditions of the policies
+type policyStatusReconciler struct {
+	client client.Client
+	log    *zap.Logger
+	config *config.Config
+}
+
+func (r *policyStatusReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	kind, newReq, err := utils.ParseKindWithReq(req)
+	if err != nil {
+		r.log.Sugar().Errorf("parse req(%v) with error: %v", req, err)
+		return reconcile.Result{}, err
+	}
+
+	log := r.log.With(zap.String("namespace", newReq.Namespace), zap.String("name", newReq.Name), zap.String("kind", kind))
+	log.Debug("policy status controller: reconciling")
+	switch kind {
+	case "EgressPolicy":
+		return r.reconcilePolicy(ctx, newReq, log)
+	case "EgressClusterPolicy":
+		return r.reconcileClusterPolicy(ctx, newReq, log)
+	default:
+		return reconcile.Result{}, nil
+	}
+}
+
+func (r *policyStatusReconciler) reconcilePolicy(ctx context.Context, req reconcile.Request, log *zap.Logger) (reconcile.Result, error) {
+	policy := new(egressv1.EgressPolicy)
+	err := r.client.Get(ctx, req.NamespacedName, policy)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{Requeue: true}, err
+	}
+
+	slices := new(egressv1.EgressEndpointSliceList)
+	err = r.client.List(ctx, slices, client.InNamespace(policy.Namespace),
+		client.MatchingLabels{egressv1.LabelPolicyName: policy.Name})
+	if err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	var endpoints int32
+	for _, item := range slices.Items {
+		endpoints += int32(len(item.Endpoints))
+	}
+
+	status := policy.Status.DeepCopy()
+	err = r.buildStatus(ctx, egressv1.Policy{Namespace: policy.Namespace, Name: policy.Name},
+		policy.Generation, policy.Spec.EgressGatewayName, endpoints, status)
+	if err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	if reflect.DeepEqual(status, &policy.Status) {
+		return reconcile.Result{}, nil
+	}
+
+	policy.Status = *status
+	log.Sugar().Debugf("update egresspolicy status\n%v", policy.Status)
+	err = r.client.Status().Update(ctx, policy)
+	if err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *policyStatusReconciler) reconcileClusterPolicy(ctx context.Context, req reconcile.Request, log *zap.Logger) (reconcile.Result, error) {
+	policy := new(egressv1.EgressClusterPolicy)
+	err := r.client.Get(ctx, req.NamespacedName, policy)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{Requeue: true}, err
+	}
+
+	slices := new(egressv1.EgressClusterEndpointSliceList)
+	err = r.client.List(ctx, slices, client.MatchingLabels{egressv1.LabelPolicyName: policy.Name})
+	if err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	var endpoints int32
+	for _, item := range slices.Items {
+		endpoints += int32(len(item.Endpoints))
+	}
+
+	status := policy.Status.DeepCopy()
+	err = r.buildStatus(ctx, egressv1.Policy{Name: policy.Name},
+		policy.Generation, policy.Spec.EgressGatewayName, endpoints, status)
+	if err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	if reflect.DeepEqual(status, &policy.Status) {
+		return reconcile.Result{}, nil
+	}
+
+	policy.Status = *status
+	log.Sugar().Debugf("update egressclusterpolicy status\n%v", policy.Status)
+	err = r.client.Status().Update(ctx, policy)
+	if err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *policyStatusReconciler) buildStatus(ctx context.Context, policy egressv1.Policy, generation int64,
+	gatewayName string, endpoints int32, status *egressv1.EgressPolicyStatus) error {
+	var gateway *egressv1.EgressGateway
+	if gatewayName != "" {
+		gateway = new(egressv1.EgressGateway)
+		err := r.client.Get(ctx, types.NamespacedName{Name: gatewayName}, gateway)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			gateway = nil
+		}
+	}
+
+	ready := new(egressv1.EgressNodeList)
+	err := r.client.List(ctx, ready, client.MatchingFields{nodePhaseIndex: string(egressv1.EgressNodeSucceeded)},
+		client.UnsafeDisableDeepCopy)
+	if err != nil {
+		return err
+	}
+
+	// only the nodes which acked the policy and the gateway nodes are checked
+	acked := new(egressv1.EgressNodeList)
+	err = r.client.List(ctx, acked, client.MatchingFields{nodePolicyIndex: policy.String()})
+	if err != nil {
+		return err
+	}
+	nodes := acked.Items
+	if gateway != nil {
+		for _, name := range egressgateway.GetNodesByPolicy(policy, *gateway) {
+			if containsNode(nodes, name) {
+				continue
+			}
+			node := new(egressv1.EgressNode)
+			err := r.client.Get(ctx, types.NamespacedName{Name: name}, node)
+			if err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			nodes = append(nodes, *node)
+		}
+	}
+
+	status.MatchedEndpoints = endpoints
+	for _, cond := range policyConditions(policy, generation, gatewayName, gateway, len(ready.Items), nodes) {
+		meta.SetStatusCondition(&status.Conditions, cond)
+	}
+	return nil
+}
+
+func containsNode(nodes []egressv1.EgressNode, name string) bool {
+	for _, node := range nodes {
+		if node.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// policyConditions returns the Accepted, Programmed and Degraded conditions of
+// the policy, ready is the number of ready nodes, nodes are the nodes which
+// acked the policy and the gateway nodes
+func policyConditions(policy egressv1.Policy, generation int64, gatewayName string,
+	gateway *egressv1.EgressGateway, ready int, nodes []egressv1.EgressNode) []metav1.Condition {
+	accepted := metav1.Condition{
+		Type:               egressv1.PolicyConditionAccepted,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+	}
+	var gatewayNodes []string
+	if gateway != nil {
+		gatewayNodes = egressgateway.GetNodesByPolicy(policy, *gateway)
+	}
+	if gateway == nil {
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = "GatewayNotFound"
+		accepted.Message = fmt.Sprintf("EgressGateway %q is not found", gatewayName)
+	} else if len(gatewayNodes) == 0 {
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = "NoGatewayNode"
+		accepted.Message = fmt.Sprintf("no gateway node of EgressGateway %s is selected", gateway.Name)
+	} else {
+		accepted.Reason = "GatewayNodeSelected"
+		accepted.Message = fmt.Sprintf("gateway node %s is selected", strings.Join(gatewayNodes, ", "))
+	}
+
+	done := 0
+	failed := make([]string, 0)
+	readyNodes := make(map[string]bool)
+	for _, node := range nodes {
+		if node.Status.Phase != egressv1.EgressNodeSucceeded {
+			continue
+		}
+		readyNodes[node.Name] = true
+		for _, item := range node.Status.Policies {
+			if item.Name != policy.Name || item.Namespace != policy.Namespace || item.Generation < generation {
+				continue
+			}
+			if item.Error != "" {
+				failed = append(failed, fmt.Sprintf("%s: %s", node.Name, item.Error))
+			} else {
+				done++
+			}
+		}
+	}
+	sort.Strings(failed)
+	notReady := make([]string, 0)
+	for _, node := range gatewayNodes {
+		if !readyNodes[node] {
+			notReady = append(notReady, node)
+		}
+	}
+
+	programmed := metav1.Condition{
+		Type:               egressv1.PolicyConditionProgrammed,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+	}
+	switch {
+	case accepted.Status != metav1.ConditionTrue:
+		programmed.Reason = "NotAccepted"
+		programmed.Message = "the policy is not accepted"
+	case ready == 0:
+		programmed.Reason = "NoReadyNode"
+		programmed.Message = "no EgressNode is ready"
+	case done == ready:
+		programmed.Status = metav1.ConditionTrue
+		programmed.Reason = "Programmed"
+		programmed.Message = fmt.Sprintf("programmed on %d nodes", done)
+	default:
+		programmed.Reason = "Pending"
+		programmed.Message = fmt.Sprintf("programmed on %d/%d nodes", done, ready)
+	}
+
+	degraded := metav1.Condition{
+		Type:               egressv1.PolicyConditionDegraded,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+	}
+	switch {
+	case len(failed) != 0:
+		degraded.Reason = "ProgramFailed"
+		degraded.Message = strings.Join(failed, "; ")
+	case len(notReady) != 0:
+		degraded.Reason = "GatewayNodeNotReady"
+		degraded.Message = fmt.Sprintf("gateway node %s is not ready", strings.Join(notReady, ", "))
+	default:
+		degraded.Status = metav1.ConditionFalse
+		degraded.Reason = "AsExpected"
+	}
+
+	return []metav1.Condition{accepted, programmed, degraded}
+}
+
+const (
+	// nodePolicyIndex indexes the EgressNodes by the policies they acked
+	nodePolicyIndex = "status.policies"
+	// nodePhaseIndex indexes the EgressNodes by their phase
+	nodePhaseIndex = "status.phase"
+)
+
+func indexNodePolicies(obj client.Object) []string {
+	node, ok := obj.(*egressv1.EgressNode)
+	if !ok {
+		return nil
+	}
+	res := make([]string, 0, len(node.Status.Policies))
+	for _, item := range node.Status.Policies {
+		res = append(res, egressv1.Policy{Namespace: item.Namespace, Name: item.Name}.String())
+	}
+	return res
+}
+
+func indexNodePhase(obj client.Object) []string {
+	node, ok := obj.(*egressv1.EgressNode)
+	if !ok {
+		return nil
+	}
+	return []string{string(node.Status.Phase)}
+}
+
+// nodePolicies returns the policies acked in the EgressNode status
+func nodePolicies(obj client.Object) []egressv1.Policy {
+	node, ok := obj.(*egressv1.EgressNode)
+	if !ok {
+		return nil
+	}
+	res := make([]egressv1.Policy, 0, len(node.Status.Policies))
+	for _, item := range node.Status.Policies {
+		res = append(res, egressv1.Policy{Namespace: item.Namespace, Name: item.Name})
+	}
+	return res
+}
+
+// gatewayPolicies returns the policies assigned in the EgressGateway status
+func gatewayPolicies(obj client.Object) []egressv1.Policy {
+	gateway, ok := obj.(*egressv1.EgressGateway)
+	if !ok {
+		return nil
+	}
+	res := make([]egressv1.Policy, 0)
+	for _, node := range gateway.Status.NodeList {
+		for _, eip := range node.Eips {
+			res = append(res, eip.Policies...)
+		}
+	}
+	return res
+}
+
+// enqueueReferencedPolicies enqueues the policies referenced by the object,
+// both the old and the new object of an update, the policies without
+// namespace are EgressClusterPolicies
+func enqueueReferencedPolicies(refs func(client.Object) []egressv1.Policy) handler.Funcs {
+	add := func(ctx context.Context, q workqueue.RateLimitingInterface, objs ...client.Object) {
+		for _, obj := range objs {
+			for _, policy := range refs(obj) {
+				kind := "EgressPolicy"
+				if policy.Namespace == "" {
+					kind = "EgressClusterPolicy"
+				}
+				policyMeta := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: policy.Namespace, Name: policy.Name}}
+				for _, req := range utils.KindToMapFlat(kind)(ctx, policyMeta) {
+					q.Add(req)
+				}
+			}
+		}
+	}
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			add(ctx, q, e.Object)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			add(ctx, q, e.ObjectOld, e.ObjectNew)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			add(ctx, q, e.Object)
+		},
+	}
+}
+
+// enqueueSlicePolicy enqueues the policy of the endpoint slice
+func enqueueSlicePolicy(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		name, ok := obj.GetLabels()[egressv1.LabelPolicyName]
+		if !ok {
+			return nil
+		}
+		policy := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: obj.GetNamespace(), Name: name}}
+		return utils.KindToMapFlat(kind)(ctx, policy)
+	}
+}
+
+func newPolicyStatusController(mgr manager.Manager, log *zap.Logger, cfg *config.Config) error {
+	if log == nil {
+		return fmt.Errorf("log can not be nil")
+	}
+	if cfg == nil {
+		return fmt.Errorf("cfg can not be nil")
+	}
+
+	r := &policyStatusReconciler{
+		client: mgr.GetClient(),
+		log:    log,
+		config: cfg,
+	}
+
+	indexer := mgr.GetFieldIndexer()
+	if err := indexer.IndexField(context.Background(), &egressv1.EgressNode{}, nodePolicyIndex, indexNodePolicies); err != nil {
+		return fmt.Errorf("failed to index EgressNode by policies: %w", err)
+	}
+	if err := indexer.IndexField(context.Background(), &egressv1.EgressNode{}, nodePhaseIndex, indexNodePhase); err != nil {
+		return fmt.Errorf("failed to index EgressNode by phase: %w", err)
+	}
+
+	log.Sugar().Infof("new policy status controller")
+	cache, err := coalescing.NewRequestCache(time.Second)
+	if err != nil {
+		return err
+	}
+	reduce := coalescing.NewReconciler(r, cache, log)
+
+	c, err := controller.New("policystatus", mgr, controller.Options{Reconciler: reduce})
+	if err != nil {
+		return err
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressPolicy{}),
+		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressPolicy"))); err != nil {
+		return fmt.Errorf("failed to watch EgressPolicy: %w", err)
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressClusterPolicy{}),
+		handler.EnqueueRequestsFromMapFunc(utils.KindToMapFlat("EgressClusterPolicy"))); err != nil {
+		return fmt.Errorf("failed to watch EgressClusterPolicy: %w", err)
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressNode{}),
+		enqueueReferencedPolicies(nodePolicies)); err != nil {
+		return fmt.Errorf("failed to watch EgressNode: %w", err)
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressGateway{}),
+		enqueueReferencedPolicies(gatewayPolicies)); err != nil {
+		return fmt.Errorf("failed to watch EgressGateway: %w", err)
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressEndpointSlice{}),
+		handler.EnqueueRequestsFromMapFunc(enqueueSlicePolicy("EgressPolicy"))); err != nil {
+		return fmt.Errorf("failed to watch EgressEndpointSlice: %w", err)
+	}
+
+	if err := c.Watch(source.Kind(mgr.GetCache(), &egressv1.EgressClusterEndpointSlice{}),
+		handler.EnqueueRequestsFromMapFunc(enqueueSlicePolicy("EgressClusterPolicy"))); err != nil {
+		return fmt.Errorf("failed to watch EgressClusterEndpointSlice: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/logger"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestPolicyConditions(t *testing.T) {
+	policy := egressv1.Policy{Namespace: "default", Name: "policy"}
+	gateway := &egressv1.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Status: egressv1.EgressGatewayStatus{NodeList: []egressv1.EgressIPStatus{
+			{Name: "node1", Eips: []egressv1.Eips{{IPv4: "10.6.1.21", Policies: []egressv1.Policy{policy}}}},
+		}},
+	}
+	newNode := func(name string, phase egressv1.EgressNodePhase, acks ...egressv1.ProgrammedPolicy) egressv1.EgressNode {
+		return egressv1.EgressNode{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     egressv1.EgressNodeStatus{Phase: phase, Policies: acks},
+		}
+	}
+	ack := func(generation int64, err string) egressv1.ProgrammedPolicy {
+		return egressv1.ProgrammedPolicy{Namespace: policy.Namespace, Name: policy.Name, Generation: generation, Error: err}
+	}
+
+	cases := map[string]struct {
+		gateway     *egressv1.EgressGateway
+		nodes       []egressv1.EgressNode
+		expAccepted metav1.ConditionStatus
+		expProgram  metav1.ConditionStatus
+		expDegraded metav1.ConditionStatus
+		expReason   string
+	}{
+		"gateway not found": {
+			gateway:     nil,
+			expAccepted: metav1.ConditionFalse,
+			expProgram:  metav1.ConditionFalse,
+			expDegraded: metav1.ConditionFalse,
+			expReason:   "NotAccepted",
+		},
+		"no gateway node": {
+			gateway:     &egressv1.EgressGateway{ObjectMeta: metav1.ObjectMeta{Name: "eg"}},
+			expAccepted: metav1.ConditionFalse,
+			expProgram:  metav1.ConditionFalse,
+			expDegraded: metav1.ConditionFalse,
+			expReason:   "NotAccepted",
+		},
+		"programmed on all nodes": {
+			gateway: gateway,
+			nodes: []egressv1.EgressNode{
+				newNode("node1", egressv1.EgressNodeSucceeded, ack(2, "")),
+				newNode("node2", egressv1.EgressNodeSucceeded, ack(3, "")),
+				newNode("node3", egressv1.EgressNodePending),
+			},
+			expAccepted: metav1.ConditionTrue,
+			expProgram:  metav1.ConditionTrue,
+			expDegraded: metav1.ConditionFalse,
+			expReason:   "Programmed",
+		},
+		"old generation": {
+			gateway: gateway,
+			nodes: []egressv1.EgressNode{
+				newNode("node1", egressv1.EgressNodeSucceeded, ack(2, "")),
+				newNode("node2", egressv1.EgressNodeSucceeded, ack(1, "")),
+			},
+			expAccepted: metav1.ConditionTrue,
+			expProgram:  metav1.ConditionFalse,
+			expDegraded: metav1.ConditionFalse,
+			expReason:   "Pending",
+		},
+		"failed on a node": {
+			gateway: gateway,
+			nodes: []egressv1.EgressNode{
+				newNode("node1", egressv1.EgressNodeSucceeded, ack(2, "")),
+				newNode("node2", egressv1.EgressNodeSucceeded, ack(2, "ipset error")),
+			},
+			expAccepted: metav1.ConditionTrue,
+			expProgram:  metav1.ConditionFalse,
+			expDegraded: metav1.ConditionTrue,
+			expReason:   "Pending",
+		},
+		"gateway node not ready": {
+			gateway: gateway,
+			nodes: []egressv1.EgressNode{
+				newNode("node1", egressv1.EgressNodeFailed, ack(2, "")),
+				newNode("node2", egressv1.EgressNodeSucceeded, ack(2, "")),
+			},
+			expAccepted: metav1.ConditionTrue,
+			expProgram:  metav1.ConditionTrue,
+			expDegraded: metav1.ConditionTrue,
+			expReason:   "Programmed",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ready := 0
+			for _, node := range c.nodes {
+				if node.Status.Phase == egressv1.EgressNodeSucceeded {
+					ready++
+				}
+			}
+			conditions := policyConditions(policy, 2, "eg", c.gateway, ready, c.nodes)
+			assert.Equal(t, c.expAccepted, meta.FindStatusCondition(conditions, egressv1.PolicyConditionAccepted).Status)
+			programmed := meta.FindStatusCondition(conditions, egressv1.PolicyConditionProgrammed)
+			assert.Equal(t, c.expProgram, programmed.Status)
+			assert.Equal(t, c.expReason, programmed.Reason)
+			assert.Equal(t, c.expDegraded, meta.FindStatusCondition(conditions, egressv1.PolicyConditionDegraded).Status)
+			for _, cond := range conditions {
+				assert.Equal(t, int64(2), cond.ObservedGeneration)
+			}
+		})
+	}
+}
+
+func TestPolicyStatusReconcile(t *testing.T) {
+	ctx := context.Background()
+	policy := &egressv1.EgressPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy", Generation: 1},
+		Spec:       egressv1.EgressPolicySpec{EgressGatewayName: "eg"},
+	}
+	clusterPolicy := &egressv1.EgressClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-policy", Generation: 1},
+		Spec:       egressv1.EgressClusterPolicySpec{EgressGatewayName: "eg"},
+	}
+	initialObjects := []client.Object{
+		policy,
+		clusterPolicy,
+		&egressv1.EgressGateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+			Status: egressv1.EgressGatewayStatus{NodeList: []egressv1.EgressIPStatus{
+				{Name: "node1", Eips: []egressv1.Eips{{Policies: []egressv1.Policy{
+					{Namespace: "default", Name: "policy"}, {Name: "cluster-policy"},
+				}}}},
+			}},
+		},
+		&egressv1.EgressNode{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Status: egressv1.EgressNodeStatus{
+				Phase: egressv1.EgressNodeSucceeded,
+				Policies: []egressv1.ProgrammedPolicy{
+					{Namespace: "default", Name: "policy", Generation: 1},
+					{Name: "cluster-policy", Generation: 1},
+				},
+			},
+		},
+		&egressv1.EgressEndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy-x",
+				Labels: map[string]string{egressv1.LabelPolicyName: "policy"}},
+			Endpoints: []egressv1.EgressEndpoint{{Pod: "a"}, {Pod: "b"}},
+		},
+		&egressv1.EgressClusterEndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-policy-x",
+				Labels: map[string]string{egressv1.LabelPolicyName: "cluster-policy"}},
+			Endpoints: []egressv1.EgressEndpoint{{Pod: "a"}},
+		},
+	}
+
+	builder := fake.NewClientBuilder()
+	builder.WithScheme(schema.GetScheme())
+	builder.WithObjects(initialObjects...)
+	builder.WithStatusSubresource(initialObjects...)
+	builder.WithIndex(&egressv1.EgressNode{}, nodePolicyIndex, indexNodePolicies)
+	builder.WithIndex(&egressv1.EgressNode{}, nodePhaseIndex, indexNodePhase)
+	cli := builder.Build()
+
+	r := &policyStatusReconciler{
+		client: cli,
+		log:    logger.NewStdoutLogger(os.Getenv("LOG_LEVEL")),
+		config: &config.Config{},
+	}
+
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "EgressPolicy/default", Name: "policy"}})
+	assert.NoError(t, err)
+	res := new(egressv1.EgressPolicy)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Namespace: "default", Name: "policy"}, res))
+	assert.Equal(t, int32(2), res.Status.MatchedEndpoints)
+	assert.True(t, meta.IsStatusConditionTrue(res.Status.Conditions, egressv1.PolicyConditionProgrammed))
+
+	_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "EgressClusterPolicy/", Name: "cluster-policy"}})
+	assert.NoError(t, err)
+	clusterRes := new(egressv1.EgressClusterPolicy)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "cluster-policy"}, clusterRes))
+	assert.Equal(t, int32(1), clusterRes.Status.MatchedEndpoints)
+	assert.True(t, meta.IsStatusConditionTrue(clusterRes.Status.Conditions, egressv1.PolicyConditionAccepted))
+	assert.True(t, meta.IsStatusConditionTrue(clusterRes.Status.Conditions, egressv1.PolicyConditionProgrammed))
+	assert.True(t, meta.IsStatusConditionFalse(clusterRes.Status.Conditions, egressv1.PolicyConditionDegraded))
+}
+
+func TestEnqueueReferencedPolicies(t *testing.T) {
+	ctx := context.Background()
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	oldNode := &egressv1.EgressNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: egressv1.EgressNodeStatus{Policies: []egressv1.ProgrammedPolicy{
+			{Namespace: "default", Name: "policy", Generation: 1},
+		}},
+	}
+	newNode := oldNode.DeepCopy()
+	newNode.Status.Policies = []egressv1.ProgrammedPolicy{{Name: "cluster-policy", Generation: 1}}
+
+	h := enqueueReferencedPolicies(nodePolicies)
+	h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, q)
+
+	got := make([]reconcile.Request, 0)
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		got = append(got, item.(reconcile.Request))
+		q.Done(item)
+	}
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "EgressPolicy/default", Name: "policy"}},
+		{NamespacedName: types.NamespacedName{Namespace: "EgressClusterPolicy/", Name: "cluster-policy"}},
+	}, got)
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+// policyGateway is the part of EgressPolicy and EgressClusterPolicy read by
+// the mutating webhook, it is the same in all the versions
+type policyGateway struct {
+	Spec struct {
+		EgressGatewayName string `json:"egressGatewayName,omitempty"`
+	} `json:"spec"`
+}
+
+// mutatePolicyGateway fills the empty egressGatewayName of the policy with
+// the default EgressGateway
+func mutatePolicyGateway(ctx context.Context, cli client.Client, req webhook.AdmissionRequest) webhook.AdmissionResponse {
+	policy := new(policyGateway)
+	if err := json.Unmarshal(req.Object.Raw, policy); err != nil {
+		return utils.Denied("MalformedObject", fmt.Sprintf("json unmarshal %s with error: %v", req.Kind.Kind, err))
+	}
+	if policy.Spec.EgressGatewayName != "" {
+		return webhook.Allowed("checked")
+	}
+
+	name, err := defaultGateway(ctx, cli, req.Namespace)
+	if err != nil {
+		return utils.Denied("InternalError", fmt.Sprintf("failed to get the default EgressGateway: %v", err))
+	}
+	if name == "" {
+		return webhook.Allowed("checked")
+	}
+
+	patch, err := json.Marshal([]map[string]string{
+		{"op": "add", "path": "/spec/egressGatewayName", "value": name},
+	})
+	if err != nil {
+		return utils.Denied("InternalError", fmt.Sprintf("failed to set the default EgressGateway: %v", err))
+	}
+	pt := admissionv1.PatchTypeJSONPatch
+	return webhook.AdmissionResponse{
+		AdmissionResponse: admissionv1.AdmissionResponse{Allowed: true, Patch: patch, PatchType: &pt},
+	}
+}
+
+// defaultGateway returns the default EgressGateway of the policies in the
+// namespace, the annotation of the namespace takes precedence over the
+// cluster default. An empty name is returned when there is no default.
+func defaultGateway(ctx context.Context, cli client.Client, namespace string) (string, error) {
+	if namespace != "" {
+		ns := new(corev1.Namespace)
+		err := cli.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", err
+		}
+		if name := ns.Annotations[egressv1.AnnotationDefaultEgressGateway]; name != "" {
+			return name, nil
+		}
+	}
+
+	egList := new(egressv1.EgressGatewayList)
+	if err := cli.List(ctx, egList); err != nil {
+		return "", err
+	}
+	for _, item := range egList.Items {
+		if item.Spec.ClusterDefault {
+			return item.Name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestMutatePolicyGateway(t *testing.T) {
+	ctx := context.Background()
+	clusterDefault := &egressv1.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg-cluster"},
+		Spec:       egressv1.EgressGatewaySpec{ClusterDefault: true},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "shop",
+		Annotations: map[string]string{egressv1.AnnotationDefaultEgressGateway: "eg-shop"},
+	}}
+
+	cases := map[string]struct {
+		existing  []client.Object
+		kind      string
+		namespace string
+		gateway   string
+		expPatch  string
+	}{
+		"no default gateway": {
+			kind:      EgressPolicy,
+			namespace: "default",
+		},
+		"cluster default gateway": {
+			existing:  []client.Object{clusterDefault},
+			kind:      EgressPolicy,
+			namespace: "default",
+			expPatch:  `[{"op":"add","path":"/spec/egressGatewayName","value":"eg-cluster"}]`,
+		},
+		"namespace default gateway": {
+			existing:  []client.Object{clusterDefault, ns},
+			kind:      EgressPolicy,
+			namespace: "shop",
+			expPatch:  `[{"op":"add","path":"/spec/egressGatewayName","value":"eg-shop"}]`,
+		},
+		"cluster policy": {
+			existing: []client.Object{clusterDefault, ns},
+			kind:     EgressClusterPolicy,
+			expPatch: `[{"op":"add","path":"/spec/egressGatewayName","value":"eg-cluster"}]`,
+		},
+		"gateway is set": {
+			existing:  []client.Object{clusterDefault},
+			kind:      EgressPolicy,
+			namespace: "default",
+			gateway:   "eg",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			raw, err := json.Marshal(&egressv1.EgressPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: "policy"},
+				Spec:       egressv1.EgressPolicySpec{EgressGatewayName: c.gateway},
+			})
+			assert.NoError(t, err)
+
+			cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(c.existing...).Build()
+			resp := MutateHook(cli, &config.Config{}).Handle(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Name:      "policy",
+					Namespace: c.namespace,
+					Kind:      metav1.GroupVersionKind{Kind: c.kind},
+					Operation: admissionv1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+			assert.True(t, resp.Allowed)
+			if c.expPatch == "" {
+				assert.Empty(t, resp.Patch)
+				return
+			}
+			assert.JSONEq(t, c.expPatch, string(resp.Patch))
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// policyScope describes which pods a policy is applied to
+type policyScope struct {
+	policy            egressv1.Policy
+	priority          uint64
+	namespaceSelector *metav1.LabelSelector
+	podSelector       *metav1.LabelSelector
+	podSubnet         []string
+}
+
+func newPolicyScope(policy *egressv1.EgressPolicy) policyScope {
+	return policyScope{
+		policy:      egressv1.Policy{Name: policy.Name, Namespace: policy.Namespace},
+		priority:    policy.GetPriority(),
+		podSelector: policy.Spec.AppliedTo.PodSelector,
+		podSubnet:   policy.Spec.AppliedTo.PodSubnet,
+	}
+}
+
+func newClusterPolicyScope(policy *egressv1.EgressClusterPolicy) policyScope {
+	scope := policyScope{
+		policy:            egressv1.Policy{Name: policy.Name},
+		priority:          policy.GetPriority(),
+		namespaceSelector: policy.Spec.AppliedTo.NamespaceSelector,
+		podSelector:       policy.Spec.AppliedTo.PodSelector,
+	}
+	if policy.Spec.AppliedTo.PodSubnet != nil {
+		scope.podSubnet = *policy.Spec.AppliedTo.PodSubnet
+	}
+	return scope
+}
+
+func (s policyScope) String() string {
+	if s.policy.Namespace == "" {
+		return fmt.Sprintf("EgressClusterPolicy %s", s.policy.Name)
+	}
+	return fmt.Sprintf("EgressPolicy %s/%s", s.policy.Namespace, s.policy.Name)
+}
+
+// priorityWarnings returns a warning for every other policy which has the same
+// priority as the given policy and may be applied to the same pods, if the
+// policies can not be listed, the error is returned as the only warning
+func priorityWarnings(ctx context.Context, cli client.Client, scope policyScope) []string {
+	warnings, err := samePriorityPolicies(ctx, cli, scope)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to check the priority of the other policies: %v", err)}
+	}
+	return warnings
+}
+
+func samePriorityPolicies(ctx context.Context, cli client.Client, scope policyScope) ([]string, error) {
+	scopes := make([]policyScope, 0)
+
+	policies := new(egressv1.EgressPolicyList)
+	if err := cli.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("failed to list EgressPolicy: %v", err)
+	}
+	for i := range policies.Items {
+		scopes = append(scopes, newPolicyScope(&policies.Items[i]))
+	}
+
+	clusterPolicies := new(egressv1.EgressClusterPolicyList)
+	if err := cli.List(ctx, clusterPolicies); err != nil {
+		return nil, fmt.Errorf("failed to list EgressClusterPolicy: %v", err)
+	}
+	for i := range clusterPolicies.Items {
+		scopes = append(scopes, newClusterPolicyScope(&clusterPolicies.Items[i]))
+	}
+
+	warnings := make([]string, 0)
+	for _, other := range scopes {
+		if other.policy == scope.policy || other.priority != scope.priority {
+			continue
+		}
+		if !namespaceOverlap(ctx, cli, scope, other) || !podOverlap(scope, other) {
+			continue
+		}
+		winner := scope
+		if egressv1.PolicyLess(other.policy, other.priority, scope.policy, scope.priority) {
+			winner = other
+		}
+		warnings = append(warnings, fmt.Sprintf("%s has the same priority %d and may select the same pods, %s takes effect on them",
+			other, scope.priority, winner))
+	}
+	return warnings, nil
+}
+
+func namespaceOverlap(ctx context.Context, cli client.Client, a, b policyScope) bool {
+	switch {
+	case a.policy.Namespace != "" && b.policy.Namespace != "":
+		return a.policy.Namespace == b.policy.Namespace
+	case a.policy.Namespace != "":
+		return namespaceMatches(ctx, cli, a.policy.Namespace, b.namespaceSelector)
+	case b.policy.Namespace != "":
+		return namespaceMatches(ctx, cli, b.policy.Namespace, a.namespaceSelector)
+	}
+	if a.namespaceSelector == nil || b.namespaceSelector == nil {
+		return true
+	}
+	return selectorOverlap(a.namespaceSelector, b.namespaceSelector)
+}
+
+func namespaceMatches(ctx context.Context, cli client.Client, namespace string, selector *metav1.LabelSelector) bool {
+	if selector == nil {
+		return true
+	}
+	ns := new(corev1.Namespace)
+	if err := cli.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return false
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return sel.Matches(labels.Set(ns.Labels))
+}
+
+func podOverlap(a, b policyScope) bool {
+	if len(a.podSubnet) != 0 && len(b.podSubnet) != 0 {
+		return subnetOverlap(a.podSubnet, b.podSubnet)
+	}
+	if len(a.podSubnet) != 0 || len(b.podSubnet) != 0 {
+		return false
+	}
+	return selectorOverlap(a.podSelector, b.podSelector)
+}
+
+func subnetOverlap(a, b []string) bool {
+	for _, itemA := range a {
+		_, netA, err := net.ParseCIDR(itemA)
+		if err != nil {
+			continue
+		}
+		for _, itemB := range b {
+			_, netB, err := net.ParseCIDR(itemB)
+			if err != nil {
+				continue
+			}
+			if netA.Contains(netB.IP) || netB.Contains(netA.IP) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectorOverlap reports whether a label set may exist that matches both
+// selectors. It builds a candidate label set from matchLabels and the
+// values of In/Exists expressions, then checks it against both selectors.
+func selectorOverlap(a, b *metav1.LabelSelector) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	selA, err := metav1.LabelSelectorAsSelector(a)
+	if err != nil {
+		return false
+	}
+	selB, err := metav1.LabelSelectorAsSelector(b)
+	if err != nil {
+		return false
+	}
+
+	set := labels.Set{}
+	for _, sel := range []*metav1.LabelSelector{a, b} {
+		for k, v := range sel.MatchLabels {
+			if old, ok := set[k]; ok && old != v {
+				return false
+			}
+			set[k] = v
+		}
+	}
+
+	values := make(map[string]sets.Set[string])
+	exists := sets.New[string]()
+	for _, sel := range []*metav1.LabelSelector{a, b} {
+		for _, exp := range sel.MatchExpressions {
+			switch exp.Operator {
+			case metav1.LabelSelectorOpIn:
+				if old, ok := values[exp.Key]; ok {
+					values[exp.Key] = old.Intersection(sets.New(exp.Values...))
+				} else {
+					values[exp.Key] = sets.New(exp.Values...)
+				}
+			case metav1.LabelSelectorOpExists:
+				exists.Insert(exp.Key)
+			}
+		}
+	}
+	for key, vals := range values {
+		if _, ok := set[key]; ok {
+			continue
+		}
+		if vals.Len() == 0 {
+			return false
+		}
+		set[key] = sets.List(vals)[0]
+	}
+	for key := range exists {
+		if _, ok := set[key]; !ok {
+			set[key] = ""
+		}
+	}
+
+	return selA.Matches(set) && selB.Matches(set)
+}
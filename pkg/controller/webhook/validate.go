@@ -8,15 +8,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 
 	v1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/constant"
+	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
 	"github.com/spidernet-io/egressgateway/pkg/egressgateway"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
 )
 
 const (
@@ -29,12 +37,12 @@ const (
 // ValidateHook ValidateHook
 func ValidateHook(client client.Client, cfg *config.Config) *webhook.Admission {
 	return &webhook.Admission{
-		Handler: admission.HandlerFunc(func(ctx context.Context, req webhook.AdmissionRequest) webhook.AdmissionResponse {
+		Handler: countDenials("validate", func(ctx context.Context, req webhook.AdmissionRequest) webhook.AdmissionResponse {
 
 			switch req.Kind.Kind {
 			case EgressClusterInfo:
 				if req.Operation == v1.Delete {
-					return webhook.Denied("EgressClusterInfo 'default' is not allowed to be deleted")
+					return utils.Denied("DefaultClusterInfo", "EgressClusterInfo 'default' is not allowed to be deleted")
 				}
 				return webhook.Allowed("checked")
 			case EgressGateway:
@@ -46,35 +54,52 @@ func ValidateHook(client client.Client, cfg *config.Config) *webhook.Admission {
 				policy := new(egressv1.EgressClusterPolicy)
 				err := json.Unmarshal(req.Object.Raw, policy)
 				if err != nil {
-					return webhook.Denied(fmt.Sprintf("json unmarshal EgressClusterPolicy with error: %v", err))
+					return utils.Denied("MalformedObject", fmt.Sprintf("json unmarshal EgressClusterPolicy with error: %v", err))
 				}
-				return validateSubnet(policy.Spec.DestSubnet)
+				spec := policySpec{
+					egressGatewayName:  policy.Spec.EgressGatewayName,
+					egressIP:           policy.Spec.EgressIP,
+					gatewayReplicas:    policy.Spec.GatewayReplicas,
+					podSelector:        policy.Spec.AppliedTo.PodSelector,
+					excludePodSelector: policy.Spec.AppliedTo.ExcludePodSelector,
+					destSubnet:         policy.Spec.DestSubnet,
+					excludeDestSubnet:  policy.Spec.ExcludeDestSubnet,
+					destDomains:        policy.Spec.DestDomains,
+					destPorts:          policy.Spec.DestPorts,
+				}
+				if policy.Spec.AppliedTo.PodSubnet != nil {
+					spec.podSubnet = *policy.Spec.AppliedTo.PodSubnet
+				}
+				resp := validatePolicy(ctx, client, spec)
+				if !resp.Allowed {
+					return resp
+				}
+				return resp.WithWarnings(priorityWarnings(ctx, client, newClusterPolicyScope(policy))...)
 			case EgressPolicy:
 				if req.Operation == v1.Delete {
 					return webhook.Allowed("checked")
 				}
-
 				policy := new(egressv1.EgressPolicy)
 				err := json.Unmarshal(req.Object.Raw, policy)
 				if err != nil {
-					return webhook.Denied(fmt.Sprintf("json unmarshal EgressPolicy with error: %v", err))
+					return utils.Denied("MalformedObject", fmt.Sprintf("json unmarshal EgressPolicy with error: %v", err))
 				}
-
-				if len(policy.Spec.EgressGatewayName) == 0 {
-					return webhook.Denied("egressGatewayName cannot be empty")
+				resp := validatePolicy(ctx, client, policySpec{
+					egressGatewayName:  policy.Spec.EgressGatewayName,
+					egressIP:           policy.Spec.EgressIP,
+					gatewayReplicas:    policy.Spec.GatewayReplicas,
+					podSelector:        policy.Spec.AppliedTo.PodSelector,
+					excludePodSelector: policy.Spec.AppliedTo.ExcludePodSelector,
+					podSubnet:          policy.Spec.AppliedTo.PodSubnet,
+					destSubnet:         policy.Spec.DestSubnet,
+					excludeDestSubnet:  policy.Spec.ExcludeDestSubnet,
+					destDomains:        policy.Spec.DestDomains,
+					destPorts:          policy.Spec.DestPorts,
+				})
+				if !resp.Allowed {
+					return resp
 				}
-
-				if policy.Spec.EgressIP.UseNodeIP {
-					if len(policy.Spec.EgressIP.IPv4) != 0 || len(policy.Spec.EgressIP.IPv6) != 0 {
-						return webhook.Denied("useNodeIP cannot be used with egressIP.ipv4 or egressIP.ipv6 at the same time")
-					}
-				}
-
-				if len(policy.Spec.AppliedTo.PodSelector.MatchLabels) != 0 && len(policy.Spec.AppliedTo.PodSubnet) != 0 {
-					return webhook.Denied("podSelector and podSubnet cannot be used together")
-				}
-
-				return validateSubnet(policy.Spec.DestSubnet)
+				return resp.WithWarnings(priorityWarnings(ctx, client, newPolicyScope(policy))...)
 			}
 
 			return webhook.Allowed("checked")
@@ -85,11 +110,13 @@ func ValidateHook(client client.Client, cfg *config.Config) *webhook.Admission {
 // ValidateHook ValidateHook
 func MutateHook(client client.Client, cfg *config.Config) *webhook.Admission {
 	return &webhook.Admission{
-		Handler: admission.HandlerFunc(func(ctx context.Context, req webhook.AdmissionRequest) webhook.AdmissionResponse {
+		Handler: countDenials("mutate", func(ctx context.Context, req webhook.AdmissionRequest) webhook.AdmissionResponse {
 
 			switch req.Kind.Kind {
 			case EgressGateway:
 				return (&egressgateway.EgressGatewayWebhook{Client: client, Config: cfg}).EgressGatewayMutate(ctx, req)
+			case EgressPolicy, EgressClusterPolicy:
+				return mutatePolicyGateway(ctx, client, req)
 			}
 
 			return webhook.Allowed("checked")
@@ -97,7 +124,222 @@ func MutateHook(client client.Client, cfg *config.Config) *webhook.Admission {
 	}
 }
 
-func validateSubnet(subnet []string) webhook.AdmissionResponse {
+// countDenials counts the requests denied by the handler of the webhook by
+// the kind, the operation and the reason of the denial
+func countDenials(name string, handler admission.HandlerFunc) admission.HandlerFunc {
+	return func(ctx context.Context, req webhook.AdmissionRequest) webhook.AdmissionResponse {
+		resp := handler(ctx, req)
+		if !resp.Allowed {
+			reason := "Unknown"
+			if resp.Result != nil && resp.Result.Reason != "" {
+				reason = string(resp.Result.Reason)
+			}
+			metrics.WebhookDenials.WithLabelValues(name, req.Kind.Kind, string(req.Operation), reason).Inc()
+		}
+		return resp
+	}
+}
+
+// policySpec is the part of EgressPolicy and EgressClusterPolicy validated in
+// the same way
+type policySpec struct {
+	egressGatewayName  string
+	egressIP           egressv1.EgressIP
+	gatewayReplicas    int32
+	podSelector        *metav1.LabelSelector
+	excludePodSelector *metav1.LabelSelector
+	podSubnet          []string
+	destSubnet         []string
+	excludeDestSubnet  []string
+	destDomains        []string
+	destPorts          []egressv1.PolicyPort
+}
+
+// validatePolicy checks the fields of the policy and the EgressGateway it
+// references, the risky but legal settings are returned as warnings
+func validatePolicy(ctx context.Context, cli client.Client, spec policySpec) webhook.AdmissionResponse {
+	if len(spec.egressGatewayName) == 0 {
+		return utils.Denied("NoEgressGateway", "egressGatewayName cannot be empty when there is no default EgressGateway")
+	}
+
+	switch spec.egressIP.AllocatorPolicy {
+	case "", egressv1.EipAllocatorDefault, egressv1.EipAllocatorRR:
+	default:
+		return utils.Denied("InvalidAllocatorPolicy", fmt.Sprintf("invalid egressIP.allocatorPolicy %q, it should be one of %s, %s",
+			spec.egressIP.AllocatorPolicy, egressv1.EipAllocatorDefault, egressv1.EipAllocatorRR))
+	}
+	if spec.egressIP.UseNodeIP {
+		if len(spec.egressIP.IPv4) != 0 || len(spec.egressIP.IPv6) != 0 {
+			return utils.Denied("InvalidEgressIP", "useNodeIP cannot be used with egressIP.ipv4 or egressIP.ipv6 at the same time")
+		}
+	}
+
+	if resp := validateAppliedTo(spec.podSelector, spec.podSubnet); !resp.Allowed {
+		return resp
+	}
+	if resp := validateExcludePodSelector(spec.podSelector, spec.excludePodSelector); !resp.Allowed {
+		return resp
+	}
+	if resp := validateGatewayReplicas(spec.gatewayReplicas, spec.egressIP); !resp.Allowed {
+		return resp
+	}
+	if resp := validateDestPorts(spec.destPorts); !resp.Allowed {
+		return resp
+	}
+	if resp := validateDestDomains(spec.destDomains); !resp.Allowed {
+		return resp
+	}
+	if resp := validateSubnet("excludeDestSubnet", "InvalidExcludeDestSubnet", spec.excludeDestSubnet); !resp.Allowed {
+		return resp
+	}
+	if resp := validateSubnet("destSubnet", "InvalidDestSubnet", spec.destSubnet); !resp.Allowed {
+		return resp
+	}
+
+	eg := new(egressv1.EgressGateway)
+	err := cli.Get(ctx, types.NamespacedName{Name: spec.egressGatewayName}, eg)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return utils.Denied("EgressGatewayNotFound", fmt.Sprintf("EgressGateway %s does not exist", spec.egressGatewayName))
+		}
+		return utils.Denied("InternalError", fmt.Sprintf("failed to get EgressGateway %s: %v", spec.egressGatewayName, err))
+	}
+	if resp := validateEgressIP(eg, spec.egressIP); !resp.Allowed {
+		return resp
+	}
+
+	return webhook.Allowed("checked").WithWarnings(policyWarnings(eg, spec)...)
+}
+
+// validateAppliedTo checks the policy selects the pods by either podSelector
+// or podSubnet
+func validateAppliedTo(podSelector *metav1.LabelSelector, podSubnet []string) webhook.AdmissionResponse {
+	if podSelector != nil && len(podSubnet) != 0 {
+		return utils.Denied("InvalidAppliedTo", "podSelector and podSubnet cannot be used together")
+	}
+	if podSelector == nil && len(podSubnet) == 0 {
+		return utils.Denied("InvalidAppliedTo", "either podSelector or podSubnet must be set")
+	}
+	if podSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(podSelector); err != nil {
+			return utils.Denied("InvalidAppliedTo", fmt.Sprintf("invalid podSelector: %v", err))
+		}
+	}
+	return validateSubnet("podSubnet", "InvalidAppliedTo", podSubnet)
+}
+
+// validateEgressIP checks the requested EIP lies in the ippools of the EgressGateway
+func validateEgressIP(eg *egressv1.EgressGateway, egressIP egressv1.EgressIP) webhook.AdmissionResponse {
+	items := []struct {
+		field   string
+		ip      string
+		version constant.IPVersion
+		ippools []string
+	}{
+		{"egressIP.ipv4", egressIP.IPv4, constant.IPv4, eg.Spec.Ippools.IPv4},
+		{"egressIP.ipv6", egressIP.IPv6, constant.IPv6, eg.Spec.Ippools.IPv6},
+	}
+	for _, item := range items {
+		if item.ip == "" {
+			continue
+		}
+		ip := net.ParseIP(item.ip)
+		if ip == nil {
+			return utils.Denied("InvalidEgressIP", fmt.Sprintf("invalid %s %q", item.field, item.ip))
+		}
+		set, err := utils.NewIPRangeSet(item.version, item.ippools)
+		if err != nil {
+			return utils.Denied("InvalidEgressIP", fmt.Sprintf("invalid ippools of EgressGateway %s: %v", eg.Name, err))
+		}
+		if !set.Contains(ip) {
+			return utils.Denied("InvalidEgressIP", fmt.Sprintf("%s %s is not in the ippools of EgressGateway %s", item.field, item.ip, eg.Name))
+		}
+	}
+	return webhook.Allowed("checked")
+}
+
+// policyWarnings returns the warnings of the settings which are legal but may
+// not work as expected
+func policyWarnings(eg *egressv1.EgressGateway, spec policySpec) []string {
+	warnings := make([]string, 0)
+	if spec.podSelector != nil && len(spec.podSelector.MatchLabels) == 0 && len(spec.podSelector.MatchExpressions) == 0 {
+		warnings = append(warnings, "the empty podSelector selects all the pods")
+	}
+	if !spec.egressIP.UseNodeIP && len(eg.Spec.Ippools.IPv4) == 0 && len(eg.Spec.Ippools.IPv6) == 0 {
+		warnings = append(warnings, fmt.Sprintf("EgressGateway %s has no ippools, no EIP can be allocated to the policy", eg.Name))
+	}
+	if spec.gatewayReplicas > 1 && int(spec.gatewayReplicas) > len(eg.Status.NodeList) {
+		warnings = append(warnings, fmt.Sprintf("gatewayReplicas %d is greater than the %d gateway nodes of EgressGateway %s",
+			spec.gatewayReplicas, len(eg.Status.NodeList), eg.Name))
+	}
+	return warnings
+}
+
+// validateDestDomains checks the domains are valid names, wildcard is not
+// supported because the domains are resolved by the agent
+func validateDestDomains(domains []string) webhook.AdmissionResponse {
+	for _, domain := range domains {
+		name := strings.TrimSuffix(strings.ToLower(domain), ".")
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+			return utils.Denied("InvalidDestDomains", fmt.Sprintf("invalid destDomains %q: %s", domain, strings.Join(errs, ", ")))
+		}
+	}
+	return webhook.Allowed("checked")
+}
+
+// validateExcludePodSelector checks the exclude selector narrows down the pods
+// of podSelector, it makes no sense with podSubnet
+func validateExcludePodSelector(podSelector, exclude *metav1.LabelSelector) webhook.AdmissionResponse {
+	if exclude == nil {
+		return webhook.Allowed("checked")
+	}
+	if podSelector == nil {
+		return utils.Denied("InvalidAppliedTo", "excludePodSelector can only be used with podSelector")
+	}
+	if _, err := metav1.LabelSelectorAsSelector(exclude); err != nil {
+		return utils.Denied("InvalidAppliedTo", fmt.Sprintf("invalid excludePodSelector: %v", err))
+	}
+	return webhook.Allowed("checked")
+}
+
+// validateGatewayReplicas checks a policy spread across several gateway nodes
+// gets an EIP for each of them
+func validateGatewayReplicas(replicas int32, egressIP egressv1.EgressIP) webhook.AdmissionResponse {
+	if replicas <= 1 {
+		return webhook.Allowed("checked")
+	}
+	if len(egressIP.IPv4) != 0 || len(egressIP.IPv6) != 0 {
+		return utils.Denied("InvalidGatewayReplicas", "gatewayReplicas greater than 1 cannot be used with egressIP.ipv4 or egressIP.ipv6")
+	}
+	if !egressIP.UseNodeIP && egressIP.AllocatorPolicy != egressv1.EipAllocatorRR {
+		return utils.Denied("InvalidGatewayReplicas", "gatewayReplicas greater than 1 can only be used with useNodeIP or the rr allocatorPolicy")
+	}
+	return webhook.Allowed("checked")
+}
+
+func validateDestPorts(ports []egressv1.PolicyPort) webhook.AdmissionResponse {
+	for _, port := range ports {
+		switch port.Protocol {
+		case "", "TCP", "UDP", "SCTP":
+		default:
+			return utils.Denied("InvalidDestPorts", fmt.Sprintf("invalid destPorts protocol %q, it should be one of TCP, UDP and SCTP", port.Protocol))
+		}
+		if port.Port < 0 || port.Port > 65535 {
+			return utils.Denied("InvalidDestPorts", fmt.Sprintf("invalid destPorts port %d", port.Port))
+		}
+		if port.EndPort != 0 {
+			if port.Port == 0 {
+				return utils.Denied("InvalidDestPorts", "destPorts endPort cannot be used without port")
+			}
+			if port.EndPort < port.Port || port.EndPort > 65535 {
+				return utils.Denied("InvalidDestPorts", fmt.Sprintf("invalid destPorts endPort %d, it should be in range [%d, 65535]", port.EndPort, port.Port))
+			}
+		}
+	}
+	return webhook.Allowed("checked")
+}
+
+func validateSubnet(field string, reason metav1.StatusReason, subnet []string) webhook.AdmissionResponse {
 	invalidList := make([]string, 0)
 	for _, subnet := range subnet {
 		ip, _, err := net.ParseCIDR(subnet)
@@ -110,7 +352,7 @@ func validateSubnet(subnet []string) webhook.AdmissionResponse {
 		}
 	}
 	if len(invalidList) > 0 {
-		return webhook.Denied(fmt.Sprintf("invalid destSubnet list: %v", invalidList))
+		return utils.Denied(reason, fmt.Sprintf("invalid %s list: %v", field, invalidList))
 	}
 	return webhook.Allowed("checked")
 }
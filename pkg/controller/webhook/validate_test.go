@@ -6,20 +6,41 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"testing"
 
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	admissionv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
 	"github.com/spidernet-io/egressgateway/pkg/schema"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
 )
 
+// newTestGateway returns the EgressGateway referenced by the policies of the tests
+func newTestGateway() *egressv1.EgressGateway {
+	return &egressv1.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: egressv1.EgressGatewaySpec{
+			Ippools: egressv1.Ippools{
+				IPv4: []string{"10.6.1.21-10.6.1.30"},
+				IPv6: []string{"fd00::21-fd00::30"},
+			},
+		},
+	}
+}
+
 func TestValidateEgressGateway(t *testing.T) {
 	ctx := context.Background()
 
@@ -43,6 +64,50 @@ func TestValidateEgressGateway(t *testing.T) {
 			},
 			expAllow: false,
 		},
+		"EgressGateway second cluster default": {
+			existingResources: []runtime.Object{&egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg-default"},
+				Spec:       egressv1.EgressGatewaySpec{ClusterDefault: true},
+			}},
+			newResource: &egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg-test"},
+				Spec:       egressv1.EgressGatewaySpec{ClusterDefault: true},
+			},
+			expAllow:      false,
+			expErrMessage: "EgressGateway eg-default is already the cluster default",
+		},
+		"EgressGateway overlapping ippools": {
+			existingResources: []runtime.Object{newTestGateway()},
+			newResource: &egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg-test"},
+				Spec: egressv1.EgressGatewaySpec{
+					Ippools: egressv1.Ippools{IPv4: []string{"10.6.1.0/24"}},
+				},
+			},
+			expAllow:      false,
+			expErrMessage: "the ippools overlap 10.6.1.21-10.6.1.30 of EgressGateway test",
+		},
+		"EgressGateway disjoint ippools": {
+			existingResources: []runtime.Object{newTestGateway()},
+			newResource: &egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg-test"},
+				Spec: egressv1.EgressGatewaySpec{
+					Ippools: egressv1.Ippools{IPv4: []string{"10.6.2.0/24"}},
+				},
+			},
+			expAllow: true,
+		},
+		"EgressGateway cluster default": {
+			existingResources: []runtime.Object{&egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg-test"},
+				Spec:       egressv1.EgressGatewaySpec{ClusterDefault: true},
+			}},
+			newResource: &egressv1.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg-test"},
+				Spec:       egressv1.EgressGatewaySpec{ClusterDefault: true},
+			},
+			expAllow: true,
+		},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -51,6 +116,7 @@ func TestValidateEgressGateway(t *testing.T) {
 
 			builder := fake.NewClientBuilder()
 			builder.WithScheme(schema.GetScheme())
+			builder.WithRuntimeObjects(c.existingResources...)
 			cli := builder.Build()
 			conf := &config.Config{
 				FileConfig: config.FileConfig{
@@ -87,7 +153,9 @@ func TestValidateEgressGatewayPolicy(t *testing.T) {
 	cases := map[string]struct {
 		existingResources []runtime.Object
 		destSubnet        []string
+		excludeDestSubnet []string
 		expAllow          bool
+		expReason         metav1.StatusReason
 		expErrMessage     string
 	}{
 		"case, valid": {
@@ -105,7 +173,8 @@ func TestValidateEgressGatewayPolicy(t *testing.T) {
 			destSubnet: []string{
 				"1.1.1.1",
 			},
-			expAllow: false,
+			expAllow:  false,
+			expReason: "InvalidDestSubnet",
 		},
 		"case2, not valid": {
 			existingResources: nil,
@@ -121,6 +190,17 @@ func TestValidateEgressGatewayPolicy(t *testing.T) {
 			},
 			expAllow: false,
 		},
+		"case4, valid excludeDestSubnet": {
+			destSubnet:        []string{"10.0.0.0/8"},
+			excludeDestSubnet: []string{"10.6.0.0/16", "fd00::/64"},
+			expAllow:          true,
+		},
+		"case5, not valid excludeDestSubnet": {
+			excludeDestSubnet: []string{"10.6.0.0"},
+			expAllow:          false,
+			expReason:         "InvalidExcludeDestSubnet",
+			expErrMessage:     "invalid excludeDestSubnet list: [10.6.0.0]",
+		},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -141,7 +221,8 @@ func TestValidateEgressGatewayPolicy(t *testing.T) {
 							MatchLabels: map[string]string{"app": "test"},
 						},
 					},
-					DestSubnet: c.destSubnet,
+					DestSubnet:        c.destSubnet,
+					ExcludeDestSubnet: c.excludeDestSubnet,
 				},
 			}
 
@@ -150,6 +231,7 @@ func TestValidateEgressGatewayPolicy(t *testing.T) {
 
 			builder := fake.NewClientBuilder()
 			builder.WithScheme(schema.GetScheme())
+			builder.WithObjects(newTestGateway())
 			cli := builder.Build()
 			conf := &config.Config{
 				FileConfig: config.FileConfig{
@@ -173,6 +255,9 @@ func TestValidateEgressGatewayPolicy(t *testing.T) {
 			})
 
 			assert.Equal(t, c.expAllow, resp.Allowed)
+			if c.expReason != "" {
+				assert.Equal(t, c.expReason, resp.AdmissionResponse.Result.Reason)
+			}
 			if c.expErrMessage != "" {
 				assert.Equal(t, c.expErrMessage, resp.AdmissionResponse.Result.Message)
 			}
@@ -235,3 +320,600 @@ func TestValidateEgressNode(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePolicyPriorityWarning(t *testing.T) {
+	ctx := context.Background()
+
+	newPolicy := func(ns, name string, priority uint64, matchLabels map[string]string) *egressv1.EgressPolicy {
+		return &egressv1.EgressPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+			Spec: egressv1.EgressPolicySpec{
+				EgressGatewayName: "test",
+				AppliedTo: egressv1.AppliedTo{
+					PodSelector: &metav1.LabelSelector{MatchLabels: matchLabels},
+				},
+				Priority: pointer.Uint64(priority),
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		existingResources []client.Object
+		newResource       *egressv1.EgressPolicy
+		expWarnings       int
+	}{
+		"same priority, overlapping selector": {
+			existingResources: []client.Object{
+				newPolicy("default", "p1", 100, map[string]string{"app": "test"}),
+			},
+			newResource: newPolicy("default", "p2", 100, map[string]string{"app": "test", "tier": "web"}),
+			expWarnings: 1,
+		},
+		"same priority, conflicting selector": {
+			existingResources: []client.Object{
+				newPolicy("default", "p1", 100, map[string]string{"app": "test"}),
+			},
+			newResource: newPolicy("default", "p2", 100, map[string]string{"app": "other"}),
+			expWarnings: 0,
+		},
+		"different priority": {
+			existingResources: []client.Object{
+				newPolicy("default", "p1", 100, map[string]string{"app": "test"}),
+			},
+			newResource: newPolicy("default", "p2", 200, map[string]string{"app": "test"}),
+			expWarnings: 0,
+		},
+		"same priority, other namespace": {
+			existingResources: []client.Object{
+				newPolicy("ns1", "p1", 100, map[string]string{"app": "test"}),
+			},
+			newResource: newPolicy("default", "p2", 100, map[string]string{"app": "test"}),
+			expWarnings: 0,
+		},
+		"default priority of cluster policy": {
+			existingResources: []client.Object{
+				&egressv1.EgressClusterPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "cp1"},
+					Spec: egressv1.EgressClusterPolicySpec{
+						EgressGatewayName: "test",
+						AppliedTo: egressv1.ClusterAppliedTo{
+							PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+						},
+					},
+				},
+			},
+			newResource: newPolicy("default", "p2", egressv1.DefaultClusterPolicyPriority, map[string]string{"app": "test"}),
+			expWarnings: 1,
+		},
+		"priority 0": {
+			existingResources: []client.Object{
+				newPolicy("default", "p1", 0, map[string]string{"app": "test"}),
+			},
+			newResource: newPolicy("default", "p2", 0, map[string]string{"app": "test"}),
+			expWarnings: 1,
+		},
+		"priority 0 is not the default priority": {
+			existingResources: []client.Object{
+				&egressv1.EgressPolicy{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "p1"},
+					Spec: egressv1.EgressPolicySpec{
+						EgressGatewayName: "test",
+						AppliedTo: egressv1.AppliedTo{
+							PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+						},
+					},
+				},
+			},
+			newResource: newPolicy("default", "p2", 0, map[string]string{"app": "test"}),
+			expWarnings: 0,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			marshalledRequestObject, err := json.Marshal(c.newResource)
+			assert.NoError(t, err)
+
+			builder := fake.NewClientBuilder()
+			builder.WithScheme(schema.GetScheme())
+			builder.WithObjects(c.existingResources...)
+			builder.WithObjects(newTestGateway())
+			cli := builder.Build()
+			conf := &config.Config{
+				FileConfig: config.FileConfig{
+					EnableIPv4: true,
+					EnableIPv6: false,
+				},
+			}
+
+			validator := ValidateHook(cli, conf)
+			resp := validator.Handle(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: c.newResource.Namespace,
+					Name:      c.newResource.Name,
+					Kind: metav1.GroupVersionKind{
+						Kind: "EgressPolicy",
+					},
+					Operation: admissionv1.Create,
+					Object: runtime.RawExtension{
+						Raw: marshalledRequestObject,
+					},
+				},
+			})
+
+			assert.True(t, resp.Allowed)
+			assert.Len(t, resp.Warnings, c.expWarnings)
+		})
+	}
+}
+
+func TestSelectorOverlap(t *testing.T) {
+	cases := map[string]struct {
+		a, b   *metav1.LabelSelector
+		expect bool
+	}{
+		"nil selector": {
+			a:      nil,
+			b:      &metav1.LabelSelector{},
+			expect: false,
+		},
+		"empty selector matches everything": {
+			a:      &metav1.LabelSelector{},
+			b:      &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+			expect: true,
+		},
+		"in expression": {
+			a: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+			}},
+			b: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"b", "c"}},
+			}},
+			expect: true,
+		},
+		"in expression without common value": {
+			a: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"a"}},
+			}},
+			b: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"c"}},
+			}},
+			expect: false,
+		},
+		"does not exist": {
+			a: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+			b: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpDoesNotExist},
+			}},
+			expect: false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expect, selectorOverlap(c.a, c.b))
+		})
+	}
+}
+
+func TestValidatePolicyPodSubnet(t *testing.T) {
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		kind      string
+		appliedTo egressv1.AppliedTo
+		expAllow  bool
+		expReason metav1.StatusReason
+	}{
+		"EgressPolicy podSubnet only": {
+			kind:      "EgressPolicy",
+			appliedTo: egressv1.AppliedTo{PodSubnet: []string{"172.30.0.0/16", "fd00:1::/112"}},
+			expAllow:  true,
+		},
+		"EgressPolicy invalid podSubnet": {
+			kind:      "EgressPolicy",
+			appliedTo: egressv1.AppliedTo{PodSubnet: []string{"172.30.0.0"}},
+			expAllow:  false,
+			expReason: "InvalidAppliedTo",
+		},
+		"EgressPolicy podSelector and podSubnet": {
+			kind: "EgressPolicy",
+			appliedTo: egressv1.AppliedTo{
+				PodSelector: &metav1.LabelSelector{},
+				PodSubnet:   []string{"172.30.0.0/16"},
+			},
+			expAllow: false,
+		},
+		"EgressClusterPolicy podSubnet only": {
+			kind:      "EgressClusterPolicy",
+			appliedTo: egressv1.AppliedTo{PodSubnet: []string{"172.30.0.0/16"}},
+			expAllow:  true,
+		},
+		"EgressClusterPolicy invalid podSubnet": {
+			kind:      "EgressClusterPolicy",
+			appliedTo: egressv1.AppliedTo{PodSubnet: []string{"---"}},
+			expAllow:  false,
+			expReason: "InvalidAppliedTo",
+		},
+		"EgressClusterPolicy podSelector and podSubnet": {
+			kind: "EgressClusterPolicy",
+			appliedTo: egressv1.AppliedTo{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+				PodSubnet:   []string{"172.30.0.0/16"},
+			},
+			expAllow: false,
+		},
+		"EgressPolicy excludePodSelector": {
+			kind: "EgressPolicy",
+			appliedTo: egressv1.AppliedTo{
+				PodSelector:        &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+				ExcludePodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "db"}},
+			},
+			expAllow: true,
+		},
+		"EgressPolicy excludePodSelector without podSelector": {
+			kind: "EgressPolicy",
+			appliedTo: egressv1.AppliedTo{
+				PodSubnet:          []string{"172.30.0.0/16"},
+				ExcludePodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "db"}},
+			},
+			expAllow: false,
+		},
+		"EgressClusterPolicy invalid excludePodSelector": {
+			kind: "EgressClusterPolicy",
+			appliedTo: egressv1.AppliedTo{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+				ExcludePodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: "Bad"},
+				}},
+			},
+			expAllow: false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var obj interface{}
+			if c.kind == "EgressPolicy" {
+				obj = &egressv1.EgressPolicy{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+					Spec: egressv1.EgressPolicySpec{
+						EgressGatewayName: "test",
+						AppliedTo:         c.appliedTo,
+					},
+				}
+			} else {
+				podSubnet := c.appliedTo.PodSubnet
+				obj = &egressv1.EgressClusterPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+					Spec: egressv1.EgressClusterPolicySpec{
+						EgressGatewayName: "test",
+						AppliedTo: egressv1.ClusterAppliedTo{
+							PodSelector:        c.appliedTo.PodSelector,
+							ExcludePodSelector: c.appliedTo.ExcludePodSelector,
+							PodSubnet:          &podSubnet,
+						},
+					},
+				}
+			}
+			marshalledRequestObject, err := json.Marshal(obj)
+			assert.NoError(t, err)
+
+			builder := fake.NewClientBuilder()
+			builder.WithScheme(schema.GetScheme())
+			builder.WithObjects(newTestGateway())
+			cli := builder.Build()
+			conf := &config.Config{
+				FileConfig: config.FileConfig{
+					EnableIPv4: true,
+					EnableIPv6: true,
+				},
+			}
+
+			validator := ValidateHook(cli, conf)
+			resp := validator.Handle(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Name: "policy",
+					Kind: metav1.GroupVersionKind{
+						Kind: c.kind,
+					},
+					Operation: admissionv1.Create,
+					Object: runtime.RawExtension{
+						Raw: marshalledRequestObject,
+					},
+				},
+			})
+
+			assert.Equal(t, c.expAllow, resp.Allowed)
+			if c.expReason != "" {
+				assert.Equal(t, c.expReason, resp.AdmissionResponse.Result.Reason)
+			}
+		})
+	}
+}
+
+func TestValidateDestPorts(t *testing.T) {
+	cases := map[string]struct {
+		ports    []egressv1.PolicyPort
+		expAllow bool
+	}{
+		"valid": {
+			ports: []egressv1.PolicyPort{
+				{Protocol: "TCP", Port: 443},
+				{Protocol: "UDP", Port: 53},
+				{Protocol: "TCP", Port: 8000, EndPort: 8080},
+				{Protocol: "SCTP"},
+				{Port: 80},
+			},
+			expAllow: true,
+		},
+		"invalid protocol": {
+			ports:    []egressv1.PolicyPort{{Protocol: "ICMP"}},
+			expAllow: false,
+		},
+		"invalid port": {
+			ports:    []egressv1.PolicyPort{{Protocol: "TCP", Port: 70000}},
+			expAllow: false,
+		},
+		"endPort without port": {
+			ports:    []egressv1.PolicyPort{{Protocol: "TCP", EndPort: 80}},
+			expAllow: false,
+		},
+		"endPort less than port": {
+			ports:    []egressv1.PolicyPort{{Protocol: "TCP", Port: 443, EndPort: 80}},
+			expAllow: false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := validateDestPorts(c.ports)
+			assert.Equal(t, c.expAllow, resp.Allowed)
+		})
+	}
+}
+
+func TestValidateDestDomains(t *testing.T) {
+	cases := map[string]struct {
+		domains  []string
+		expAllow bool
+	}{
+		"valid": {
+			domains:  []string{"api.example.com", "Partner.Example.com.", "localhost"},
+			expAllow: true,
+		},
+		"wildcard": {
+			domains:  []string{"*.example.com"},
+			expAllow: false,
+		},
+		"invalid character": {
+			domains:  []string{"api_example.com"},
+			expAllow: false,
+		},
+		"empty": {
+			domains:  []string{""},
+			expAllow: false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := validateDestDomains(c.domains)
+			assert.Equal(t, c.expAllow, resp.Allowed)
+		})
+	}
+}
+
+func TestValidateGatewayReplicas(t *testing.T) {
+	cases := map[string]struct {
+		replicas int32
+		egressIP egressv1.EgressIP
+		expAllow bool
+	}{
+		"one replica with egressIP": {
+			replicas: 1,
+			egressIP: egressv1.EgressIP{IPv4: "10.6.1.21"},
+			expAllow: true,
+		},
+		"replicas with useNodeIP": {
+			replicas: 2,
+			egressIP: egressv1.EgressIP{UseNodeIP: true},
+			expAllow: true,
+		},
+		"replicas with rr": {
+			replicas: 3,
+			egressIP: egressv1.EgressIP{AllocatorPolicy: egressv1.EipAllocatorRR},
+			expAllow: true,
+		},
+		"replicas with default allocatorPolicy": {
+			replicas: 2,
+			egressIP: egressv1.EgressIP{AllocatorPolicy: egressv1.EipAllocatorDefault},
+			expAllow: false,
+		},
+		"replicas with egressIP": {
+			replicas: 2,
+			egressIP: egressv1.EgressIP{IPv4: "10.6.1.21", AllocatorPolicy: egressv1.EipAllocatorRR},
+			expAllow: false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := validateGatewayReplicas(c.replicas, c.egressIP)
+			assert.Equal(t, c.expAllow, resp.Allowed, resp.Result.Message)
+		})
+	}
+}
+
+func TestValidatePolicyGateway(t *testing.T) {
+	ctx := context.Background()
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}}
+	cases := map[string]struct {
+		kind          string
+		gatewayName   string
+		egressIP      egressv1.EgressIP
+		replicas      int32
+		podSelector   *metav1.LabelSelector
+		expAllow      bool
+		expErrMessage string
+		expWarnings   int
+	}{
+		"EgressPolicy valid egressIP": {
+			kind:        "EgressPolicy",
+			gatewayName: "test",
+			egressIP:    egressv1.EgressIP{IPv4: "10.6.1.22", IPv6: "fd00::22"},
+			podSelector: selector,
+			expAllow:    true,
+		},
+		"EgressClusterPolicy egressIP out of the ippools": {
+			kind:          "EgressClusterPolicy",
+			gatewayName:   "test",
+			egressIP:      egressv1.EgressIP{IPv4: "10.6.2.22"},
+			podSelector:   selector,
+			expAllow:      false,
+			expErrMessage: "egressIP.ipv4 10.6.2.22 is not in the ippools of EgressGateway test",
+		},
+		"EgressPolicy gateway does not exist": {
+			kind:          "EgressPolicy",
+			gatewayName:   "other",
+			podSelector:   selector,
+			expAllow:      false,
+			expErrMessage: "EgressGateway other does not exist",
+		},
+		"EgressClusterPolicy empty gateway": {
+			kind:        "EgressClusterPolicy",
+			podSelector: selector,
+			expAllow:    false,
+		},
+		"EgressPolicy invalid allocatorPolicy": {
+			kind:        "EgressPolicy",
+			gatewayName: "test",
+			egressIP:    egressv1.EgressIP{AllocatorPolicy: "random"},
+			podSelector: selector,
+			expAllow:    false,
+		},
+		"EgressClusterPolicy useNodeIP with egressIP": {
+			kind:        "EgressClusterPolicy",
+			gatewayName: "test",
+			egressIP:    egressv1.EgressIP{UseNodeIP: true, IPv4: "10.6.1.22"},
+			podSelector: selector,
+			expAllow:    false,
+		},
+		"EgressPolicy no podSelector nor podSubnet": {
+			kind:          "EgressPolicy",
+			gatewayName:   "test",
+			expAllow:      false,
+			expErrMessage: "either podSelector or podSubnet must be set",
+		},
+		"EgressClusterPolicy empty podSelector and gatewayReplicas": {
+			kind:        "EgressClusterPolicy",
+			gatewayName: "test",
+			egressIP:    egressv1.EgressIP{UseNodeIP: true},
+			replicas:    2,
+			podSelector: &metav1.LabelSelector{},
+			expAllow:    true,
+			expWarnings: 2,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var obj interface{}
+			if c.kind == "EgressPolicy" {
+				obj = &egressv1.EgressPolicy{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+					Spec: egressv1.EgressPolicySpec{
+						EgressGatewayName: c.gatewayName,
+						EgressIP:          c.egressIP,
+						GatewayReplicas:   c.replicas,
+						AppliedTo:         egressv1.AppliedTo{PodSelector: c.podSelector},
+					},
+				}
+			} else {
+				obj = &egressv1.EgressClusterPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+					Spec: egressv1.EgressClusterPolicySpec{
+						EgressGatewayName: c.gatewayName,
+						EgressIP:          c.egressIP,
+						GatewayReplicas:   c.replicas,
+						AppliedTo:         egressv1.ClusterAppliedTo{PodSelector: c.podSelector},
+					},
+				}
+			}
+			marshalledRequestObject, err := json.Marshal(obj)
+			assert.NoError(t, err)
+
+			builder := fake.NewClientBuilder()
+			builder.WithScheme(schema.GetScheme())
+			builder.WithObjects(newTestGateway())
+			cli := builder.Build()
+			conf := &config.Config{
+				FileConfig: config.FileConfig{
+					EnableIPv4: true,
+					EnableIPv6: true,
+				},
+			}
+
+			validator := ValidateHook(cli, conf)
+			resp := validator.Handle(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: "default",
+					Name:      "policy",
+					Kind: metav1.GroupVersionKind{
+						Kind: c.kind,
+					},
+					Operation: admissionv1.Create,
+					Object: runtime.RawExtension{
+						Raw: marshalledRequestObject,
+					},
+				},
+			})
+
+			assert.Equal(t, c.expAllow, resp.Allowed)
+			if c.expErrMessage != "" {
+				assert.Equal(t, c.expErrMessage, resp.AdmissionResponse.Result.Message)
+			}
+			assert.Len(t, resp.Warnings, c.expWarnings)
+		})
+	}
+}
+
+func TestCountDenials(t *testing.T) {
+	value := func(reason string) float64 {
+		m := &dto.Metric{}
+		err := metrics.WebhookDenials.WithLabelValues("test", EgressPolicy, "CREATE", reason).Write(m)
+		assert.NoError(t, err)
+		return m.GetCounter().GetValue()
+	}
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: EgressPolicy},
+		Operation: admissionv1.Create,
+	}}
+	handle := func(resp admission.Response) {
+		countDenials("test", func(context.Context, admission.Request) admission.Response { return resp })(context.Background(), req)
+	}
+
+	handle(admission.Allowed("checked"))
+	handle(utils.Denied("InvalidDestPorts", "invalid port"))
+	handle(utils.Denied("InvalidDestPorts", "invalid port"))
+	handle(admission.Errored(http.StatusInternalServerError, fmt.Errorf("failed")))
+
+	assert.Equal(t, float64(2), value("InvalidDestPorts"))
+	assert.Equal(t, float64(1), value("Unknown"))
+}
+
+func TestPriorityWarningsListError(t *testing.T) {
+	cli := fake.NewClientBuilder().
+		WithScheme(schema.GetScheme()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, client client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				return fmt.Errorf("connection refused")
+			},
+		}).
+		Build()
+	policy := &egressv1.EgressPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "p1"}}
+
+	warnings := priorityWarnings(context.Background(), cli, newPolicyScope(policy))
+	assert.Equal(t, []string{"failed to check the priority of the other policies: failed to list EgressPolicy: connection refused"}, warnings)
+}
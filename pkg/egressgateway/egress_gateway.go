@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"sort"
 	"time"
 
 	"go.uber.org/zap"
@@ -17,6 +18,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -26,6 +29,7 @@ import (
 
 	"github.com/spidernet-io/egressgateway/pkg/config"
 	"github.com/spidernet-io/egressgateway/pkg/constant"
+	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
 	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
 	"github.com/spidernet-io/egressgateway/pkg/utils"
 )
@@ -39,6 +43,10 @@ type egnReconciler struct {
 	client client.Client
 	log    *zap.Logger
 	config *config.Config
+	clock  clock.PassiveClock
+	// recorder records the Events of the policies and the EgressGateways,
+	// the recorder of the manager aggregates and rate limits them
+	recorder record.EventRecorder
 }
 
 type policyInfo struct {
@@ -49,6 +57,7 @@ type policyInfo struct {
 	policy          egress.Policy
 	isUseNodeIP     bool
 	allocatorPolicy string
+	replicas        int
 }
 
 func (r egnReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
@@ -99,7 +108,7 @@ func (r egnReconciler) reconcileNode(ctx context.Context, req reconcile.Request,
 	// Node NoReady event, complete in reconcile EgressNode event
 	if deleted {
 		r.log.Info("request item is deleted")
-		err := r.deleteNodeFromEGs(ctx, req.Name, egList)
+		err := r.deleteNodeFromEGs(ctx, req.Name, egList, metrics.ReasonNodeDeleted)
 		if err != nil {
 			return reconcile.Result{Requeue: true}, nil
 		}
@@ -121,7 +130,7 @@ func (r egnReconciler) reconcileNode(ctx context.Context, req reconcile.Request,
 				eg.Status.NodeList = append(eg.Status.NodeList, egress.EgressIPStatus{Name: node.Name})
 
 				r.log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
-				err := r.client.Status().Update(ctx, &eg)
+				err := r.updateEGStatus(ctx, &eg)
 				if err != nil {
 					r.log.Sugar().Errorf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
 					return reconcile.Result{Requeue: true}, nil
@@ -131,7 +140,7 @@ func (r egnReconciler) reconcileNode(ctx context.Context, req reconcile.Request,
 			// Labels do not match. If there is a node in status, delete the node from status and reallocate the policy
 			_, isExist := GetPoliciesByNode(node.Name, eg)
 			if isExist {
-				err := r.deleteNodeFromEG(ctx, node.Name, eg)
+				err := r.deleteNodeFromEG(ctx, node.Name, eg, metrics.ReasonNodeUnselected)
 				if err != nil {
 					return reconcile.Result{Requeue: true}, nil
 				}
@@ -158,6 +167,7 @@ func (r egnReconciler) reconcileEG(ctx context.Context, req reconcile.Request, l
 
 	if deleted {
 		log.Info("request item is deleted")
+		metrics.DeleteGateway(req.Name)
 		return reconcile.Result{}, nil
 	}
 
@@ -209,6 +219,7 @@ func (r egnReconciler) reconcileEG(ctx context.Context, req reconcile.Request, l
 		isUpdete = true
 	}
 
+	var reallocated []egress.Policy
 	log.Sugar().Infof("delete a gateway nodes: %d", delNodeMap)
 	if len(delNodeMap) != 0 {
 		// Select a gateway node for the policy again
@@ -223,9 +234,11 @@ func (r egnReconciler) reconcileEG(ctx context.Context, req reconcile.Request, l
 			err = r.reAllocatorPolicy(ctx, policy, eg, perNodeMap)
 			if err != nil {
 				log.Sugar().Errorf("reallocator Failed to reassign a gateway node for EgressPolicy %v: %v", policy, err)
+				r.recordAllocationFailure(ctx, policy, eg, err)
 				return reconcile.Result{Requeue: true}, err
 			}
 		}
+		reallocated = reSetPolicies
 
 		isUpdete = true
 	}
@@ -238,11 +251,19 @@ func (r egnReconciler) reconcileEG(ctx context.Context, req reconcile.Request, l
 		eg.Status.NodeList = perNodeList
 
 		log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
-		err = r.client.Status().Update(ctx, eg)
+		err = r.updateEGStatus(ctx, eg)
 		if err != nil {
 			log.Sugar().Errorf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
 			return reconcile.Result{Requeue: true}, err
 		}
+		r.recordReallocations(ctx, *eg, metrics.ReasonNodeUnselected, reallocated)
+		return reconcile.Result{}, nil
+	}
+
+	// The ippools or the phase of the nodes may be changed
+	if err := r.syncEGStatus(ctx, eg); err != nil {
+		log.Sugar().Errorf("sync egress gateway status: %v", err)
+		return reconcile.Result{Requeue: true}, err
 	}
 
 	return reconcile.Result{}, nil
@@ -283,10 +304,14 @@ func (r egnReconciler) reconcileEN(ctx context.Context, req reconcile.Request, l
 					}
 				}
 
+				if failbackEnabled(eg) {
+					recordPreferredNode(&eg.Status, en.Name, policies)
+				}
 				for _, policy := range policies {
 					err = r.reAllocatorPolicy(ctx, policy, &eg, perNodeMap)
 					if err != nil {
 						log.Sugar().Errorf("reallocator Failed to reassign a gateway node for EgressPolicy %v: %v", policy, err)
+						r.recordAllocationFailure(ctx, policy, &eg, err)
 						return reconcile.Result{Requeue: true}, err
 					}
 				}
@@ -300,19 +325,80 @@ func (r egnReconciler) reconcileEN(ctx context.Context, req reconcile.Request, l
 				eg.Status.NodeList = perNodeList
 
 				log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
-				err = r.client.Status().Update(ctx, &eg)
+				err = r.updateEGStatus(ctx, &eg)
 				if err != nil {
 					log.Sugar().Errorf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
 					return reconcile.Result{Requeue: true}, err
 				}
+				r.recordReallocations(ctx, eg, metrics.ReasonNodeNotReady, policies)
 			}
 		}
 
+	} else {
+		// Update the readiness of the node in the status of the EgressGateways,
+		// and move the policies back to the node by the failbackPolicy
+		egList := &egress.EgressGatewayList{}
+		if err := r.client.List(ctx, egList); err != nil {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		res := reconcile.Result{}
+		for _, eg := range egList.Items {
+			if _, isExist := GetPoliciesByNode(en.Name, eg); !isExist {
+				continue
+			}
+			isUpdate, moved, wait, err := r.failback(ctx, en.Name, &eg)
+			if err != nil {
+				log.Sugar().Errorf("failed to move the policies back to node %s: %v", en.Name, err)
+				return reconcile.Result{Requeue: true}, err
+			}
+			if wait > 0 && (res.RequeueAfter == 0 || wait < res.RequeueAfter) {
+				res.RequeueAfter = wait
+			}
+			if isUpdate {
+				log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
+				err = r.updateEGStatus(ctx, &eg)
+			} else {
+				err = r.syncEGStatus(ctx, &eg)
+			}
+			if err != nil {
+				log.Sugar().Errorf("sync egress gateway status: %v", err)
+				return reconcile.Result{Requeue: true}, err
+			}
+			r.recordReallocations(ctx, eg, metrics.ReasonFailback, moved)
+		}
+		return res, nil
 	}
 
 	return reconcile.Result{}, nil
 }
 
+// deletePolicyFromGateways deletes the policy from the EgressGateways other
+// than keep. If the referenced EIP is not used by any other policy, the
+// system reclaims the EIP.
+func (r egnReconciler) deletePolicyFromGateways(ctx context.Context, policy egress.Policy, keep string, log *zap.Logger) error {
+	egwList := &egress.EgressGatewayList{}
+	if err := r.client.List(ctx, egwList); err != nil {
+		return err
+	}
+	for _, egw := range egwList.Items {
+		if egw.Name == keep {
+			continue
+		}
+		if _, isExist := GetEIPStatusByPolicy(policy, egw); !isExist {
+			continue
+		}
+		log.Sugar().Infof("delete policy %v from eg %v", policy, egw.Name)
+		DeletePolicyFromEG(policy, &egw)
+
+		log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(egw.Status))
+		if err := r.updateEGStatus(ctx, &egw); err != nil {
+			log.Sugar().Errorf("update egress gateway status\n%s", mustMarshalJson(egw.Status))
+			return err
+		}
+	}
+	return nil
+}
+
 // reconcileEN reconcile egresspolicy and egressclusterpolicy
 func (r egnReconciler) reconcileEGP(ctx context.Context, req reconcile.Request, log *zap.Logger) (reconcile.Result, error) {
 	deleted := false
@@ -337,6 +423,7 @@ func (r egnReconciler) reconcileEGP(ctx context.Context, req reconcile.Request,
 			pi.ipv6 = egcp.Spec.EgressIP.IPv6
 			pi.isUseNodeIP = egcp.Spec.EgressIP.UseNodeIP
 			pi.egw = egcp.Spec.EgressGatewayName
+			pi.replicas = egcp.GetGatewayReplicas()
 		}
 	} else {
 		egp := &egress.EgressPolicy{}
@@ -356,35 +443,24 @@ func (r egnReconciler) reconcileEGP(ctx context.Context, req reconcile.Request,
 			pi.ipv6 = egp.Spec.EgressIP.IPv6
 			pi.isUseNodeIP = egp.Spec.EgressIP.UseNodeIP
 			pi.egw = egp.Spec.EgressGatewayName
+			pi.replicas = egp.GetGatewayReplicas()
 		}
 	}
 
-	policy := pi.policy
+	policy := egress.Policy{Name: req.Name, Namespace: req.Namespace}
 	if deleted {
-		egwList := &egress.EgressGatewayList{}
-		if err := r.client.List(context.Background(), egwList); err != nil {
-			return reconcile.Result{Requeue: true}, nil
-		}
-		for _, egw := range egwList.Items {
-			_, isExist := GetEIPStatusByPolicy(policy, egw)
-			if isExist {
-				log.Sugar().Infof("delete policy %v from eg %v", policy, egw.Name)
-				// Delete the policy from the EgressGateway. If the referenced EIP is not used by any other policy,
-				// the system reclaims the EIP.
-				DeletePolicyFromEG(policy, &egw)
-
-				log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(egw.Status))
-				err := r.client.Status().Update(ctx, &egw)
-				if err != nil {
-					log.Sugar().Errorf("update egress gateway status\n%s", mustMarshalJson(egw.Status))
-					return reconcile.Result{Requeue: true}, err
-				}
-				return reconcile.Result{}, nil
-			}
+		if err := r.deletePolicyFromGateways(ctx, policy, "", log); err != nil {
+			return reconcile.Result{Requeue: true}, err
 		}
 		return reconcile.Result{}, nil
 	}
 
+	// the policy is left in the status of the old EgressGateway when its
+	// egressGatewayName is changed
+	if err := r.deletePolicyFromGateways(ctx, policy, pi.egw, log); err != nil {
+		return reconcile.Result{Requeue: true}, err
+	}
+
 	egwName := pi.egw
 	eg := &egress.EgressGateway{}
 	err := r.client.Get(ctx, types.NamespacedName{Name: egwName}, eg)
@@ -396,6 +472,8 @@ func (r egnReconciler) reconcileEGP(ctx context.Context, req reconcile.Request,
 		return reconcile.Result{Requeue: true}, err
 	}
 
+	assigned := describeGateways(policy, *eg)
+
 	// Assigned if the policy does not have a gateway node
 	eipStatus, isExist := GetEIPStatusByPolicy(policy, *eg)
 	if !isExist {
@@ -407,6 +485,7 @@ func (r egnReconciler) reconcileEGP(ctx context.Context, req reconcile.Request,
 		err := r.reAllocatorPolicy(ctx, policy, eg, perNodeMap)
 		if err != nil {
 			r.log.Sugar().Errorf("reallocator Failed to reassign a gateway node for EgressPolicy %v: %v", policy, err)
+			r.recordAllocationFailure(ctx, policy, eg, err)
 			return reconcile.Result{Requeue: true}, err
 		}
 
@@ -444,6 +523,7 @@ func (r egnReconciler) reconcileEGP(ctx context.Context, req reconcile.Request,
 						err := r.reAllocatorPolicy(ctx, policy, eg, perNodeMap)
 						if err != nil {
 							r.log.Sugar().Errorf("reallocator Failed to reassign a gateway node for EgressPolicy %v: %v", policy, err)
+							r.recordAllocationFailure(ctx, policy, eg, err)
 							return reconcile.Result{Requeue: true}, err
 						}
 
@@ -463,22 +543,29 @@ func (r egnReconciler) reconcileEGP(ctx context.Context, req reconcile.Request,
 	}
 
 update:
-	if isUpdete {
+	spread, err := r.spreadPolicy(ctx, pi, eg)
+	if err != nil {
+		r.log.Sugar().Errorf("failed to spread EgressPolicy %v across gateway nodes: %v", policy, err)
+		r.recordAllocationFailure(ctx, policy, eg, err)
+		return reconcile.Result{Requeue: true}, err
+	}
+	if spread || isUpdete {
 		r.log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
-		err = r.client.Status().Update(ctx, eg)
+		err = r.updateEGStatus(ctx, eg)
 		if err != nil {
 			r.log.Sugar().Errorf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
 			return reconcile.Result{Requeue: true}, err
 		}
+		r.recordAssigned(ctx, policy, *eg, assigned)
 	}
 	return reconcile.Result{}, nil
 }
 
-func (r egnReconciler) deleteNodeFromEGs(ctx context.Context, nodeName string, egList *egress.EgressGatewayList) error {
+func (r egnReconciler) deleteNodeFromEGs(ctx context.Context, nodeName string, egList *egress.EgressGatewayList, reason string) error {
 	for _, eg := range egList.Items {
 		for _, eipStatus := range eg.Status.NodeList {
 			if nodeName == eipStatus.Name {
-				err := r.deleteNodeFromEG(ctx, nodeName, eg)
+				err := r.deleteNodeFromEG(ctx, nodeName, eg, reason)
 				if err != nil {
 					return err
 				}
@@ -490,8 +577,9 @@ func (r egnReconciler) deleteNodeFromEGs(ctx context.Context, nodeName string, e
 	return nil
 }
 
-// Delete the node from the EgressGateway
-func (r egnReconciler) deleteNodeFromEG(ctx context.Context, nodeName string, eg egress.EgressGateway) error {
+// Delete the node from the EgressGateway, reason is the reason of the reallocations
+// of the policies of the node
+func (r egnReconciler) deleteNodeFromEG(ctx context.Context, nodeName string, eg egress.EgressGateway, reason string) error {
 	// Get the policy that needs to be reassigned
 	policies, isExist := GetPoliciesByNode(nodeName, eg)
 
@@ -508,6 +596,7 @@ func (r egnReconciler) deleteNodeFromEG(ctx context.Context, nodeName string, eg
 			err := r.reAllocatorPolicy(ctx, policy, &eg, perNodeMap)
 			if err != nil {
 				r.log.Sugar().Errorf("reallocator Failed to reassign a gateway node for EgressPolicy %v: %v", policy, err)
+				r.recordAllocationFailure(ctx, policy, &eg, err)
 				return err
 			}
 		}
@@ -519,11 +608,12 @@ func (r egnReconciler) deleteNodeFromEG(ctx context.Context, nodeName string, eg
 
 		eg.Status.NodeList = perNodeList
 		r.log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
-		err := r.client.Status().Update(ctx, &eg)
+		err := r.updateEGStatus(ctx, &eg)
 		if err != nil {
 			r.log.Sugar().Errorf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
 			return err
 		}
+		r.recordReallocations(ctx, eg, reason, policies)
 	}
 
 	return nil
@@ -532,41 +622,22 @@ func (r egnReconciler) deleteNodeFromEG(ctx context.Context, nodeName string, eg
 func (r egnReconciler) reAllocatorPolicy(ctx context.Context, policy egress.Policy, eg *egress.EgressGateway, nodeMap map[string]egress.EgressIPStatus) error {
 	var perNode string
 	var ipv4, ipv6 string
-	var err error
-	pi := policyInfo{}
-	pi.policy = policy
-
-	if len(pi.policy.Namespace) == 0 {
-		egcp := &egress.EgressClusterPolicy{}
-		err := r.client.Get(ctx, types.NamespacedName{Name: pi.policy.Name}, egcp)
-		if err != nil {
-			return err
-		}
-
-		pi.ipv4 = egcp.Spec.EgressIP.IPv4
-		pi.ipv6 = egcp.Spec.EgressIP.IPv6
-		pi.isUseNodeIP = egcp.Spec.EgressIP.UseNodeIP
-		pi.egw = egcp.Spec.EgressGatewayName
-		pi.allocatorPolicy = egcp.Spec.EgressIP.AllocatorPolicy
-	} else {
-		egp := &egress.EgressPolicy{}
-		err := r.client.Get(ctx, types.NamespacedName{Namespace: pi.policy.Namespace, Name: pi.policy.Name}, egp)
-		if err != nil {
-			return err
-		}
+	pi, err := r.getPolicyInfo(ctx, policy)
+	if err != nil {
+		return err
+	}
 
-		pi.ipv4 = egp.Spec.EgressIP.IPv4
-		pi.ipv6 = egp.Spec.EgressIP.IPv6
-		pi.isUseNodeIP = egp.Spec.EgressIP.UseNodeIP
-		pi.egw = egp.Spec.EgressGatewayName
-		pi.allocatorPolicy = egp.Spec.EgressIP.AllocatorPolicy
+	// a policy spread across several gateway nodes is not allocated twice on a node
+	candidates := nodesWithoutPolicy(policy, nodeMap)
+	if len(candidates) == 0 && len(nodeMap) != 0 {
+		return nil
 	}
 
 	ipv4 = pi.ipv4
 	if len(ipv4) != 0 {
-		perNode = GetNodeByIP(ipv4, *eg)
+		perNode = getNodeByIPFromMap(ipv4, nodeMap)
 		if len(perNode) == 0 {
-			perNode, err = r.allocatorNode("rr", nodeMap)
+			perNode, err = r.allocatorNode(ctx, pi.policy, eg, candidates)
 			if err != nil {
 				return err
 			}
@@ -579,7 +650,7 @@ func (r egnReconciler) reAllocatorPolicy(ctx context.Context, policy egress.Poli
 	} else {
 		allocatorPolicy := pi.allocatorPolicy
 		if allocatorPolicy == egress.EipAllocatorRR {
-			perNode, err := r.allocatorNode("rr", nodeMap)
+			perNode, err = r.allocatorNode(ctx, pi.policy, eg, candidates)
 			if err != nil {
 				return err
 			}
@@ -592,9 +663,9 @@ func (r egnReconciler) reAllocatorPolicy(ctx context.Context, policy egress.Poli
 			ipv4 = eg.Spec.Ippools.Ipv4DefaultEIP
 			ipv6 = eg.Spec.Ippools.Ipv6DefaultEIP
 
-			perNode = GetNodeByIP(ipv4, *eg)
+			perNode = getNodeByIPFromMap(ipv4, nodeMap)
 			if len(perNode) == 0 {
-				perNode, err = r.allocatorNode("rr", nodeMap)
+				perNode, err = r.allocatorNode(ctx, pi.policy, eg, candidates)
 				if err != nil {
 					return err
 				}
@@ -610,33 +681,119 @@ func (r egnReconciler) reAllocatorPolicy(ctx context.Context, policy egress.Poli
 	return nil
 }
 
-func (r egnReconciler) allocatorNode(selNodePolicy string, nodeMap map[string]egress.EgressIPStatus) (string, error) {
+// getPolicyInfo returns the allocation related spec of the policy
+func (r egnReconciler) getPolicyInfo(ctx context.Context, policy egress.Policy) (policyInfo, error) {
+	pi := policyInfo{policy: policy}
+	if len(policy.Namespace) == 0 {
+		egcp := &egress.EgressClusterPolicy{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: policy.Name}, egcp)
+		if err != nil {
+			return pi, err
+		}
 
-	if len(nodeMap) == 0 {
-		err := fmt.Errorf("nodeList is empty")
-		return "", err
+		pi.ipv4 = egcp.Spec.EgressIP.IPv4
+		pi.ipv6 = egcp.Spec.EgressIP.IPv6
+		pi.isUseNodeIP = egcp.Spec.EgressIP.UseNodeIP
+		pi.egw = egcp.Spec.EgressGatewayName
+		pi.allocatorPolicy = egcp.Spec.EgressIP.AllocatorPolicy
+		pi.replicas = egcp.GetGatewayReplicas()
+	} else {
+		egp := &egress.EgressPolicy{}
+		err := r.client.Get(ctx, types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}, egp)
+		if err != nil {
+			return pi, err
+		}
+
+		pi.ipv4 = egp.Spec.EgressIP.IPv4
+		pi.ipv6 = egp.Spec.EgressIP.IPv6
+		pi.isUseNodeIP = egp.Spec.EgressIP.UseNodeIP
+		pi.egw = egp.Spec.EgressGatewayName
+		pi.allocatorPolicy = egp.Spec.EgressIP.AllocatorPolicy
+		pi.replicas = egp.GetGatewayReplicas()
 	}
+	return pi, nil
+}
 
-	var perNode string
-	perNodePolicyNum := 0
-	i := 0
-	for _, node := range nodeMap {
-		policyNum := 0
-		for _, eip := range node.Eips {
-			policyNum += len(eip.Policies)
+// spreadPolicy allocates gateway nodes and EIPs for the policy until it has
+// the replicas of them, the extra ones are released when replicas decreases.
+// It reports whether the status of eg is changed.
+func (r egnReconciler) spreadPolicy(ctx context.Context, pi policyInfo, eg *egress.EgressGateway) (bool, error) {
+	nodes := GetNodesByPolicy(pi.policy, *eg)
+	if len(nodes) == 0 {
+		return false, nil
+	}
+	if len(nodes) > pi.replicas {
+		for _, node := range nodes[pi.replicas:] {
+			deletePolicyFromNode(pi.policy, node, eg)
+		}
+		return true, nil
+	}
+
+	isUpdate := false
+	for len(nodes) < pi.replicas {
+		nodeMap := make(map[string]egress.EgressIPStatus, len(eg.Status.NodeList))
+		for _, item := range eg.Status.NodeList {
+			nodeMap[item.Name] = item
+		}
+		candidates := nodesWithoutPolicy(pi.policy, nodeMap)
+		if len(candidates) == 0 {
+			r.log.Sugar().Debugf("no more gateway node for policy %v, %d of %d are allocated", pi.policy, len(nodes), pi.replicas)
+			break
 		}
 
-		if i == 0 {
-			i++
-			perNode = node.Name
-			perNodePolicyNum = policyNum
-		} else if policyNum <= perNodePolicyNum {
-			perNode = node.Name
-			perNodePolicyNum = policyNum
+		node, err := r.allocatorNode(ctx, pi.policy, eg, candidates)
+		if err != nil {
+			return isUpdate, err
+		}
+		ipv4, ipv6, err := r.allocatorEIP("", node, pi, *eg)
+		if err != nil {
+			return isUpdate, err
+		}
+		err = setEipStatus(ipv4, ipv6, node, pi.policy, nodeMap)
+		if err != nil {
+			return isUpdate, err
+		}
+
+		nodeList := make([]egress.EgressIPStatus, 0, len(nodeMap))
+		for _, item := range eg.Status.NodeList {
+			nodeList = append(nodeList, nodeMap[item.Name])
+		}
+		eg.Status.NodeList = nodeList
+		nodes = append(nodes, node)
+		isUpdate = true
+	}
+	return isUpdate, nil
+}
+
+// nodesWithoutPolicy returns the nodes of nodeMap not used by the policy
+func nodesWithoutPolicy(policy egress.Policy, nodeMap map[string]egress.EgressIPStatus) map[string]egress.EgressIPStatus {
+	res := make(map[string]egress.EgressIPStatus, len(nodeMap))
+	for name, node := range nodeMap {
+		if !nodeHasPolicy(policy, node) {
+			res[name] = node
+		}
+	}
+	return res
+}
+
+func nodeHasPolicy(policy egress.Policy, node egress.EgressIPStatus) bool {
+	for _, eip := range node.Eips {
+		for _, p := range eip.Policies {
+			if p == policy {
+				return true
+			}
 		}
 	}
+	return false
+}
 
-	return perNode, nil
+// allocatorNode selects a gateway node for the policy by the node select policy of eg
+func (r egnReconciler) allocatorNode(ctx context.Context, policy egress.Policy, eg *egress.EgressGateway, nodeMap map[string]egress.EgressIPStatus) (string, error) {
+	selector, err := r.newNodeSelector(ctx, eg, nodeMap)
+	if err != nil {
+		return "", err
+	}
+	return selector.Select(policy, nodeMap)
 }
 
 func (r egnReconciler) allocatorEIP(selEipLolicy string, nodeName string, pi policyInfo, eg egress.EgressGateway) (string, string, error) {
@@ -652,14 +809,13 @@ func (r egnReconciler) allocatorEIP(selEipLolicy string, nodeName string, pi pol
 		var useIpv4s []net.IP
 		var useIpv4sByNode []net.IP
 
-		ipv4Ranges, _ := utils.MergeIPRanges(constant.IPv4, eg.Spec.Ippools.IPv4)
+		ipv4Set, err := utils.NewIPRangeSet(constant.IPv4, eg.Spec.Ippools.IPv4)
+		if err != nil {
+			return "", "", err
+		}
 		perIpv4 = pi.ipv4
 		if len(perIpv4) != 0 {
-			result, err := utils.IsIPIncludedRange(constant.IPv4, perIpv4, ipv4Ranges)
-			if err != nil {
-				return "", "", err
-			}
-			if !result {
+			if !ipv4Set.Contains(net.ParseIP(perIpv4)) {
 				return "", "", fmt.Errorf("%v is not within the EIP range of EgressGateway %v", perIpv4, eg.Name)
 			}
 		} else {
@@ -671,10 +827,8 @@ func (r egnReconciler) allocatorEIP(selEipLolicy string, nodeName string, pi pol
 				}
 			}
 
-			ipv4s, _ := utils.ParseIPRanges(constant.IPv4, ipv4Ranges)
-			freeIpv4s := utils.IPsDiffSet(ipv4s, useIpv4s, false)
-
-			if len(freeIpv4s) == 0 {
+			freeIpv4, ok := ipv4Set.RandomFree(rander, useIpv4s)
+			if !ok {
 				for _, node := range eg.Status.NodeList {
 					if node.Name == nodeName {
 						for _, eip := range node.Eips {
@@ -686,12 +840,12 @@ func (r egnReconciler) allocatorEIP(selEipLolicy string, nodeName string, pi pol
 				}
 
 				if len(useIpv4sByNode) == 0 {
-					return "", "", fmt.Errorf("No EIP meeting requirements is found on node %v; EG %v", nodeName, eg.Name)
+					return "", "", fmt.Errorf("%w on node %v; EG %v", errEIPExhausted, nodeName, eg.Name)
 				}
 
 				perIpv4 = useIpv4sByNode[rander.Intn(len(useIpv4sByNode))].String()
 			} else {
-				perIpv4 = freeIpv4s[rander.Intn(len(freeIpv4s))].String()
+				perIpv4 = freeIpv4.String()
 			}
 		}
 	}
@@ -704,15 +858,14 @@ func (r egnReconciler) allocatorEIP(selEipLolicy string, nodeName string, pi pol
 		var useIpv6s []net.IP
 		var useIpv6sByNode []net.IP
 
-		ipv6Ranges, _ := utils.MergeIPRanges(constant.IPv6, eg.Spec.Ippools.IPv6)
+		ipv6Set, err := utils.NewIPRangeSet(constant.IPv6, eg.Spec.Ippools.IPv6)
+		if err != nil {
+			return "", "", err
+		}
 
 		perIpv6 = pi.ipv6
 		if len(perIpv6) != 0 {
-			result, err := utils.IsIPIncludedRange(constant.IPv6, perIpv6, ipv6Ranges)
-			if err != nil {
-				return "", "", err
-			}
-			if !result {
+			if !ipv6Set.Contains(net.ParseIP(perIpv6)) {
 				return "", "", fmt.Errorf("%v is not within the EIP range of EgressGateway %v", perIpv6, eg.Name)
 			}
 		} else {
@@ -724,10 +877,8 @@ func (r egnReconciler) allocatorEIP(selEipLolicy string, nodeName string, pi pol
 				}
 			}
 
-			ipv6s, _ := utils.ParseIPRanges(constant.IPv6, ipv6Ranges)
-			freeIpv6s := utils.IPsDiffSet(ipv6s, useIpv6s, false)
-
-			if len(freeIpv6s) == 0 {
+			freeIpv6, ok := ipv6Set.RandomFree(rander, useIpv6s)
+			if !ok {
 				for _, node := range eg.Status.NodeList {
 					if node.Name == nodeName {
 						for _, eip := range node.Eips {
@@ -739,11 +890,11 @@ func (r egnReconciler) allocatorEIP(selEipLolicy string, nodeName string, pi pol
 				}
 
 				if len(useIpv6sByNode) == 0 {
-					return "", "", fmt.Errorf("No EIP meeting requirements is found on node %v; EG %v", nodeName, eg.Name)
+					return "", "", fmt.Errorf("%w on node %v; EG %v", errEIPExhausted, nodeName, eg.Name)
 				}
 				perIpv6 = useIpv6sByNode[rander.Intn(len(useIpv6sByNode))].String()
 			} else {
-				perIpv6 = freeIpv6s[rander.Intn(len(freeIpv6s))].String()
+				perIpv6 = freeIpv6.String()
 			}
 		}
 	}
@@ -762,6 +913,9 @@ func NewEgressGatewayController(mgr manager.Manager, log *zap.Logger, cfg *confi
 		client: mgr.GetClient(),
 		log:    log,
 		config: cfg,
+		clock:  clock.RealClock{},
+
+		recorder: mgr.GetEventRecorderFor("egressgateway-controller"),
 	}
 
 	c, err := controller.New("egressGateway", mgr,
@@ -824,6 +978,22 @@ func GetNodeByIP(ipv4 string, eg egress.EgressGateway) string {
 	return nodeName
 }
 
+// getNodeByIPFromMap returns the node of nodeMap the EIP is bound to, the
+// EIP of a node not in nodeMap, such as a failed node, is allocated again
+func getNodeByIPFromMap(ipv4 string, nodeMap map[string]egress.EgressIPStatus) string {
+	if len(ipv4) == 0 {
+		return ""
+	}
+	for name, node := range nodeMap {
+		for _, eip := range node.Eips {
+			if eip.IPv4 == ipv4 {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
 func setEipStatus(ipv4, ipv6 string, nodeName string, policy egress.Policy, nodeMap map[string]egress.EgressIPStatus) error {
 	eipStatus, ok := nodeMap[nodeName]
 	if !ok {
@@ -903,11 +1073,54 @@ func GetEIPStatusByPolicy(policy egress.Policy, eg egress.EgressGateway) (egress
 	return eipStatus, isExist
 }
 
+// GetNodesByPolicy returns the sorted gateway nodes of the policy
+func GetNodesByPolicy(policy egress.Policy, eg egress.EgressGateway) []string {
+	var nodes []string
+	for _, item := range eg.Status.NodeList {
+		if nodeHasPolicy(policy, item) {
+			nodes = append(nodes, item.Name)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// GetPolicyGateways returns the gateway nodes of the policy and their EIPs,
+// sorted by the node name
+func GetPolicyGateways(policy egress.Policy, eg egress.EgressGateway) []egress.PolicyGateway {
+	var res []egress.PolicyGateway
+	for _, item := range eg.Status.NodeList {
+		for _, eip := range item.Eips {
+			for _, p := range eip.Policies {
+				if p == policy {
+					res = append(res, egress.PolicyGateway{Node: item.Name, Ipv4: eip.IPv4, Ipv6: eip.IPv6})
+				}
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Node < res[j].Node
+	})
+	return res
+}
+
+// DeletePolicyFromEG deletes the policy from all the gateway nodes of eg
 func DeletePolicyFromEG(policy egress.Policy, eg *egress.EgressGateway) {
+	for _, item := range eg.Status.NodeList {
+		deletePolicyFromNode(policy, item.Name, eg)
+	}
+}
+
+// deletePolicyFromNode deletes the policy from the gateway node. If the
+// referenced EIP is not used by any other policy, the EIP is released.
+func deletePolicyFromNode(policy egress.Policy, nodeName string, eg *egress.EgressGateway) {
 	var policies []egress.Policy
 	var eips []egress.Eips
 
 	for i, node := range eg.Status.NodeList {
+		if node.Name != nodeName {
+			continue
+		}
 		for j, eip := range node.Eips {
 			for k, item := range eip.Policies {
 				if item == policy {
@@ -925,11 +1138,9 @@ func DeletePolicyFromEG(policy egress.Policy, eg *egress.EgressGateway) {
 					} else {
 						eg.Status.NodeList[i].Eips[j].Policies = policies
 					}
-					goto breakHere
+					return
 				}
 			}
 		}
 	}
-breakHere:
-	return
 }
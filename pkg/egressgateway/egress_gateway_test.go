@@ -0,0 +1,336 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package egressgateway
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	"github.com/spidernet-io/egressgateway/pkg/constant"
+	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+func TestSpreadPolicy(t *testing.T) {
+	policy := egress.Policy{Namespace: "default", Name: "policy"}
+	eg := &egress.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Status: egress.EgressGatewayStatus{NodeList: []egress.EgressIPStatus{
+			{Name: "node1", Eips: []egress.Eips{{Policies: []egress.Policy{policy}}}},
+			{Name: "node2"},
+			{Name: "node3"},
+		}},
+	}
+	r := egnReconciler{
+		client: fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build(),
+		log:    zap.NewNop(),
+		config: &config.Config{FileConfig: config.FileConfig{EnableIPv4: true}},
+	}
+	ctx := context.Background()
+	pi := policyInfo{policy: policy, isUseNodeIP: true, replicas: 1}
+
+	updated, err := r.spreadPolicy(ctx, pi, eg)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, []string{"node1"}, GetNodesByPolicy(policy, *eg))
+
+	// more replicas than the gateway nodes
+	pi.replicas = 4
+	updated, err = r.spreadPolicy(ctx, pi, eg)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []string{"node1", "node2", "node3"}, GetNodesByPolicy(policy, *eg))
+	assert.Len(t, GetPolicyGateways(policy, *eg), 3)
+
+	pi.replicas = 2
+	updated, err = r.spreadPolicy(ctx, pi, eg)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []string{"node1", "node2"}, GetNodesByPolicy(policy, *eg))
+
+	DeletePolicyFromEG(policy, eg)
+	assert.Empty(t, GetNodesByPolicy(policy, *eg))
+	for _, node := range eg.Status.NodeList {
+		assert.Empty(t, node.Eips, node.Name)
+	}
+}
+
+func TestReconcileFailedNode(t *testing.T) {
+	policy := egress.Policy{Namespace: "default", Name: "policy"}
+	egp := &egress.EgressPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+		Spec: egress.EgressPolicySpec{
+			EgressGatewayName: "eg",
+			EgressIP:          egress.EgressIP{UseNodeIP: true},
+		},
+	}
+	// the default EIP is moved with the policy
+	defaultPolicy := egress.Policy{Namespace: "default", Name: "default-eip"}
+	defaultEgp := &egress.EgressPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "default-eip"},
+		Spec:       egress.EgressPolicySpec{EgressGatewayName: "eg"},
+	}
+	eg := &egress.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Spec:       egress.EgressGatewaySpec{Ippools: egress.Ippools{IPv4: []string{"10.6.1.21"}, Ipv4DefaultEIP: "10.6.1.21"}},
+		Status: egress.EgressGatewayStatus{NodeList: []egress.EgressIPStatus{
+			{Name: "node1", Eips: []egress.Eips{
+				{Policies: []egress.Policy{policy}},
+				{IPv4: "10.6.1.21", Policies: []egress.Policy{defaultPolicy}},
+			}},
+			{Name: "node2"},
+		}},
+	}
+	node1 := &egress.EgressNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status:     egress.EgressNodeStatus{Phase: egress.EgressNodeFailed},
+	}
+	node2 := &egress.EgressNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+		Status:     egress.EgressNodeStatus{Phase: egress.EgressNodeSucceeded},
+	}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).
+		WithObjects(egp, defaultEgp, eg, node1, node2).WithStatusSubresource(eg).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := egnReconciler{
+		client:   cli,
+		log:      zap.NewNop(),
+		config:   &config.Config{FileConfig: config.FileConfig{EnableIPv4: true}},
+		recorder: recorder,
+	}
+
+	ctx := context.Background()
+	_, err := r.reconcileEN(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}}, zap.NewNop())
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		assert.Contains(t, <-recorder.Events, "Normal Reallocated Moved by NodeNotReady, gateway nodes of EgressGateway eg: node2")
+	}
+
+	res := new(egress.EgressGateway)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg"}, res))
+	assert.Equal(t, []string{"node2"}, GetNodesByPolicy(policy, *res))
+	assert.Equal(t, []egress.PolicyGateway{{Node: "node2", Ipv4: "10.6.1.21"}}, GetPolicyGateways(defaultPolicy, *res))
+}
+
+func TestReconcileGatewayChanged(t *testing.T) {
+	policy := egress.Policy{Namespace: "default", Name: "policy"}
+	egp := &egress.EgressPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+		Spec: egress.EgressPolicySpec{
+			EgressGatewayName: "eg-new",
+			EgressIP:          egress.EgressIP{UseNodeIP: true},
+		},
+	}
+	oldEg := &egress.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg-old"},
+		Status: egress.EgressGatewayStatus{NodeList: []egress.EgressIPStatus{
+			{Name: "node1", Eips: []egress.Eips{{Policies: []egress.Policy{policy}}}},
+		}},
+	}
+	newEg := &egress.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg-new"},
+		Status:     egress.EgressGatewayStatus{NodeList: []egress.EgressIPStatus{{Name: "node2"}}},
+	}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).
+		WithObjects(egp, oldEg, newEg).WithStatusSubresource(oldEg, newEg).Build()
+	r := egnReconciler{
+		client:   cli,
+		log:      zap.NewNop(),
+		config:   &config.Config{FileConfig: config.FileConfig{EnableIPv4: true}},
+		recorder: record.NewFakeRecorder(10),
+	}
+
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "policy"}}
+	_, err := r.reconcileEGP(ctx, req, zap.NewNop())
+	assert.NoError(t, err)
+
+	res := new(egress.EgressGateway)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg-old"}, res))
+	assert.Empty(t, GetNodesByPolicy(policy, *res))
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg-new"}, res))
+	assert.Equal(t, []string{"node2"}, GetNodesByPolicy(policy, *res))
+
+	// the deleted policy is removed from the EgressGateway
+	assert.NoError(t, cli.Delete(ctx, egp))
+	_, err = r.reconcileEGP(ctx, req, zap.NewNop())
+	assert.NoError(t, err)
+	assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg-new"}, res))
+	assert.Empty(t, GetNodesByPolicy(policy, *res))
+}
+
+func TestNodesWithoutPolicy(t *testing.T) {
+	policy := egress.Policy{Name: "policy"}
+	nodeMap := newNodeMap(map[string]int{"node1": 1, "node2": 0})
+	node := nodeMap["node1"]
+	node.Eips[0].Policies = append(node.Eips[0].Policies, policy)
+
+	res := nodesWithoutPolicy(policy, nodeMap)
+	assert.Len(t, res, 1)
+	assert.Contains(t, res, "node2")
+}
+
+func TestReconcileFailback(t *testing.T) {
+	policy := egress.Policy{Namespace: "default", Name: "policy"}
+	defaultPolicy := egress.Policy{Namespace: "default", Name: "default-eip"}
+	cases := map[string]struct {
+		failbackPolicy string
+		// wait is the RequeueAfter when node1 recovers
+		wait  time.Duration
+		nodes []string
+	}{
+		"never": {
+			failbackPolicy: egress.FailbackNever,
+			nodes:          []string{"node2"},
+		},
+		"immediate": {
+			failbackPolicy: egress.FailbackImmediate,
+			nodes:          []string{"node1"},
+		},
+		"after delay": {
+			failbackPolicy: egress.FailbackAfterDelay,
+			wait:           10 * time.Second,
+			nodes:          []string{"node1"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			egp := &egress.EgressPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+				Spec: egress.EgressPolicySpec{
+					EgressGatewayName: "eg",
+					EgressIP:          egress.EgressIP{UseNodeIP: true},
+				},
+			}
+			defaultEgp := &egress.EgressPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "default-eip"},
+				Spec:       egress.EgressPolicySpec{EgressGatewayName: "eg"},
+			}
+			eg := &egress.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+				Spec: egress.EgressGatewaySpec{
+					Ippools:              egress.Ippools{IPv4: []string{"10.6.1.21"}, Ipv4DefaultEIP: "10.6.1.21"},
+					FailbackPolicy:       c.failbackPolicy,
+					FailbackDelaySeconds: 10,
+				},
+				Status: egress.EgressGatewayStatus{NodeList: []egress.EgressIPStatus{
+					{Name: "node1", Eips: []egress.Eips{
+						{Policies: []egress.Policy{policy}},
+						{IPv4: "10.6.1.21", Policies: []egress.Policy{defaultPolicy}},
+					}},
+					{Name: "node2"},
+				}},
+			}
+			node1 := &egress.EgressNode{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Status:     egress.EgressNodeStatus{Phase: egress.EgressNodeFailed},
+			}
+			node2 := &egress.EgressNode{
+				ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+				Status:     egress.EgressNodeStatus{Phase: egress.EgressNodeSucceeded},
+			}
+			cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).
+				WithObjects(egp, defaultEgp, eg, node1, node2).WithStatusSubresource(eg, node1).Build()
+			fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+			r := egnReconciler{
+				client: cli,
+				log:    zap.NewNop(),
+				config: &config.Config{FileConfig: config.FileConfig{EnableIPv4: true}},
+				clock:  fakeClock,
+
+				recorder: &record.FakeRecorder{},
+			}
+
+			ctx := context.Background()
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}}
+			_, err := r.reconcileEN(ctx, req, zap.NewNop())
+			assert.NoError(t, err)
+
+			res := new(egress.EgressGateway)
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg"}, res))
+			assert.Equal(t, []string{"node2"}, GetNodesByPolicy(policy, *res))
+
+			// node1 recovers
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "node1"}, node1))
+			node1.Status.Phase = egress.EgressNodeSucceeded
+			assert.NoError(t, cli.Status().Update(ctx, node1))
+
+			result, err := r.reconcileEN(ctx, req, zap.NewNop())
+			assert.NoError(t, err)
+			assert.Equal(t, c.wait, result.RequeueAfter)
+			if c.wait > 0 {
+				assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg"}, res))
+				assert.Equal(t, []string{"node2"}, GetNodesByPolicy(policy, *res))
+				assert.Len(t, res.Status.PreferredNodes, 1)
+
+				fakeClock.SetTime(fakeClock.Now().Add(c.wait))
+				result, err = r.reconcileEN(ctx, req, zap.NewNop())
+				assert.NoError(t, err)
+				assert.Zero(t, result.RequeueAfter)
+			}
+
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg"}, res))
+			assert.Equal(t, c.nodes, GetNodesByPolicy(policy, *res))
+			assert.Equal(t, c.nodes, GetNodesByPolicy(defaultPolicy, *res))
+			assert.Empty(t, res.Status.PreferredNodes)
+		})
+	}
+}
+
+func TestAllocatorEIPLargePool(t *testing.T) {
+	policy := egress.Policy{Namespace: "default", Name: "policy"}
+	eg := egress.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Spec:       egress.EgressGatewaySpec{Ippools: egress.Ippools{IPv6: []string{"fd00::/64"}}},
+		Status: egress.EgressGatewayStatus{NodeList: []egress.EgressIPStatus{
+			{Name: "node1", Eips: []egress.Eips{{IPv6: "fd00::1", Policies: []egress.Policy{policy}}}},
+		}},
+	}
+	r := egnReconciler{
+		log:    zap.NewNop(),
+		config: &config.Config{FileConfig: config.FileConfig{EnableIPv6: true}},
+	}
+
+	_, ipv6, err := r.allocatorEIP("", "node1", policyInfo{policy: policy}, eg)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "fd00::1", ipv6)
+	_, cidr, _ := net.ParseCIDR("fd00::/64")
+	assert.True(t, cidr.Contains(net.ParseIP(ipv6)))
+
+	// the specified EIP must be in the pool
+	_, _, err = r.allocatorEIP("", "node1", policyInfo{policy: policy, ipv6: "fd00:0:0:1::1"}, eg)
+	assert.Error(t, err)
+}
+
+func TestCheckIppoolsOverlap(t *testing.T) {
+	set, err := utils.NewIPRangeSet(constant.IPv4, []string{"10.6.1.21-10.6.1.30"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.True(t, checkIppoolsOverlap(set, []string{"10.6.1.31-10.6.1.40"}, "other").Allowed)
+
+	resp := checkIppoolsOverlap(set, []string{"10.6.1.25"}, "other")
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, metav1.StatusReason("IppoolsOverlap"), resp.Result.Reason)
+
+	// a pool which can not be parsed is not skipped
+	resp = checkIppoolsOverlap(set, []string{"10.6.1.25-bad"}, "other")
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, metav1.StatusReason("InvalidIppools"), resp.Result.Reason)
+}
@@ -40,13 +40,13 @@ func (egw *EgressGatewayWebhook) EgressGatewayValidate(ctx context.Context, req
 		delEG := new(egress.EgressGateway)
 		err := json.Unmarshal(req.OldObject.Raw, delEG)
 		if err != nil {
-			return webhook.Denied(fmt.Sprintf("json unmarshal EgressGateway with error: %v", err))
+			return utils.Denied("MalformedObject", fmt.Sprintf("json unmarshal EgressGateway with error: %v", err))
 		}
 
 		for _, item := range delEG.Status.NodeList {
 			for _, eip := range item.Eips {
 				if len(eip.Policies) != 0 {
-					return webhook.Denied(fmt.Sprintf("Do not delete %v:%v because it is already referenced by EgressPolicy", req.Namespace, req.Name))
+					return utils.Denied("EgressGatewayInUse", fmt.Sprintf("Do not delete %v:%v because it is already referenced by EgressPolicy", req.Namespace, req.Name))
 				}
 			}
 		}
@@ -56,36 +56,59 @@ func (egw *EgressGatewayWebhook) EgressGatewayValidate(ctx context.Context, req
 	newEg := new(egress.EgressGateway)
 	err := json.Unmarshal(req.Object.Raw, newEg)
 	if err != nil {
-		return webhook.Denied(fmt.Sprintf("json unmarshal EgressGateway with error: %v", err))
+		return utils.Denied("MalformedObject", fmt.Sprintf("json unmarshal EgressGateway with error: %v", err))
+	}
+
+	// a stored unknown policy is kept, so that the other fields can still be
+	// updated, the controller selects the nodes by the default policy
+	oldPolicy := ""
+	if req.Operation == v1.Update {
+		oldEg := new(egress.EgressGateway)
+		if err := json.Unmarshal(req.OldObject.Raw, oldEg); err != nil {
+			return utils.Denied("MalformedObject", fmt.Sprintf("json unmarshal EgressGateway with error: %v", err))
+		}
+		oldPolicy = oldEg.Spec.NodeSelector.Policy
+	}
+	if newEg.Spec.NodeSelector.Policy != oldPolicy && !egress.IsValidNodeSelectPolicy(newEg.Spec.NodeSelector.Policy) {
+		return utils.Denied("InvalidNodeSelector", fmt.Sprintf("invalid nodeSelector.policy %q, it should be one of %s, %s, %s, %s",
+			newEg.Spec.NodeSelector.Policy, egress.NodeSelectLeastPolicies, egress.NodeSelectRR,
+			egress.NodeSelectWeighted, egress.NodeSelectConsistentHash))
 	}
 
 	// Checking the number of IPV4 and IPV6 addresses
-	var ipv4s, ipv6s []net.IP
-	ipv4Ranges, err := utils.MergeIPRanges(constant.IPv4, newEg.Spec.Ippools.IPv4)
+	ipv4Set, err := utils.NewIPRangeSet(constant.IPv4, newEg.Spec.Ippools.IPv4)
 	if err != nil {
-		return webhook.Denied(fmt.Sprintf("Failed to check IP: %v", err))
+		return utils.Denied("InvalidIppools", fmt.Sprintf("Failed to check IP: %v", err))
 	}
 
-	ipv6Ranges, _ := utils.MergeIPRanges(constant.IPv6, newEg.Spec.Ippools.IPv6)
+	ipv6Set, err := utils.NewIPRangeSet(constant.IPv6, newEg.Spec.Ippools.IPv6)
 	if err != nil {
-		return webhook.Denied(fmt.Sprintf("Failed to check IP: %v", err))
+		return utils.Denied("InvalidIppools", fmt.Sprintf("Failed to check IP: %v", err))
 	}
 
-	if egw.Config.FileConfig.EnableIPv4 {
-		ipv4s, err = utils.ParseIPRanges(constant.IPv4, ipv4Ranges)
-		if err != nil {
-			return webhook.Denied(fmt.Sprintf("Failed to check IP: %v", err))
-		}
+	egList := new(egress.EgressGatewayList)
+	if err := egw.Client.List(ctx, egList); err != nil {
+		return utils.Denied("InternalError", fmt.Sprintf("failed to list EgressGateway: %v", err))
 	}
-	if egw.Config.FileConfig.EnableIPv6 {
-		ipv6s, err = utils.ParseIPRanges(constant.IPv6, ipv6Ranges)
-		if err != nil {
-			return webhook.Denied(fmt.Sprintf("Failed to check IP: %v", err))
+	for _, item := range egList.Items {
+		if item.Name == newEg.Name {
+			continue
+		}
+		if newEg.Spec.ClusterDefault && item.Spec.ClusterDefault {
+			return utils.Denied("DefaultEgressGatewayExists", fmt.Sprintf("EgressGateway %s is already the cluster default", item.Name))
+		}
+		// an EIP can only be bound by one EgressGateway
+		if resp := checkIppoolsOverlap(ipv4Set, item.Spec.Ippools.IPv4, item.Name); !resp.Allowed {
+			return resp
+		}
+		if resp := checkIppoolsOverlap(ipv6Set, item.Spec.Ippools.IPv6, item.Name); !resp.Allowed {
+			return resp
 		}
 	}
+
 	if egw.Config.FileConfig.EnableIPv4 && egw.Config.FileConfig.EnableIPv6 {
-		if len(ipv4s) != len(ipv6s) {
-			return webhook.Denied("The number of ipv4 and ipv6 is not equal")
+		if ipv4Set.Size().Cmp(ipv6Set.Size()) != 0 {
+			return utils.Denied("InvalidIppools", "The number of ipv4 and ipv6 is not equal")
 		}
 	}
 
@@ -93,45 +116,53 @@ func (egw *EgressGatewayWebhook) EgressGatewayValidate(ctx context.Context, req
 	err = egw.Client.Get(ctx, types.NamespacedName{Name: req.Name, Namespace: req.Namespace}, eg)
 	if err != nil {
 		if !errors.IsNotFound(err) {
-			return webhook.Denied(fmt.Sprintf("failed to obtain the EgressGateway: %v", err))
+			return utils.Denied("InternalError", fmt.Sprintf("failed to obtain the EgressGateway: %v", err))
 		}
 	}
 
 	// Check whether the IP address to be deleted has been allocated
 	for _, item := range eg.Status.NodeList {
 		for _, eip := range item.Eips {
-			result, err := utils.IsIPIncludedRange(constant.IPv4, eip.IPv4, ipv4Ranges)
-			if err != nil {
-				return webhook.Denied(fmt.Sprintf("Failed to check IP: %v", err))
+			if len(eip.IPv4) != 0 && !ipv4Set.Contains(net.ParseIP(eip.IPv4)) {
+				return utils.Denied("EIPInUse", fmt.Sprintf("%v has been allocated and cannot be deleted", eip.IPv4))
 			}
-
-			if !result {
-				return webhook.Denied(fmt.Sprintf("%v has been allocated and cannot be deleted", eip.IPv4))
+			if len(eip.IPv6) != 0 && !ipv6Set.Contains(net.ParseIP(eip.IPv6)) {
+				return utils.Denied("EIPInUse", fmt.Sprintf("%v has been allocated and cannot be deleted", eip.IPv6))
 			}
 		}
 	}
 
 	// Check the defaultEIP
 	if len(newEg.Spec.Ippools.Ipv4DefaultEIP) != 0 {
-		result, err := utils.IsIPIncludedRange(constant.IPv4, newEg.Spec.Ippools.Ipv4DefaultEIP, ipv4Ranges)
-		if err != nil {
-			return webhook.Denied(fmt.Sprintf("Failed to check Ipv4DefaultEIP: %v", err))
-		}
-		if !result {
-			return webhook.Denied(fmt.Sprintf("%v is not covered by Ippools", newEg.Spec.Ippools.Ipv4DefaultEIP))
+		if !ipv4Set.Contains(net.ParseIP(newEg.Spec.Ippools.Ipv4DefaultEIP)) {
+			return utils.Denied("InvalidDefaultEIP", fmt.Sprintf("%v is not covered by Ippools", newEg.Spec.Ippools.Ipv4DefaultEIP))
 		}
 	}
 
 	if len(newEg.Spec.Ippools.Ipv6DefaultEIP) != 0 {
-		result, err := utils.IsIPIncludedRange(constant.IPv6, newEg.Spec.Ippools.Ipv6DefaultEIP, ipv6Ranges)
+		if !ipv6Set.Contains(net.ParseIP(newEg.Spec.Ippools.Ipv6DefaultEIP)) {
+			return utils.Denied("InvalidDefaultEIP", fmt.Sprintf("%v is not covered by Ippools", newEg.Spec.Ippools.Ipv6DefaultEIP))
+		}
+	}
+
+	resp := webhook.Allowed("checked")
+	if len(newEg.Spec.Ippools.IPv4) == 0 && len(newEg.Spec.Ippools.IPv6) == 0 {
+		resp = resp.WithWarnings("the ippools are empty, only the policies using the node IP take effect")
+	}
+	return resp
+}
+
+// checkIppoolsOverlap denies the ippools overlapping the ippools of another EgressGateway
+func checkIppoolsOverlap(set *utils.IPRangeSet, ippools []string, name string) webhook.AdmissionResponse {
+	for _, item := range ippools {
+		overlap, err := set.Overlaps(item)
 		if err != nil {
-			return webhook.Denied(fmt.Sprintf("Failed to check Ipv6DefaultEIP: %v", err))
+			return utils.Denied("InvalidIppools", fmt.Sprintf("failed to check the ippools %s of EgressGateway %s: %v", item, name, err))
 		}
-		if !result {
-			return webhook.Denied(fmt.Sprintf("%v is not covered by Ippools", newEg.Spec.Ippools.Ipv6DefaultEIP))
+		if overlap {
+			return utils.Denied("IppoolsOverlap", fmt.Sprintf("the ippools overlap %s of EgressGateway %s", item, name))
 		}
 	}
-
 	return webhook.Allowed("checked")
 }
 
@@ -141,7 +172,7 @@ func (egw *EgressGatewayWebhook) EgressGatewayMutate(ctx context.Context, req we
 	eg := new(egress.EgressGateway)
 	err := json.Unmarshal(req.Object.Raw, eg)
 	if err != nil {
-		return webhook.Denied(fmt.Sprintf("json unmarshal EgressGateway with error: %v", err))
+		return utils.Denied("MalformedObject", fmt.Sprintf("json unmarshal EgressGateway with error: %v", err))
 	}
 
 	reviewResponse := webhook.AdmissionResponse{}
@@ -149,17 +180,16 @@ func (egw *EgressGatewayWebhook) EgressGatewayMutate(ctx context.Context, req we
 
 	if egw.Config.FileConfig.EnableIPv4 {
 		if len(eg.Spec.Ippools.Ipv4DefaultEIP) == 0 && len(eg.Spec.Ippools.IPv4) != 0 {
-			ipv4Ranges, err := utils.MergeIPRanges(constant.IPv4, eg.Spec.Ippools.IPv4)
+			ipv4Set, err := utils.NewIPRangeSet(constant.IPv4, eg.Spec.Ippools.IPv4)
 			if err != nil {
-				return webhook.Denied(fmt.Sprintf("ippools.ipv4 format error: %v", err))
+				return utils.Denied("InvalidIppools", fmt.Sprintf("ippools.ipv4 format error: %v", err))
 			}
 
-			ipv4s, _ := utils.ParseIPRanges(constant.IPv4, ipv4Ranges)
-			if len(ipv4s) != 0 {
+			if ip, ok := ipv4Set.RandomFree(rander, nil); ok {
 				patch = append(patch, patchOperation{
 					Op:    "add",
 					Path:  "/spec/ippools/ipv4DefaultEIP",
-					Value: ipv4s[rander.Intn(len(ipv4s))].String(),
+					Value: ip.String(),
 				})
 				isPatch = true
 			}
@@ -170,17 +200,16 @@ func (egw *EgressGatewayWebhook) EgressGatewayMutate(ctx context.Context, req we
 
 	if egw.Config.FileConfig.EnableIPv6 {
 		if len(eg.Spec.Ippools.Ipv6DefaultEIP) == 0 && len(eg.Spec.Ippools.IPv6) != 0 {
-			ipv6Ranges, err := utils.MergeIPRanges(constant.IPv6, eg.Spec.Ippools.IPv6)
+			ipv6Set, err := utils.NewIPRangeSet(constant.IPv6, eg.Spec.Ippools.IPv6)
 			if err != nil {
-				return webhook.Denied(fmt.Sprintf("ippools.ipv6 format error: %v", err))
+				return utils.Denied("InvalidIppools", fmt.Sprintf("ippools.ipv6 format error: %v", err))
 			}
 
-			ipv6s, _ := utils.ParseIPRanges(constant.IPv6, ipv6Ranges)
-			if len(ipv6s) != 0 {
+			if ip, ok := ipv6Set.RandomFree(rander, nil); ok {
 				patch = append(patch, patchOperation{
 					Op:    "add",
 					Path:  "/spec/ippools/ipv6DefaultEIP",
-					Value: ipv6s[rander.Intn(len(ipv6s))].String(),
+					Value: ip.String(),
 				})
 				isPatch = true
 			}
@@ -191,7 +220,7 @@ func (egw *EgressGatewayWebhook) EgressGatewayMutate(ctx context.Context, req we
 	if isPatch {
 		patchBytes, err := json.Marshal(patch)
 		if err != nil {
-			return webhook.Denied(fmt.Sprintf("failed to allocate defaultEIP.: %v", err))
+			return utils.Denied("InvalidDefaultEIP", fmt.Sprintf("failed to allocate defaultEIP.: %v", err))
 		}
 
 		reviewResponse.Allowed = true
@@ -0,0 +1,109 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package egressgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
+	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// the reasons of the Events of the policies and the EgressGateways
+const (
+	// eventAssigned is recorded on a policy when its gateway nodes or EIPs are changed
+	eventAssigned = "Assigned"
+	// eventReallocated is recorded on a policy moved to another gateway node
+	eventReallocated = "Reallocated"
+	// eventAllocationFailed is recorded on a policy when no gateway node or EIP can be allocated
+	eventAllocationFailed = "AllocationFailed"
+	// eventEIPExhausted is recorded on an EgressGateway when its ippools have no EIP left
+	eventEIPExhausted = "EIPExhausted"
+)
+
+// errEIPExhausted is returned by allocatorEIP when the ippools have no EIP left
+var errEIPExhausted = errors.New("No EIP meeting requirements is found")
+
+// policyObject returns the EgressPolicy or the EgressClusterPolicy of the policy
+func (r egnReconciler) policyObject(ctx context.Context, policy egress.Policy) (client.Object, error) {
+	var obj client.Object = &egress.EgressPolicy{}
+	if policy.Namespace == "" {
+		obj = &egress.EgressClusterPolicy{}
+	}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}, obj)
+	return obj, err
+}
+
+// describeGateways returns the gateway nodes of the policy and their EIPs
+func describeGateways(policy egress.Policy, eg egress.EgressGateway) string {
+	var res []string
+	for _, item := range GetPolicyGateways(policy, eg) {
+		var eips []string
+		for _, ip := range []string{item.Ipv4, item.Ipv6} {
+			if ip != "" {
+				eips = append(eips, ip)
+			}
+		}
+		if len(eips) == 0 {
+			eips = append(eips, "node IP")
+		}
+		res = append(res, fmt.Sprintf("%s (%s)", item.Node, strings.Join(eips, ", ")))
+	}
+	if len(res) == 0 {
+		return "none"
+	}
+	return strings.Join(res, ", ")
+}
+
+// recordAssigned records an Event on the policy when its gateway nodes or
+// EIPs are changed from old
+func (r egnReconciler) recordAssigned(ctx context.Context, policy egress.Policy, eg egress.EgressGateway, old string) {
+	assigned := describeGateways(policy, eg)
+	if assigned == old {
+		return
+	}
+	obj, err := r.policyObject(ctx, policy)
+	if err != nil {
+		return
+	}
+	r.recorder.Eventf(obj, corev1.EventTypeNormal, eventAssigned,
+		"Gateway nodes of EgressGateway %s: %s", eg.Name, assigned)
+}
+
+// recordReallocations counts the policies moved to another gateway node of
+// eg and records an Event on each of them, reason is the reason of the move
+func (r egnReconciler) recordReallocations(ctx context.Context, eg egress.EgressGateway, reason string, policies []egress.Policy) {
+	if len(policies) == 0 {
+		return
+	}
+	metrics.PolicyReallocations.WithLabelValues(eg.Name, reason).Add(float64(len(policies)))
+	for _, policy := range policies {
+		obj, err := r.policyObject(ctx, policy)
+		if err != nil {
+			continue
+		}
+		r.recorder.Eventf(obj, corev1.EventTypeNormal, eventReallocated,
+			"Moved by %s, gateway nodes of EgressGateway %s: %s", reason, eg.Name, describeGateways(policy, eg))
+	}
+}
+
+// recordAllocationFailure records the failure to allocate a gateway node or
+// an EIP for the policy, the exhaustion of the ippools is also recorded on eg
+func (r egnReconciler) recordAllocationFailure(ctx context.Context, policy egress.Policy, eg *egress.EgressGateway, err error) {
+	if obj, getErr := r.policyObject(ctx, policy); getErr == nil {
+		r.recorder.Eventf(obj, corev1.EventTypeWarning, eventAllocationFailed,
+			"Failed to allocate a gateway node of EgressGateway %s: %v", eg.Name, err)
+	}
+	if errors.Is(err, errEIPExhausted) {
+		r.recorder.Eventf(eg, corev1.EventTypeWarning, eventEIPExhausted,
+			"No EIP is left for policy %s: %v", policy, err)
+	}
+}
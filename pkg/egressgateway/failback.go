@@ -0,0 +1,183 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package egressgateway
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+const defaultFailbackDelay = 30 * time.Second
+
+func failbackEnabled(eg egress.EgressGateway) bool {
+	switch eg.Spec.FailbackPolicy {
+	case egress.FailbackImmediate, egress.FailbackAfterDelay:
+		return true
+	}
+	return false
+}
+
+func failbackDelay(eg egress.EgressGateway) time.Duration {
+	if eg.Spec.FailbackDelaySeconds > 0 {
+		return time.Duration(eg.Spec.FailbackDelaySeconds) * time.Second
+	}
+	return defaultFailbackDelay
+}
+
+// recordPreferredNode remembers the policies moved away from the failed node,
+// the hold-down timer of the node is restarted
+func recordPreferredNode(status *egress.EgressGatewayStatus, nodeName string, policies []egress.Policy) {
+	for i, item := range status.PreferredNodes {
+		if item.Name != nodeName {
+			continue
+		}
+		status.PreferredNodes[i].ReadyTime = nil
+		for _, policy := range policies {
+			if !containsPolicy(item.Policies, policy) {
+				status.PreferredNodes[i].Policies = append(status.PreferredNodes[i].Policies, policy)
+			}
+		}
+		return
+	}
+	if len(policies) == 0 {
+		return
+	}
+	status.PreferredNodes = append(status.PreferredNodes, egress.PreferredNode{
+		Name:     nodeName,
+		Policies: append([]egress.Policy(nil), policies...),
+	})
+}
+
+// prunePreferredNodes drops the records of the nodes which are no longer
+// gateway nodes, all the records are dropped when failback is disabled
+func prunePreferredNodes(eg *egress.EgressGateway) {
+	if !failbackEnabled(*eg) {
+		eg.Status.PreferredNodes = nil
+		return
+	}
+	var res []egress.PreferredNode
+	for _, item := range eg.Status.PreferredNodes {
+		if _, isExist := GetPoliciesByNode(item.Name, *eg); isExist {
+			res = append(res, item)
+		}
+	}
+	eg.Status.PreferredNodes = res
+}
+
+// failback moves the policies recorded for the recovered node back to it by
+// the failbackPolicy of eg. It reports whether the status of eg is changed, the
+// moved policies and how long to wait for the hold-down timer.
+func (r egnReconciler) failback(ctx context.Context, nodeName string, eg *egress.EgressGateway) (bool, []egress.Policy, time.Duration, error) {
+	index := -1
+	for i, item := range eg.Status.PreferredNodes {
+		if item.Name == nodeName {
+			index = i
+			break
+		}
+	}
+	if index < 0 || !failbackEnabled(*eg) {
+		return false, nil, 0, nil
+	}
+
+	isUpdate := false
+	preferred := &eg.Status.PreferredNodes[index]
+	if eg.Spec.FailbackPolicy == egress.FailbackAfterDelay {
+		now := r.clock.Now()
+		if preferred.ReadyTime == nil {
+			readyTime := metav1.NewTime(now)
+			preferred.ReadyTime = &readyTime
+			isUpdate = true
+		}
+		if wait := preferred.ReadyTime.Add(failbackDelay(*eg)).Sub(now); wait > 0 {
+			return isUpdate, nil, wait, nil
+		}
+	}
+
+	var moved []egress.Policy
+	for _, policy := range preferred.Policies {
+		if _, err := r.getPolicyInfo(ctx, policy); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return isUpdate, nil, 0, err
+		}
+		if movePolicyToNode(policy, nodeName, eg) {
+			moved = append(moved, policy)
+			r.log.Sugar().Infof("move EgressPolicy %v back to the recovered gateway node %s", policy, nodeName)
+		}
+	}
+	eg.Status.PreferredNodes = append(eg.Status.PreferredNodes[:index], eg.Status.PreferredNodes[index+1:]...)
+	return true, moved, 0, nil
+}
+
+// movePolicyToNode moves the policy to the gateway node. The EIP of the policy
+// is moved together with all the policies using it, a policy using the node
+// IP is moved alone. It reports whether the policy is moved.
+func movePolicyToNode(policy egress.Policy, nodeName string, eg *egress.EgressGateway) bool {
+	target := -1
+	for i, item := range eg.Status.NodeList {
+		if item.Name == nodeName {
+			target = i
+			break
+		}
+	}
+	if target < 0 || nodeHasPolicy(policy, eg.Status.NodeList[target]) {
+		return false
+	}
+
+	for i, item := range eg.Status.NodeList {
+		if i == target {
+			continue
+		}
+		for j, eip := range item.Eips {
+			if !containsPolicy(eip.Policies, policy) {
+				continue
+			}
+			if eip.IPv4 == "" && eip.IPv6 == "" {
+				deletePolicyFromNode(policy, item.Name, eg)
+				addEipToNode(&eg.Status.NodeList[target], egress.Eips{Policies: []egress.Policy{policy}})
+				return true
+			}
+			// the EIP can not be bound to two nodes
+			for _, p := range eip.Policies {
+				if nodeHasPolicy(p, eg.Status.NodeList[target]) {
+					return false
+				}
+			}
+			eg.Status.NodeList[i].Eips = append(item.Eips[:j:j], item.Eips[j+1:]...)
+			addEipToNode(&eg.Status.NodeList[target], eip)
+			return true
+		}
+	}
+	return false
+}
+
+// addEipToNode merges the policies of eip into the same EIP of the node
+func addEipToNode(node *egress.EgressIPStatus, eip egress.Eips) {
+	for i, item := range node.Eips {
+		if item.IPv4 == eip.IPv4 && item.IPv6 == eip.IPv6 {
+			for _, policy := range eip.Policies {
+				if !containsPolicy(item.Policies, policy) {
+					node.Eips[i].Policies = append(node.Eips[i].Policies, policy)
+				}
+			}
+			return
+		}
+	}
+	node.Eips = append(node.Eips, eip)
+}
+
+func containsPolicy(policies []egress.Policy, policy egress.Policy) bool {
+	for _, p := range policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package egressgateway
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/spidernet-io/egressgateway/pkg/constant"
+	"github.com/spidernet-io/egressgateway/pkg/controller/metrics"
+	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+// updateEGStatus refreshes the computed fields of the status and updates it
+func (r egnReconciler) updateEGStatus(ctx context.Context, eg *egress.EgressGateway) error {
+	if err := r.refreshEGStatus(ctx, eg); err != nil {
+		return err
+	}
+	return r.client.Status().Update(ctx, eg)
+}
+
+// syncEGStatus updates the status only when the computed fields are changed,
+// such as the phase of an EgressNode or the ippools of the EgressGateway
+func (r egnReconciler) syncEGStatus(ctx context.Context, eg *egress.EgressGateway) error {
+	old := eg.Status.DeepCopy()
+	if err := r.refreshEGStatus(ctx, eg); err != nil {
+		return err
+	}
+	if reflect.DeepEqual(old, &eg.Status) {
+		return nil
+	}
+	r.log.Sugar().Debugf("update egress gateway status\n%s", mustMarshalJson(eg.Status))
+	return r.client.Status().Update(ctx, eg)
+}
+
+// refreshEGStatus computes the EIP usage, the node readiness and the conditions of eg
+func (r egnReconciler) refreshEGStatus(ctx context.Context, eg *egress.EgressGateway) error {
+	enList := &egress.EgressNodeList{}
+	if err := r.client.List(ctx, enList); err != nil {
+		return fmt.Errorf("failed to list EgressNode: %v", err)
+	}
+	phases := make(map[string]egress.EgressNodePhase, len(enList.Items))
+	for _, en := range enList.Items {
+		phases[en.Name] = en.Status.Phase
+	}
+
+	status := &eg.Status
+	status.ReadyNodes = 0
+	for i, node := range status.NodeList {
+		status.NodeList[i].Status = string(phases[node.Name])
+		if phases[node.Name] == egress.EgressNodeSucceeded {
+			status.ReadyNodes++
+		}
+	}
+
+	usage, err := r.ipUsage(eg)
+	if err != nil {
+		return err
+	}
+	status.IPUsage = usage
+
+	status.DefaultEIP = egress.DefaultEIPStatus{
+		IPv4: eg.Spec.Ippools.Ipv4DefaultEIP,
+		IPv6: eg.Spec.Ippools.Ipv6DefaultEIP,
+	}
+	if status.DefaultEIP.IPv4 != "" {
+		status.DefaultEIP.Node = GetNodeByIP(status.DefaultEIP.IPv4, *eg)
+	} else if status.DefaultEIP.IPv6 != "" {
+		status.DefaultEIP.Node = getNodeByIPv6(status.DefaultEIP.IPv6, *eg)
+	}
+
+	prunePreferredNodes(eg)
+
+	for _, condition := range r.gatewayConditions(eg) {
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+	recordGatewayMetrics(eg)
+	return nil
+}
+
+// recordGatewayMetrics records the EIP usage and the policies of the gateway
+// nodes of eg, the series of the removed nodes are dropped
+func recordGatewayMetrics(eg *egress.EgressGateway) {
+	usage := eg.Status.IPUsage
+	metrics.DeleteGateway(eg.Name)
+	metrics.GatewayEIPAllocated.WithLabelValues(eg.Name, "ipv4").Set(float64(usage.IPv4Allocated))
+	metrics.GatewayEIPTotal.WithLabelValues(eg.Name, "ipv4").Set(float64(usage.IPv4Total))
+	metrics.GatewayEIPAllocated.WithLabelValues(eg.Name, "ipv6").Set(float64(usage.IPv6Allocated))
+	metrics.GatewayEIPTotal.WithLabelValues(eg.Name, "ipv6").Set(float64(usage.IPv6Total))
+	for _, node := range eg.Status.NodeList {
+		metrics.GatewayNodePolicies.WithLabelValues(eg.Name, node.Name).Set(float64(countPolicies(node)))
+	}
+}
+
+// maxIPCount is the max total of IPUsage, the total of a large IPv6 pool is
+// saturated to it, so that it is still an exact integer in JSON
+const maxIPCount = 1<<53 - 1
+
+// ipUsage counts the EIPs of the enabled IP families, an EIP is allocated
+// when it is bound to a gateway node
+func (r egnReconciler) ipUsage(eg *egress.EgressGateway) (egress.IPUsage, error) {
+	res := egress.IPUsage{}
+	var allocated []net.IP
+	for _, node := range eg.Status.NodeList {
+		for _, eip := range node.Eips {
+			if eip.IPv4 != "" {
+				allocated = append(allocated, net.ParseIP(eip.IPv4))
+			}
+			if eip.IPv6 != "" {
+				allocated = append(allocated, net.ParseIP(eip.IPv6))
+			}
+		}
+	}
+
+	count := func(version constant.IPVersion, pool []string) (int64, int64, error) {
+		set, err := utils.NewIPRangeSet(version, pool)
+		if err != nil {
+			return 0, 0, err
+		}
+		total := int64(maxIPCount)
+		if size := set.Size(); size.Cmp(big.NewInt(maxIPCount)) < 0 {
+			total = size.Int64()
+		}
+		return total, set.CountIn(allocated), nil
+	}
+
+	var err error
+	if r.config.FileConfig.EnableIPv4 {
+		res.IPv4Total, res.IPv4Allocated, err = count(constant.IPv4, eg.Spec.Ippools.IPv4)
+		if err != nil {
+			return res, fmt.Errorf("failed to count ippools.ipv4: %v", err)
+		}
+		res.IPv4Free = res.IPv4Total - res.IPv4Allocated
+	}
+	if r.config.FileConfig.EnableIPv6 {
+		res.IPv6Total, res.IPv6Allocated, err = count(constant.IPv6, eg.Spec.Ippools.IPv6)
+		if err != nil {
+			return res, fmt.Errorf("failed to count ippools.ipv6: %v", err)
+		}
+		res.IPv6Free = res.IPv6Total - res.IPv6Allocated
+	}
+	return res, nil
+}
+
+func (r egnReconciler) gatewayConditions(eg *egress.EgressGateway) []metav1.Condition {
+	usage := eg.Status.IPUsage
+	exhausted := metav1.Condition{
+		Type:               egress.GatewayConditionPoolExhausted,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: eg.Generation,
+		Reason:             "FreeIPAvailable",
+	}
+	var families []string
+	if usage.IPv4Total > 0 && usage.IPv4Free == 0 {
+		families = append(families, "IPv4")
+	}
+	if usage.IPv6Total > 0 && usage.IPv6Free == 0 {
+		families = append(families, "IPv6")
+	}
+	if len(families) > 0 {
+		exhausted.Status = metav1.ConditionTrue
+		exhausted.Reason = "NoFreeIP"
+		exhausted.Message = fmt.Sprintf("no free %v EIP, new policies reuse the EIPs of the gateway node", families)
+	}
+
+	noReady := metav1.Condition{
+		Type:               egress.GatewayConditionNoReadyNodes,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: eg.Generation,
+		Reason:             "ReadyNodesAvailable",
+		Message:            fmt.Sprintf("%d/%d gateway nodes are ready", eg.Status.ReadyNodes, len(eg.Status.NodeList)),
+	}
+	if len(eg.Status.NodeList) == 0 {
+		noReady.Status = metav1.ConditionTrue
+		noReady.Reason = "NoNodeSelected"
+		noReady.Message = "no node matches the nodeSelector"
+	} else if eg.Status.ReadyNodes == 0 {
+		noReady.Status = metav1.ConditionTrue
+		noReady.Reason = "NoReadyNode"
+	}
+
+	return []metav1.Condition{exhausted, noReady}
+}
+
+func getNodeByIPv6(ipv6 string, eg egress.EgressGateway) string {
+	ip := net.ParseIP(ipv6)
+	for _, node := range eg.Status.NodeList {
+		for _, eip := range node.Eips {
+			if eip.IPv6 != "" && net.ParseIP(eip.IPv6).Equal(ip) {
+				return node.Name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package egressgateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestRefreshEGStatus(t *testing.T) {
+	policy := egress.Policy{Namespace: "default", Name: "policy"}
+	cases := map[string]struct {
+		ipv4       []string
+		ipv6       []string
+		nodeList   []egress.EgressIPStatus
+		phases     map[string]egress.EgressNodePhase
+		expUsage   egress.IPUsage
+		expDefault egress.DefaultEIPStatus
+		expReady   int32
+		expPool    metav1.ConditionStatus
+		expNoReady metav1.ConditionStatus
+		expReason  string
+	}{
+		"free ip available": {
+			ipv4: []string{"10.6.1.21-10.6.1.23"},
+			ipv6: []string{"fd00::21-fd00::22"},
+			nodeList: []egress.EgressIPStatus{
+				{Name: "node1", Eips: []egress.Eips{{IPv4: "10.6.1.21", IPv6: "fd00::21", Policies: []egress.Policy{policy}}}},
+				{Name: "node2"},
+			},
+			phases: map[string]egress.EgressNodePhase{"node1": egress.EgressNodeSucceeded, "node2": egress.EgressNodeFailed},
+			expUsage: egress.IPUsage{
+				IPv4Total: 3, IPv4Allocated: 1, IPv4Free: 2,
+				IPv6Total: 2, IPv6Allocated: 1, IPv6Free: 1,
+			},
+			expDefault: egress.DefaultEIPStatus{IPv4: "10.6.1.21", IPv6: "fd00::21", Node: "node1"},
+			expReady:   1,
+			expPool:    metav1.ConditionFalse,
+			expNoReady: metav1.ConditionFalse,
+			expReason:  "ReadyNodesAvailable",
+		},
+		"pool exhausted": {
+			ipv4: []string{"10.6.1.21-10.6.1.22"},
+			nodeList: []egress.EgressIPStatus{
+				{Name: "node1", Eips: []egress.Eips{{IPv4: "10.6.1.21"}, {IPv4: "10.6.1.22"}}},
+			},
+			phases:     map[string]egress.EgressNodePhase{"node1": egress.EgressNodeSucceeded},
+			expUsage:   egress.IPUsage{IPv4Total: 2, IPv4Allocated: 2},
+			expDefault: egress.DefaultEIPStatus{IPv4: "10.6.1.21", Node: "node1"},
+			expReady:   1,
+			expPool:    metav1.ConditionTrue,
+			expNoReady: metav1.ConditionFalse,
+			expReason:  "ReadyNodesAvailable",
+		},
+		"large ipv6 pool": {
+			ipv6: []string{"fd00::/64"},
+			nodeList: []egress.EgressIPStatus{
+				{Name: "node1", Eips: []egress.Eips{{IPv6: "fd00::21", Policies: []egress.Policy{policy}}}},
+			},
+			phases:     map[string]egress.EgressNodePhase{"node1": egress.EgressNodeSucceeded},
+			expUsage:   egress.IPUsage{IPv6Total: maxIPCount, IPv6Allocated: 1, IPv6Free: maxIPCount - 1},
+			expDefault: egress.DefaultEIPStatus{IPv6: "fd00::21", Node: "node1"},
+			expReady:   1,
+			expPool:    metav1.ConditionFalse,
+			expNoReady: metav1.ConditionFalse,
+			expReason:  "ReadyNodesAvailable",
+		},
+		"no ready node": {
+			ipv4:       []string{"10.6.1.21"},
+			nodeList:   []egress.EgressIPStatus{{Name: "node1"}, {Name: "node2"}},
+			phases:     map[string]egress.EgressNodePhase{"node1": egress.EgressNodePending},
+			expUsage:   egress.IPUsage{IPv4Total: 1, IPv4Free: 1},
+			expDefault: egress.DefaultEIPStatus{IPv4: "10.6.1.21"},
+			expPool:    metav1.ConditionFalse,
+			expNoReady: metav1.ConditionTrue,
+			expReason:  "NoReadyNode",
+		},
+		"no node selected": {
+			ipv4:       []string{"10.6.1.21"},
+			expUsage:   egress.IPUsage{IPv4Total: 1, IPv4Free: 1},
+			expDefault: egress.DefaultEIPStatus{IPv4: "10.6.1.21"},
+			expPool:    metav1.ConditionFalse,
+			expNoReady: metav1.ConditionTrue,
+			expReason:  "NoNodeSelected",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			eg := &egress.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+				Spec: egress.EgressGatewaySpec{Ippools: egress.Ippools{
+					IPv4: c.ipv4, IPv6: c.ipv6,
+					Ipv4DefaultEIP: c.expDefault.IPv4, Ipv6DefaultEIP: c.expDefault.IPv6,
+				}},
+				Status: egress.EgressGatewayStatus{NodeList: c.nodeList},
+			}
+			builder := fake.NewClientBuilder().WithScheme(schema.GetScheme())
+			builder.WithObjects(eg).WithStatusSubresource(eg)
+			for node, phase := range c.phases {
+				builder.WithObjects(&egress.EgressNode{
+					ObjectMeta: metav1.ObjectMeta{Name: node},
+					Status:     egress.EgressNodeStatus{Phase: phase},
+				})
+			}
+			cli := builder.Build()
+			r := egnReconciler{
+				client: cli,
+				log:    zap.NewNop(),
+				config: &config.Config{FileConfig: config.FileConfig{EnableIPv4: true, EnableIPv6: true}},
+			}
+
+			ctx := context.Background()
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg"}, eg))
+			assert.NoError(t, r.syncEGStatus(ctx, eg))
+
+			res := new(egress.EgressGateway)
+			assert.NoError(t, cli.Get(ctx, types.NamespacedName{Name: "eg"}, res))
+			assert.Equal(t, c.expUsage, res.Status.IPUsage)
+			assert.Equal(t, c.expDefault, res.Status.DefaultEIP)
+			assert.Equal(t, c.expReady, res.Status.ReadyNodes)
+			for _, node := range res.Status.NodeList {
+				assert.Equal(t, string(c.phases[node.Name]), node.Status)
+			}
+			pool := meta.FindStatusCondition(res.Status.Conditions, egress.GatewayConditionPoolExhausted)
+			assert.Equal(t, c.expPool, pool.Status)
+			noReady := meta.FindStatusCondition(res.Status.Conditions, egress.GatewayConditionNoReadyNodes)
+			assert.Equal(t, c.expNoReady, noReady.Status)
+			assert.Equal(t, c.expReason, noReady.Reason)
+
+			// nothing changes when synced again
+			version := res.ResourceVersion
+			assert.NoError(t, r.syncEGStatus(ctx, res))
+			assert.Equal(t, version, res.ResourceVersion)
+		})
+	}
+}
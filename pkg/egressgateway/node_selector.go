@@ -0,0 +1,190 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package egressgateway
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// nodeSelector selects a gateway node for the policy from nodeMap
+type nodeSelector interface {
+	Select(policy egress.Policy, nodeMap map[string]egress.EgressIPStatus) (string, error)
+}
+
+type leastPoliciesSelector struct{}
+
+// rrSelector keeps its cursor in the status of the gateway, which is updated
+// together with the policies assigned to the nodes
+type rrSelector struct {
+	gateway *egress.EgressGateway
+}
+
+type weightedSelector struct {
+	weights map[string]int
+}
+
+type consistentHashSelector struct{}
+
+// newNodeSelector returns the nodeSelector of the node select policy of eg,
+// an unknown policy stored before the policies were validated falls back to
+// the default one
+func (r egnReconciler) newNodeSelector(ctx context.Context, eg *egress.EgressGateway, nodeMap map[string]egress.EgressIPStatus) (nodeSelector, error) {
+	switch eg.Spec.NodeSelector.Policy {
+	case egress.NodeSelectRR:
+		return rrSelector{gateway: eg}, nil
+	case egress.NodeSelectWeighted:
+		weights := make(map[string]int, len(nodeMap))
+		for name := range nodeMap {
+			node := new(corev1.Node)
+			err := r.client.Get(ctx, types.NamespacedName{Name: name}, node)
+			if err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			weights[name] = nodeWeight(node)
+		}
+		return weightedSelector{weights: weights}, nil
+	case egress.NodeSelectConsistentHash:
+		return consistentHashSelector{}, nil
+	default:
+		return leastPoliciesSelector{}, nil
+	}
+}
+
+// nodeWeight returns the weight of the node, it is 1 when not set or invalid
+func nodeWeight(node *corev1.Node) int {
+	val, ok := node.Annotations[egress.LabelNodeWeight]
+	if !ok {
+		val, ok = node.Labels[egress.LabelNodeWeight]
+	}
+	if !ok {
+		return 1
+	}
+	weight, err := strconv.Atoi(val)
+	if err != nil || weight < 0 {
+		return 1
+	}
+	return weight
+}
+
+func sortedNodeNames(nodeMap map[string]egress.EgressIPStatus) []string {
+	names := make([]string, 0, len(nodeMap))
+	for name := range nodeMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func countPolicies(node egress.EgressIPStatus) int {
+	num := 0
+	for _, eip := range node.Eips {
+		num += len(eip.Policies)
+	}
+	return num
+}
+
+// Select the node with the fewest policies, the first one in name order on tie
+func (leastPoliciesSelector) Select(_ egress.Policy, nodeMap map[string]egress.EgressIPStatus) (string, error) {
+	if len(nodeMap) == 0 {
+		return "", fmt.Errorf("nodeList is empty")
+	}
+	perNode := ""
+	perNodePolicyNum := 0
+	for _, name := range sortedNodeNames(nodeMap) {
+		num := countPolicies(nodeMap[name])
+		if perNode == "" || num < perNodePolicyNum {
+			perNode = name
+			perNodePolicyNum = num
+		}
+	}
+	return perNode, nil
+}
+
+// Select the node after the last selected one in name order, it wraps to the
+// first node, and a removed node does not reset the order of the others
+func (s rrSelector) Select(_ egress.Policy, nodeMap map[string]egress.EgressIPStatus) (string, error) {
+	if len(nodeMap) == 0 {
+		return "", fmt.Errorf("nodeList is empty")
+	}
+	names := sortedNodeNames(nodeMap)
+	last := ""
+	if s.gateway != nil {
+		last = s.gateway.Status.LastSelectedNode
+	}
+	node := names[0]
+	for _, name := range names {
+		if name > last {
+			node = name
+			break
+		}
+	}
+	if s.gateway != nil {
+		s.gateway.Status.LastSelectedNode = node
+	}
+	return node, nil
+}
+
+// Select the node with the smallest policies/weight ratio, so the policies
+// are spread in proportion to the weight. Nodes with weight 0 are only
+// selected when all nodes have weight 0.
+func (s weightedSelector) Select(policy egress.Policy, nodeMap map[string]egress.EgressIPStatus) (string, error) {
+	if len(nodeMap) == 0 {
+		return "", fmt.Errorf("nodeList is empty")
+	}
+	perNode := ""
+	var perNum, perWeight int
+	for _, name := range sortedNodeNames(nodeMap) {
+		weight, ok := s.weights[name]
+		if !ok {
+			weight = 1
+		}
+		if weight == 0 {
+			continue
+		}
+		num := countPolicies(nodeMap[name])
+		// num/weight < perNum/perWeight
+		if perNode == "" || num*perWeight < perNum*weight {
+			perNode = name
+			perNum = num
+			perWeight = weight
+		}
+	}
+	if perNode == "" {
+		return leastPoliciesSelector{}.Select(policy, nodeMap)
+	}
+	return perNode, nil
+}
+
+// Select the node with the highest hash of the policy and node name
+// (rendezvous hashing), a policy only moves when its node leaves
+func (consistentHashSelector) Select(policy egress.Policy, nodeMap map[string]egress.EgressIPStatus) (string, error) {
+	if len(nodeMap) == 0 {
+		return "", fmt.Errorf("nodeList is empty")
+	}
+	perNode := ""
+	var perScore uint64
+	for _, name := range sortedNodeNames(nodeMap) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(policy.Namespace + "/" + policy.Name + "/" + name))
+		score := h.Sum64()
+		if perNode == "" || score > perScore {
+			perNode = name
+			perScore = score
+		}
+	}
+	return perNode, nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package egressgateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/spidernet-io/egressgateway/pkg/config"
+	egress "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+// newNodeMap builds a node map, the value is the number of policies of the node
+func newNodeMap(nodes map[string]int) map[string]egress.EgressIPStatus {
+	res := make(map[string]egress.EgressIPStatus, len(nodes))
+	for name, num := range nodes {
+		policies := make([]egress.Policy, 0, num)
+		for i := 0; i < num; i++ {
+			policies = append(policies, egress.Policy{Namespace: "default", Name: fmt.Sprintf("%s-p%d", name, i)})
+		}
+		res[name] = egress.EgressIPStatus{
+			Name: name,
+			Eips: []egress.Eips{{Policies: policies}},
+		}
+	}
+	return res
+}
+
+func TestLeastPoliciesSelector(t *testing.T) {
+	cases := map[string]struct {
+		nodes  map[string]int
+		expect string
+		expErr bool
+	}{
+		"empty": {
+			nodes:  map[string]int{},
+			expErr: true,
+		},
+		"fewest policies": {
+			nodes:  map[string]int{"node1": 3, "node2": 1, "node3": 2},
+			expect: "node2",
+		},
+		"name order on tie": {
+			nodes:  map[string]int{"node3": 1, "node1": 1, "node2": 1},
+			expect: "node1",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				node, err := leastPoliciesSelector{}.Select(egress.Policy{Name: "p"}, newNodeMap(c.nodes))
+				if c.expErr {
+					assert.Error(t, err)
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, c.expect, node)
+			}
+		})
+	}
+}
+
+func TestRRSelector(t *testing.T) {
+	nodeMap := newNodeMap(map[string]int{"node1": 0, "node2": 0, "node3": 0})
+
+	eg := &egress.EgressGateway{}
+	s := rrSelector{gateway: eg}
+
+	res := make([]string, 0)
+	for i := 0; i < 6; i++ {
+		policy := egress.Policy{Namespace: "default", Name: fmt.Sprintf("p%d", i)}
+		node, err := s.Select(policy, nodeMap)
+		assert.NoError(t, err)
+		assert.NoError(t, setEipStatus("", "", node, policy, nodeMap))
+		res = append(res, node)
+	}
+	assert.Equal(t, []string{"node1", "node2", "node3", "node1", "node2", "node3"}, res)
+	assert.Equal(t, "node3", eg.Status.LastSelectedNode)
+
+	// the cursor does not depend on the policies already on the nodes
+	loaded := newNodeMap(map[string]int{"node1": 3, "node2": 0, "node3": 0})
+	eg.Status.LastSelectedNode = "node1"
+	node, err := s.Select(egress.Policy{Name: "p"}, loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, "node2", node)
+
+	// the node after a removed one is selected
+	delete(loaded, "node2")
+	eg.Status.LastSelectedNode = "node1"
+	node, err = s.Select(egress.Policy{Name: "p"}, loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, "node3", node)
+}
+
+func TestWeightedSelector(t *testing.T) {
+	cases := map[string]struct {
+		weights map[string]int
+		count   int
+		expect  map[string]int
+	}{
+		"proportional to weight": {
+			weights: map[string]int{"node1": 1, "node2": 2, "node3": 3},
+			count:   12,
+			expect:  map[string]int{"node1": 2, "node2": 4, "node3": 6},
+		},
+		"missing weight is 1": {
+			weights: map[string]int{"node1": 3},
+			count:   8,
+			expect:  map[string]int{"node1": 6, "node2": 2},
+		},
+		"zero weight is skipped": {
+			weights: map[string]int{"node1": 0, "node2": 1},
+			count:   3,
+			expect:  map[string]int{"node1": 0, "node2": 3},
+		},
+		"all zero weight": {
+			weights: map[string]int{"node1": 0, "node2": 0},
+			count:   4,
+			expect:  map[string]int{"node1": 2, "node2": 2},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			nodes := make(map[string]int)
+			for node := range c.expect {
+				nodes[node] = 0
+			}
+			nodeMap := newNodeMap(nodes)
+			s := weightedSelector{weights: c.weights}
+			for i := 0; i < c.count; i++ {
+				policy := egress.Policy{Namespace: "default", Name: fmt.Sprintf("p%d", i)}
+				node, err := s.Select(policy, nodeMap)
+				assert.NoError(t, err)
+				assert.NoError(t, setEipStatus("", "", node, policy, nodeMap))
+			}
+			for node, num := range c.expect {
+				assert.Equal(t, num, countPolicies(nodeMap[node]), node)
+			}
+		})
+	}
+}
+
+func TestConsistentHashSelector(t *testing.T) {
+	nodes := map[string]int{"node1": 0, "node2": 0, "node3": 0, "node4": 0}
+
+	assigned := make(map[egress.Policy]string)
+	used := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		policy := egress.Policy{Namespace: "default", Name: fmt.Sprintf("p%d", i)}
+		node, err := consistentHashSelector{}.Select(policy, newNodeMap(nodes))
+		assert.NoError(t, err)
+		assigned[policy] = node
+		used[node] = true
+	}
+	assert.Len(t, used, len(nodes))
+
+	// the load of the nodes does not change the result
+	loaded := newNodeMap(map[string]int{"node1": 10, "node2": 0, "node3": 5, "node4": 1})
+	for policy, node := range assigned {
+		res, err := consistentHashSelector{}.Select(policy, loaded)
+		assert.NoError(t, err)
+		assert.Equal(t, node, res)
+	}
+
+	// only the policies of the removed node move
+	delete(nodes, "node2")
+	for policy, node := range assigned {
+		res, err := consistentHashSelector{}.Select(policy, newNodeMap(nodes))
+		assert.NoError(t, err)
+		if node != "node2" {
+			assert.Equal(t, node, res)
+		} else {
+			assert.NotEqual(t, "node2", res)
+		}
+	}
+}
+
+func TestNewNodeSelector(t *testing.T) {
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: map[string]string{egress.LabelNodeWeight: "3"},
+			Labels: map[string]string{egress.LabelNodeWeight: "5"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{egress.LabelNodeWeight: "2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3", Labels: map[string]string{egress.LabelNodeWeight: "x"}}},
+	}
+	builder := fake.NewClientBuilder().WithScheme(schema.GetScheme())
+	for _, node := range nodes {
+		builder.WithObjects(node)
+	}
+	r := egnReconciler{client: builder.Build()}
+	nodeMap := newNodeMap(map[string]int{"node1": 0, "node2": 0, "node3": 0, "node4": 0})
+
+	cases := map[string]struct {
+		policy string
+		expect nodeSelector
+	}{
+		"default":         {policy: "", expect: leastPoliciesSelector{}},
+		"least-policies":  {policy: egress.NodeSelectLeastPolicies, expect: leastPoliciesSelector{}},
+		"rr":              {policy: egress.NodeSelectRR, expect: rrSelector{}},
+		"consistent-hash": {policy: egress.NodeSelectConsistentHash, expect: consistentHashSelector{}},
+		"weighted": {
+			policy: egress.NodeSelectWeighted,
+			expect: weightedSelector{weights: map[string]int{"node1": 3, "node2": 2, "node3": 1}},
+		},
+		"legacy": {policy: "average", expect: leastPoliciesSelector{}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			eg := &egress.EgressGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+				Spec:       egress.EgressGatewaySpec{NodeSelector: egress.NodeSelector{Policy: c.policy}},
+			}
+			s, err := r.newNodeSelector(context.Background(), eg, nodeMap)
+			assert.NoError(t, err)
+			expect := c.expect
+			// the rr cursor is kept in the gateway
+			if _, ok := expect.(rrSelector); ok {
+				expect = rrSelector{gateway: eg}
+			}
+			assert.Equal(t, expect, s)
+		})
+	}
+}
+
+func TestValidateNodeSelectPolicy(t *testing.T) {
+	legacy := &egress.EgressGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Spec:       egress.EgressGatewaySpec{NodeSelector: egress.NodeSelector{Policy: "average"}},
+	}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(legacy).Build()
+	egw := &EgressGatewayWebhook{Client: cli, Config: &config.Config{}}
+
+	cases := map[string]struct {
+		operation admissionv1.Operation
+		policy    string
+		allowed   bool
+	}{
+		"create with unknown policy":      {operation: admissionv1.Create, policy: "average"},
+		"update keeping the legacy value": {operation: admissionv1.Update, policy: "average", allowed: true},
+		"update to another unknown value": {operation: admissionv1.Update, policy: "doing"},
+		"update to a known value":         {operation: admissionv1.Update, policy: egress.NodeSelectRR, allowed: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			eg := legacy.DeepCopy()
+			eg.Spec.NodeSelector.Policy = c.policy
+			raw, err := json.Marshal(eg)
+			assert.NoError(t, err)
+			oldRaw, err := json.Marshal(legacy)
+			assert.NoError(t, err)
+
+			req := webhook.AdmissionRequest{AdmissionRequest: admissionv1.AdmissionRequest{
+				Name:      eg.Name,
+				Operation: c.operation,
+				Object:    runtime.RawExtension{Raw: raw},
+			}}
+			if c.operation == admissionv1.Update {
+				req.OldObject = runtime.RawExtension{Raw: oldRaw}
+			}
+			resp := egw.EgressGatewayValidate(context.Background(), req)
+			assert.Equal(t, c.allowed, resp.Allowed, resp.Result)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package health holds the readiness and liveness checks of the agent and the
+// controller. The checks are served by the health probe server of the manager
+// and by the /healthy endpoints of the http server API.
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/runtime/middleware"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi"
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi/healthy"
+)
+
+// Checks are named health checks
+type Checks map[string]healthz.Checker
+
+// Check runs all the checks, the error lists the failed checks by name
+func (c Checks) Check(req *http.Request) error {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := make([]string, 0)
+	for _, name := range names {
+		if err := c[name](req); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Probes are the checks of the readiness and liveness probes, the startup
+// probe succeeds once the readiness checks succeed
+type Probes struct {
+	Readiness Checks
+	Liveness  Checks
+}
+
+// AddToManager adds the checks to the health probe server of the manager,
+// /readyz for the readiness checks and /healthz for the liveness checks
+func (p Probes) AddToManager(mgr manager.Manager) error {
+	for name, check := range p.Readiness {
+		if err := mgr.AddReadyzCheck(name, check); err != nil {
+			return fmt.Errorf("failed to add readiness check %s: %v", name, err)
+		}
+	}
+	for name, check := range p.Liveness {
+		if err := mgr.AddHealthzCheck(name, check); err != nil {
+			return fmt.Errorf("failed to add liveness check %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Register sets the handlers of the /healthy endpoints of the API
+func (p Probes) Register(api *restapi.HTTPServerAPIAPI) {
+	api.HealthyGetHealthyStartupHandler = healthy.GetHealthyStartupHandlerFunc(
+		func(params healthy.GetHealthyStartupParams) middleware.Responder {
+			if err := p.Readiness.Check(params.HTTPRequest); err != nil {
+				return healthy.NewGetHealthyStartupInternalServerError().WithPayload(err.Error())
+			}
+			return healthy.NewGetHealthyStartupOK()
+		})
+	api.HealthyGetHealthyReadinessHandler = healthy.GetHealthyReadinessHandlerFunc(
+		func(params healthy.GetHealthyReadinessParams) middleware.Responder {
+			if err := p.Readiness.Check(params.HTTPRequest); err != nil {
+				return healthy.NewGetHealthyReadinessInternalServerError().WithPayload(err.Error())
+			}
+			return healthy.NewGetHealthyReadinessOK()
+		})
+	api.HealthyGetHealthyLivenessHandler = healthy.GetHealthyLivenessHandlerFunc(
+		func(params healthy.GetHealthyLivenessParams) middleware.Responder {
+			if err := p.Liveness.Check(params.HTTPRequest); err != nil {
+				return healthy.NewGetHealthyLivenessInternalServerError().WithPayload(err.Error())
+			}
+			return healthy.NewGetHealthyLivenessOK()
+		})
+}
+
+// SyncState records the result of the last sync of a datapath, it fails the
+// check until the first sync and while the last sync failed
+type SyncState struct {
+	mu     sync.RWMutex
+	synced bool
+	err    error
+}
+
+// Record records the result of a sync
+func (s *SyncState) Record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.synced = true
+	s.err = err
+}
+
+// RecordFailure records the failure of a partial sync, it is kept until the
+// next full sync recorded by Record, a partial sync succeeding does not
+// change the state
+func (s *SyncState) RecordFailure(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.synced = true
+	s.err = err
+}
+
+// Check is the healthz.Checker of the state
+func (s *SyncState) Check(_ *http.Request) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.synced {
+		return fmt.Errorf("not synced yet")
+	}
+	if s.err != nil {
+		return fmt.Errorf("last sync failed: %v", s.err)
+	}
+	return nil
+}
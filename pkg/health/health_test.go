@@ -0,0 +1,107 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-openapi/loads"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	"github.com/spidernet-io/egressgateway/api/v1/server"
+	"github.com/spidernet-io/egressgateway/api/v1/server/restapi"
+)
+
+func TestChecks(t *testing.T) {
+	failed := func(_ *http.Request) error { return errors.New("failed") }
+	cases := []struct {
+		name   string
+		checks Checks
+		expErr string
+	}{
+		{name: "empty", checks: Checks{}},
+		{name: "ok", checks: Checks{"a": healthz.Ping, "b": healthz.Ping}},
+		{name: "one failed", checks: Checks{"a": healthz.Ping, "b": failed}, expErr: "b: failed"},
+		{name: "sorted by name", checks: Checks{"b": failed, "a": failed}, expErr: "a: failed; b: failed"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.checks.Check(nil)
+			if c.expErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, c.expErr)
+		})
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	s := new(SyncState)
+	assert.EqualError(t, s.Check(nil), "not synced yet")
+
+	s.Record(errors.New("apply failed"))
+	assert.EqualError(t, s.Check(nil), "last sync failed: apply failed")
+
+	s.Record(nil)
+	assert.NoError(t, s.Check(nil))
+
+	// a partial sync only records the failures
+	s.RecordFailure(nil)
+	assert.NoError(t, s.Check(nil))
+	s.RecordFailure(errors.New("policy failed"))
+	s.RecordFailure(nil)
+	assert.EqualError(t, s.Check(nil), "last sync failed: policy failed")
+	s.Record(nil)
+	assert.NoError(t, s.Check(nil))
+}
+
+func TestRegister(t *testing.T) {
+	state := new(SyncState)
+	probes := Probes{
+		Readiness: Checks{"sync": state.Check},
+		Liveness:  Checks{"ping": healthz.Ping},
+	}
+
+	spec, err := loads.Analyzed(server.SwaggerJSON, "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	api := restapi.NewHTTPServerAPIAPI(spec)
+	probes.Register(api)
+	srv := server.NewServer(api)
+	srv.ConfigureAPI()
+	ts := httptest.NewServer(srv.GetHandler())
+	defer ts.Close()
+
+	get := func(path string) (int, string) {
+		res, err := http.Get(ts.URL + "/v1/healthy/" + path)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	code, body := get("readiness")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Contains(t, body, "sync: not synced yet")
+	code, _ = get("startup")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	code, _ = get("liveness")
+	assert.Equal(t, http.StatusOK, code)
+
+	state.Record(nil)
+	code, _ = get("readiness")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = get("startup")
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,166 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ipallocator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/spidernet-io/egressgateway/pkg/constant"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+var (
+	ErrFull       = errors.New("range is full")
+	ErrAllocated  = errors.New("provided IP is already allocated")
+	ErrNotInRange = errors.New("provided IP is not in the valid range")
+)
+
+// Range allocates the IP addresses of a CIDR except the network and the
+// broadcast address. Only the allocated addresses are stored, so the size
+// of the CIDR does not matter, such as an IPv6 /64.
+type Range struct {
+	lock     sync.Mutex
+	cidr     *net.IPNet
+	set      *utils.IPRangeSet
+	reserved []net.IP
+	// allocated is keyed by the string of the IP address
+	allocated map[string]net.IP
+	rander    *rand.Rand
+}
+
+// NewCIDRRange creates a Range over the CIDR
+func NewCIDRRange(cidr *net.IPNet) (*Range, error) {
+	version := constant.IPv6
+	network := cidr.IP.To16()
+	if ip := cidr.IP.To4(); ip != nil {
+		version = constant.IPv4
+		network = ip
+	}
+	set, err := utils.NewIPRangeSet(version, []string{cidr.String()})
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %v: %v", cidr, err)
+	}
+
+	mask := cidr.Mask
+	if len(mask) != len(network) {
+		return nil, fmt.Errorf("invalid CIDR %v: the mask does not match the IP", cidr)
+	}
+	broadcast := make(net.IP, len(network))
+	for i := range network {
+		broadcast[i] = network[i] | ^mask[i]
+	}
+
+	return &Range{
+		cidr:      cidr,
+		set:       set,
+		reserved:  []net.IP{network.Mask(mask), broadcast},
+		allocated: make(map[string]net.IP),
+		rander:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// CIDR returns the CIDR covered by the range
+func (r *Range) CIDR() net.IPNet {
+	return *r.cidr
+}
+
+// Used returns the number of the allocated IP addresses
+func (r *Range) Used() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.allocated)
+}
+
+// Free returns the number of the IP addresses left in the range
+func (r *Range) Free() *big.Int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	res := r.set.Size()
+	res.Sub(res, big.NewInt(r.set.CountIn(r.reserved)+int64(len(r.allocated))))
+	if res.Sign() < 0 {
+		res.SetInt64(0)
+	}
+	return res
+}
+
+// Allocate reserves the IP address. ErrNotInRange or ErrAllocated is returned
+// if the IP is not valid for the range or has already been reserved.
+func (r *Range) Allocate(ip net.IP) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.valid(ip) {
+		return ErrNotInRange
+	}
+	key := ip.String()
+	if _, ok := r.allocated[key]; ok {
+		return ErrAllocated
+	}
+	r.allocated[key] = ip
+	return nil
+}
+
+// AllocateNext reserves a free IP address of the range at random. ErrFull is
+// returned if there is no address left.
+func (r *Range) AllocateNext() (net.IP, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	used := append([]net.IP(nil), r.reserved...)
+	for _, ip := range r.allocated {
+		used = append(used, ip)
+	}
+	ip, ok := r.set.RandomFree(r.rander, used)
+	if !ok {
+		return nil, ErrFull
+	}
+	r.allocated[ip.String()] = ip
+	return ip, nil
+}
+
+// Release releases the IP address back to the range. Releasing an
+// unallocated IP or an IP out of the range is a no-op.
+func (r *Range) Release(ip net.IP) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.allocated, ip.String())
+	return nil
+}
+
+// ForEach calls fn for each allocated IP address
+func (r *Range) ForEach(fn func(net.IP)) {
+	r.lock.Lock()
+	ips := make([]net.IP, 0, len(r.allocated))
+	for _, ip := range r.allocated {
+		ips = append(ips, ip)
+	}
+	r.lock.Unlock()
+	for _, ip := range ips {
+		fn(ip)
+	}
+}
+
+// Has reports whether the IP address is allocated
+func (r *Range) Has(ip net.IP) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	_, ok := r.allocated[ip.String()]
+	return ok
+}
+
+func (r *Range) valid(ip net.IP) bool {
+	if !r.set.Contains(ip) {
+		return false
+	}
+	for _, item := range r.reserved {
+		if item.Equal(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ipallocator
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	_, cidr, _ := net.ParseCIDR("10.6.0.0/30")
+	r, err := NewCIDRRange(cidr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), r.Free().Int64())
+
+	// the network and the broadcast address are not allocated
+	assert.ErrorIs(t, r.Allocate(net.ParseIP("10.6.0.0")), ErrNotInRange)
+	assert.ErrorIs(t, r.Allocate(net.ParseIP("10.6.0.3")), ErrNotInRange)
+	assert.NoError(t, r.Allocate(net.ParseIP("10.6.0.1")))
+	assert.ErrorIs(t, r.Allocate(net.ParseIP("10.6.0.1")), ErrAllocated)
+
+	ip, err := r.AllocateNext()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.6.0.2", ip.String())
+	_, err = r.AllocateNext()
+	assert.ErrorIs(t, err, ErrFull)
+	assert.Equal(t, 2, r.Used())
+
+	assert.NoError(t, r.Release(ip))
+	assert.False(t, r.Has(ip))
+	assert.True(t, r.Has(net.ParseIP("10.6.0.1")))
+}
+
+func TestLargeRange(t *testing.T) {
+	_, cidr, _ := net.ParseCIDR("fd00::/64")
+	r, err := NewCIDRRange(cidr)
+	assert.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		ip, err := r.AllocateNext()
+		assert.NoError(t, err)
+		assert.True(t, cidr.Contains(ip))
+		seen[ip.String()] = struct{}{}
+	}
+	assert.Len(t, seen, 100)
+	assert.Equal(t, 100, r.Used())
+	assert.NoError(t, r.Allocate(net.ParseIP("fd00::ffff:ffff:ffff:fffe")))
+}
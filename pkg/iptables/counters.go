@@ -0,0 +1,72 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package iptables
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// counterAppendRegexp matches an iptables-save -c output line for an
+	// append operation, capturing the packet and byte counters and the chain.
+	counterAppendRegexp = regexp.MustCompile(`^\[(\d+):(\d+)\] -A (\S+)`)
+	// commentRegexp matches a comment of a rule, capturing the quoted or the
+	// unquoted comment.
+	commentRegexp = regexp.MustCompile(`--comment (?:"([^"]*)"|(\S+))`)
+)
+
+// RuleCounter is the packet and byte counter of a rule of the table
+type RuleCounter struct {
+	Chain string
+	// Comments are the comments of the rule, the hash comment excepted
+	Comments []string
+	Packets  uint64
+	Bytes    uint64
+}
+
+// ReadCounters returns the counters of the rules of the table, only the rules
+// with our hash comment are returned
+func (t *Table) ReadCounters() ([]RuleCounter, error) {
+	output, err := t.newCmd(t.iptablesSaveCmd, "-c", "-t", t.Name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %v", t.iptablesSaveCmd, err)
+	}
+	return t.parseCounters(output)
+}
+
+func (t *Table) parseCounters(output []byte) ([]RuleCounter, error) {
+	res := make([]RuleCounter, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		captures := counterAppendRegexp.FindSubmatch(line)
+		if captures == nil || !t.hashCommentRegexp.Match(line) {
+			continue
+		}
+		packets, err := strconv.ParseUint(string(captures[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the packet counter of %q: %v", line, err)
+		}
+		byteCount, err := strconv.ParseUint(string(captures[2]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the byte counter of %q: %v", line, err)
+		}
+
+		counter := RuleCounter{Chain: string(captures[3]), Comments: []string{}, Packets: packets, Bytes: byteCount}
+		for _, comment := range commentRegexp.FindAllSubmatch(line, -1) {
+			text := string(comment[1]) + string(comment[2])
+			if strings.HasPrefix(text, t.hashCommentPrefix) {
+				continue
+			}
+			counter.Comments = append(counter.Comments, text)
+		}
+		res = append(res, counter)
+	}
+	return res, scanner.Err()
+}
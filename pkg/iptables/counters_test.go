@@ -0,0 +1,41 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package iptables
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/spidernet-io/egressgateway/pkg/logger"
+)
+
+func TestParseCounters(t *testing.T) {
+	table, err := NewTable("mangle", 4, "egw:", Options{
+		HistoricChainPrefixes: []string{"egw"},
+		XTablesLock:           DummyLock{},
+		LookPathOverride:      func(file string) (string, error) { return file, nil },
+	}, logger.NewStdoutLogger(os.Getenv("LOG_LEVEL")))
+	assert.NoError(t, err)
+
+	output := []byte(`# Generated by iptables-save
+*mangle
+:PREROUTING ACCEPT [100:8000]
+:EGRESSGATEWAY-MARK-REQUEST - [0:0]
+[3:180] -A PREROUTING -m comment --comment "egw:abc" -j EGRESSGATEWAY-MARK-REQUEST
+[12:3456] -A EGRESSGATEWAY-MARK-REQUEST -m comment --comment "egw:def" -m comment --comment policy=default/p1 -m comment --comment "gateway=gw1" -j MARK --set-xmark 0x26000001/0xffffffff
+[7:70] -A EGRESSGATEWAY-MARK-REQUEST -m comment --comment "other" -j ACCEPT
+COMMIT
+`)
+	counters, err := table.parseCounters(output)
+	assert.NoError(t, err)
+	assert.Equal(t, []RuleCounter{
+		{Chain: "PREROUTING", Comments: []string{}, Packets: 3, Bytes: 180},
+		{Chain: "EGRESSGATEWAY-MARK-REQUEST", Comments: []string{"policy=default/p1", "gateway=gw1"}, Packets: 12, Bytes: 3456},
+	}, counters)
+
+	_, err = table.parseCounters([]byte(`[99999999999999999999:1] -A FORWARD -m comment --comment "egw:abc" -j ACCEPT`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package iptables
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChainState is a chain hooked or programmed by the table. Expected holds
+// the rules the table believes are in the chain, Actual holds the rules of
+// the table read back with iptables-save, both in the iptables-save format.
+type ChainState struct {
+	Name     string
+	Expected []string
+	Actual   []string
+	InSync   bool
+}
+
+// Dump returns the state of the chains of the table, the chains not known by
+// the table but named with one of our prefixes are reported as stale
+func (t *Table) Dump() ([]ChainState, error) {
+	expected, expectedHashes, owned := t.expectedRules()
+
+	output, err := t.newCmd(t.iptablesSaveCmd, "-t", t.Name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %v", t.iptablesSaveCmd, err)
+	}
+	actual, actualHashes := t.readRules(output, expected, owned)
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	res := make([]ChainState, 0, len(names))
+	for _, name := range names {
+		res = append(res, ChainState{
+			Name:     name,
+			Expected: expected[name],
+			Actual:   actual[name],
+			InSync:   reflect.DeepEqual(expectedHashes[name], actualHashes[name]),
+		})
+	}
+	return res, nil
+}
+
+// expectedRules renders the rules of the referenced chains, which are owned
+// by the table, and the rules inserted into or appended to the other chains
+func (t *Table) expectedRules() (rules, hashes map[string][]string, owned map[string]struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rules = make(map[string][]string)
+	hashes = make(map[string][]string)
+	owned = make(map[string]struct{})
+	for name := range t.chainNameToChain {
+		chain, ok := t.desiredStateOfChain(name)
+		if !ok {
+			continue
+		}
+		chainHashes := chain.RuleHashes(t.opt)
+		lines := make([]string, 0, len(chain.Rules))
+		for i, rule := range chain.Rules {
+			lines = append(lines, rule.RenderAppend(name, t.commentFrag(chainHashes[i]), t.opt))
+		}
+		rules[name] = lines
+		hashes[name] = append([]string{}, chainHashes...)
+		owned[name] = struct{}{}
+	}
+
+	insertAppendChains := make(map[string]struct{})
+	for name := range t.chainToInsertedRules {
+		insertAppendChains[name] = struct{}{}
+	}
+	for name := range t.chainToAppendedRules {
+		insertAppendChains[name] = struct{}{}
+	}
+	for name := range insertAppendChains {
+		_, insertedHashes, appendedHashes := t.expectedHashesForInsertAppendChain(name, 0)
+		lines := make([]string, 0)
+		for i, rule := range t.chainToInsertedRules[name] {
+			lines = append(lines, rule.RenderAppend(name, t.commentFrag(insertedHashes[i]), t.opt))
+		}
+		for i, rule := range t.chainToAppendedRules[name] {
+			lines = append(lines, rule.RenderAppend(name, t.commentFrag(appendedHashes[i]), t.opt))
+		}
+		rules[name] = lines
+		hashes[name] = append(append([]string{}, insertedHashes...), appendedHashes...)
+	}
+	return rules, hashes, owned
+}
+
+// readRules parses the iptables-save output, all the rules of our chains are
+// kept, only the rules with our hash are kept for the other chains. A missing
+// chain of ours has no hashes, so it is not in sync even if it is empty.
+func (t *Table) readRules(output []byte, expected map[string][]string, owned map[string]struct{}) (rules, hashes map[string][]string) {
+	rules = make(map[string][]string)
+	hashes = make(map[string][]string)
+	ours := func(name string) bool {
+		_, ok := owned[name]
+		return ok || t.ourChainsRegexp.MatchString(name)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if captures := chainCreateRegexp.FindSubmatch(line); captures != nil {
+			name := string(captures[1])
+			if ours(name) {
+				rules[name] = []string{}
+				hashes[name] = []string{}
+			}
+			continue
+		}
+		captures := appendRegexp.FindSubmatch(line)
+		if captures == nil {
+			continue
+		}
+		name := string(captures[1])
+
+		hash := ""
+		if captures := t.hashCommentRegexp.FindSubmatch(line); captures != nil {
+			hash = string(captures[1])
+		}
+		if hash == "" && !ours(name) {
+			continue
+		}
+		rules[name] = append(rules[name], string(line))
+		hashes[name] = append(hashes[name], hash)
+	}
+	for name := range expected {
+		if _, ok := hashes[name]; !ok && !ours(name) {
+			hashes[name] = []string{}
+		}
+	}
+	return rules, hashes
+}
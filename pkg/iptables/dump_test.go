@@ -0,0 +1,70 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package iptables
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/spidernet-io/egressgateway/pkg/iptables/testutils"
+	"github.com/spidernet-io/egressgateway/pkg/logger"
+)
+
+func TestDump(t *testing.T) {
+	dataplane := testutils.NewMockDataplane("filter", map[string][]string{
+		"FORWARD": {"-m comment --comment \"other\" -j ACCEPT"},
+		"INPUT":   {},
+		"OUTPUT":  {},
+	}, "legacy")
+	table, err := NewTable("filter", 4, "egw:", Options{
+		HistoricChainPrefixes: []string{"egw"},
+		XTablesLock:           DummyLock{},
+		NewCmdOverride:        dataplane.NewCmd,
+		SleepOverride:         dataplane.Sleep,
+		NowOverride:           dataplane.Now,
+		LookPathOverride:      func(file string) (string, error) { return file, nil },
+	}, logger.NewStdoutLogger(os.Getenv("LOG_LEVEL")))
+	assert.NoError(t, err)
+
+	table.UpdateChain(&Chain{Name: "EGRESSGATEWAY-FW", Rules: []Rule{{Action: AcceptAction{}}}})
+	table.InsertOrAppendRules("FORWARD", []Rule{{Action: JumpAction{Target: "EGRESSGATEWAY-FW"}}})
+	_, err = table.Apply()
+	assert.NoError(t, err)
+
+	chains, err := table.Dump()
+	assert.NoError(t, err)
+	states := make(map[string]ChainState)
+	for _, chain := range chains {
+		states[chain.Name] = chain
+	}
+	assert.True(t, states["EGRESSGATEWAY-FW"].InSync)
+	assert.Len(t, states["EGRESSGATEWAY-FW"].Actual, 1)
+	// only the rule inserted by the table is read back
+	assert.True(t, states["FORWARD"].InSync)
+	assert.Len(t, states["FORWARD"].Expected, 1)
+	assert.Len(t, states["FORWARD"].Actual, 1)
+
+	// the rule removed by others is reported
+	dataplane.Chains["EGRESSGATEWAY-FW"] = []string{}
+	chains, err = table.Dump()
+	assert.NoError(t, err)
+	for _, chain := range chains {
+		if chain.Name == "EGRESSGATEWAY-FW" {
+			assert.False(t, chain.InSync)
+			assert.Len(t, chain.Expected, 1)
+			assert.Empty(t, chain.Actual)
+		}
+	}
+
+	delete(dataplane.Chains, "EGRESSGATEWAY-FW")
+	chains, err = table.Dump()
+	assert.NoError(t, err)
+	for _, chain := range chains {
+		if chain.Name == "EGRESSGATEWAY-FW" {
+			assert.False(t, chain.InSync)
+		}
+	}
+}
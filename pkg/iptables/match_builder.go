@@ -81,6 +81,22 @@ func (m MatchCriteria) NotMarkMatchesWithMask(mark, mask uint32) MatchCriteria {
 	return append(m, fmt.Sprintf("-m mark ! --mark %#x/%#x", mark, mask))
 }
 
+// ConnMarkMatchesWithMask matches the mark of the connection the packet belongs to.
+func (m MatchCriteria) ConnMarkMatchesWithMask(mark, mask uint32) MatchCriteria {
+	if mask == 0 {
+		panic("Bug: mask is 0.")
+	}
+	if mark&mask != mark {
+		panic("Bug: mark is not contained in mask")
+	}
+	return append(m, fmt.Sprintf("-m connmark --mark %#x/%#x", mark, mask))
+}
+
+// StatisticRandom matches packets randomly with the given probability.
+func (m MatchCriteria) StatisticRandom(probability float64) MatchCriteria {
+	return append(m, fmt.Sprintf("-m statistic --mode random --probability %.5f", probability))
+}
+
 func (m MatchCriteria) InInterface(ifaceMatch string) MatchCriteria {
 	return append(m, fmt.Sprintf("--in-interface %s", ifaceMatch))
 }
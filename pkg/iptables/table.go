@@ -95,6 +95,8 @@ var (
 // caches the desired state of that table, then attempts to bring it into sync when Apply() is
 // called.
 type Table struct {
+	// mu guards the state of the table, it is also read by the datapath API
+	mu  sync.Mutex
 	opt *Options
 
 	Name      string
@@ -308,8 +310,20 @@ func NewTable(name string, ipVersion uint8, hashPrefix string, options Options,
 	return table, nil
 }
 
+// TableName returns the name of the table
+func (t *Table) TableName() string {
+	return t.Name
+}
+
+// TableIPVersion returns the IP version of the table
+func (t *Table) TableIPVersion() uint8 {
+	return t.IPVersion
+}
+
 // InsertOrAppendRules insert or append rules to chain
 func (t *Table) InsertOrAppendRules(chainName string, newRules []Rule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.logCxt.Debug("updating rule insertions", zap.String("chainName", chainName))
 
 	oldRules := t.chainToInsertedRules[chainName]
@@ -322,11 +336,13 @@ func (t *Table) InsertOrAppendRules(chainName string, newRules []Rule) {
 	// By incrementing first we avoid marking a still-referenced chain as dirty.
 	t.increfReferredChains(newRules)
 	t.decrefReferredChains(oldRules)
-	t.InvalidateDataplaneCache("insertion")
+	t.invalidateDataplaneCache("insertion")
 }
 
 // AppendRules append rules
 func (t *Table) AppendRules(chainName string, rules []Rule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.logCxt.Debug("Updating rule appends", zap.String("chainName", chainName))
 	oldRules := t.chainToAppendedRules[chainName]
 	t.chainToAppendedRules[chainName] = rules
@@ -338,16 +354,24 @@ func (t *Table) AppendRules(chainName string, rules []Rule) {
 	// avoid marking a still-referenced chain as dirty.
 	t.increfReferredChains(rules)
 	t.decrefReferredChains(oldRules)
-	t.InvalidateDataplaneCache("insertion")
+	t.invalidateDataplaneCache("insertion")
 }
 
 func (t *Table) UpdateChains(chains []*Chain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	for _, chain := range chains {
-		t.UpdateChain(chain)
+		t.updateChain(chain)
 	}
 }
 
 func (t *Table) UpdateChain(chain *Chain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.updateChain(chain)
+}
+
+func (t *Table) updateChain(chain *Chain) {
 	t.logCxt.Info("Queueing update of chain.", zap.String("chainName", chain.Name))
 	oldNumRules := 0
 
@@ -369,16 +393,24 @@ func (t *Table) UpdateChain(chain *Chain) {
 	// code was originally designed not to need this, we found that other users of
 	// iptables-restore can still clobber our updates, so it's safest to re-read the state before
 	// each write.
-	t.InvalidateDataplaneCache("chain update")
+	t.invalidateDataplaneCache("chain update")
 }
 
 func (t *Table) RemoveChains(chains []*Chain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	for _, chain := range chains {
-		t.RemoveChainByName(chain.Name)
+		t.removeChainByName(chain.Name)
 	}
 }
 
 func (t *Table) RemoveChainByName(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeChainByName(name)
+}
+
+func (t *Table) removeChainByName(name string) {
 	t.logCxt.Info("Queuing deletion of chain.", zap.String("chainName", name))
 	if oldChain, known := t.chainNameToChain[name]; known {
 		t.gaugeNumRules.Sub(float64(len(oldChain.Rules)))
@@ -393,7 +425,7 @@ func (t *Table) RemoveChainByName(name string) {
 	// code was originally designed not to need this, we found that other users of
 	// iptables-restore can still clobber out updates, so it's safest to re-read the state before
 	// each write.
-	t.InvalidateDataplaneCache("chain removal")
+	t.invalidateDataplaneCache("chain removal")
 }
 
 // increfReferredChains finds all the chains that the given rules refer to  (i.e. have jumps/gotos to) and
@@ -761,6 +793,12 @@ func (t *Table) readHashesAndRulesFrom(r io.ReadCloser) (hashes map[string][]str
 }
 
 func (t *Table) InvalidateDataplaneCache(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.invalidateDataplaneCache(reason)
+}
+
+func (t *Table) invalidateDataplaneCache(reason string) {
 	logCxt := t.logCxt.With(zap.String("reason", reason))
 	if !t.inSyncWithDataPlane {
 		logCxt.Debug("would invalidate dataplane cache but it was already invalid")
@@ -771,13 +809,16 @@ func (t *Table) InvalidateDataplaneCache(reason string) {
 }
 
 func (t *Table) Apply() (rescheduleAfter time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	now := t.timeNow()
 	// We _think_ we're in sync, check if there are any reasons to think we might not be in sync.
 	lastReadToNow := now.Sub(t.lastReadTime)
 	invalidated := false
 	if t.opt.RefreshInterval > 0 && lastReadToNow > t.opt.RefreshInterval {
 		// Too long since we've forced a refresh.
-		t.InvalidateDataplaneCache("refresh timer")
+		t.invalidateDataplaneCache("refresh timer")
 		invalidated = true
 	}
 	// To prevent another process from overwriting our updates,
@@ -792,7 +833,7 @@ func (t *Table) Apply() (rescheduleAfter time.Duration, err error) {
 		t.postWriteInterval *= 2
 		t.logCxt.Info("updating post-write interval", zap.Duration("newPostWriteInterval", t.postWriteInterval))
 		if !invalidated {
-			t.InvalidateDataplaneCache("post update")
+			t.invalidateDataplaneCache("post update")
 			invalidated = true
 		}
 	}
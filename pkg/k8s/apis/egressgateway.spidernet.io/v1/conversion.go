@@ -0,0 +1,13 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+// Hub marks EgressGateway as the conversion hub, v1beta1 converts to and from it
+func (*EgressGateway) Hub() {}
+
+// Hub marks EgressPolicy as the conversion hub, v1beta1 converts to and from it
+func (*EgressPolicy) Hub() {}
+
+// Hub marks EgressClusterPolicy as the conversion hub, v1beta1 converts to and from it
+func (*EgressClusterPolicy) Hub() {}
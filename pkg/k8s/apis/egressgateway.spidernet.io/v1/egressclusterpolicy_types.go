@@ -0,0 +1,87 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EgressClusterPolicyList contains a list of egress gateway policies
+// +kubebuilder:object:root=true
+type EgressClusterPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []EgressClusterPolicy `json:"items"`
+}
+
+// EgressClusterPolicy represents a cluster egress policy
+// +kubebuilder:resource:categories={egressclusterpolicy},path="egressclusterpolicies",singular="egressclusterpolicy",scope="Cluster",shortName={egcp}
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.egressGatewayName",description="egressGatewayName",name="gateway",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.eip.ipv4",description="ipv4",name="ipv4",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.eip.ipv6",description="ipv6",name="ipv6",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.node",description="egressNode",name="egressNode",type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.priority",description="priority",name="priority",type=integer,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type==\"Programmed\")].status",description="programmed",name="programmed",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.matchedEndpoints",description="matchedEndpoints",name="endpoints",type=integer,priority=1
+type EgressClusterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   EgressClusterPolicySpec `json:"spec,omitempty"`
+	Status EgressPolicyStatus      `json:"status,omitempty"`
+}
+
+type EgressClusterPolicySpec struct {
+	// +kubebuilder:validation:Optional
+	EgressGatewayName string `json:"egressGatewayName,omitempty"`
+	// +kubebuilder:validation:Optional
+	EgressIP EgressIP `json:"egressIP,omitempty"`
+	// GatewayReplicas is the number of gateway nodes the traffic of the policy
+	// is spread across, each of them has an EIP of the policy. It can be greater
+	// than 1 only with useNodeIP or the rr allocatorPolicy.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	GatewayReplicas int32 `json:"gatewayReplicas,omitempty"`
+	// +kubebuilder:validation:Required
+	AppliedTo ClusterAppliedTo `json:"appliedTo"`
+	// +kubebuilder:validation:Optional
+	DestSubnet []string `json:"destSubnet,omitempty"`
+	// ExcludeDestSubnet is the destinations which are not forwarded to the
+	// gateway node, it takes precedence over destSubnet and destDomains
+	// +kubebuilder:validation:Optional
+	ExcludeDestSubnet []string `json:"excludeDestSubnet,omitempty"`
+	// DestDomains are resolved by the controller, the traffic to the resolved
+	// addresses is matched together with destSubnet
+	// +kubebuilder:validation:Optional
+	DestDomains []string `json:"destDomains,omitempty"`
+	// DestPorts limits the policy to the traffic of the given protocols and
+	// destination ports, all traffic to destSubnet is matched when it is empty
+	// +kubebuilder:validation:Optional
+	DestPorts []PolicyPort `json:"destPorts,omitempty"`
+	// Priority of the policy, a smaller value means a higher priority, 0 is
+	// the highest
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=32768
+	Priority *uint64 `json:"priority,omitempty"`
+}
+
+type ClusterAppliedTo struct {
+	// +kubebuilder:validation:Optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// ExcludePodSelector excludes the pods selected by podSelector, it can
+	// not be used with podSubnet
+	// +kubebuilder:validation:Optional
+	ExcludePodSelector *metav1.LabelSelector `json:"excludePodSelector,omitempty"`
+	// +kubebuilder:validation:Optional
+	PodSubnet []string `json:"podSubnet,omitempty"`
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EgressClusterPolicy{}, &EgressClusterPolicyList{})
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EgressGatewayList contains a list of EgressGateway
+// +kubebuilder:object:root=true
+type EgressGatewayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []EgressGateway `json:"items"`
+}
+
+// EgressGateway egress gateway
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories={egressgateway},path="egressgateways",singular="egressgateway",scope="Cluster",shortName={egw}
+// +kubebuilder:printcolumn:JSONPath=".spec.ippools.ipv4DefaultEIP",description="ipv4DefaultEIP",name="ipv4DefaultEIP",type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.ippools.ipv6DefaultEIP",description="ipv6DefaultEIP",name="ipv6DefaultEIP",type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.clusterDefault",description="clusterDefault",name="default",type=boolean,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.readyNodes",description="readyNodes",name="readyNodes",type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.ipUsage.ipv4Free",description="ipv4Free",name="ipv4Free",type=integer,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.ipUsage.ipv6Free",description="ipv6Free",name="ipv6Free",type=integer,priority=1
+// +kubebuilder:subresource:status
+type EgressGateway struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   EgressGatewaySpec   `json:"spec,omitempty"`
+	Status EgressGatewayStatus `json:"status,omitempty"`
+}
+
+type EgressGatewaySpec struct {
+	// +kubebuilder:validation:Optional
+	Ippools Ippools `json:"ippools,omitempty"`
+	// +kubebuilder:validation:Required
+	NodeSelector NodeSelector `json:"nodeSelector,omitempty"`
+	// ClusterDefault makes the gateway the default of the policies without
+	// egressGatewayName, at most one gateway is the cluster default
+	// +kubebuilder:validation:Optional
+	ClusterDefault bool `json:"clusterDefault,omitempty"`
+	// FailbackPolicy decides whether the policies moved away from a failed
+	// gateway node go back to it when it recovers, one of Never, Immediate
+	// and AfterDelay, the default is Never
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Never;Immediate;AfterDelay
+	// +kubebuilder:default:="Never"
+	FailbackPolicy FailbackPolicy `json:"failbackPolicy,omitempty"`
+	// FailbackDelaySeconds is how long a recovered node stays ready before
+	// the policies go back with AfterDelay, the default is 30
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	FailbackDelaySeconds int32 `json:"failbackDelaySeconds,omitempty"`
+}
+
+type Ippools struct {
+	// +kubebuilder:validation:Optional
+	IPv4 []string `json:"ipv4,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6 []string `json:"ipv6,omitempty"`
+	// +kubebuilder:validation:Optional
+	Ipv4DefaultEIP string `json:"ipv4DefaultEIP,omitempty"`
+	// +kubebuilder:validation:Optional
+	Ipv6DefaultEIP string `json:"ipv6DefaultEIP,omitempty"`
+}
+
+type NodeSelector struct {
+	// Policy of selecting the gateway node for a policy, the default is least-policies
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=least-policies;rr;weighted;consistent-hash
+	Policy NodeSelectPolicy `json:"policy,omitempty"`
+	// +kubebuilder:validation:Required
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// NodeSelectPolicy is the policy of selecting the gateway node for a policy
+type NodeSelectPolicy string
+
+const (
+	// NodeSelectLeastPolicies selects the node with the fewest policies
+	NodeSelectLeastPolicies NodeSelectPolicy = "least-policies"
+	// NodeSelectRR selects the nodes one by one in name order
+	NodeSelectRR NodeSelectPolicy = "rr"
+	// NodeSelectWeighted selects nodes in proportion to the weight of the node
+	NodeSelectWeighted NodeSelectPolicy = "weighted"
+	// NodeSelectConsistentHash selects the node by hashing the policy name
+	NodeSelectConsistentHash NodeSelectPolicy = "consistent-hash"
+)
+
+// FailbackPolicy decides whether the policies go back to a recovered gateway node
+type FailbackPolicy string
+
+const (
+	// FailbackNever keeps the policies on the nodes they are moved to
+	FailbackNever FailbackPolicy = "Never"
+	// FailbackImmediate moves the policies back as soon as the node recovers
+	FailbackImmediate FailbackPolicy = "Immediate"
+	// FailbackAfterDelay moves the policies back when the node has been
+	// ready for failbackDelaySeconds
+	FailbackAfterDelay FailbackPolicy = "AfterDelay"
+)
+
+type EgressGatewayStatus struct {
+	// +kubebuilder:validation:Optional
+	NodeList []EgressIPStatus `json:"nodeList,omitempty"`
+	// IPUsage is the number of total, allocated and free EIPs in the ippools,
+	// the counts of a large IPv6 pool are capped at 2^53-1
+	// +kubebuilder:validation:Optional
+	IPUsage IPUsage `json:"ipUsage,omitempty"`
+	// DefaultEIP is the default EIP and the gateway node it is bound to
+	// +kubebuilder:validation:Optional
+	DefaultEIP DefaultEIPStatus `json:"defaultEIP,omitempty"`
+	// ReadyNodes is the number of gateway nodes whose EgressNode is Succeeded
+	// +kubebuilder:validation:Optional
+	ReadyNodes int32 `json:"readyNodes,omitempty"`
+	// PreferredNodes records the policies moved away from the failed gateway
+	// nodes, they go back to the nodes by the failbackPolicy
+	// +kubebuilder:validation:Optional
+	PreferredNodes []PreferredNode `json:"preferredNodes,omitempty"`
+	// LastSelectedNode is the cursor of the rr node select policy, the next
+	// policy goes to the node after it in name order
+	// +kubebuilder:validation:Optional
+	LastSelectedNode string `json:"lastSelectedNode,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// PreferredNode is a failed gateway node and the policies moved away from it
+type PreferredNode struct {
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+	// +kubebuilder:validation:Optional
+	Policies []Policy `json:"policies,omitempty"`
+	// ReadyTime is when the node became ready again, it is set with AfterDelay
+	// +kubebuilder:validation:Optional
+	ReadyTime *metav1.Time `json:"readyTime,omitempty"`
+}
+
+type IPUsage struct {
+	// +kubebuilder:validation:Optional
+	IPv4Total int64 `json:"ipv4Total,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv4Allocated int64 `json:"ipv4Allocated,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv4Free int64 `json:"ipv4Free,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6Total int64 `json:"ipv6Total,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6Allocated int64 `json:"ipv6Allocated,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6Free int64 `json:"ipv6Free,omitempty"`
+}
+
+type DefaultEIPStatus struct {
+	// +kubebuilder:validation:Optional
+	IPv4 string `json:"ipv4,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6 string `json:"ipv6,omitempty"`
+	// Node is empty until a policy uses the default EIP
+	// +kubebuilder:validation:Optional
+	Node string `json:"node,omitempty"`
+}
+
+type EgressIPStatus struct {
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+	// +kubebuilder:validation:Optional
+	Eips []Eips `json:"eips,omitempty"`
+	// Status is the phase of the EgressNode of the gateway node
+	// +kubebuilder:validation:Optional
+	Status string `json:"status,omitempty"`
+}
+
+type Eips struct {
+	// +kubebuilder:validation:Optional
+	IPv4 string `json:"ipv4,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6 string `json:"ipv6,omitempty"`
+	// +kubebuilder:validation:Optional
+	Policies []Policy `json:"policies,omitempty"`
+}
+
+type Policy struct {
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EgressGateway{}, &EgressGatewayList{})
+}
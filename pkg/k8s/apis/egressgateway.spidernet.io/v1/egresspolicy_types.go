@@ -0,0 +1,166 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EgressPolicyList contains a list of egress gateway policies
+// +kubebuilder:object:root=true
+type EgressPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []EgressPolicy `json:"items"`
+}
+
+// EgressPolicy represents a single egress gateway policy
+// +kubebuilder:resource:categories={egresspolicy},path="egresspolicies",singular="egresspolicy",scope="Namespaced",shortName={egp}
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.egressGatewayName",description="egressGatewayName",name="gateway",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.eip.ipv4",description="ipv4",name="ipv4",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.eip.ipv6",description="ipv6",name="ipv6",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.node",description="egressNode",name="egressNode",type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.priority",description="priority",name="priority",type=integer,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type==\"Programmed\")].status",description="programmed",name="programmed",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.matchedEndpoints",description="matchedEndpoints",name="endpoints",type=integer,priority=1
+type EgressPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   EgressPolicySpec   `json:"spec,omitempty"`
+	Status EgressPolicyStatus `json:"status,omitempty"`
+}
+
+type EgressPolicySpec struct {
+	// +kubebuilder:validation:Optional
+	EgressGatewayName string `json:"egressGatewayName,omitempty"`
+	// +kubebuilder:validation:Optional
+	EgressIP EgressIP `json:"egressIP,omitempty"`
+	// GatewayReplicas is the number of gateway nodes the traffic of the policy
+	// is spread across, each of them has an EIP of the policy. It can be greater
+	// than 1 only with useNodeIP or the rr allocatorPolicy.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	GatewayReplicas int32 `json:"gatewayReplicas,omitempty"`
+	// +kubebuilder:validation:Required
+	AppliedTo AppliedTo `json:"appliedTo"`
+	// +kubebuilder:validation:Optional
+	DestSubnet []string `json:"destSubnet,omitempty"`
+	// ExcludeDestSubnet is the destinations which are not forwarded to the
+	// gateway node, it takes precedence over destSubnet and destDomains
+	// +kubebuilder:validation:Optional
+	ExcludeDestSubnet []string `json:"excludeDestSubnet,omitempty"`
+	// DestDomains are resolved by the controller, the traffic to the resolved
+	// addresses is matched together with destSubnet
+	// +kubebuilder:validation:Optional
+	DestDomains []string `json:"destDomains,omitempty"`
+	// DestPorts limits the policy to the traffic of the given protocols and
+	// destination ports, all traffic to destSubnet is matched when it is empty
+	// +kubebuilder:validation:Optional
+	DestPorts []PolicyPort `json:"destPorts,omitempty"`
+	// Priority of the policy, a smaller value means a higher priority, 0 is
+	// the highest
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=1000
+	Priority *uint64 `json:"priority,omitempty"`
+}
+
+type EgressPolicyStatus struct {
+	// +kubebuilder:validation:Optional
+	Eip Eip `json:"eip,omitempty"`
+	// +kubebuilder:validation:Optional
+	Node string `json:"node,omitempty"`
+	// Gateways are all the gateway nodes of the policy when gatewayReplicas
+	// is greater than 1, eip and node are the first of them
+	// +kubebuilder:validation:Optional
+	Gateways []PolicyGateway `json:"gateways,omitempty"`
+	// MatchedEndpoints is the number of endpoints selected by the policy
+	// +kubebuilder:validation:Optional
+	MatchedEndpoints int32 `json:"matchedEndpoints,omitempty"`
+	// DestDomainIPs are the addresses of destDomains resolved by the
+	// controller, the agents of all nodes match the same addresses
+	// +kubebuilder:validation:Optional
+	DestDomainIPs IPListPair `json:"destDomainIPs,omitempty"`
+	// Conditions of the policy, the types are Accepted, Programmed and Degraded
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type Eip struct {
+	// +kubebuilder:validation:Optional
+	IPv4 string `json:"ipv4,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6 string `json:"ipv6,omitempty"`
+}
+
+type PolicyGateway struct {
+	// +kubebuilder:validation:Optional
+	Node string `json:"node,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv4 string `json:"ipv4,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6 string `json:"ipv6,omitempty"`
+}
+
+type EgressIP struct {
+	// +kubebuilder:validation:Optional
+	IPv4 string `json:"ipv4,omitempty"`
+	// +kubebuilder:validation:Optional
+	IPv6 string `json:"ipv6,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=false
+	UseNodeIP bool `json:"useNodeIP,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=default;rr
+	// +kubebuilder:default:="default"
+	AllocatorPolicy EipAllocatorPolicy `json:"allocatorPolicy,omitempty"`
+}
+
+// EipAllocatorPolicy is the policy of allocating the EIP when it is not specified
+type EipAllocatorPolicy string
+
+const (
+	// EipAllocatorDefault uses Ipv4DefaultEIP and Ipv6DefaultEIP of the EgressGateway
+	EipAllocatorDefault EipAllocatorPolicy = "default"
+	// EipAllocatorRR prefers the unassigned EIP, if no EIP is available, select one at random
+	EipAllocatorRR EipAllocatorPolicy = "rr"
+)
+
+type PolicyPort struct {
+	// Protocol of the traffic, one of TCP, UDP and SCTP
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +kubebuilder:default:="TCP"
+	Protocol string `json:"protocol,omitempty"`
+	// Port is the destination port, all ports of the protocol are matched when it is not set
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+	// EndPort makes the range from port to endPort matched
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	EndPort int32 `json:"endPort,omitempty"`
+}
+
+type AppliedTo struct {
+	// +kubebuilder:validation:Optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// ExcludePodSelector excludes the pods selected by podSelector, it can
+	// not be used with podSubnet
+	// +kubebuilder:validation:Optional
+	ExcludePodSelector *metav1.LabelSelector `json:"excludePodSelector,omitempty"`
+	// +kubebuilder:validation:Optional
+	PodSubnet []string `json:"podSubnet,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EgressPolicy{}, &EgressPolicyList{})
+}
@@ -0,0 +1,27 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// +kubebuilder:object:generate=true
+// +groupName=egressgateway.spidernet.io
+
+// Package v1 contains API Schema definitions for the egressgateway v1 API group.
+// The v1beta1 version is still the storage version, EgressGateway, EgressPolicy
+// and EgressClusterPolicy are converted by the conversion webhook of the
+// controller, the other kinds have the same schema in both versions.
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "egressgateway.spidernet.io", Version: "v1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
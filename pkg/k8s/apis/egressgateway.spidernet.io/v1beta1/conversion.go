@@ -0,0 +1,295 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1"
+)
+
+// ConvertTo converts the EgressGateway to the v1 hub version
+func (src *EgressGateway) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1.EgressGateway)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", dstRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.EgressGatewaySpec{
+		Ippools: v1.Ippools(src.Spec.Ippools),
+		NodeSelector: v1.NodeSelector{
+			Policy:   v1.NodeSelectPolicy(src.Spec.NodeSelector.Policy),
+			Selector: src.Spec.NodeSelector.Selector,
+		},
+		ClusterDefault:       src.Spec.ClusterDefault,
+		FailbackPolicy:       v1.FailbackPolicy(src.Spec.FailbackPolicy),
+		FailbackDelaySeconds: src.Spec.FailbackDelaySeconds,
+	}
+	dst.Status = v1.EgressGatewayStatus{
+		IPUsage:          v1.IPUsage(src.Status.IPUsage),
+		DefaultEIP:       v1.DefaultEIPStatus(src.Status.DefaultEIP),
+		ReadyNodes:       src.Status.ReadyNodes,
+		LastSelectedNode: src.Status.LastSelectedNode,
+		Conditions:       src.Status.Conditions,
+	}
+	for _, node := range src.Status.NodeList {
+		item := v1.EgressIPStatus{Name: node.Name, Status: node.Status}
+		for _, eip := range node.Eips {
+			item.Eips = append(item.Eips, v1.Eips{IPv4: eip.IPv4, IPv6: eip.IPv6, Policies: convertPoliciesTo(eip.Policies)})
+		}
+		dst.Status.NodeList = append(dst.Status.NodeList, item)
+	}
+	for _, node := range src.Status.PreferredNodes {
+		dst.Status.PreferredNodes = append(dst.Status.PreferredNodes, v1.PreferredNode{
+			Name:      node.Name,
+			Policies:  convertPoliciesTo(node.Policies),
+			ReadyTime: node.ReadyTime,
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1 hub version to the EgressGateway
+func (dst *EgressGateway) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1.EgressGateway)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", srcRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = EgressGatewaySpec{
+		Ippools: Ippools(src.Spec.Ippools),
+		NodeSelector: NodeSelector{
+			Policy:   string(src.Spec.NodeSelector.Policy),
+			Selector: src.Spec.NodeSelector.Selector,
+		},
+		ClusterDefault:       src.Spec.ClusterDefault,
+		FailbackPolicy:       string(src.Spec.FailbackPolicy),
+		FailbackDelaySeconds: src.Spec.FailbackDelaySeconds,
+	}
+	dst.Status = EgressGatewayStatus{
+		IPUsage:          IPUsage(src.Status.IPUsage),
+		DefaultEIP:       DefaultEIPStatus(src.Status.DefaultEIP),
+		ReadyNodes:       src.Status.ReadyNodes,
+		LastSelectedNode: src.Status.LastSelectedNode,
+		Conditions:       src.Status.Conditions,
+	}
+	for _, node := range src.Status.NodeList {
+		item := EgressIPStatus{Name: node.Name, Status: node.Status}
+		for _, eip := range node.Eips {
+			item.Eips = append(item.Eips, Eips{IPv4: eip.IPv4, IPv6: eip.IPv6, Policies: convertPoliciesFrom(eip.Policies)})
+		}
+		dst.Status.NodeList = append(dst.Status.NodeList, item)
+	}
+	for _, node := range src.Status.PreferredNodes {
+		dst.Status.PreferredNodes = append(dst.Status.PreferredNodes, PreferredNode{
+			Name:      node.Name,
+			Policies:  convertPoliciesFrom(node.Policies),
+			ReadyTime: node.ReadyTime,
+		})
+	}
+	return nil
+}
+
+// ConvertTo converts the EgressPolicy to the v1 hub version
+func (src *EgressPolicy) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1.EgressPolicy)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", dstRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.EgressPolicySpec{
+		EgressGatewayName: src.Spec.EgressGatewayName,
+		EgressIP:          convertEgressIPTo(src.Spec.EgressIP),
+		GatewayReplicas:   src.Spec.GatewayReplicas,
+		AppliedTo: v1.AppliedTo{
+			PodSelector:        src.Spec.AppliedTo.PodSelector,
+			ExcludePodSelector: src.Spec.AppliedTo.ExcludePodSelector,
+			PodSubnet:          src.Spec.AppliedTo.PodSubnet,
+		},
+		DestSubnet:        src.Spec.DestSubnet,
+		ExcludeDestSubnet: src.Spec.ExcludeDestSubnet,
+		DestDomains:       src.Spec.DestDomains,
+		DestPorts:         convertPortsTo(src.Spec.DestPorts),
+		Priority:          src.Spec.Priority,
+	}
+	dst.Status = convertPolicyStatusTo(src.Status)
+	return nil
+}
+
+// ConvertFrom converts the v1 hub version to the EgressPolicy
+func (dst *EgressPolicy) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1.EgressPolicy)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", srcRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = EgressPolicySpec{
+		EgressGatewayName: src.Spec.EgressGatewayName,
+		EgressIP:          convertEgressIPFrom(src.Spec.EgressIP),
+		GatewayReplicas:   src.Spec.GatewayReplicas,
+		AppliedTo: AppliedTo{
+			PodSelector:        src.Spec.AppliedTo.PodSelector,
+			ExcludePodSelector: src.Spec.AppliedTo.ExcludePodSelector,
+			PodSubnet:          src.Spec.AppliedTo.PodSubnet,
+		},
+		DestSubnet:        src.Spec.DestSubnet,
+		ExcludeDestSubnet: src.Spec.ExcludeDestSubnet,
+		DestDomains:       src.Spec.DestDomains,
+		DestPorts:         convertPortsFrom(src.Spec.DestPorts),
+		Priority:          src.Spec.Priority,
+	}
+	dst.Status = convertPolicyStatusFrom(src.Status)
+	return nil
+}
+
+// ConvertTo converts the EgressClusterPolicy to the v1 hub version
+func (src *EgressClusterPolicy) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1.EgressClusterPolicy)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", dstRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.EgressClusterPolicySpec{
+		EgressGatewayName: src.Spec.EgressGatewayName,
+		EgressIP:          convertEgressIPTo(src.Spec.EgressIP),
+		GatewayReplicas:   src.Spec.GatewayReplicas,
+		AppliedTo: v1.ClusterAppliedTo{
+			PodSelector:        src.Spec.AppliedTo.PodSelector,
+			ExcludePodSelector: src.Spec.AppliedTo.ExcludePodSelector,
+			NamespaceSelector:  src.Spec.AppliedTo.NamespaceSelector,
+		},
+		DestSubnet:        src.Spec.DestSubnet,
+		ExcludeDestSubnet: src.Spec.ExcludeDestSubnet,
+		DestDomains:       src.Spec.DestDomains,
+		DestPorts:         convertPortsTo(src.Spec.DestPorts),
+		Priority:          src.Spec.Priority,
+	}
+	if src.Spec.AppliedTo.PodSubnet != nil {
+		dst.Spec.AppliedTo.PodSubnet = *src.Spec.AppliedTo.PodSubnet
+	}
+	dst.Status = convertPolicyStatusTo(src.Status)
+	return nil
+}
+
+// ConvertFrom converts the v1 hub version to the EgressClusterPolicy
+func (dst *EgressClusterPolicy) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1.EgressClusterPolicy)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", srcRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = EgressClusterPolicySpec{
+		EgressGatewayName: src.Spec.EgressGatewayName,
+		EgressIP:          convertEgressIPFrom(src.Spec.EgressIP),
+		GatewayReplicas:   src.Spec.GatewayReplicas,
+		AppliedTo: ClusterAppliedTo{
+			PodSelector:        src.Spec.AppliedTo.PodSelector,
+			ExcludePodSelector: src.Spec.AppliedTo.ExcludePodSelector,
+			NamespaceSelector:  src.Spec.AppliedTo.NamespaceSelector,
+		},
+		DestSubnet:        src.Spec.DestSubnet,
+		ExcludeDestSubnet: src.Spec.ExcludeDestSubnet,
+		DestDomains:       src.Spec.DestDomains,
+		DestPorts:         convertPortsFrom(src.Spec.DestPorts),
+		Priority:          src.Spec.Priority,
+	}
+	if src.Spec.AppliedTo.PodSubnet != nil {
+		podSubnet := src.Spec.AppliedTo.PodSubnet
+		dst.Spec.AppliedTo.PodSubnet = &podSubnet
+	}
+	dst.Status = convertPolicyStatusFrom(src.Status)
+	return nil
+}
+
+func convertEgressIPTo(src EgressIP) v1.EgressIP {
+	return v1.EgressIP{
+		IPv4:            src.IPv4,
+		IPv6:            src.IPv6,
+		UseNodeIP:       src.UseNodeIP,
+		AllocatorPolicy: v1.EipAllocatorPolicy(src.AllocatorPolicy),
+	}
+}
+
+func convertEgressIPFrom(src v1.EgressIP) EgressIP {
+	return EgressIP{
+		IPv4:            src.IPv4,
+		IPv6:            src.IPv6,
+		UseNodeIP:       src.UseNodeIP,
+		AllocatorPolicy: string(src.AllocatorPolicy),
+	}
+}
+
+func convertPortsTo(src []PolicyPort) []v1.PolicyPort {
+	if src == nil {
+		return nil
+	}
+	res := make([]v1.PolicyPort, 0, len(src))
+	for _, port := range src {
+		res = append(res, v1.PolicyPort(port))
+	}
+	return res
+}
+
+func convertPortsFrom(src []v1.PolicyPort) []PolicyPort {
+	if src == nil {
+		return nil
+	}
+	res := make([]PolicyPort, 0, len(src))
+	for _, port := range src {
+		res = append(res, PolicyPort(port))
+	}
+	return res
+}
+
+func convertPolicyStatusTo(src EgressPolicyStatus) v1.EgressPolicyStatus {
+	res := v1.EgressPolicyStatus{
+		Eip:              v1.Eip{IPv4: src.Eip.Ipv4, IPv6: src.Eip.Ipv6},
+		Node:             src.Node,
+		MatchedEndpoints: src.MatchedEndpoints,
+		DestDomainIPs:    v1.IPListPair{IPv4: src.DestDomainIPs.IPv4, IPv6: src.DestDomainIPs.IPv6},
+		Conditions:       src.Conditions,
+	}
+	for _, gateway := range src.Gateways {
+		res.Gateways = append(res.Gateways, v1.PolicyGateway{Node: gateway.Node, IPv4: gateway.Ipv4, IPv6: gateway.Ipv6})
+	}
+	return res
+}
+
+func convertPolicyStatusFrom(src v1.EgressPolicyStatus) EgressPolicyStatus {
+	res := EgressPolicyStatus{
+		Eip:              Eip{Ipv4: src.Eip.IPv4, Ipv6: src.Eip.IPv6},
+		Node:             src.Node,
+		MatchedEndpoints: src.MatchedEndpoints,
+		DestDomainIPs:    IPListPair{IPv4: src.DestDomainIPs.IPv4, IPv6: src.DestDomainIPs.IPv6},
+		Conditions:       src.Conditions,
+	}
+	for _, gateway := range src.Gateways {
+		res.Gateways = append(res.Gateways, PolicyGateway{Node: gateway.Node, Ipv4: gateway.IPv4, Ipv6: gateway.IPv6})
+	}
+	return res
+}
+
+func convertPoliciesTo(src []Policy) []v1.Policy {
+	if src == nil {
+		return nil
+	}
+	res := make([]v1.Policy, 0, len(src))
+	for _, policy := range src {
+		res = append(res, v1.Policy(policy))
+	}
+	return res
+}
+
+func convertPoliciesFrom(src []v1.Policy) []Policy {
+	if src == nil {
+		return nil
+	}
+	res := make([]Policy, 0, len(src))
+	for _, policy := range src {
+		res = append(res, Policy(policy))
+	}
+	return res
+}
@@ -20,6 +20,7 @@ type EgressClusterEndpointSliceList struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:categories={egressclusterendpointslice},path="egressclusterendpointslices",singular="egressclusterendpointslice",scope="Cluster",shortName={egcep}
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 type EgressClusterEndpointSlice struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
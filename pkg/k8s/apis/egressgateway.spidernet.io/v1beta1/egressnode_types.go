@@ -25,6 +25,7 @@ type EgressNodeList struct {
 // +kubebuilder:printcolumn:JSONPath=".status.phase",description="phase",name="phase",type=string
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 type EgressNode struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
@@ -42,6 +43,22 @@ type EgressNodeStatus struct {
 	Phase EgressNodePhase `json:"phase,omitempty"`
 	// +kubebuilder:validation:Optional
 	Mark string `json:"mark,omitempty"`
+	// Policies are the policies programmed by the agent of the node
+	// +kubebuilder:validation:Optional
+	Policies []ProgrammedPolicy `json:"policies,omitempty"`
+}
+
+type ProgrammedPolicy struct {
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+	// Generation of the policy handled by the agent
+	// +kubebuilder:validation:Optional
+	Generation int64 `json:"generation,omitempty"`
+	// Error of programming the policy, it is empty when succeeded
+	// +kubebuilder:validation:Optional
+	Error string `json:"error,omitempty"`
 }
 
 type Tunnel struct {
@@ -20,10 +20,14 @@ type EgressPolicyList struct {
 // +kubebuilder:resource:categories={egresspolicy},path="egresspolicies",singular="egresspolicy",scope="Namespaced",shortName={egp}
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 // +kubebuilder:printcolumn:JSONPath=".spec.egressGatewayName",description="egressGatewayName",name="gateway",type=string
 // +kubebuilder:printcolumn:JSONPath=".status.eip.ipv4",description="ipv4",name="ipv4",type=string
 // +kubebuilder:printcolumn:JSONPath=".status.eip.ipv6",description="ipv6",name="ipv6",type=string
 // +kubebuilder:printcolumn:JSONPath=".status.node",description="egressNode",name="egressNode",type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.priority",description="priority",name="priority",type=integer,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type==\"Programmed\")].status",description="programmed",name="programmed",type=string
+// +kubebuilder:printcolumn:JSONPath=".status.matchedEndpoints",description="matchedEndpoints",name="endpoints",type=integer,priority=1
 type EgressPolicy struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
@@ -37,12 +41,33 @@ type EgressPolicySpec struct {
 	EgressGatewayName string `json:"egressGatewayName,omitempty"`
 	// +kubebuilder:validation:Optional
 	EgressIP EgressIP `json:"egressIP,omitempty"`
+	// GatewayReplicas is the number of gateway nodes the traffic of the policy
+	// is spread across, each of them has an EIP of the policy. It can be greater
+	// than 1 only with useNodeIP or the rr allocatorPolicy.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	GatewayReplicas int32 `json:"gatewayReplicas,omitempty"`
 	// +kubebuilder:validation:Required
 	AppliedTo AppliedTo `json:"appliedTo"`
 	// +kubebuilder:validation:Optional
 	DestSubnet []string `json:"destSubnet"`
+	// ExcludeDestSubnet is the destinations which are not forwarded to the
+	// gateway node, it takes precedence over destSubnet and destDomains
+	// +kubebuilder:validation:Optional
+	ExcludeDestSubnet []string `json:"excludeDestSubnet,omitempty"`
+	// DestDomains are resolved by the controller, the traffic to the resolved
+	// addresses is matched together with destSubnet
+	// +kubebuilder:validation:Optional
+	DestDomains []string `json:"destDomains,omitempty"`
+	// DestPorts limits the policy to the traffic of the given protocols and
+	// destination ports, all traffic to destSubnet is matched when it is empty
 	// +kubebuilder:validation:Optional
-	Priority uint64 `json:"priority,omitempty"`
+	DestPorts []PolicyPort `json:"destPorts,omitempty"`
+	// Priority of the policy, a smaller value means a higher priority, 0 is
+	// the highest
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=1000
+	Priority *uint64 `json:"priority,omitempty"`
 }
 
 type EgressPolicyStatus struct {
@@ -50,8 +75,35 @@ type EgressPolicyStatus struct {
 	Eip Eip `json:"eip,omitempty"`
 	// +kubebuilder:validation:Optional
 	Node string `json:"node,omitempty"`
+	// Gateways are all the gateway nodes of the policy when gatewayReplicas
+	// is greater than 1, eip and node are the first of them
+	// +kubebuilder:validation:Optional
+	Gateways []PolicyGateway `json:"gateways,omitempty"`
+	// MatchedEndpoints is the number of endpoints selected by the policy
+	// +kubebuilder:validation:Optional
+	MatchedEndpoints int32 `json:"matchedEndpoints,omitempty"`
+	// DestDomainIPs are the addresses of destDomains resolved by the
+	// controller, the agents of all nodes match the same addresses
+	// +kubebuilder:validation:Optional
+	DestDomainIPs IPListPair `json:"destDomainIPs,omitempty"`
+	// Conditions of the policy, the types are Accepted, Programmed and Degraded
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// PolicyConditionAccepted means a gateway node is selected for the policy
+	PolicyConditionAccepted = "Accepted"
+	// PolicyConditionProgrammed means the agents of all ready nodes have
+	// programmed the datapath of the latest generation of the policy
+	PolicyConditionProgrammed = "Programmed"
+	// PolicyConditionDegraded means an agent failed to program the policy,
+	// or the gateway node of the policy is not ready
+	PolicyConditionDegraded = "Degraded"
+)
+
 type Eip struct {
 	// +kubebuilder:validation:Optional
 	Ipv4 string `json:"ipv4,omitempty"`
@@ -59,6 +111,15 @@ type Eip struct {
 	Ipv6 string `json:"ipv6,omitempty"`
 }
 
+type PolicyGateway struct {
+	// +kubebuilder:validation:Optional
+	Node string `json:"node,omitempty"`
+	// +kubebuilder:validation:Optional
+	Ipv4 string `json:"ipv4,omitempty"`
+	// +kubebuilder:validation:Optional
+	Ipv6 string `json:"ipv6,omitempty"`
+}
+
 type EgressIP struct {
 	// +kubebuilder:validation:Optional
 	IPv4 string `json:"ipv4,omitempty"`
@@ -72,9 +133,31 @@ type EgressIP struct {
 	AllocatorPolicy string `json:"allocatorPolicy,omitempty"`
 }
 
+type PolicyPort struct {
+	// Protocol of the traffic, one of TCP, UDP and SCTP
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +kubebuilder:default:="TCP"
+	Protocol string `json:"protocol,omitempty"`
+	// Port is the destination port, all ports of the protocol are matched when it is not set
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+	// EndPort makes the range from port to endPort matched
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	EndPort int32 `json:"endPort,omitempty"`
+}
+
 type AppliedTo struct {
 	// +kubebuilder:validation:Optional
 	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// ExcludePodSelector excludes the pods selected by podSelector, it can
+	// not be used with podSubnet
+	// +kubebuilder:validation:Optional
+	ExcludePodSelector *metav1.LabelSelector `json:"excludePodSelector,omitempty"`
 	// +kubebuilder:validation:Optional
 	PodSubnet []string `json:"podSubnet,omitempty"`
 }
@@ -89,3 +172,43 @@ const (
 	// The unassigned EIP is preferred. If no EIP is available, select one at random
 	EipAllocatorRR = "rr"
 )
+
+const (
+	// DefaultPolicyPriority is the priority of an EgressPolicy without spec.priority
+	DefaultPolicyPriority uint64 = 1000
+	// DefaultClusterPolicyPriority is the priority of an EgressClusterPolicy without spec.priority
+	DefaultClusterPolicyPriority uint64 = 32768
+)
+
+// GetPriority returns the effective priority of the policy
+func (p *EgressPolicy) GetPriority() uint64 {
+	if p.Spec.Priority == nil {
+		return DefaultPolicyPriority
+	}
+	return *p.Spec.Priority
+}
+
+// GetGatewayReplicas returns the number of gateway nodes of the policy
+func (p *EgressPolicy) GetGatewayReplicas() int {
+	if p.Spec.GatewayReplicas < 1 {
+		return 1
+	}
+	return int(p.Spec.GatewayReplicas)
+}
+
+// PolicyLess reports whether policy a takes precedence over policy b.
+// The smaller priority wins; on a tie, a namespaced policy wins over
+// a cluster policy, then policies are ordered by namespace and name.
+func PolicyLess(a Policy, aPriority uint64, b Policy, bPriority uint64) bool {
+	if aPriority != bPriority {
+		return aPriority < bPriority
+	}
+	aCluster, bCluster := a.Namespace == "", b.Namespace == ""
+	if aCluster != bCluster {
+		return bCluster
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
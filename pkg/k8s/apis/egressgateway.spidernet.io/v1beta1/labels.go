@@ -4,3 +4,23 @@
 package v1beta1
 
 const LabelPolicyName = "spidernet.io/policy-name"
+
+// LabelNodeWeight is the node label or annotation read by the weighted
+// gateway node selection policy, the annotation takes precedence
+const LabelNodeWeight = "spidernet.io/gateway-weight"
+
+// AnnotationDefaultEgressGateway is the namespace annotation naming the
+// default EgressGateway of the namespace, the controller creates a managed
+// EgressPolicy selecting all pods of the namespace
+const AnnotationDefaultEgressGateway = "egressgateway.spidernet.io/default-egressgateway"
+
+// LabelDefaultPolicy marks the EgressPolicy managed for the default
+// EgressGateway of the namespace
+const LabelDefaultPolicy = "egressgateway.spidernet.io/default-policy"
+
+// LabelEgressNodeLease is the label of the lease renewed by the agent, the
+// value is the name of the EgressNode
+const LabelEgressNodeLease = "egressgateway.spidernet.io/egressnode"
+
+// EgressNodeLeasePrefix is the name prefix of the lease of the EgressNode
+const EgressNodeLeasePrefix = "egressnode-"
@@ -4,10 +4,12 @@
 // +kubebuilder:rbac:groups=egressgateway.spidernet.io,resources=egressgateways;egressnodes;egressclusterpolicies;egresspolicies;egressendpointslices;egressclusterendpointslices;egressclusterinfos,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=egressgateway.spidernet.io,resources=egressgateways/status;egressnodes/status;egressclusterpolicies/status;egresspolicies/status;egressclusterinfos/status,verbs=get;update;patch
 
-// +kubebuilder:rbac:groups="",resources=events,verbs=create;get;list;watch;update;delete
-// +kubebuilder:rbac:groups="coordination.k8s.io",resources=leases,verbs=create;get;update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;get;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups="coordination.k8s.io",resources=leases,verbs=create;get;list;watch;update
 // +kubebuilder:rbac:groups="",resources=nodes;namespaces;endpoints;pods;services,verbs=get;list;watch;update
 
+// +kubebuilder:rbac:groups="apiextensions.k8s.io",resources=customresourcedefinitions,verbs=get;list;watch;update;patch
+
 // +kubebuilder:rbac:groups=crd.projectcalico.org,resources=ippools,verbs=get;list;watch;create;update;patch;delete
 
 package v1beta1
@@ -18,6 +18,11 @@ func (in *AppliedTo) DeepCopyInto(out *AppliedTo) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExcludePodSelector != nil {
+		in, out := &in.ExcludePodSelector, &out.ExcludePodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.PodSubnet != nil {
 		in, out := &in.PodSubnet, &out.PodSubnet
 		*out = make([]string, len(*in))
@@ -43,6 +48,11 @@ func (in *ClusterAppliedTo) DeepCopyInto(out *ClusterAppliedTo) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExcludePodSelector != nil {
+		in, out := &in.ExcludePodSelector, &out.ExcludePodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.PodSubnet != nil {
 		in, out := &in.PodSubnet, &out.PodSubnet
 		*out = new([]string)
@@ -69,6 +79,21 @@ func (in *ClusterAppliedTo) DeepCopy() *ClusterAppliedTo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultEIPStatus) DeepCopyInto(out *DefaultEIPStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultEIPStatus.
+func (in *DefaultEIPStatus) DeepCopy() *DefaultEIPStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultEIPStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EgressClusterEndpointSlice) DeepCopyInto(out *EgressClusterEndpointSlice) {
 	*out = *in
@@ -198,7 +223,7 @@ func (in *EgressClusterPolicy) DeepCopyInto(out *EgressClusterPolicy) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressClusterPolicy.
@@ -261,6 +286,26 @@ func (in *EgressClusterPolicySpec) DeepCopyInto(out *EgressClusterPolicySpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExcludeDestSubnet != nil {
+		in, out := &in.ExcludeDestSubnet, &out.ExcludeDestSubnet
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestDomains != nil {
+		in, out := &in.DestDomains, &out.DestDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestPorts != nil {
+		in, out := &in.DestPorts, &out.DestPorts
+		*out = make([]PolicyPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(uint64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressClusterPolicySpec.
@@ -479,6 +524,22 @@ func (in *EgressGatewayStatus) DeepCopyInto(out *EgressGatewayStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	out.IPUsage = in.IPUsage
+	out.DefaultEIP = in.DefaultEIP
+	if in.PreferredNodes != nil {
+		in, out := &in.PreferredNodes, &out.PreferredNodes
+		*out = make([]PreferredNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressGatewayStatus.
@@ -552,7 +613,7 @@ func (in *EgressNode) DeepCopyInto(out *EgressNode) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressNode.
@@ -624,6 +685,11 @@ func (in *EgressNodeSpec) DeepCopy() *EgressNodeSpec {
 func (in *EgressNodeStatus) DeepCopyInto(out *EgressNodeStatus) {
 	*out = *in
 	out.Tunnel = in.Tunnel
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]ProgrammedPolicy, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressNodeStatus.
@@ -642,7 +708,7 @@ func (in *EgressPolicy) DeepCopyInto(out *EgressPolicy) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressPolicy.
@@ -705,6 +771,26 @@ func (in *EgressPolicySpec) DeepCopyInto(out *EgressPolicySpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExcludeDestSubnet != nil {
+		in, out := &in.ExcludeDestSubnet, &out.ExcludeDestSubnet
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestDomains != nil {
+		in, out := &in.DestDomains, &out.DestDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestPorts != nil {
+		in, out := &in.DestPorts, &out.DestPorts
+		*out = make([]PolicyPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(uint64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressPolicySpec.
@@ -721,6 +807,19 @@ func (in *EgressPolicySpec) DeepCopy() *EgressPolicySpec {
 func (in *EgressPolicyStatus) DeepCopyInto(out *EgressPolicyStatus) {
 	*out = *in
 	out.Eip = in.Eip
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]PolicyGateway, len(*in))
+		copy(*out, *in)
+	}
+	in.DestDomainIPs.DeepCopyInto(&out.DestDomainIPs)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressPolicyStatus.
@@ -793,6 +892,21 @@ func (in *IPListPair) DeepCopy() *IPListPair {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPUsage) DeepCopyInto(out *IPUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPUsage.
+func (in *IPUsage) DeepCopy() *IPUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(IPUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Ippools) DeepCopyInto(out *Ippools) {
 	*out = *in
@@ -868,6 +982,75 @@ func (in *Policy) DeepCopy() *Policy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyGateway) DeepCopyInto(out *PolicyGateway) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyGateway.
+func (in *PolicyGateway) DeepCopy() *PolicyGateway {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyGateway)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyPort) DeepCopyInto(out *PolicyPort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyPort.
+func (in *PolicyPort) DeepCopy() *PolicyPort {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreferredNode) DeepCopyInto(out *PreferredNode) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]Policy, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReadyTime != nil {
+		in, out := &in.ReadyTime, &out.ReadyTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreferredNode.
+func (in *PreferredNode) DeepCopy() *PreferredNode {
+	if in == nil {
+		return nil
+	}
+	out := new(PreferredNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProgrammedPolicy) DeepCopyInto(out *ProgrammedPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProgrammedPolicy.
+func (in *ProgrammedPolicy) DeepCopy() *ProgrammedPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgrammedPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Tunnel) DeepCopyInto(out *Tunnel) {
 	*out = *in
@@ -342,6 +342,20 @@ func (a *Announce) AnnounceName(name string) bool {
 	return ok
 }
 
+// GetBalancers returns the announced IPs by name.
+func (a *Announce) GetBalancers() map[string][]net.IP {
+	a.RLock()
+	defer a.RUnlock()
+
+	res := make(map[string][]net.IP, len(a.ips))
+	for name, advs := range a.ips {
+		for _, adv := range advs {
+			res[name] = append(res[name], adv.ip)
+		}
+	}
+	return res
+}
+
 // GetInterfaces returns current interfaces list.
 func (a *Announce) GetInterfaces() []string {
 	a.Lock()
@@ -23,6 +23,10 @@ type Interface interface {
 	AllocateNext() (string, error)
 	Release(mark string) error
 	ForEach(func(mark string))
+	// Used returns the number of the allocated marks
+	Used() int
+	// Size returns the number of the marks of the range
+	Size() int
 
 	// Has function for testing
 	Has(mark string) bool
@@ -156,6 +160,16 @@ func (r *Range) ForEach(fn func(mark string)) {
 	})
 }
 
+// Used returns the number of the allocated marks
+func (r *Range) Used() int {
+	return r.max - r.alloc.Free()
+}
+
+// Size returns the number of the marks of the range
+func (r *Range) Size() int {
+	return r.max
+}
+
 // GetIndexedMark returns a string that is r.base + index in the contiguous mark space.
 func GetIndexedMark(base *big.Int, index int) (string, error) {
 	mark := addMarkOffset(base, index)
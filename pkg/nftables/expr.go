@@ -0,0 +1,271 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package nftables
+
+import (
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// expr is an expression of a rule, the kernel evaluates the expressions of
+// a rule one by one until one of them breaks
+type expr interface {
+	// name is the name of the expression in the kernel
+	name() string
+	// attrs are the attributes of the expression
+	attrs() []*nl.RtAttr
+}
+
+func marshalExprs(exprs []expr) *nl.RtAttr {
+	list := make([]*nl.RtAttr, 0, len(exprs))
+	for _, e := range exprs {
+		elem := nested(unix.NFTA_LIST_ELEM, stringAttr(unix.NFTA_EXPR_NAME, e.name()))
+		if attrs := e.attrs(); len(attrs) > 0 {
+			elem.AddChild(nested(unix.NFTA_EXPR_DATA, attrs...))
+		}
+		list = append(list, elem)
+	}
+	return nested(unix.NFTA_RULE_EXPRESSIONS, list...)
+}
+
+// payloadExpr loads the bytes of a header of the packet into the register
+type payloadExpr struct {
+	base   uint32
+	offset uint32
+	len    uint32
+}
+
+func (payloadExpr) name() string { return "payload" }
+
+func (e payloadExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		attr(unix.NFTA_PAYLOAD_DREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_PAYLOAD_BASE, be32(e.base)),
+		attr(unix.NFTA_PAYLOAD_OFFSET, be32(e.offset)),
+		attr(unix.NFTA_PAYLOAD_LEN, be32(e.len)),
+	}
+}
+
+// metaExpr loads the meta key of the packet into the register, or sets it
+// from the register
+type metaExpr struct {
+	key uint32
+	set bool
+}
+
+func (metaExpr) name() string { return "meta" }
+
+func (e metaExpr) attrs() []*nl.RtAttr {
+	reg := uint16(unix.NFTA_META_DREG)
+	if e.set {
+		reg = unix.NFTA_META_SREG
+	}
+	return []*nl.RtAttr{
+		attr(unix.NFTA_META_KEY, be32(e.key)),
+		attr(reg, be32(unix.NFT_REG_1)),
+	}
+}
+
+// ctExpr loads the conntrack key of the packet into the register, or sets
+// it from the register
+type ctExpr struct {
+	key uint32
+	set bool
+}
+
+func (ctExpr) name() string { return "ct" }
+
+func (e ctExpr) attrs() []*nl.RtAttr {
+	reg := uint16(unix.NFTA_CT_DREG)
+	if e.set {
+		reg = unix.NFTA_CT_SREG
+	}
+	return []*nl.RtAttr{
+		attr(unix.NFTA_CT_KEY, be32(e.key)),
+		attr(reg, be32(unix.NFT_REG_1)),
+	}
+}
+
+// bitwiseExpr sets the register to (register & mask) ^ xor
+type bitwiseExpr struct {
+	mask []byte
+	xor  []byte
+}
+
+func (bitwiseExpr) name() string { return "bitwise" }
+
+func (e bitwiseExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		attr(unix.NFTA_BITWISE_SREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_BITWISE_DREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_BITWISE_LEN, be32(uint32(len(e.mask)))),
+		dataValue(unix.NFTA_BITWISE_MASK, e.mask),
+		dataValue(unix.NFTA_BITWISE_XOR, e.xor),
+	}
+}
+
+// cmpExpr compares the register with the data
+type cmpExpr struct {
+	op   uint32
+	data []byte
+}
+
+func (cmpExpr) name() string { return "cmp" }
+
+func (e cmpExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		attr(unix.NFTA_CMP_SREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_CMP_OP, be32(e.op)),
+		dataValue(unix.NFTA_CMP_DATA, e.data),
+	}
+}
+
+// lookupExpr looks the register up in the set
+type lookupExpr struct {
+	set    string
+	setID  uint32
+	invert bool
+}
+
+func (lookupExpr) name() string { return "lookup" }
+
+func (e lookupExpr) attrs() []*nl.RtAttr {
+	var flags uint32
+	if e.invert {
+		flags = unix.NFT_LOOKUP_F_INV
+	}
+	res := []*nl.RtAttr{
+		stringAttr(unix.NFTA_LOOKUP_SET, e.set),
+		attr(unix.NFTA_LOOKUP_SREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_LOOKUP_FLAGS, be32(flags)),
+	}
+	if e.setID != 0 {
+		res = append(res, attr(unix.NFTA_LOOKUP_SET_ID, be32(e.setID)))
+	}
+	return res
+}
+
+// immediateExpr loads the data into the register
+type immediateExpr struct {
+	data []byte
+}
+
+func (immediateExpr) name() string { return "immediate" }
+
+func (e immediateExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		attr(unix.NFTA_IMMEDIATE_DREG, be32(unix.NFT_REG_1)),
+		dataValue(unix.NFTA_IMMEDIATE_DATA, e.data),
+	}
+}
+
+// verdictExpr ends the evaluation of the rule with the verdict, chain is
+// the target of a jump or a goto
+type verdictExpr struct {
+	code  int32
+	chain string
+}
+
+func (verdictExpr) name() string { return "immediate" }
+
+func (e verdictExpr) attrs() []*nl.RtAttr {
+	verdict := nested(unix.NFTA_DATA_VERDICT, attr(unix.NFTA_VERDICT_CODE, be32(uint32(e.code))))
+	if e.chain != "" {
+		verdict.AddChild(stringAttr(unix.NFTA_VERDICT_CHAIN, e.chain))
+	}
+	return []*nl.RtAttr{
+		attr(unix.NFTA_IMMEDIATE_DREG, be32(unix.NFT_REG_VERDICT)),
+		nested(unix.NFTA_IMMEDIATE_DATA, verdict),
+	}
+}
+
+// numgenExpr loads a random number in [0, mod) into the register
+type numgenExpr struct {
+	mod uint32
+}
+
+func (numgenExpr) name() string { return "numgen" }
+
+func (e numgenExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		attr(unix.NFTA_NG_DREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_NG_MODULUS, be32(e.mod)),
+		attr(unix.NFTA_NG_TYPE, be32(unix.NFT_NG_RANDOM)),
+		attr(unix.NFTA_NG_OFFSET, be32(0)),
+	}
+}
+
+// byteorderExpr converts the 32 bits register from the host byte order to
+// the network byte order, so that it is compared as a number
+type byteorderExpr struct{}
+
+func (byteorderExpr) name() string { return "byteorder" }
+
+func (byteorderExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		attr(unix.NFTA_BYTEORDER_SREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_BYTEORDER_DREG, be32(unix.NFT_REG_1)),
+		attr(unix.NFTA_BYTEORDER_OP, be32(unix.NFT_BYTEORDER_HTON)),
+		attr(unix.NFTA_BYTEORDER_LEN, be32(4)),
+		attr(unix.NFTA_BYTEORDER_SIZE, be32(4)),
+	}
+}
+
+// counterExpr counts the packets and the bytes reaching it
+type counterExpr struct{}
+
+func (counterExpr) name() string { return "counter" }
+
+func (counterExpr) attrs() []*nl.RtAttr { return nil }
+
+// natExpr NATs the connection to the address of the register, typ is
+// unix.NFT_NAT_SNAT or unix.NFT_NAT_DNAT
+type natExpr struct {
+	typ    uint32
+	family uint32
+}
+
+func (natExpr) name() string { return "nat" }
+
+func (e natExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		attr(unix.NFTA_NAT_TYPE, be32(e.typ)),
+		attr(unix.NFTA_NAT_FAMILY, be32(e.family)),
+		attr(unix.NFTA_NAT_REG_ADDR_MIN, be32(unix.NFT_REG_1)),
+	}
+}
+
+// targetExpr runs the xtables target through nft_compat, as iptables-nft
+// does for the targets nftables cannot express
+type targetExpr struct {
+	target string
+	rev    uint32
+	info   []byte
+}
+
+func (targetExpr) name() string { return "target" }
+
+func (e targetExpr) attrs() []*nl.RtAttr {
+	return []*nl.RtAttr{
+		stringAttr(unix.NFTA_TARGET_NAME, e.target),
+		attr(unix.NFTA_TARGET_REV, be32(e.rev)),
+		attr(unix.NFTA_TARGET_INFO, e.info),
+	}
+}
+
+// the modes of the CONNMARK target
+const (
+	connmarkSave    = 1
+	connmarkRestore = 2
+)
+
+// connmarkTarget returns the CONNMARK target of revision 1, the info is
+// struct xt_connmark_tginfo1 in the host byte order
+func connmarkTarget(mode uint8, ctmask, nfmask uint32) targetExpr {
+	info := make([]byte, 16)
+	nl.NativeEndian().PutUint32(info[4:], ctmask)
+	nl.NativeEndian().PutUint32(info[8:], nfmask)
+	info[12] = mode
+	return targetExpr{target: "CONNMARK", rev: 1, info: info}
+}
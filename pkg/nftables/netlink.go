@@ -0,0 +1,262 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package nftables
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// the verdicts of the netfilter hooks
+const (
+	nfDrop   = 0
+	nfAccept = 1
+)
+
+// receiveTimeout bounds the wait for the acks of the kernel
+const receiveTimeout = 10 * time.Second
+
+var nextSeq uint32
+
+// message is a message of the nf_tables netlink subsystem
+type message struct {
+	// typ is one of unix.NFT_MSG_*
+	typ    uint16
+	flags  uint16
+	family uint8
+	attrs  []*nl.RtAttr
+	// desc describes the message in the errors
+	desc string
+}
+
+func (m message) serialize(seq uint32, flags uint16) []byte {
+	return serializeMessage(unix.NFNL_SUBSYS_NFTABLES<<8|m.typ, m.flags|flags, seq, m.family, 0, m.attrs)
+}
+
+func serializeMessage(typ, flags uint16, seq uint32, family uint8, resID uint16, attrs []*nl.RtAttr) []byte {
+	// nfgenmsg: family, version and the big endian resource id
+	data := []byte{family, unix.NFNETLINK_V0, 0, 0}
+	binary.BigEndian.PutUint16(data[2:], resID)
+	for _, attr := range attrs {
+		data = append(data, attr.Serialize()...)
+	}
+
+	req := nl.NewNetlinkRequest(int(typ), int(flags))
+	req.Seq = seq
+	req.AddRawData(data)
+	return req.Serialize()
+}
+
+// conn sends the messages to the kernel
+type conn interface {
+	// send sends the messages in a batch, the kernel applies all of them or
+	// none of them
+	send(msgs []message) error
+	// dump sends a dump request and returns the payload of the replies
+	dump(msg message) ([][]byte, error)
+}
+
+// netlinkConn opens a netfilter netlink socket for each request
+type netlinkConn struct{}
+
+func openSocket() (int, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_NETFILTER)
+	if err != nil {
+		return -1, fmt.Errorf("failed to open netfilter netlink socket: %v", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to bind netfilter netlink socket: %v", err)
+	}
+	tv := unix.NsecToTimeval(receiveTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to set the receive timeout: %v", err)
+	}
+	return fd, nil
+}
+
+func (netlinkConn) send(msgs []message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	fd, err := openSocket()
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	base := atomic.AddUint32(&nextSeq, uint32(len(msgs)+2))
+	seqs := make(map[uint32]message, len(msgs))
+	buf := serializeMessage(unix.NFNL_MSG_BATCH_BEGIN, 0, base, unix.AF_UNSPEC, unix.NFNL_SUBSYS_NFTABLES, nil)
+	for i, msg := range msgs {
+		seq := base + uint32(i) + 1
+		seqs[seq] = msg
+		buf = append(buf, msg.serialize(seq, unix.NLM_F_ACK)...)
+	}
+	buf = append(buf, serializeMessage(unix.NFNL_MSG_BATCH_END, 0, base+uint32(len(msgs))+1,
+		unix.AF_UNSPEC, unix.NFNL_SUBSYS_NFTABLES, nil)...)
+
+	// the batch is handled in a single skb, the send buffer must hold it
+	if size, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUF); err == nil && size < len(buf) {
+		_ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUFFORCE, len(buf))
+	}
+	if err := unix.Sendto(fd, buf, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return fmt.Errorf("failed to send the batch: %v", err)
+	}
+
+	acked := 0
+	for acked < len(msgs) {
+		replies, err := receive(fd)
+		if err != nil {
+			return err
+		}
+		for _, reply := range replies {
+			if reply.Header.Type != unix.NLMSG_ERROR {
+				continue
+			}
+			if err := replyError(reply); err != nil {
+				if msg, ok := seqs[reply.Header.Seq]; ok {
+					return fmt.Errorf("failed to %s: %v", msg.desc, err)
+				}
+				return err
+			}
+			acked++
+		}
+	}
+	return nil
+}
+
+func (netlinkConn) dump(msg message) ([][]byte, error) {
+	fd, err := openSocket()
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	seq := atomic.AddUint32(&nextSeq, 1)
+	if err := unix.Sendto(fd, msg.serialize(seq, unix.NLM_F_DUMP), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("failed to %s: %v", msg.desc, err)
+	}
+
+	res := make([][]byte, 0)
+	for {
+		replies, err := receive(fd)
+		if err != nil {
+			return nil, err
+		}
+		for _, reply := range replies {
+			switch reply.Header.Type {
+			case unix.NLMSG_DONE:
+				return res, nil
+			case unix.NLMSG_ERROR:
+				if err := replyError(reply); err != nil {
+					return nil, fmt.Errorf("failed to %s: %w", msg.desc, err)
+				}
+			default:
+				res = append(res, reply.Data)
+			}
+		}
+	}
+}
+
+func receive(fd int) ([]syscall.NetlinkMessage, error) {
+	buf := make([]byte, 1<<16)
+	n, _, err := unix.Recvfrom(fd, buf, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive from netfilter netlink socket: %v", err)
+	}
+	replies, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse netlink message: %v", err)
+	}
+	return replies, nil
+}
+
+// replyError returns the error of an NLMSG_ERROR reply, it is nil for an ack
+func replyError(reply syscall.NetlinkMessage) error {
+	if len(reply.Data) < 4 {
+		return fmt.Errorf("short netlink error message")
+	}
+	code := int32(nl.NativeEndian().Uint32(reply.Data[:4]))
+	if code == 0 {
+		return nil
+	}
+	return syscall.Errno(-code)
+}
+
+// parseAttrs parses the attributes of a reply, the nfgenmsg header is skipped
+func parseAttrs(data []byte) (map[uint16][]byte, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("short nf_tables message")
+	}
+	return parseNested(data[4:])
+}
+
+func parseNested(data []byte) (map[uint16][]byte, error) {
+	attrs, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uint16][]byte, len(attrs))
+	for _, attr := range attrs {
+		res[attr.Attr.Type&^unix.NLA_F_NESTED] = attr.Value
+	}
+	return res, nil
+}
+
+// parseList parses a nested list of NFTA_LIST_ELEM attributes
+func parseList(data []byte) ([][]byte, error) {
+	attrs, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return nil, err
+	}
+	res := make([][]byte, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Attr.Type&^unix.NLA_F_NESTED == unix.NFTA_LIST_ELEM {
+			res = append(res, attr.Value)
+		}
+	}
+	return res, nil
+}
+
+func be32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func native32(v uint32) []byte {
+	b := make([]byte, 4)
+	nl.NativeEndian().PutUint32(b, v)
+	return b
+}
+
+func attr(typ uint16, data []byte) *nl.RtAttr {
+	return nl.NewRtAttr(int(typ), data)
+}
+
+func stringAttr(typ uint16, s string) *nl.RtAttr {
+	return nl.NewRtAttr(int(typ), nl.ZeroTerminated(s))
+}
+
+// nested returns a nested attribute of the children
+func nested(typ uint16, children ...*nl.RtAttr) *nl.RtAttr {
+	res := nl.NewRtAttr(int(typ|unix.NLA_F_NESTED), nil)
+	for _, child := range children {
+		res.AddChild(child)
+	}
+	return res
+}
+
+// dataValue returns the nested NFTA_DATA_VALUE attribute of the value
+func dataValue(typ uint16, value []byte) *nl.RtAttr {
+	return nested(typ, attr(unix.NFTA_DATA_VALUE, value))
+}
@@ -0,0 +1,442 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package nftables
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+)
+
+// the modulus of the random number matching the statistic probability
+const probabilityModulus = 100000
+
+// the conntrack states of the ct state key
+var ctStates = map[string]uint32{
+	"INVALID":     1,
+	"ESTABLISHED": 2,
+	"RELATED":     4,
+	"NEW":         8,
+	"UNTRACKED":   64,
+}
+
+var (
+	setRegexp         = regexp.MustCompile(`^-m set (! )?--match-set (\S+) (src|dst)$`)
+	markRegexp        = regexp.MustCompile(`^-m (mark|connmark) (! )?--mark (0x[0-9a-f]+)/(0x[0-9a-f]+)$`)
+	ctDirectionRegexp = regexp.MustCompile(`^-m conntrack --ctdir (ORIGINAL|REPLY)$`)
+	ctStateRegexp     = regexp.MustCompile(`^-m conntrack (! )?--ctstate (\S+)$`)
+	statisticRegexp   = regexp.MustCompile(`^-m statistic --mode random --probability (\S+)$`)
+	protocolRegexp    = regexp.MustCompile(`^(! )?-p (\S+)$`)
+	portsRegexp       = regexp.MustCompile(`^-m multiport (! )?--(source|destination)-ports (\S+)$`)
+	netRegexp         = regexp.MustCompile(`^(! )?--(source|destination) (\S+)$`)
+	interfaceRegexp   = regexp.MustCompile(`^--(in|out)-interface (\S+)$`)
+)
+
+// rule is a rule rendered for the kernel, text is the rule in the syntax of
+// nft, it is reported by Dump
+type rule struct {
+	exprs []expr
+	text  []string
+	// sets are the anonymous sets of the rule, they are created before it
+	sets []*anonSet
+}
+
+func (r *rule) add(text string, exprs ...expr) {
+	r.text = append(r.text, text)
+	r.exprs = append(r.exprs, exprs...)
+}
+
+func (r *rule) String() string {
+	return strings.Join(r.text, " ")
+}
+
+// renderer renders the rules of a chain of the family
+type renderer struct {
+	version uint8
+	// nat is the NAT type of the hook running the chain, snat or dnat
+	nat string
+	// chainName returns the name of a chain of the table in the kernel
+	chainName func(name string) string
+	// setID returns the ID of a set, it is 0 when the set is not known
+	setID func(name string) uint32
+	// anonID returns the ID of a new anonymous set
+	anonID func() uint32
+}
+
+// render renders the rule, the matches are followed by a counter
+func (r renderer) render(in iptables.Rule) (*rule, error) {
+	res := &rule{}
+	for _, match := range in.Match {
+		if err := r.renderMatch(res, match); err != nil {
+			return nil, err
+		}
+	}
+	res.add("counter", counterExpr{})
+	if in.Action == nil {
+		return res, nil
+	}
+	if err := r.renderAction(res, in.Action); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r renderer) renderMatch(res *rule, match string) error {
+	if m := setRegexp.FindStringSubmatch(match); m != nil {
+		if r.setID(m[2]) == 0 {
+			return fmt.Errorf("set %s of match %q does not exist", m[2], match)
+		}
+		field := "saddr"
+		if m[3] == "dst" {
+			field = "daddr"
+		}
+		op := ""
+		if m[1] != "" {
+			op = "!= "
+		}
+		res.add(fmt.Sprintf("%s %s %s@%s", r.family(), field, op, m[2]),
+			r.addrPayload(field), lookupExpr{set: m[2], setID: r.setID(m[2]), invert: m[1] != ""})
+		return nil
+	}
+
+	if m := markRegexp.FindStringSubmatch(match); m != nil {
+		mark, _ := strconv.ParseUint(m[3], 0, 32)
+		mask, _ := strconv.ParseUint(m[4], 0, 32)
+		load, key := expr(metaExpr{key: unix.NFT_META_MARK}), "meta mark"
+		if m[1] == "connmark" {
+			load, key = ctExpr{key: unix.NFT_CT_MARK}, "ct mark"
+		}
+		op, cmpOp := "==", uint32(unix.NFT_CMP_EQ)
+		if m[2] != "" {
+			op, cmpOp = "!=", unix.NFT_CMP_NEQ
+		}
+		text := fmt.Sprintf("%s %s %#x", key, op, mark)
+		exprs := []expr{load}
+		if mask != math.MaxUint32 {
+			text = fmt.Sprintf("%s & %#x %s %#x", key, mask, op, mark)
+			exprs = append(exprs, bitwiseExpr{mask: native32(uint32(mask)), xor: native32(0)})
+		}
+		res.add(text, append(exprs, cmpExpr{op: cmpOp, data: native32(uint32(mark))})...)
+		return nil
+	}
+
+	if m := ctDirectionRegexp.FindStringSubmatch(match); m != nil {
+		var dir byte
+		if m[1] == string(iptables.DirectionReply) {
+			dir = 1
+		}
+		res.add("ct direction "+strings.ToLower(m[1]),
+			ctExpr{key: unix.NFT_CT_DIRECTION}, cmpExpr{op: unix.NFT_CMP_EQ, data: []byte{dir}})
+		return nil
+	}
+
+	if m := ctStateRegexp.FindStringSubmatch(match); m != nil {
+		var states uint32
+		for _, name := range strings.Split(m[2], ",") {
+			state, ok := ctStates[name]
+			if !ok {
+				return fmt.Errorf("unsupported conntrack state %s of match %q", name, match)
+			}
+			states |= state
+		}
+		op, cmpOp := "", uint32(unix.NFT_CMP_NEQ)
+		if m[1] != "" {
+			op, cmpOp = "!= ", unix.NFT_CMP_EQ
+		}
+		res.add(fmt.Sprintf("ct state %s%s", op, strings.ToLower(m[2])),
+			ctExpr{key: unix.NFT_CT_STATE},
+			bitwiseExpr{mask: native32(states), xor: native32(0)},
+			cmpExpr{op: cmpOp, data: native32(0)})
+		return nil
+	}
+
+	if m := statisticRegexp.FindStringSubmatch(match); m != nil {
+		probability, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse probability of match %q: %v", match, err)
+		}
+		threshold := uint32(math.Round(probability * probabilityModulus))
+		res.add(fmt.Sprintf("numgen random mod %d < %d", probabilityModulus, threshold),
+			numgenExpr{mod: probabilityModulus}, byteorderExpr{}, cmpExpr{op: unix.NFT_CMP_LT, data: be32(threshold)})
+		return nil
+	}
+
+	if m := protocolRegexp.FindStringSubmatch(match); m != nil {
+		proto, err := protocolNumber(m[2])
+		if err != nil {
+			return err
+		}
+		op, cmpOp := "", uint32(unix.NFT_CMP_EQ)
+		if m[1] != "" {
+			op, cmpOp = "!= ", unix.NFT_CMP_NEQ
+		}
+		res.add(fmt.Sprintf("meta l4proto %s%s", op, m[2]),
+			metaExpr{key: unix.NFT_META_L4PROTO}, cmpExpr{op: cmpOp, data: []byte{proto}})
+		return nil
+	}
+
+	if m := portsRegexp.FindStringSubmatch(match); m != nil {
+		field, offset := "sport", uint32(0)
+		if m[2] == "destination" {
+			field, offset = "dport", 2
+		}
+		load := payloadExpr{base: unix.NFT_PAYLOAD_TRANSPORT_HEADER, offset: offset, len: 2}
+		if strings.Contains(m[3], ",") {
+			return r.renderPortList(res, field, load, m[1] != "", m[3], match)
+		}
+		first, last, err := parsePortRange(m[3])
+		if err != nil {
+			return fmt.Errorf("failed to parse ports of match %q: %v", match, err)
+		}
+		if m[1] != "" {
+			if first != last {
+				return fmt.Errorf("negated port range of match %q is not supported", match)
+			}
+			res.add(fmt.Sprintf("th %s != %d", field, first), load, cmpExpr{op: unix.NFT_CMP_NEQ, data: be16(first)})
+			return nil
+		}
+		if first == last {
+			res.add(fmt.Sprintf("th %s %d", field, first), load, cmpExpr{op: unix.NFT_CMP_EQ, data: be16(first)})
+			return nil
+		}
+		res.add(fmt.Sprintf("th %s %d-%d", field, first, last), load,
+			cmpExpr{op: unix.NFT_CMP_GTE, data: be16(first)}, cmpExpr{op: unix.NFT_CMP_LTE, data: be16(last)})
+		return nil
+	}
+
+	if m := netRegexp.FindStringSubmatch(match); m != nil {
+		field := "saddr"
+		if m[2] == "destination" {
+			field = "daddr"
+		}
+		ipNet, err := r.parseNet(m[3])
+		if err != nil {
+			return fmt.Errorf("failed to parse network of match %q: %v", match, err)
+		}
+		op, cmpOp := "", uint32(unix.NFT_CMP_EQ)
+		if m[1] != "" {
+			op, cmpOp = "!= ", unix.NFT_CMP_NEQ
+		}
+		res.add(fmt.Sprintf("%s %s %s%s", r.family(), field, op, ipNet),
+			r.addrPayload(field),
+			bitwiseExpr{mask: ipNet.Mask, xor: make([]byte, len(ipNet.Mask))},
+			cmpExpr{op: cmpOp, data: ipNet.IP})
+		return nil
+	}
+
+	if m := interfaceRegexp.FindStringSubmatch(match); m != nil {
+		key, field := uint32(unix.NFT_META_IIFNAME), "iifname"
+		if m[1] == "out" {
+			key, field = unix.NFT_META_OIFNAME, "oifname"
+		}
+		// the name of the interface is matched as a prefix when it ends with +
+		name := []byte(strings.TrimSuffix(m[2], "+"))
+		data := make([]byte, unix.IFNAMSIZ)
+		copy(data, name)
+		if strings.HasSuffix(m[2], "+") {
+			data = name
+		}
+		res.add(fmt.Sprintf("%s %q", field, strings.ReplaceAll(m[2], "+", "*")),
+			metaExpr{key: key}, cmpExpr{op: unix.NFT_CMP_EQ, data: data})
+		return nil
+	}
+
+	return fmt.Errorf("unsupported match %q", match)
+}
+
+// renderPortList looks the port up in an anonymous set of the ports and the
+// port ranges of the list
+func (r renderer) renderPortList(res *rule, field string, load payloadExpr, invert bool, list, match string) error {
+	items := strings.Split(list, ",")
+	spans := make([]span, 0, len(items))
+	for i, item := range items {
+		first, last, err := parsePortRange(item)
+		if err != nil {
+			return fmt.Errorf("failed to parse ports of match %q: %v", match, err)
+		}
+		spans = append(spans, span{first: be16(first), last: be16(last)})
+		items[i] = strings.Replace(item, ":", "-", 1)
+	}
+	s := &anonSet{id: r.anonID(), keyType: typeInetService, keyLen: 2, intervals: mergeSpans(spans)}
+	op := ""
+	if invert {
+		op = "!= "
+	}
+	res.sets = append(res.sets, s)
+	res.add(fmt.Sprintf("th %s %s{ %s }", field, op, strings.Join(items, ", ")),
+		load, lookupExpr{set: anonSetName, setID: s.id, invert: invert})
+	return nil
+}
+
+func (r renderer) renderAction(res *rule, action iptables.Action) error {
+	switch action := action.(type) {
+	case iptables.AcceptAction:
+		if r.nat == "" {
+			res.add("accept", verdictExpr{code: nfAccept})
+			break
+		}
+		// an accept does not stop the NAT chains hooked after the table, e.g.
+		// the MASQUERADE of the CNI, but they are skipped once the connection
+		// is NATed, so the connection is bound to its own address
+		typ, field := uint32(unix.NFT_NAT_SNAT), "saddr"
+		if r.nat == "dnat" {
+			typ, field = unix.NFT_NAT_DNAT, "daddr"
+		}
+		res.add(fmt.Sprintf("%s to %s %s", r.nat, r.family(), field),
+			r.addrPayload(field), natExpr{typ: typ, family: uint32(family(r.version))})
+	case iptables.DropAction:
+		res.add("drop", verdictExpr{code: nfDrop})
+	case iptables.ReturnAction:
+		res.add("return", verdictExpr{code: unix.NFT_RETURN})
+	case iptables.JumpAction:
+		chain := r.chainName(action.Target)
+		res.add("jump "+chain, verdictExpr{code: unix.NFT_JUMP, chain: chain})
+	case iptables.GotoAction:
+		chain := r.chainName(action.Target)
+		res.add("goto "+chain, verdictExpr{code: unix.NFT_GOTO, chain: chain})
+	case iptables.SetMaskedMarkAction:
+		setMark(res, "meta mark", metaExpr{key: unix.NFT_META_MARK}, action.Mark, action.Mask)
+	case iptables.SetMarkAction:
+		setMark(res, "meta mark", metaExpr{key: unix.NFT_META_MARK}, action.Mark, action.Mark)
+	case iptables.ClearMarkAction:
+		setMark(res, "meta mark", metaExpr{key: unix.NFT_META_MARK}, 0, action.Mark)
+	case iptables.SetConnMarkAction:
+		mask := action.Mask
+		if mask == 0 {
+			mask = math.MaxUint32
+		}
+		setMark(res, "ct mark", ctExpr{key: unix.NFT_CT_MARK}, action.Mark, mask)
+	case iptables.SaveConnMarkAction:
+		// the bitwise expression only takes constants, so the bits of the
+		// packet mark are merged into the ct mark by the CONNMARK target
+		if action.SaveMask != 0 && action.SaveMask != math.MaxUint32 {
+			res.add(fmt.Sprintf("ct mark set ct mark & %#x | meta mark & %#x", ^action.SaveMask, action.SaveMask),
+				connmarkTarget(connmarkSave, action.SaveMask, action.SaveMask))
+			break
+		}
+		res.add("ct mark set meta mark", metaExpr{key: unix.NFT_META_MARK}, ctExpr{key: unix.NFT_CT_MARK, set: true})
+	case iptables.RestoreConnMarkAction:
+		if action.RestoreMask != 0 && action.RestoreMask != math.MaxUint32 {
+			res.add(fmt.Sprintf("meta mark set meta mark & %#x | ct mark & %#x", ^action.RestoreMask, action.RestoreMask),
+				connmarkTarget(connmarkRestore, action.RestoreMask, action.RestoreMask))
+			break
+		}
+		res.add("meta mark set ct mark", ctExpr{key: unix.NFT_CT_MARK}, metaExpr{key: unix.NFT_META_MARK, set: true})
+	case iptables.SNATAction:
+		ip := net.ParseIP(action.ToAddr)
+		if r.version == 4 {
+			ip = ip.To4()
+		}
+		if ip == nil {
+			return fmt.Errorf("invalid SNAT address %s for IPv%d", action.ToAddr, r.version)
+		}
+		res.add("snat to "+action.ToAddr, immediateExpr{data: ip},
+			natExpr{typ: unix.NFT_NAT_SNAT, family: uint32(family(r.version))})
+	default:
+		return fmt.Errorf("unsupported action %s", action)
+	}
+	return nil
+}
+
+// setMark sets the bits of the mask of the mark to value, the other bits
+// are kept
+func setMark(res *rule, key string, e expr, value, mask uint32) {
+	set := func(e expr) expr {
+		switch e := e.(type) {
+		case metaExpr:
+			e.set = true
+			return e
+		case ctExpr:
+			e.set = true
+			return e
+		}
+		return e
+	}
+	if mask == math.MaxUint32 {
+		res.add(fmt.Sprintf("%s set %#x", key, value), immediateExpr{data: native32(value)}, set(e))
+		return
+	}
+	res.add(fmt.Sprintf("%s set %s & %#x ^ %#x", key, key, ^mask, value),
+		e, bitwiseExpr{mask: native32(^mask), xor: native32(value)}, set(e))
+}
+
+func (r renderer) family() string {
+	if r.version == 6 {
+		return "ip6"
+	}
+	return "ip"
+}
+
+// addrPayload loads the source or the destination address of the packet
+func (r renderer) addrPayload(field string) payloadExpr {
+	if r.version == 6 {
+		if field == "saddr" {
+			return payloadExpr{base: unix.NFT_PAYLOAD_NETWORK_HEADER, offset: 8, len: 16}
+		}
+		return payloadExpr{base: unix.NFT_PAYLOAD_NETWORK_HEADER, offset: 24, len: 16}
+	}
+	if field == "saddr" {
+		return payloadExpr{base: unix.NFT_PAYLOAD_NETWORK_HEADER, offset: 12, len: 4}
+	}
+	return payloadExpr{base: unix.NFT_PAYLOAD_NETWORK_HEADER, offset: 16, len: 4}
+}
+
+func (r renderer) parseNet(s string) (*net.IPNet, error) {
+	ipNet, err := parseEntry(s, r.version)
+	if err != nil {
+		return nil, err
+	}
+	return ipNet, nil
+}
+
+func protocolNumber(name string) (uint8, error) {
+	switch name {
+	case "tcp":
+		return unix.IPPROTO_TCP, nil
+	case "udp":
+		return unix.IPPROTO_UDP, nil
+	case "sctp":
+		return unix.IPPROTO_SCTP, nil
+	case "icmp":
+		return unix.IPPROTO_ICMP, nil
+	case "icmpv6":
+		return unix.IPPROTO_ICMPV6, nil
+	}
+	num, err := strconv.ParseUint(name, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("unsupported protocol %s", name)
+	}
+	return uint8(num), nil
+}
+
+func parsePortRange(s string) (first, last uint16, err error) {
+	from, to, isRange := strings.Cut(s, ":")
+	v, err := strconv.ParseUint(from, 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	first, last = uint16(v), uint16(v)
+	if isRange {
+		v, err = strconv.ParseUint(to, 10, 16)
+		if err != nil {
+			return 0, 0, err
+		}
+		last = uint16(v)
+	}
+	return first, last, nil
+}
+
+func be16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package nftables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+)
+
+func TestRender(t *testing.T) {
+	r := renderer{
+		version:   4,
+		chainName: func(name string) string { return "mangle-" + name },
+		setID: func(name string) uint32 {
+			if name == "egress-src-v4-policy" {
+				return 1
+			}
+			return 0
+		},
+		anonID: func() uint32 { return 2 },
+	}
+
+	cases := []struct {
+		name string
+		rule iptables.Rule
+		text string
+		sets int
+		err  bool
+	}{
+		{
+			name: "mark request",
+			rule: iptables.Rule{
+				Match: iptables.MatchCriteria{}.
+					SourceIPSet("egress-src-v4-policy").
+					NotMarkMatchesWithMask(0x26000000, 0xff000000).
+					CTDirectionOriginal(iptables.DirectionOriginal).
+					StatisticRandom(0.33333),
+				Action: iptables.SetMaskedMarkAction{Mark: 0x26000001, Mask: 0xffffffff},
+			},
+			text: "ip saddr @egress-src-v4-policy meta mark & 0xff000000 != 0x26000000 ct direction original " +
+				"numgen random mod 100000 < 33333 counter meta mark set 0x26000001",
+		},
+		{
+			name: "masked mark",
+			rule: iptables.Rule{
+				Match:  iptables.MatchCriteria{}.NotSourceIPSet("egress-src-v4-policy"),
+				Action: iptables.SetMaskedMarkAction{Mark: 0x26000000, Mask: 0xff000000},
+			},
+			text: "ip saddr != @egress-src-v4-policy counter meta mark set meta mark & 0xffffff ^ 0x26000000",
+		},
+		{
+			name: "ports",
+			rule: iptables.Rule{
+				Match:  iptables.MatchCriteria{}.Protocol("tcp").DestPortRanges([]*iptables.PortRange{{First: 80, Last: 90}}),
+				Action: iptables.JumpAction{Target: "EGRESSGATEWAY-MARK-REQUEST"},
+			},
+			text: "meta l4proto tcp th dport 80-90 counter jump mangle-EGRESSGATEWAY-MARK-REQUEST",
+		},
+		{
+			name: "port list",
+			rule: iptables.Rule{
+				Match:  iptables.MatchCriteria{}.Protocol("tcp").DestPorts(80, 443, 8000),
+				Action: iptables.AcceptAction{},
+			},
+			text: "meta l4proto tcp th dport { 80, 443, 8000 } counter accept",
+			sets: 1,
+		},
+		{
+			name: "snat",
+			rule: iptables.Rule{
+				Match:  iptables.MatchCriteria{}.MarkMatchesWithMask(0x26000001, 0xffffffff),
+				Action: iptables.SNATAction{ToAddr: "10.6.1.21"},
+			},
+			text: "meta mark == 0x26000001 counter snat to 10.6.1.21",
+		},
+		{
+			name: "save mark",
+			rule: iptables.Rule{
+				Match:  iptables.MatchCriteria{}.SourceNet("172.30.0.0/16"),
+				Action: iptables.SaveConnMarkAction{SaveMask: 0xffffffff},
+			},
+			text: "ip saddr 172.30.0.0/16 counter ct mark set meta mark",
+		},
+		{
+			name: "save masked mark",
+			rule: iptables.Rule{
+				Match:  iptables.MatchCriteria{}.SourceNet("172.30.0.0/16"),
+				Action: iptables.SaveConnMarkAction{SaveMask: 0xff000000},
+			},
+			text: "ip saddr 172.30.0.0/16 counter ct mark set ct mark & 0xffffff | meta mark & 0xff000000",
+		},
+		{
+			name: "restore masked mark",
+			rule: iptables.Rule{Action: iptables.RestoreConnMarkAction{RestoreMask: 0xff000000}},
+			text: "counter meta mark set meta mark & 0xffffff | ct mark & 0xff000000",
+		},
+		{
+			name: "unknown set",
+			rule: iptables.Rule{Match: iptables.MatchCriteria{}.SourceIPSet("unknown")},
+			err:  true,
+		},
+		{
+			name: "unsupported match",
+			rule: iptables.Rule{Match: iptables.MatchCriteria{}.ICMPType(8)},
+			err:  true,
+		},
+		{
+			name: "invalid snat address",
+			rule: iptables.Rule{Action: iptables.SNATAction{ToAddr: "fd00::1"}},
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := r.render(c.rule)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.text, res.String())
+			assert.Len(t, res.sets, c.sets)
+		})
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package nftables
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/egressgateway/pkg/ipset"
+)
+
+// the data types of the keys of the sets, nft prints the elements by them
+const (
+	typeIPv4Addr    = 7
+	typeIPv6Addr    = 8
+	typeInetService = 13
+)
+
+// set is a native set of IP addresses and CIDRs, it takes the place of an
+// ipset of the hash:net type
+type set struct {
+	name    string
+	version uint8
+	// id identifies the set in the batch creating it
+	id      uint32
+	entries map[string]*net.IPNet
+}
+
+// parseEntry parses an IP address or a CIDR of the IP version
+func parseEntry(entry string, version uint8) (*net.IPNet, error) {
+	if !strings.Contains(entry, "/") {
+		if strings.Contains(entry, ":") {
+			entry += "/128"
+		} else {
+			entry += "/32"
+		}
+	}
+	_, ipNet, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, err
+	}
+	if ip4 := ipNet.IP.To4(); ip4 != nil {
+		if version != 4 {
+			return nil, fmt.Errorf("%s is not an IPv%d address", entry, version)
+		}
+		ipNet.IP = ip4
+		ipNet.Mask = ipNet.Mask[len(ipNet.Mask)-net.IPv4len:]
+		return ipNet, nil
+	}
+	if version != 6 {
+		return nil, fmt.Errorf("%s is not an IPv%d address", entry, version)
+	}
+	return ipNet, nil
+}
+
+func (s *set) keyLen() uint32 {
+	if s.version == 6 {
+		return net.IPv6len
+	}
+	return net.IPv4len
+}
+
+func (s *set) newMessage(table string) message {
+	keyType := uint32(typeIPv4Addr)
+	if s.version == 6 {
+		keyType = typeIPv6Addr
+	}
+	return message{
+		typ:    unix.NFT_MSG_NEWSET,
+		flags:  unix.NLM_F_CREATE,
+		family: family(s.version),
+		attrs: []*nl.RtAttr{
+			stringAttr(unix.NFTA_SET_TABLE, table),
+			stringAttr(unix.NFTA_SET_NAME, s.name),
+			attr(unix.NFTA_SET_FLAGS, be32(unix.NFT_SET_INTERVAL)),
+			attr(unix.NFTA_SET_KEY_TYPE, be32(keyType)),
+			attr(unix.NFTA_SET_KEY_LEN, be32(s.keyLen())),
+			attr(unix.NFTA_SET_ID, be32(s.id)),
+		},
+		desc: "create set " + s.name,
+	}
+}
+
+// elementsMessage adds the elements of the set, ok is false when the set is
+// empty
+func (s *set) elementsMessage(table string) (message, bool) {
+	intervals := s.intervals()
+	if len(intervals) == 0 {
+		return message{}, false
+	}
+	return intervalsMessage(unix.NFT_MSG_NEWSETELEM, table, s.name, s.id, s.version, intervals), true
+}
+
+// intervalsMessage adds or deletes the elements of the intervals, an
+// interval is an element of its start and one of its end
+func intervalsMessage(typ uint16, table, name string, id uint32, version uint8, intervals []interval) message {
+	elems := make([]*nl.RtAttr, 0, 2*len(intervals))
+	for _, item := range intervals {
+		elems = append(elems, nested(unix.NFTA_LIST_ELEM, dataValue(unix.NFTA_SET_ELEM_KEY, item.start)))
+		if item.end != nil {
+			elems = append(elems, nested(unix.NFTA_LIST_ELEM,
+				dataValue(unix.NFTA_SET_ELEM_KEY, item.end),
+				attr(unix.NFTA_SET_ELEM_FLAGS, be32(unix.NFT_SET_ELEM_INTERVAL_END))))
+		}
+	}
+	msg := message{
+		typ:    typ,
+		family: family(version),
+		attrs: []*nl.RtAttr{
+			stringAttr(unix.NFTA_SET_ELEM_LIST_TABLE, table),
+			stringAttr(unix.NFTA_SET_ELEM_LIST_SET, name),
+			attr(unix.NFTA_SET_ELEM_LIST_SET_ID, be32(id)),
+			nested(unix.NFTA_SET_ELEM_LIST_ELEMENTS, elems...),
+		},
+		desc: "add elements to set " + name,
+	}
+	if typ == unix.NFT_MSG_NEWSETELEM {
+		msg.flags = unix.NLM_F_CREATE
+	} else {
+		msg.desc = "delete elements from set " + name
+	}
+	return msg
+}
+
+func (s *set) deleteMessage(table string) message {
+	return message{
+		typ:    unix.NFT_MSG_DELSET,
+		family: family(s.version),
+		attrs: []*nl.RtAttr{
+			stringAttr(unix.NFTA_SET_TABLE, table),
+			stringAttr(unix.NFTA_SET_NAME, s.name),
+		},
+		desc: "delete set " + s.name,
+	}
+}
+
+// interval is a range of addresses of the set, end is the first address
+// after the range, it is nil when the range reaches the last address
+type interval struct {
+	start []byte
+	end   []byte
+}
+
+// span is a range of keys, last is in the range
+type span struct {
+	first []byte
+	last  []byte
+}
+
+// intervals returns the ranges of the entries
+func (s *set) intervals() []interval {
+	spans := make([]span, 0, len(s.entries))
+	for _, ipNet := range s.entries {
+		first := append([]byte{}, ipNet.IP...)
+		last := append([]byte{}, ipNet.IP...)
+		for i := range last {
+			last[i] |= ^ipNet.Mask[i]
+		}
+		spans = append(spans, span{first: first, last: last})
+	}
+	return mergeSpans(spans)
+}
+
+// mergeSpans returns the intervals of the spans, the overlapping and the
+// adjacent ones are merged, the kernel rejects overlapping intervals
+func mergeSpans(spans []span) []interval {
+	sort.Slice(spans, func(i, j int) bool {
+		return bytes.Compare(spans[i].first, spans[j].first) < 0
+	})
+
+	res := make([]interval, 0, len(spans))
+	var cur *span
+	for i := range spans {
+		item := spans[i]
+		if cur != nil {
+			next := increment(cur.last)
+			if next == nil || bytes.Compare(item.first, next) <= 0 {
+				if bytes.Compare(item.last, cur.last) > 0 {
+					cur.last = item.last
+				}
+				continue
+			}
+			res = append(res, interval{start: cur.first, end: increment(cur.last)})
+		}
+		cur = &item
+	}
+	if cur != nil {
+		res = append(res, interval{start: cur.first, end: increment(cur.last)})
+	}
+	return res
+}
+
+// diffIntervals returns the intervals only in before and the ones only in
+// after
+func diffIntervals(before, after []interval) (deleted, added []interval) {
+	key := func(item interval) string {
+		return string(item.start) + "-" + string(item.end)
+	}
+	kept := make(map[string]bool, len(after))
+	for _, item := range after {
+		kept[key(item)] = true
+	}
+	existing := make(map[string]bool, len(before))
+	for _, item := range before {
+		existing[key(item)] = true
+		if !kept[key(item)] {
+			deleted = append(deleted, item)
+		}
+	}
+	for _, item := range after {
+		if !existing[key(item)] {
+			added = append(added, item)
+		}
+	}
+	return deleted, added
+}
+
+// anonSet is an anonymous set of a rule, it is created in the batch of the
+// rule and the kernel removes it with the rule
+type anonSet struct {
+	id        uint32
+	keyType   uint32
+	keyLen    uint32
+	intervals []interval
+}
+
+// anonSetName is the name of the anonymous sets, the kernel replaces %d
+const anonSetName = "__set%d"
+
+// messages creates the set and adds its elements, the elements of an
+// anonymous set cannot be changed once a rule is bound to it
+func (s *anonSet) messages(table string, version uint8) []message {
+	return []message{
+		{
+			typ:    unix.NFT_MSG_NEWSET,
+			flags:  unix.NLM_F_CREATE,
+			family: family(version),
+			attrs: []*nl.RtAttr{
+				stringAttr(unix.NFTA_SET_TABLE, table),
+				stringAttr(unix.NFTA_SET_NAME, anonSetName),
+				attr(unix.NFTA_SET_FLAGS, be32(unix.NFT_SET_ANONYMOUS|unix.NFT_SET_CONSTANT|unix.NFT_SET_INTERVAL)),
+				attr(unix.NFTA_SET_KEY_TYPE, be32(s.keyType)),
+				attr(unix.NFTA_SET_KEY_LEN, be32(s.keyLen)),
+				attr(unix.NFTA_SET_ID, be32(s.id)),
+			},
+			desc: fmt.Sprintf("create anonymous set %d", s.id),
+		},
+		intervalsMessage(unix.NFT_MSG_NEWSETELEM, table, anonSetName, s.id, version, s.intervals),
+	}
+}
+
+// increment returns the address after ip, it is nil for the last address
+func increment(ip []byte) []byte {
+	res := append([]byte{}, ip...)
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i]++
+		if res[i] != 0 {
+			return res
+		}
+	}
+	return nil
+}
+
+// Sets manages the native sets of the tables, it is a drop-in replacement
+// of the ipset client. The sets are held in memory and programmed with the
+// tables, a change of a set is programmed at once when its table is in the
+// kernel.
+type Sets struct {
+	rs *Ruleset
+}
+
+var _ ipset.Interface = &Sets{}
+
+func (s *Sets) FlushSet(name string) error {
+	return s.rs.updateSet(name, func(item *set) error {
+		item.entries = make(map[string]*net.IPNet)
+		return nil
+	})
+}
+
+func (s *Sets) DestroySet(name string) error {
+	return s.rs.destroySet(name)
+}
+
+func (s *Sets) DestroyAllSets() error {
+	for _, name := range s.rs.setNames() {
+		if err := s.rs.destroySet(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sets) CreateSet(ipSet *ipset.IPSet, ignoreExistErr bool) error {
+	if ipSet.SetType != "" && ipSet.SetType != ipset.HashNet {
+		return fmt.Errorf("error creating set %s: type %s is not supported", ipSet.Name, ipSet.SetType)
+	}
+	version := uint8(4)
+	if ipSet.HashFamily == ipset.ProtocolFamilyIPV6 {
+		version = 6
+	}
+	return s.rs.createSet(ipSet.Name, version, ignoreExistErr)
+}
+
+func (s *Sets) AddEntry(entry string, ipSet *ipset.IPSet, ignoreExistErr bool) error {
+	return s.rs.updateSet(ipSet.Name, func(item *set) error {
+		if _, ok := item.entries[entry]; ok {
+			if ignoreExistErr {
+				return nil
+			}
+			return ipset.ErrAlreadyAddedEntry
+		}
+		ipNet, err := parseEntry(entry, item.version)
+		if err != nil {
+			return fmt.Errorf("error adding entry %s, error: %v", entry, err)
+		}
+		item.entries[entry] = ipNet
+		return nil
+	})
+}
+
+func (s *Sets) DelEntry(entry string, name string) error {
+	return s.rs.updateSet(name, func(item *set) error {
+		if _, ok := item.entries[entry]; !ok {
+			return fmt.Errorf("error deleting entry %s: from set: %s, error: the element does not exist", entry, name)
+		}
+		delete(item.entries, entry)
+		return nil
+	})
+}
+
+func (s *Sets) TestEntry(entry string, name string) (bool, error) {
+	entries, err := s.ListEntries(name)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range entries {
+		if item == entry {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Sets) ListEntries(name string) ([]string, error) {
+	return s.rs.listEntries(name)
+}
+
+func (s *Sets) ListSets() ([]string, error) {
+	return s.rs.setNames(), nil
+}
+
+// GetVersion returns the version of the ipset client, the native sets have no version
+func (s *Sets) GetVersion() (string, error) {
+	return "nftables", nil
+}
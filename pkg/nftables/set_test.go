@@ -0,0 +1,111 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package nftables
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/egressgateway/pkg/ipset"
+)
+
+func TestIntervals(t *testing.T) {
+	cases := []struct {
+		name    string
+		version uint8
+		entries []string
+		expect  [][2]string
+	}{
+		{
+			name:    "address",
+			version: 4,
+			entries: []string{"10.6.1.21"},
+			expect:  [][2]string{{"10.6.1.21", "10.6.1.22"}},
+		},
+		{
+			name:    "overlapping and adjacent",
+			version: 4,
+			entries: []string{"10.6.0.0/16", "10.6.1.0/24", "10.7.0.0/16", "10.9.0.1"},
+			expect:  [][2]string{{"10.6.0.0", "10.8.0.0"}, {"10.9.0.1", "10.9.0.2"}},
+		},
+		{
+			name:    "last address",
+			version: 4,
+			entries: []string{"255.255.255.0/24"},
+			expect:  [][2]string{{"255.255.255.0", ""}},
+		},
+		{
+			name:    "ipv6",
+			version: 6,
+			entries: []string{"fd00::/64", "fd00::1"},
+			expect:  [][2]string{{"fd00::", "fd00:0:0:1::"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := &set{version: c.version, entries: make(map[string]*net.IPNet)}
+			for _, entry := range c.entries {
+				ipNet, err := parseEntry(entry, c.version)
+				assert.NoError(t, err)
+				s.entries[entry] = ipNet
+			}
+			res := make([][2]string, 0)
+			for _, item := range s.intervals() {
+				end := ""
+				if item.end != nil {
+					end = net.IP(item.end).String()
+				}
+				res = append(res, [2]string{net.IP(item.start).String(), end})
+			}
+			assert.Equal(t, c.expect, res)
+		})
+	}
+}
+
+func TestSets(t *testing.T) {
+	c := &fakeConn{}
+	sets := newRuleset(c, zap.NewNop()).Sets()
+	v4 := &ipset.IPSet{Name: "egress-src-v4-policy", SetType: ipset.HashNet, HashFamily: ipset.ProtocolFamilyIPV4}
+	v6 := &ipset.IPSet{Name: "egress-src-v6-policy", SetType: ipset.HashNet, HashFamily: ipset.ProtocolFamilyIPV6}
+
+	assert.NoError(t, sets.CreateSet(v4, true))
+	assert.NoError(t, sets.CreateSet(v6, true))
+	assert.NoError(t, sets.CreateSet(v4, true))
+	assert.Error(t, sets.CreateSet(v4, false))
+	assert.Error(t, sets.CreateSet(&ipset.IPSet{Name: "ports", SetType: ipset.HashIPPort}, true))
+
+	assert.NoError(t, sets.AddEntry("172.30.0.2", v4, true))
+	assert.Equal(t, ipset.ErrAlreadyAddedEntry, sets.AddEntry("172.30.0.2", v4, false))
+	assert.Error(t, sets.AddEntry("fd00::2", v4, true))
+	assert.NoError(t, sets.AddEntry("fd00::2", v6, true))
+
+	ok, err := sets.TestEntry("172.30.0.2", v4.Name)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, sets.DelEntry("172.30.0.2", v4.Name))
+	assert.Error(t, sets.DelEntry("172.30.0.2", v4.Name))
+	entries, err := sets.ListEntries(v4.Name)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	names, err := sets.ListSets()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{v4.Name, v6.Name}, names)
+
+	assert.NoError(t, sets.DestroySet(v4.Name))
+	_, err = sets.ListEntries(v4.Name)
+	assert.ErrorContains(t, err, "The set with the given name does not exist")
+	assert.NoError(t, sets.DestroyAllSets())
+	names, err = sets.ListSets()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	// nothing is programmed before the table
+	assert.Empty(t, c.batches)
+}
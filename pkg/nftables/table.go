@@ -0,0 +1,796 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nftables programs the rules of the policies with nftables. The
+// rules and the sets of an IP version live in the egressgateway table of the
+// ip or the ip6 family, the table is replaced in a single netlink batch, so
+// the kernel switches to the new rules atomically.
+package nftables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink/nl"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+)
+
+const (
+	// TableName is the name of the tables of the ip and the ip6 families
+	TableName = "egressgateway"
+	// hashCommentPrefix prefixes the hash of a rule saved in its comment
+	hashCommentPrefix = "egw:"
+	// the type of the comment in the user data of a rule
+	udataRuleComment = 0
+)
+
+// baseChain is a chain attached to a netfilter hook
+type baseChain struct {
+	typ      string
+	hook     uint32
+	priority int32
+	// nat is the NAT type of the hook of a nat chain, snat or dnat
+	nat string
+}
+
+// the priorities of the iptables tables, iptables-nft registers its chains
+// with them as well
+const (
+	priorityRaw    = -300
+	priorityMangle = -150
+	priorityDstNAT = -100
+	priorityFilter = 0
+	priorityAdjust = -1
+	prioritySrcNAT = 100
+)
+
+// baseChains are the chains of the iptables tables attached to the hooks.
+// The kernel runs the base chains of a hook one by one, an accept only ends
+// the current one, so the chains are hooked just before the iptables tables,
+// as the rules inserted at the top of the iptables chains run before the
+// others.
+var baseChains = map[string]map[string]baseChain{
+	"raw": {
+		"PREROUTING": {typ: "filter", hook: unix.NF_INET_PRE_ROUTING, priority: priorityRaw + priorityAdjust},
+		"OUTPUT":     {typ: "filter", hook: unix.NF_INET_LOCAL_OUT, priority: priorityRaw + priorityAdjust},
+	},
+	"mangle": {
+		"PREROUTING":  {typ: "filter", hook: unix.NF_INET_PRE_ROUTING, priority: priorityMangle + priorityAdjust},
+		"INPUT":       {typ: "filter", hook: unix.NF_INET_LOCAL_IN, priority: priorityMangle + priorityAdjust},
+		"FORWARD":     {typ: "filter", hook: unix.NF_INET_FORWARD, priority: priorityMangle + priorityAdjust},
+		"OUTPUT":      {typ: "route", hook: unix.NF_INET_LOCAL_OUT, priority: priorityMangle + priorityAdjust},
+		"POSTROUTING": {typ: "filter", hook: unix.NF_INET_POST_ROUTING, priority: priorityMangle + priorityAdjust},
+	},
+	"nat": {
+		"PREROUTING":  {typ: "nat", hook: unix.NF_INET_PRE_ROUTING, priority: priorityDstNAT + priorityAdjust, nat: "dnat"},
+		"INPUT":       {typ: "nat", hook: unix.NF_INET_LOCAL_IN, priority: prioritySrcNAT + priorityAdjust, nat: "snat"},
+		"OUTPUT":      {typ: "nat", hook: unix.NF_INET_LOCAL_OUT, priority: priorityDstNAT + priorityAdjust, nat: "dnat"},
+		"POSTROUTING": {typ: "nat", hook: unix.NF_INET_POST_ROUTING, priority: prioritySrcNAT + priorityAdjust, nat: "snat"},
+	},
+	"filter": {
+		"INPUT":   {typ: "filter", hook: unix.NF_INET_LOCAL_IN, priority: priorityFilter + priorityAdjust},
+		"FORWARD": {typ: "filter", hook: unix.NF_INET_FORWARD, priority: priorityFilter + priorityAdjust},
+		"OUTPUT":  {typ: "filter", hook: unix.NF_INET_LOCAL_OUT, priority: priorityFilter + priorityAdjust},
+	},
+}
+
+func family(version uint8) uint8 {
+	if version == 6 {
+		return unix.NFPROTO_IPV6
+	}
+	return unix.NFPROTO_IPV4
+}
+
+// Ruleset holds the desired state of the tables of the ip and the ip6
+// families. The chains of an iptables table are programmed into the table of
+// its IP version, prefixed by the name of the iptables table.
+type Ruleset struct {
+	mu       sync.Mutex
+	log      *zap.Logger
+	conn     conn
+	families map[uint8]*familyState
+	nextID   uint32
+}
+
+type familyState struct {
+	version uint8
+	tables  []*Table
+	sets    map[string]*set
+	// applied is set once the table is programmed, the changes of the sets
+	// are programmed at once after that
+	applied bool
+	// dirty is set when the table is changed since it was programmed
+	dirty bool
+}
+
+// New returns the ruleset programmed through netlink
+func New(log *zap.Logger) *Ruleset {
+	return newRuleset(netlinkConn{}, log)
+}
+
+func newRuleset(conn conn, log *zap.Logger) *Ruleset {
+	return &Ruleset{
+		log:  log,
+		conn: conn,
+		families: map[uint8]*familyState{
+			4: {version: 4, sets: make(map[string]*set), dirty: true},
+			6: {version: 6, sets: make(map[string]*set), dirty: true},
+		},
+	}
+}
+
+// NewTable returns the table holding the chains of the iptables table name
+func (rs *Ruleset) NewTable(name string, ipVersion uint8) (*Table, error) {
+	if _, ok := baseChains[name]; !ok {
+		return nil, fmt.Errorf("unknown table %s", name)
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	f, ok := rs.families[ipVersion]
+	if !ok {
+		return nil, fmt.Errorf("unknown IP version %d", ipVersion)
+	}
+	t := &Table{
+		Name:      name,
+		IPVersion: ipVersion,
+		rs:        rs,
+		chains:    make(map[string]*iptables.Chain),
+		inserted:  make(map[string][]iptables.Rule),
+	}
+	f.tables = append(f.tables, t)
+	return t, nil
+}
+
+// Sets returns the client of the sets of the ruleset
+func (rs *Ruleset) Sets() *Sets {
+	return &Sets{rs: rs}
+}
+
+func (rs *Ruleset) findSet(name string) (*familyState, *set, bool) {
+	for _, f := range rs.families {
+		if s, ok := f.sets[name]; ok {
+			return f, s, true
+		}
+	}
+	return nil, nil, false
+}
+
+func (rs *Ruleset) createSet(name string, version uint8, ignoreExistErr bool) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, s, ok := rs.findSet(name); ok {
+		if s.version != version {
+			return fmt.Errorf("error creating set %s, error: the set of IPv%d exists", name, s.version)
+		}
+		if !ignoreExistErr {
+			return fmt.Errorf("error creating set %s, error: the set exists", name)
+		}
+		return nil
+	}
+	rs.nextID++
+	s := &set{name: name, version: version, id: rs.nextID, entries: make(map[string]*net.IPNet)}
+	f := rs.families[version]
+	f.sets[name] = s
+	return rs.syncSet(f, s)
+}
+
+func (rs *Ruleset) updateSet(name string, update func(s *set) error) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	f, s, ok := rs.findSet(name)
+	if !ok {
+		return fmt.Errorf("error updating set %s, error: The set with the given name does not exist", name)
+	}
+	if !f.applied {
+		if err := update(s); err != nil {
+			return err
+		}
+		f.dirty = true
+		return nil
+	}
+	before := s.intervals()
+	if err := update(s); err != nil {
+		return err
+	}
+	return rs.syncElements(f, s, before)
+}
+
+// syncElements programs the changes of the intervals of the set, the
+// intervals changed by an entry are deleted and added again in one batch
+func (rs *Ruleset) syncElements(f *familyState, s *set, before []interval) error {
+	deleted, added := diffIntervals(before, s.intervals())
+	if len(deleted) == 0 && len(added) == 0 {
+		return nil
+	}
+	msgs := make([]message, 0, 2)
+	if len(deleted) != 0 {
+		msgs = append(msgs, intervalsMessage(unix.NFT_MSG_DELSETELEM, TableName, s.name, s.id, s.version, deleted))
+	}
+	if len(added) != 0 {
+		msgs = append(msgs, intervalsMessage(unix.NFT_MSG_NEWSETELEM, TableName, s.name, s.id, s.version, added))
+	}
+	if err := rs.conn.send(msgs); err != nil {
+		// the table is programmed again by the next apply
+		f.dirty = true
+		return fmt.Errorf("error updating set %s, error: %v", s.name, err)
+	}
+	return nil
+}
+
+// syncSet creates the new set when the table is in the kernel
+func (rs *Ruleset) syncSet(f *familyState, s *set) error {
+	if !f.applied {
+		f.dirty = true
+		return nil
+	}
+	if err := rs.conn.send([]message{newTableMessage(f.version), s.newMessage(TableName)}); err != nil {
+		// the table is programmed again by the next apply
+		f.dirty = true
+		return fmt.Errorf("error creating set %s, error: %v", s.name, err)
+	}
+	return nil
+}
+
+func (rs *Ruleset) destroySet(name string) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	f, s, ok := rs.findSet(name)
+	if !ok {
+		return fmt.Errorf("error destroying set %s, error: The set with the given name does not exist", name)
+	}
+	delete(f.sets, name)
+	if !f.applied {
+		return nil
+	}
+	if err := rs.conn.send([]message{s.deleteMessage(TableName)}); err != nil {
+		// the set still in use is removed by the next apply
+		f.dirty = true
+		return fmt.Errorf("error destroying set %s, error: %v", name, err)
+	}
+	return nil
+}
+
+func (rs *Ruleset) listEntries(name string) ([]string, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	_, s, ok := rs.findSet(name)
+	if !ok {
+		return nil, fmt.Errorf("error listing set: %s, error: The set with the given name does not exist", name)
+	}
+	res := make([]string, 0, len(s.entries))
+	for entry := range s.entries {
+		res = append(res, entry)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (rs *Ruleset) setNames() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	res := make([]string, 0)
+	for _, f := range rs.families {
+		for name := range f.sets {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// render returns the batch replacing the table of the family, the table is
+// created first, so that it can always be deleted
+func (rs *Ruleset) render(f *familyState) ([]message, error) {
+	msgs := []message{
+		newTableMessage(f.version),
+		{typ: unix.NFT_MSG_DELTABLE, family: family(f.version),
+			attrs: []*nl.RtAttr{stringAttr(unix.NFTA_TABLE_NAME, TableName)}, desc: "delete table " + TableName},
+		newTableMessage(f.version),
+	}
+
+	names := make([]string, 0, len(f.sets))
+	for name := range f.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := f.sets[name]
+		msgs = append(msgs, s.newMessage(TableName))
+		if msg, ok := s.elementsMessage(TableName); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	// the chains are created before the rules jumping to them
+	var rules []message
+	for _, t := range f.tables {
+		for _, name := range t.chainNames() {
+			r := t.renderer(name)
+			base, isBase := baseChains[t.Name][name]
+			var hook *baseChain
+			if isBase {
+				hook = &base
+			}
+			msgs = append(msgs, chainMessage(f.version, t.chainName(name), hook))
+
+			chainRules := t.chainRules(name)
+			hashes := (&iptables.Chain{Name: name, Rules: chainRules}).RuleHashes(&iptables.Options{})
+			for i, item := range chainRules {
+				rendered, err := r.render(item)
+				if err != nil {
+					return nil, fmt.Errorf("failed to render rule %d of chain %s of table %s: %v", i, name, t.Name, err)
+				}
+				for _, s := range rendered.sets {
+					rules = append(rules, s.messages(TableName, f.version)...)
+				}
+				rules = append(rules, ruleMessage(f.version, t.chainName(name), rendered, hashes[i]))
+			}
+		}
+	}
+	return append(msgs, rules...), nil
+}
+
+func newTableMessage(version uint8) message {
+	return message{
+		typ:    unix.NFT_MSG_NEWTABLE,
+		flags:  unix.NLM_F_CREATE,
+		family: family(version),
+		attrs: []*nl.RtAttr{
+			stringAttr(unix.NFTA_TABLE_NAME, TableName),
+			attr(unix.NFTA_TABLE_FLAGS, be32(0)),
+		},
+		desc: "create table " + TableName,
+	}
+}
+
+func chainMessage(version uint8, name string, hook *baseChain) message {
+	attrs := []*nl.RtAttr{
+		stringAttr(unix.NFTA_CHAIN_TABLE, TableName),
+		stringAttr(unix.NFTA_CHAIN_NAME, name),
+	}
+	if hook != nil {
+		attrs = append(attrs,
+			nested(unix.NFTA_CHAIN_HOOK,
+				attr(unix.NFTA_HOOK_HOOKNUM, be32(hook.hook)),
+				attr(unix.NFTA_HOOK_PRIORITY, be32(uint32(hook.priority)))),
+			stringAttr(unix.NFTA_CHAIN_TYPE, hook.typ),
+			attr(unix.NFTA_CHAIN_POLICY, be32(nfAccept)))
+	}
+	return message{
+		typ:    unix.NFT_MSG_NEWCHAIN,
+		flags:  unix.NLM_F_CREATE,
+		family: family(version),
+		attrs:  attrs,
+		desc:   "create chain " + name,
+	}
+}
+
+func ruleMessage(version uint8, chain string, r *rule, hash string) message {
+	return message{
+		typ:    unix.NFT_MSG_NEWRULE,
+		flags:  unix.NLM_F_CREATE | unix.NLM_F_APPEND,
+		family: family(version),
+		attrs: []*nl.RtAttr{
+			stringAttr(unix.NFTA_RULE_TABLE, TableName),
+			stringAttr(unix.NFTA_RULE_CHAIN, chain),
+			marshalExprs(r.exprs),
+			attr(unix.NFTA_RULE_USERDATA, commentUserData(hashCommentPrefix+hash)),
+		},
+		desc: fmt.Sprintf("add rule %q to chain %s", r, chain),
+	}
+}
+
+// commentUserData encodes the comment of a rule the way nft does
+func commentUserData(comment string) []byte {
+	value := nl.ZeroTerminated(comment)
+	return append([]byte{udataRuleComment, byte(len(value))}, value...)
+}
+
+func parseCommentUserData(data []byte) string {
+	for len(data) >= 2 {
+		typ, size := data[0], int(data[1])
+		if len(data) < 2+size {
+			break
+		}
+		if typ == udataRuleComment {
+			return strings.TrimRight(string(data[2:2+size]), "\x00")
+		}
+		data = data[2+size:]
+	}
+	return ""
+}
+
+// kernelRule is a rule of the table read back from the kernel
+type kernelRule struct {
+	chain   string
+	hash    string
+	packets uint64
+	bytes   uint64
+}
+
+// readRules returns the chains and the rules of the table of the family,
+// the table does not exist before the first apply
+func (rs *Ruleset) readRules(version uint8) (chains []string, rules []kernelRule, err error) {
+	chainReplies, err := rs.conn.dump(message{
+		typ:    unix.NFT_MSG_GETCHAIN,
+		family: family(version),
+		attrs:  []*nl.RtAttr{stringAttr(unix.NFTA_CHAIN_TABLE, TableName)},
+		desc:   "list chains of table " + TableName,
+	})
+	if errors.Is(err, unix.ENOENT) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, reply := range chainReplies {
+		attrs, err := parseAttrs(reply)
+		if err != nil {
+			return nil, nil, err
+		}
+		if nl.BytesToString(attrs[unix.NFTA_CHAIN_TABLE]) != TableName {
+			continue
+		}
+		chains = append(chains, nl.BytesToString(attrs[unix.NFTA_CHAIN_NAME]))
+	}
+
+	ruleReplies, err := rs.conn.dump(message{
+		typ:    unix.NFT_MSG_GETRULE,
+		family: family(version),
+		attrs:  []*nl.RtAttr{stringAttr(unix.NFTA_RULE_TABLE, TableName)},
+		desc:   "list rules of table " + TableName,
+	})
+	if errors.Is(err, unix.ENOENT) {
+		return chains, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, reply := range ruleReplies {
+		item, ok, err := parseRule(reply)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			rules = append(rules, item)
+		}
+	}
+	return chains, rules, nil
+}
+
+func parseRule(reply []byte) (kernelRule, bool, error) {
+	res := kernelRule{}
+	attrs, err := parseAttrs(reply)
+	if err != nil {
+		return res, false, err
+	}
+	if nl.BytesToString(attrs[unix.NFTA_RULE_TABLE]) != TableName {
+		return res, false, nil
+	}
+	res.chain = nl.BytesToString(attrs[unix.NFTA_RULE_CHAIN])
+	res.hash = strings.TrimPrefix(parseCommentUserData(attrs[unix.NFTA_RULE_USERDATA]), hashCommentPrefix)
+
+	exprs, err := parseList(attrs[unix.NFTA_RULE_EXPRESSIONS])
+	if err != nil {
+		return res, false, err
+	}
+	for _, item := range exprs {
+		exprAttrs, err := parseNested(item)
+		if err != nil {
+			return res, false, err
+		}
+		if nl.BytesToString(exprAttrs[unix.NFTA_EXPR_NAME]) != "counter" {
+			continue
+		}
+		counter, err := parseNested(exprAttrs[unix.NFTA_EXPR_DATA])
+		if err != nil {
+			return res, false, err
+		}
+		if v := counter[unix.NFTA_COUNTER_PACKETS]; len(v) == 8 {
+			res.packets = binary.BigEndian.Uint64(v)
+		}
+		if v := counter[unix.NFTA_COUNTER_BYTES]; len(v) == 8 {
+			res.bytes = binary.BigEndian.Uint64(v)
+		}
+	}
+	return res, true, nil
+}
+
+// Table holds the chains of an iptables table in the table of its IP
+// version, it takes the place of iptables.Table
+type Table struct {
+	Name      string
+	IPVersion uint8
+
+	rs     *Ruleset
+	chains map[string]*iptables.Chain
+	// inserted are the rules inserted into the chains of the hooks
+	inserted map[string][]iptables.Rule
+}
+
+var _ iptables.Interface = &Table{}
+
+// TableName returns the name of the iptables table
+func (t *Table) TableName() string {
+	return t.Name
+}
+
+// TableIPVersion returns the IP version of the table
+func (t *Table) TableIPVersion() uint8 {
+	return t.IPVersion
+}
+
+func (t *Table) UpdateChain(chain *iptables.Chain) {
+	t.UpdateChains([]*iptables.Chain{chain})
+}
+
+func (t *Table) UpdateChains(chains []*iptables.Chain) {
+	t.rs.mu.Lock()
+	defer t.rs.mu.Unlock()
+	for _, chain := range chains {
+		t.chains[chain.Name] = chain
+	}
+	t.rs.families[t.IPVersion].dirty = true
+}
+
+func (t *Table) RemoveChains(chains []*iptables.Chain) {
+	for _, chain := range chains {
+		t.RemoveChainByName(chain.Name)
+	}
+}
+
+func (t *Table) RemoveChainByName(name string) {
+	t.rs.mu.Lock()
+	defer t.rs.mu.Unlock()
+	delete(t.chains, name)
+	t.rs.families[t.IPVersion].dirty = true
+}
+
+// InsertOrAppendRules sets the rules inserted into the chain, the chains of
+// the hooks are owned by the table, so the rules are all of the chain
+func (t *Table) InsertOrAppendRules(chainName string, rules []iptables.Rule) {
+	t.rs.mu.Lock()
+	defer t.rs.mu.Unlock()
+	t.inserted[chainName] = rules
+	t.rs.families[t.IPVersion].dirty = true
+}
+
+// Apply programs the table of the IP version when it is changed, the changes
+// of the other iptables tables of the IP version are programmed with it
+func (t *Table) Apply() (time.Duration, error) {
+	t.rs.mu.Lock()
+	defer t.rs.mu.Unlock()
+	f := t.rs.families[t.IPVersion]
+	if !f.dirty {
+		return 0, nil
+	}
+	msgs, err := t.rs.render(f)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	if err := t.rs.conn.send(msgs); err != nil {
+		return 0, err
+	}
+	f.applied, f.dirty = true, false
+	t.rs.log.Debug("programmed nftables table", zap.String("table", TableName),
+		zap.Uint8("ipVersion", t.IPVersion), zap.Int("messages", len(msgs)), zap.Duration("duration", time.Since(start)))
+	return 0, nil
+}
+
+func (t *Table) chainName(name string) string {
+	return t.Name + "-" + name
+}
+
+// renderer returns the renderer of the rules of the chain
+func (t *Table) renderer(chain string) renderer {
+	f := t.rs.families[t.IPVersion]
+	return renderer{
+		version:   t.IPVersion,
+		nat:       t.natType(chain),
+		chainName: t.chainName,
+		setID: func(name string) uint32 {
+			if s, ok := f.sets[name]; ok {
+				return s.id
+			}
+			return 0
+		},
+		anonID: func() uint32 {
+			t.rs.nextID++
+			return t.rs.nextID
+		},
+	}
+}
+
+// natType returns the NAT type of the hook running the chain, it is empty
+// for the chains of the other tables. A regular chain takes the type of the
+// base chain jumping to it.
+func (t *Table) natType(chain string) string {
+	if base, ok := baseChains[t.Name][chain]; ok {
+		return base.nat
+	}
+	if t.Name != "nat" {
+		return ""
+	}
+	for name, base := range baseChains[t.Name] {
+		for _, item := range t.chainRules(name) {
+			switch action := item.Action.(type) {
+			case iptables.JumpAction:
+				if action.Target == chain {
+					return base.nat
+				}
+			case iptables.GotoAction:
+				if action.Target == chain {
+					return base.nat
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (t *Table) chainNames() []string {
+	res := make([]string, 0, len(t.chains)+len(t.inserted))
+	for name := range t.chains {
+		res = append(res, name)
+	}
+	for name := range t.inserted {
+		if _, ok := t.chains[name]; !ok {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (t *Table) chainRules(name string) []iptables.Rule {
+	rules := append([]iptables.Rule{}, t.inserted[name]...)
+	if chain, ok := t.chains[name]; ok {
+		rules = append(rules, chain.Rules...)
+	}
+	return rules
+}
+
+// expectedRule is a rule of the desired state of the table
+type expectedRule struct {
+	text     string
+	comments []string
+}
+
+// expected returns the rendered rules of the chains of the table and their
+// hashes, and the rules by their hashes
+func (t *Table) expected() (lines, hashes map[string][]string, byHash map[string]expectedRule) {
+	t.rs.mu.Lock()
+	defer t.rs.mu.Unlock()
+	lines = make(map[string][]string)
+	hashes = make(map[string][]string)
+	byHash = make(map[string]expectedRule)
+	for _, name := range t.chainNames() {
+		r := t.renderer(name)
+		chainRules := t.chainRules(name)
+		chainHashes := (&iptables.Chain{Name: name, Rules: chainRules}).RuleHashes(&iptables.Options{})
+		lines[name] = make([]string, 0, len(chainRules))
+		for i, item := range chainRules {
+			text := ""
+			if rendered, err := r.render(item); err != nil {
+				text = fmt.Sprintf("# %v", err)
+			} else {
+				text = rendered.String()
+			}
+			text = fmt.Sprintf("%s comment %q", text, hashCommentPrefix+chainHashes[i])
+			lines[name] = append(lines[name], text)
+			byHash[chainHashes[i]] = expectedRule{text: text, comments: item.Comment}
+		}
+		hashes[name] = chainHashes
+	}
+	return lines, hashes, byHash
+}
+
+// Render returns the rules of the chains of the table in the syntax of nft,
+// it fails on the first rule which cannot be rendered
+func (t *Table) Render() (map[string][]string, error) {
+	t.rs.mu.Lock()
+	defer t.rs.mu.Unlock()
+	res := make(map[string][]string)
+	for _, name := range t.chainNames() {
+		r := t.renderer(name)
+		for i, item := range t.chainRules(name) {
+			rendered, err := r.render(item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render rule %d of chain %s of table %s: %v", i, name, t.Name, err)
+			}
+			res[name] = append(res[name], rendered.String())
+		}
+	}
+	return res, nil
+}
+
+// Dump returns the state of the chains of the table, the rules read back
+// from the kernel are matched with the desired ones by their hashes
+func (t *Table) Dump() ([]iptables.ChainState, error) {
+	expected, expectedHashes, byHash := t.expected()
+	chains, rules, err := t.rs.readRules(t.IPVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := t.Name + "-"
+	actual := make(map[string][]string)
+	actualHashes := make(map[string][]string)
+	for _, name := range chains {
+		if strings.HasPrefix(name, prefix) {
+			name = strings.TrimPrefix(name, prefix)
+			actual[name] = make([]string, 0)
+			actualHashes[name] = make([]string, 0)
+		}
+	}
+	for _, item := range rules {
+		if !strings.HasPrefix(item.chain, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(item.chain, prefix)
+		text := fmt.Sprintf("comment %q", hashCommentPrefix+item.hash)
+		if rule, ok := byHash[item.hash]; ok {
+			text = rule.text
+		}
+		actual[name] = append(actual[name], text)
+		actualHashes[name] = append(actualHashes[name], item.hash)
+	}
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	res := make([]iptables.ChainState, 0, len(names))
+	for _, name := range names {
+		res = append(res, iptables.ChainState{
+			Name:     name,
+			Expected: expected[name],
+			Actual:   actual[name],
+			InSync:   reflect.DeepEqual(expectedHashes[name], actualHashes[name]),
+		})
+	}
+	return res, nil
+}
+
+// ReadCounters returns the counters of the rules of the table, the comments
+// of the rules are looked up by their hashes
+func (t *Table) ReadCounters() ([]iptables.RuleCounter, error) {
+	_, _, byHash := t.expected()
+	_, rules, err := t.rs.readRules(t.IPVersion)
+	if err != nil {
+		return nil, err
+	}
+	prefix := t.Name + "-"
+	res := make([]iptables.RuleCounter, 0)
+	for _, item := range rules {
+		rule, ok := byHash[item.hash]
+		if !ok || !strings.HasPrefix(item.chain, prefix) {
+			continue
+		}
+		res = append(res, iptables.RuleCounter{
+			Chain:    strings.TrimPrefix(item.chain, prefix),
+			Comments: rule.comments,
+			Packets:  item.packets,
+			Bytes:    item.bytes,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package nftables
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink/nl"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/egressgateway/pkg/ipset"
+	"github.com/spidernet-io/egressgateway/pkg/iptables"
+)
+
+// fakeConn records the batches and keeps the chains and the rules of the
+// last batch replacing the table, they are returned by the dumps
+type fakeConn struct {
+	batches [][]message
+	chains  [][]byte
+	rules   [][]byte
+	err     error
+}
+
+func payload(m message) []byte {
+	data := []byte{m.family, unix.NFNETLINK_V0, 0, 0}
+	for _, attr := range m.attrs {
+		data = append(data, attr.Serialize()...)
+	}
+	return data
+}
+
+func (c *fakeConn) send(msgs []message) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.batches = append(c.batches, msgs)
+	for _, msg := range msgs {
+		switch msg.typ {
+		case unix.NFT_MSG_DELTABLE:
+			c.chains, c.rules = nil, nil
+		case unix.NFT_MSG_NEWCHAIN:
+			c.chains = append(c.chains, payload(msg))
+		case unix.NFT_MSG_NEWRULE:
+			c.rules = append(c.rules, payload(msg))
+		}
+	}
+	return nil
+}
+
+func (c *fakeConn) dump(msg message) ([][]byte, error) {
+	if len(c.batches) == 0 {
+		return nil, fmt.Errorf("failed to %s: %w", msg.desc, unix.ENOENT)
+	}
+	if msg.typ == unix.NFT_MSG_GETCHAIN {
+		return c.chains, nil
+	}
+	return c.rules, nil
+}
+
+func (c *fakeConn) descs(i int) []string {
+	res := make([]string, 0, len(c.batches[i]))
+	for _, msg := range c.batches[i] {
+		res = append(res, msg.desc)
+	}
+	return res
+}
+
+// counterRule returns the rule read back with the counter
+func counterRule(chain, hash string, packets, bytes uint64) []byte {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, packets)
+	bytesData := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytesData, bytes)
+	exprs := nested(unix.NFTA_RULE_EXPRESSIONS, nested(unix.NFTA_LIST_ELEM,
+		stringAttr(unix.NFTA_EXPR_NAME, "counter"),
+		nested(unix.NFTA_EXPR_DATA,
+			attr(unix.NFTA_COUNTER_BYTES, bytesData),
+			attr(unix.NFTA_COUNTER_PACKETS, counter))))
+	return payload(message{family: unix.NFPROTO_IPV4, attrs: []*nl.RtAttr{
+		stringAttr(unix.NFTA_RULE_TABLE, TableName),
+		stringAttr(unix.NFTA_RULE_CHAIN, chain),
+		exprs,
+		attr(unix.NFTA_RULE_USERDATA, commentUserData(hashCommentPrefix+hash)),
+	}})
+}
+
+func newTestRuleset(t *testing.T) (*fakeConn, *Ruleset, *Table, *Table) {
+	c := &fakeConn{}
+	rs := newRuleset(c, zap.NewNop())
+	mangle, err := rs.NewTable("mangle", 4)
+	assert.NoError(t, err)
+	nat, err := rs.NewTable("nat", 4)
+	assert.NoError(t, err)
+
+	err = rs.Sets().CreateSet(&ipset.IPSet{Name: "egress-src-v4-policy", SetType: ipset.HashNet}, true)
+	assert.NoError(t, err)
+	err = rs.Sets().AddEntry("172.30.0.2", &ipset.IPSet{Name: "egress-src-v4-policy"}, true)
+	assert.NoError(t, err)
+
+	mangle.UpdateChain(&iptables.Chain{Name: "EGRESSGATEWAY-MARK-REQUEST", Rules: []iptables.Rule{{
+		Match:   iptables.MatchCriteria{}.SourceIPSet("egress-src-v4-policy"),
+		Action:  iptables.SetMaskedMarkAction{Mark: 0x26000001, Mask: 0xffffffff},
+		Comment: []string{"policy-a"},
+	}}})
+	mangle.InsertOrAppendRules("PREROUTING", []iptables.Rule{{
+		Action: iptables.JumpAction{Target: "EGRESSGATEWAY-MARK-REQUEST"},
+	}})
+	nat.UpdateChain(&iptables.Chain{Name: "EGRESSGATEWAY-SNAT-EIP", Rules: []iptables.Rule{{
+		Match:   iptables.MatchCriteria{}.MarkMatchesWithMask(0x26000001, 0xffffffff),
+		Action:  iptables.SNATAction{ToAddr: "10.6.1.21"},
+		Comment: []string{"policy-a"},
+	}}})
+	nat.InsertOrAppendRules("POSTROUTING", []iptables.Rule{{
+		Action: iptables.JumpAction{Target: "EGRESSGATEWAY-SNAT-EIP"},
+	}})
+	return c, rs, mangle, nat
+}
+
+func TestApply(t *testing.T) {
+	c, rs, mangle, nat := newTestRuleset(t)
+
+	// the set is programmed with the table
+	assert.Empty(t, c.batches)
+
+	_, err := nat.Apply()
+	assert.NoError(t, err)
+	if !assert.Len(t, c.batches, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{
+		"create table egressgateway",
+		"delete table egressgateway",
+		"create table egressgateway",
+		"create set egress-src-v4-policy",
+		"add elements to set egress-src-v4-policy",
+		"create chain mangle-EGRESSGATEWAY-MARK-REQUEST",
+		"create chain mangle-PREROUTING",
+		"create chain nat-EGRESSGATEWAY-SNAT-EIP",
+		"create chain nat-POSTROUTING",
+		`add rule "ip saddr @egress-src-v4-policy counter meta mark set 0x26000001" to chain mangle-EGRESSGATEWAY-MARK-REQUEST`,
+		`add rule "counter jump mangle-EGRESSGATEWAY-MARK-REQUEST" to chain mangle-PREROUTING`,
+		`add rule "meta mark == 0x26000001 counter snat to 10.6.1.21" to chain nat-EGRESSGATEWAY-SNAT-EIP`,
+		`add rule "counter jump nat-EGRESSGATEWAY-SNAT-EIP" to chain nat-POSTROUTING`,
+	}, c.descs(0))
+
+	// the other tables of the family are programmed with it
+	_, err = mangle.Apply()
+	assert.NoError(t, err)
+	assert.Len(t, c.batches, 1)
+
+	// the change of a set is programmed at once, only the changed intervals
+	// are sent, 172.30.0.3 is merged into the interval of 172.30.0.2
+	err = rs.Sets().AddEntry("172.30.0.3", &ipset.IPSet{Name: "egress-src-v4-policy"}, true)
+	assert.NoError(t, err)
+	if !assert.Len(t, c.batches, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{
+		"delete elements from set egress-src-v4-policy",
+		"add elements to set egress-src-v4-policy",
+	}, c.descs(1))
+	err = rs.Sets().AddEntry("10.6.0.0/16", &ipset.IPSet{Name: "egress-src-v4-policy"}, true)
+	assert.NoError(t, err)
+	if !assert.Len(t, c.batches, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"add elements to set egress-src-v4-policy"}, c.descs(2))
+	assert.NoError(t, rs.Sets().DelEntry("10.6.0.0/16", "egress-src-v4-policy"))
+	assert.Equal(t, []string{"delete elements from set egress-src-v4-policy"}, c.descs(3))
+
+	// the table is programmed again after a failed update of a set
+	c.err = fmt.Errorf("failed")
+	err = rs.Sets().DelEntry("172.30.0.3", "egress-src-v4-policy")
+	assert.Error(t, err)
+	c.err = nil
+	_, err = mangle.Apply()
+	assert.NoError(t, err)
+	assert.Len(t, c.batches, 5)
+
+	// the rule referring to an unknown set fails the apply
+	mangle.UpdateChain(&iptables.Chain{Name: "EGRESSGATEWAY-MARK-REQUEST", Rules: []iptables.Rule{{
+		Match:  iptables.MatchCriteria{}.SourceIPSet("unknown"),
+		Action: iptables.AcceptAction{},
+	}}})
+	_, err = mangle.Apply()
+	assert.Error(t, err)
+	assert.Len(t, c.batches, 5)
+}
+
+func TestBaseChainPriorities(t *testing.T) {
+	c := &fakeConn{}
+	rs := newRuleset(c, zap.NewNop())
+	for _, name := range []string{"raw", "mangle", "nat", "filter"} {
+		table, err := rs.NewTable(name, 4)
+		assert.NoError(t, err)
+		for chain := range baseChains[name] {
+			table.InsertOrAppendRules(chain, []iptables.Rule{{Action: iptables.AcceptAction{}}})
+		}
+	}
+	_, err := rs.families[4].tables[0].Apply()
+	if !assert.NoError(t, err) || !assert.Len(t, c.batches, 1) {
+		t.FailNow()
+	}
+
+	// the chains of the iptables tables, iptables-nft included, are hooked
+	// with these priorities, the chains of the table must run before them
+	iptablesPriorities := map[string]int32{
+		"raw-PREROUTING":     -300,
+		"raw-OUTPUT":         -300,
+		"mangle-PREROUTING":  -150,
+		"mangle-INPUT":       -150,
+		"mangle-FORWARD":     -150,
+		"mangle-OUTPUT":      -150,
+		"mangle-POSTROUTING": -150,
+		"nat-PREROUTING":     -100,
+		"nat-OUTPUT":         -100,
+		"nat-INPUT":          100,
+		"nat-POSTROUTING":    100,
+		"filter-INPUT":       0,
+		"filter-FORWARD":     0,
+		"filter-OUTPUT":      0,
+	}
+	priorities := make(map[string]int32)
+	for _, msg := range c.batches[0] {
+		if msg.typ != unix.NFT_MSG_NEWCHAIN {
+			continue
+		}
+		attrs, err := parseAttrs(payload(msg))
+		assert.NoError(t, err)
+		hook, err := parseNested(attrs[unix.NFTA_CHAIN_HOOK])
+		assert.NoError(t, err)
+		priorities[nl.BytesToString(attrs[unix.NFTA_CHAIN_NAME])] = int32(binary.BigEndian.Uint32(hook[unix.NFTA_HOOK_PRIORITY]))
+	}
+	assert.Len(t, priorities, len(iptablesPriorities))
+	for name, priority := range iptablesPriorities {
+		if assert.Contains(t, priorities, name) {
+			assert.Less(t, priorities[name], priority, name)
+		}
+	}
+
+	// an accept of a nat chain binds the connection to its own address, so
+	// the NAT chains after the table are skipped
+	assert.Contains(t, c.descs(0), `add rule "counter snat to ip saddr" to chain nat-POSTROUTING`)
+	assert.Contains(t, c.descs(0), `add rule "counter dnat to ip daddr" to chain nat-PREROUTING`)
+	assert.Contains(t, c.descs(0), `add rule "counter accept" to chain filter-FORWARD`)
+}
+
+func TestDump(t *testing.T) {
+	c, _, mangle, _ := newTestRuleset(t)
+
+	// the table does not exist before the first apply
+	chains, err := mangle.Dump()
+	assert.NoError(t, err)
+	for _, chain := range chains {
+		assert.False(t, chain.InSync)
+		assert.Empty(t, chain.Actual)
+	}
+
+	_, err = mangle.Apply()
+	assert.NoError(t, err)
+	chains, err = mangle.Dump()
+	assert.NoError(t, err)
+	if !assert.Len(t, chains, 2) {
+		t.FailNow()
+	}
+	for _, chain := range chains {
+		assert.True(t, chain.InSync, chain.Name)
+		assert.Equal(t, chain.Expected, chain.Actual)
+	}
+
+	// the rule removed by others is reported
+	c.rules = c.rules[1:]
+	chains, err = mangle.Dump()
+	assert.NoError(t, err)
+	assert.Equal(t, "EGRESSGATEWAY-MARK-REQUEST", chains[0].Name)
+	assert.False(t, chains[0].InSync)
+	assert.Len(t, chains[0].Expected, 1)
+	assert.Empty(t, chains[0].Actual)
+	assert.True(t, chains[1].InSync)
+}
+
+func TestReadCounters(t *testing.T) {
+	c, _, mangle, nat := newTestRuleset(t)
+	_, err := mangle.Apply()
+	assert.NoError(t, err)
+
+	hash := (&iptables.Chain{Name: "EGRESSGATEWAY-SNAT-EIP", Rules: nat.chainRules("EGRESSGATEWAY-SNAT-EIP")}).
+		RuleHashes(&iptables.Options{})[0]
+	c.rules = append(c.rules,
+		counterRule("nat-EGRESSGATEWAY-SNAT-EIP", hash, 10, 1000),
+		counterRule("nat-EGRESSGATEWAY-SNAT-EIP", "unknown", 1, 100))
+
+	counters, err := nat.ReadCounters()
+	assert.NoError(t, err)
+	assert.Contains(t, counters, iptables.RuleCounter{
+		Chain:    "EGRESSGATEWAY-SNAT-EIP",
+		Comments: []string{"policy-a"},
+		Packets:  10,
+		Bytes:    1000,
+	})
+	for _, counter := range counters {
+		assert.NotEqual(t, uint64(1), counter.Packets)
+	}
+
+	counters, err = mangle.ReadCounters()
+	assert.NoError(t, err)
+	for _, counter := range counters {
+		assert.NotEqual(t, "EGRESSGATEWAY-SNAT-EIP", counter.Chain)
+	}
+}
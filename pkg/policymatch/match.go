@@ -0,0 +1,158 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package policymatch holds the rules deciding which traffic an EgressPolicy
+// or EgressClusterPolicy applies to. The controller, the agent and egressctl
+// share them, so the explanation of egressctl matches the datapath.
+package policymatch
+
+import (
+	"net"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+// SelectsLabels reports whether the selector matches the labels, a nil
+// selector matches nothing
+func SelectsLabels(selector *metav1.LabelSelector, set map[string]string) (bool, error) {
+	if selector == nil {
+		return false, nil
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return sel.Matches(labels.Set(set)), nil
+}
+
+// SelectsNamespace reports whether the namespaceSelector of an
+// EgressClusterPolicy matches the namespace, a nil selector matches all
+func SelectsNamespace(namespaceSelector *metav1.LabelSelector, namespaceLabels map[string]string) (bool, error) {
+	if namespaceSelector == nil {
+		return true, nil
+	}
+	return SelectsLabels(namespaceSelector, namespaceLabels)
+}
+
+// ExcludesPod reports whether the excludePodSelector matches the pod
+func ExcludesPod(excludePodSelector *metav1.LabelSelector, podLabels map[string]string) (bool, error) {
+	return SelectsLabels(excludePodSelector, podLabels)
+}
+
+// InSubnets returns the first subnet containing ip, or an empty string
+func InSubnets(ip net.IP, subnets []string) string {
+	for _, item := range subnets {
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			continue
+		}
+		if ipNet.Contains(ip) {
+			return item
+		}
+	}
+	return ""
+}
+
+// MatchAllDest reports whether the policy matches all the destinations out of
+// the ignore CIDRs of the cluster, instead of destSubnet and destDomains
+func MatchAllDest(destSubnet, destDomains []string) bool {
+	return len(destSubnet) == 0 && len(destDomains) == 0
+}
+
+// IgnoreCIDRs returns the IPv4 and IPv6 destinations in the cluster, the
+// traffic to them does not go to the gateway when the policy matches all
+// destinations. custom is the egressIgnoreCIDR.custom of the configuration.
+func IgnoreCIDRs(info *egressv1.EgressClusterInfo, custom []string) ([]string, []string) {
+	ipv4 := make([]string, 0)
+	ipv6 := make([]string, 0)
+
+	addIP := func(items ...string) {
+		for _, ip := range items {
+			ip := net.ParseIP(ip)
+			if ip.To4() != nil {
+				ipv4 = append(ipv4, ip.String())
+			} else if ip.To16() != nil {
+				ipv6 = append(ipv6, ip.String())
+			}
+		}
+	}
+	addCIDR := func(items ...string) {
+		for _, item := range items {
+			ip, cidr, err := net.ParseCIDR(item)
+			if err != nil {
+				continue
+			}
+			if ip.To4() != nil {
+				ipv4 = append(ipv4, cidr.String())
+			} else if ip.To16() != nil {
+				ipv6 = append(ipv6, cidr.String())
+			}
+		}
+	}
+
+	if info != nil {
+		addIP(info.Status.EgressIgnoreCIDR.NodeIP.IPv4...)
+		addIP(info.Status.EgressIgnoreCIDR.NodeIP.IPv6...)
+		addCIDR(info.Status.EgressIgnoreCIDR.PodCIDR.IPv4...)
+		addCIDR(info.Status.EgressIgnoreCIDR.PodCIDR.IPv6...)
+		addCIDR(info.Status.EgressIgnoreCIDR.ClusterIP.IPv4...)
+		addCIDR(info.Status.EgressIgnoreCIDR.ClusterIP.IPv6...)
+	}
+	addCIDR(custom...)
+	return ipv4, ipv6
+}
+
+// InIgnoreCIDRs returns the first item of the ignore CIDRs containing ip, an
+// item is a CIDR or a single IP, or an empty string
+func InIgnoreCIDRs(ip net.IP, items []string) string {
+	for _, item := range items {
+		if !strings.Contains(item, "/") {
+			if net.ParseIP(item).Equal(ip) {
+				return item
+			}
+			continue
+		}
+		if InSubnets(ip, []string{item}) != "" {
+			return item
+		}
+	}
+	return ""
+}
+
+// MatchesDestPort reports whether the destination port rules match the
+// protocol and the port, all the traffic is matched without port rules
+func MatchesDestPort(ports []egressv1.PolicyPort, protocol string, port int32) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, item := range ports {
+		if !strings.EqualFold(DestPortProtocol(item), protocol) {
+			continue
+		}
+		switch {
+		case item.Port != 0 && item.EndPort > item.Port:
+			if port >= item.Port && port <= item.EndPort {
+				return true
+			}
+		case item.Port != 0:
+			if port == item.Port {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// DestPortProtocol returns the protocol of the port rule, TCP by default
+func DestPortProtocol(port egressv1.PolicyPort) string {
+	if port.Protocol == "" {
+		return "TCP"
+	}
+	return port.Protocol
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package policymatch
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+)
+
+func TestSelectsLabels(t *testing.T) {
+	podLabels := map[string]string{"app": "nginx"}
+	cases := map[string]struct {
+		selector *metav1.LabelSelector
+		exp      bool
+		expErr   bool
+	}{
+		"nil selector": {
+			selector: nil,
+			exp:      false,
+		},
+		"empty selector": {
+			selector: &metav1.LabelSelector{},
+			exp:      true,
+		},
+		"match labels": {
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}},
+			exp:      true,
+		},
+		"not match labels": {
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+			exp:      false,
+		},
+		"invalid selector": {
+			selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: "bad"},
+			}},
+			expErr: true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			res, err := SelectsLabels(c.selector, podLabels)
+			if c.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.exp, res)
+		})
+	}
+
+	res, err := SelectsNamespace(nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, res)
+}
+
+func TestIgnoreCIDRs(t *testing.T) {
+	info := &egressv1.EgressClusterInfo{}
+	info.Status.EgressIgnoreCIDR.NodeIP.IPv4 = []string{"172.18.0.2"}
+	info.Status.EgressIgnoreCIDR.PodCIDR.IPv4 = []string{"10.244.0.1/16"}
+	info.Status.EgressIgnoreCIDR.ClusterIP.IPv6 = []string{"fd00:10:96::/112"}
+
+	ipv4, ipv6 := IgnoreCIDRs(info, []string{"192.168.0.0/24", "bad"})
+	assert.Equal(t, []string{"172.18.0.2", "10.244.0.0/16", "192.168.0.0/24"}, ipv4)
+	assert.Equal(t, []string{"fd00:10:96::/112"}, ipv6)
+
+	assert.Equal(t, "172.18.0.2", InIgnoreCIDRs(net.ParseIP("172.18.0.2"), ipv4))
+	assert.Equal(t, "10.244.0.0/16", InIgnoreCIDRs(net.ParseIP("10.244.3.4"), ipv4))
+	assert.Equal(t, "", InIgnoreCIDRs(net.ParseIP("172.18.0.3"), ipv4))
+
+	ipv4, ipv6 = IgnoreCIDRs(nil, nil)
+	assert.Empty(t, ipv4)
+	assert.Empty(t, ipv6)
+}
+
+func TestMatchesDestPort(t *testing.T) {
+	ports := []egressv1.PolicyPort{
+		{Port: 80},
+		{Protocol: "UDP", Port: 1000, EndPort: 2000},
+		{Protocol: "SCTP"},
+	}
+	cases := map[string]struct {
+		ports    []egressv1.PolicyPort
+		protocol string
+		port     int32
+		exp      bool
+	}{
+		"no port rule":       {ports: nil, protocol: "TCP", port: 443, exp: true},
+		"tcp by default":     {ports: ports, protocol: "TCP", port: 80, exp: true},
+		"tcp other port":     {ports: ports, protocol: "TCP", port: 443, exp: false},
+		"udp in range":       {ports: ports, protocol: "udp", port: 1500, exp: true},
+		"udp out of range":   {ports: ports, protocol: "UDP", port: 2001, exp: false},
+		"all ports of sctp":  {ports: ports, protocol: "SCTP", port: 9, exp: true},
+		"protocol not match": {ports: ports, protocol: "UDP", port: 80, exp: false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.exp, MatchesDestPort(c.ports, c.protocol, c.port))
+		})
+	}
+}
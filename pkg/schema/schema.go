@@ -5,9 +5,11 @@ package schema
 
 import (
 	calicov1 "github.com/tigera/operator/pkg/apis/crd.projectcalico.org/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
+	egressgatewayv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1"
 	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
 )
 
@@ -22,6 +24,12 @@ func init() {
 	if err := egressv1.AddToScheme(scheme); err != nil {
 		panic(err)
 	}
+	if err := egressgatewayv1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
 	if err := calicov1.AddToScheme(scheme); err != nil {
 		panic(err)
 	}
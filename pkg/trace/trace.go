@@ -0,0 +1,418 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package trace explains how the traffic of a pod to a destination egresses,
+// from the policies selecting the pod to the gateway node and the EIP.
+package trace
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/policymatch"
+)
+
+const (
+	KindEgressPolicy        = "EgressPolicy"
+	KindEgressClusterPolicy = "EgressClusterPolicy"
+
+	clusterInfoName = "default"
+)
+
+// Options is the traffic to trace
+type Options struct {
+	Namespace string
+	Pod       string
+	Dest      net.IP
+	// Protocol and Port are checked against destPorts when Protocol is set
+	Protocol string
+	Port     int32
+	// IgnoreCIDRs is the egressIgnoreCIDR.custom of the agent configuration
+	IgnoreCIDRs []string
+}
+
+// Result explains the traffic, Policies are ordered from the highest priority
+type Result struct {
+	Pod         string         `json:"pod"`
+	PodIPs      []string       `json:"podIPs,omitempty"`
+	Destination string         `json:"destination"`
+	SourceNode  *Node          `json:"sourceNode,omitempty"`
+	Policies    []PolicyResult `json:"policies"`
+	// Effective is the policy applied to the traffic, it is empty when the
+	// traffic does not go through a gateway node
+	Effective *PolicyResult `json:"effective,omitempty"`
+	Gateway   *Gateway      `json:"gateway,omitempty"`
+}
+
+// PolicyResult tells whether the policy selects the pod and the destination
+type PolicyResult struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Priority  uint64   `json:"priority"`
+	Selected  bool     `json:"selected"`
+	Matched   bool     `json:"matched"`
+	Reasons   []string `json:"reasons"`
+}
+
+// Gateway is where the traffic of the effective policy goes
+type Gateway struct {
+	EgressGateway string `json:"egressGateway"`
+	UseNodeIP     bool   `json:"useNodeIP"`
+	Nodes         []Node `json:"nodes"`
+}
+
+// Node is an EgressNode, the traffic is forwarded when its phase is Succeeded
+type Node struct {
+	Name       string `json:"name"`
+	EIPv4      string `json:"eipv4,omitempty"`
+	EIPv6      string `json:"eipv6,omitempty"`
+	Phase      string `json:"phase"`
+	Mark       string `json:"mark,omitempty"`
+	TunnelIPv4 string `json:"tunnelIPv4,omitempty"`
+	TunnelIPv6 string `json:"tunnelIPv6,omitempty"`
+	Ready      bool   `json:"ready"`
+}
+
+// candidate is the part of EgressPolicy and EgressClusterPolicy the trace is based on
+type candidate struct {
+	result            PolicyResult
+	policy            egressv1.Policy
+	namespaceSelector *metav1.LabelSelector
+	podSelector       *metav1.LabelSelector
+	excludePod        *metav1.LabelSelector
+	podSubnet         []string
+	destSubnet        []string
+	excludeDestSubnet []string
+	destDomains       []string
+	destPorts         []egressv1.PolicyPort
+	gatewayName       string
+	useNodeIP         bool
+	status            egressv1.EgressPolicyStatus
+}
+
+// Trace explains the traffic of the options with the objects read by cli
+func Trace(ctx context.Context, cli client.Reader, opts Options) (*Result, error) {
+	if opts.Dest == nil {
+		return nil, fmt.Errorf("the destination IP is required")
+	}
+
+	pod := new(corev1.Pod)
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: opts.Namespace, Name: opts.Pod}, pod); err != nil {
+		return nil, fmt.Errorf("failed to get pod %s/%s: %v", opts.Namespace, opts.Pod, err)
+	}
+	ns := new(corev1.Namespace)
+	if err := cli.Get(ctx, types.NamespacedName{Name: pod.Namespace}, ns); err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %v", pod.Namespace, err)
+	}
+
+	res := &Result{
+		Pod:         pod.Namespace + "/" + pod.Name,
+		Destination: opts.Dest.String(),
+		Policies:    make([]PolicyResult, 0),
+	}
+	var podIPs []net.IP
+	for _, item := range pod.Status.PodIPs {
+		if ip := net.ParseIP(item.IP); ip != nil {
+			podIPs = append(podIPs, ip)
+			res.PodIPs = append(res.PodIPs, ip.String())
+		}
+	}
+	if pod.Spec.NodeName != "" {
+		node, err := getNode(ctx, cli, pod.Spec.NodeName)
+		if err != nil {
+			return nil, err
+		}
+		res.SourceNode = node
+	}
+
+	candidates, err := listCandidates(ctx, cli, pod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	ignoreV4, ignoreV6, err := ignoreCIDRs(ctx, cli, opts.IgnoreCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	ignore := ignoreV4
+	if opts.Dest.To4() == nil {
+		ignore = ignoreV6
+	}
+
+	matched := make([]*candidate, 0)
+	for _, c := range candidates {
+		if err := c.selectPod(pod, ns, podIPs, opts.Dest); err != nil {
+			return nil, err
+		}
+		if c.result.Selected {
+			c.matchDest(opts, ignore)
+		}
+		if c.result.Matched {
+			matched = append(matched, c)
+		}
+	}
+
+	// a higher priority policy is matched first by the rules of the agent
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return egressv1.PolicyLess(candidates[i].policy, candidates[i].result.Priority,
+			candidates[j].policy, candidates[j].result.Priority)
+	})
+	for _, c := range candidates {
+		res.Policies = append(res.Policies, c.result)
+	}
+	if len(matched) == 0 {
+		return res, nil
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return egressv1.PolicyLess(matched[i].policy, matched[i].result.Priority,
+			matched[j].policy, matched[j].result.Priority)
+	})
+	effective := matched[0]
+	res.Effective = &effective.result
+	res.Gateway, err = gateway(ctx, cli, effective)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func listCandidates(ctx context.Context, cli client.Reader, namespace string) ([]*candidate, error) {
+	res := make([]*candidate, 0)
+
+	policies := new(egressv1.EgressPolicyList)
+	if err := cli.List(ctx, policies, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list EgressPolicy: %v", err)
+	}
+	for i := range policies.Items {
+		p := &policies.Items[i]
+		res = append(res, &candidate{
+			result: PolicyResult{
+				Kind: KindEgressPolicy, Namespace: p.Namespace, Name: p.Name,
+				Priority: p.GetPriority(), Reasons: make([]string, 0),
+			},
+			policy:            egressv1.Policy{Name: p.Name, Namespace: p.Namespace},
+			podSelector:       p.Spec.AppliedTo.PodSelector,
+			excludePod:        p.Spec.AppliedTo.ExcludePodSelector,
+			podSubnet:         p.Spec.AppliedTo.PodSubnet,
+			destSubnet:        p.Spec.DestSubnet,
+			excludeDestSubnet: p.Spec.ExcludeDestSubnet,
+			destDomains:       p.Spec.DestDomains,
+			destPorts:         p.Spec.DestPorts,
+			gatewayName:       p.Spec.EgressGatewayName,
+			useNodeIP:         p.Spec.EgressIP.UseNodeIP,
+			status:            p.Status,
+		})
+	}
+
+	clusterPolicies := new(egressv1.EgressClusterPolicyList)
+	if err := cli.List(ctx, clusterPolicies); err != nil {
+		return nil, fmt.Errorf("failed to list EgressClusterPolicy: %v", err)
+	}
+	for i := range clusterPolicies.Items {
+		p := &clusterPolicies.Items[i]
+		c := &candidate{
+			result: PolicyResult{
+				Kind: KindEgressClusterPolicy, Name: p.Name,
+				Priority: p.GetPriority(), Reasons: make([]string, 0),
+			},
+			policy:            egressv1.Policy{Name: p.Name},
+			namespaceSelector: p.Spec.AppliedTo.NamespaceSelector,
+			podSelector:       p.Spec.AppliedTo.PodSelector,
+			excludePod:        p.Spec.AppliedTo.ExcludePodSelector,
+			destSubnet:        p.Spec.DestSubnet,
+			excludeDestSubnet: p.Spec.ExcludeDestSubnet,
+			destDomains:       p.Spec.DestDomains,
+			destPorts:         p.Spec.DestPorts,
+			gatewayName:       p.Spec.EgressGatewayName,
+			useNodeIP:         p.Spec.EgressIP.UseNodeIP,
+			status:            p.Status,
+		}
+		if p.Spec.AppliedTo.PodSubnet != nil {
+			c.podSubnet = *p.Spec.AppliedTo.PodSubnet
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+func (c *candidate) reason(format string, args ...interface{}) {
+	c.result.Reasons = append(c.result.Reasons, fmt.Sprintf(format, args...))
+}
+
+// selectPod checks the pod the same way as the controller building the
+// endpoint slices, a policy with podSubnet matches the pod IPs instead
+func (c *candidate) selectPod(pod *corev1.Pod, ns *corev1.Namespace, podIPs []net.IP, dest net.IP) error {
+	if len(c.podSubnet) != 0 {
+		for _, ip := range podIPs {
+			if (ip.To4() == nil) != (dest.To4() == nil) {
+				continue
+			}
+			if subnet := policymatch.InSubnets(ip, c.podSubnet); subnet != "" {
+				c.result.Selected = true
+				c.reason("pod IP %s is in podSubnet %s", ip, subnet)
+				return nil
+			}
+		}
+		c.reason("no pod IP of the destination IP family is in podSubnet %v", c.podSubnet)
+		return nil
+	}
+
+	if c.result.Kind == KindEgressClusterPolicy {
+		match, err := policymatch.SelectsNamespace(c.namespaceSelector, ns.Labels)
+		if err != nil {
+			return fmt.Errorf("invalid namespaceSelector of %s %s: %v", c.result.Kind, c.result.Name, err)
+		}
+		if !match {
+			c.reason("namespaceSelector does not match namespace %s", ns.Name)
+			return nil
+		}
+	}
+	match, err := policymatch.SelectsLabels(c.podSelector, pod.Labels)
+	if err != nil {
+		return fmt.Errorf("invalid podSelector of %s %s: %v", c.result.Kind, c.result.Name, err)
+	}
+	if !match {
+		c.reason("podSelector does not match the pod labels")
+		return nil
+	}
+	excluded, err := policymatch.ExcludesPod(c.excludePod, pod.Labels)
+	if err != nil {
+		return fmt.Errorf("invalid excludePodSelector of %s %s: %v", c.result.Kind, c.result.Name, err)
+	}
+	if excluded {
+		c.reason("excludePodSelector matches the pod labels")
+		return nil
+	}
+	c.result.Selected = true
+	c.reason("podSelector matches the pod labels")
+	return nil
+}
+
+// matchDest checks the destination the same way as the rules of the agent
+func (c *candidate) matchDest(opts Options, ignore []string) {
+	dest := opts.Dest
+	if subnet := policymatch.InSubnets(dest, c.excludeDestSubnet); subnet != "" {
+		c.reason("destination is in excludeDestSubnet %s", subnet)
+		return
+	}
+
+	if policymatch.MatchAllDest(c.destSubnet, c.destDomains) {
+		if item := policymatch.InIgnoreCIDRs(dest, ignore); item != "" {
+			c.reason("destination is in %s ignored by the cluster", item)
+			return
+		}
+		c.reason("policy matches all the destinations out of the cluster")
+	} else {
+		subnet := policymatch.InSubnets(dest, c.destSubnet)
+		switch {
+		case subnet != "":
+			c.reason("destination is in destSubnet %s", subnet)
+		case len(c.destDomains) != 0 && c.inDestDomainIPs(dest):
+			c.reason("destination is an address of destDomains %v", c.destDomains)
+		case len(c.destDomains) != 0:
+			c.reason("destination is neither in destSubnet nor an address of destDomains %v resolved by the controller", c.destDomains)
+			return
+		default:
+			c.reason("destination is not in destSubnet")
+			return
+		}
+	}
+
+	if len(c.destPorts) != 0 {
+		if opts.Protocol == "" {
+			c.reason("only the traffic to destPorts %s is matched", formatPorts(c.destPorts))
+		} else if !policymatch.MatchesDestPort(c.destPorts, opts.Protocol, opts.Port) {
+			c.reason("destPorts %s do not match %s/%d", formatPorts(c.destPorts), opts.Protocol, opts.Port)
+			return
+		}
+	}
+	c.result.Matched = true
+}
+
+// inDestDomainIPs reports whether ip is one of the addresses of destDomains
+// published in the policy status
+func (c *candidate) inDestDomainIPs(ip net.IP) bool {
+	for _, item := range append(append([]string{}, c.status.DestDomainIPs.IPv4...), c.status.DestDomainIPs.IPv6...) {
+		if ip.Equal(net.ParseIP(item)) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatPorts(ports []egressv1.PolicyPort) string {
+	res := ""
+	for i, port := range ports {
+		if i > 0 {
+			res += ","
+		}
+		res += policymatch.DestPortProtocol(port)
+		switch {
+		case port.Port != 0 && port.EndPort > port.Port:
+			res += fmt.Sprintf("/%d-%d", port.Port, port.EndPort)
+		case port.Port != 0:
+			res += fmt.Sprintf("/%d", port.Port)
+		}
+	}
+	return res
+}
+
+func ignoreCIDRs(ctx context.Context, cli client.Reader, custom []string) ([]string, []string, error) {
+	info := new(egressv1.EgressClusterInfo)
+	err := cli.Get(ctx, types.NamespacedName{Name: clusterInfoName}, info)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("failed to get EgressClusterInfo %s: %v", clusterInfoName, err)
+		}
+		info = nil
+	}
+	ipv4, ipv6 := policymatch.IgnoreCIDRs(info, custom)
+	return ipv4, ipv6, nil
+}
+
+// gateway returns the gateway nodes and the EIPs of the policy from its status
+func gateway(ctx context.Context, cli client.Reader, c *candidate) (*Gateway, error) {
+	res := &Gateway{EgressGateway: c.gatewayName, UseNodeIP: c.useNodeIP, Nodes: make([]Node, 0)}
+	gateways := c.status.Gateways
+	if len(gateways) == 0 && c.status.Node != "" {
+		gateways = []egressv1.PolicyGateway{{Node: c.status.Node, Ipv4: c.status.Eip.Ipv4, Ipv6: c.status.Eip.Ipv6}}
+	}
+	for _, item := range gateways {
+		node, err := getNode(ctx, cli, item.Node)
+		if err != nil {
+			return nil, err
+		}
+		node.EIPv4 = item.Ipv4
+		node.EIPv6 = item.Ipv6
+		res.Nodes = append(res.Nodes, *node)
+	}
+	return res, nil
+}
+
+func getNode(ctx context.Context, cli client.Reader, name string) (*Node, error) {
+	res := &Node{Name: name}
+	node := new(egressv1.EgressNode)
+	err := cli.Get(ctx, types.NamespacedName{Name: name}, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			res.Phase = "NotFound"
+			return res, nil
+		}
+		return nil, fmt.Errorf("failed to get EgressNode %s: %v", name, err)
+	}
+	res.Phase = string(node.Status.Phase)
+	res.Mark = node.Status.Mark
+	res.TunnelIPv4 = node.Status.Tunnel.IPv4
+	res.TunnelIPv6 = node.Status.Tunnel.IPv6
+	res.Ready = node.Status.Phase == egressv1.EgressNodeSucceeded
+	return res, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package trace
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	egressv1 "github.com/spidernet-io/egressgateway/pkg/k8s/apis/egressgateway.spidernet.io/v1beta1"
+	"github.com/spidernet-io/egressgateway/pkg/schema"
+)
+
+func TestTrace(t *testing.T) {
+	objs := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"env": "dev"}}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default", Labels: map[string]string{"app": "nginx"}},
+			Spec:       corev1.PodSpec{NodeName: "node1"},
+			Status:     corev1.PodStatus{PodIPs: []corev1.PodIP{{IP: "10.244.0.10"}}},
+		},
+		&egressv1.EgressClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Status: egressv1.EgressClusterStatus{EgressIgnoreCIDR: egressv1.EgressIgnoreCIDR{
+				PodCIDR: egressv1.IPListPair{IPv4: []string{"10.244.0.0/16"}},
+			}},
+		},
+		&egressv1.EgressPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"},
+			Spec: egressv1.EgressPolicySpec{
+				EgressGatewayName: "eg1",
+				AppliedTo: egressv1.AppliedTo{
+					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}},
+				},
+				DestSubnet:  []string{"1.1.1.0/24"},
+				DestDomains: []string{"api.example.com"},
+				DestPorts:   []egressv1.PolicyPort{{Port: 443}},
+			},
+			Status: egressv1.EgressPolicyStatus{
+				Gateways:      []egressv1.PolicyGateway{{Node: "node2", Ipv4: "10.6.1.21"}},
+				DestDomainIPs: egressv1.IPListPair{IPv4: []string{"2.2.2.2"}},
+			},
+		},
+		&egressv1.EgressPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "p2", Namespace: "default"},
+			Spec: egressv1.EgressPolicySpec{
+				EgressGatewayName: "eg1",
+				AppliedTo: egressv1.AppliedTo{
+					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+				},
+			},
+		},
+		&egressv1.EgressClusterPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "cp1"},
+			Spec: egressv1.EgressClusterPolicySpec{
+				EgressGatewayName: "eg2",
+				AppliedTo: egressv1.ClusterAppliedTo{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}},
+					PodSelector:       &metav1.LabelSelector{},
+				},
+			},
+			Status: egressv1.EgressPolicyStatus{
+				Node: "node3",
+				Eip:  egressv1.Eip{Ipv4: "10.6.2.21"},
+			},
+		},
+		&egressv1.EgressNode{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Status:     egressv1.EgressNodeStatus{Phase: egressv1.EgressNodeSucceeded, Mark: "0x26000000"},
+		},
+		&egressv1.EgressNode{
+			ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+			Status:     egressv1.EgressNodeStatus{Phase: egressv1.EgressNodeSucceeded, Mark: "0x26000001"},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(objs...).Build()
+
+	cases := map[string]struct {
+		opts         Options
+		expEffective string
+		expNodes     []Node
+		expMatched   map[string]bool
+	}{
+		"dest subnet with port": {
+			opts:         Options{Dest: net.ParseIP("1.1.1.1"), Protocol: "TCP", Port: 443},
+			expEffective: "p1",
+			expNodes: []Node{{
+				Name: "node2", EIPv4: "10.6.1.21", Phase: string(egressv1.EgressNodeSucceeded),
+				Mark: "0x26000001", Ready: true,
+			}},
+			expMatched: map[string]bool{"p1": true, "p2": false, "cp1": true},
+		},
+		"dest domain address": {
+			opts:         Options{Dest: net.ParseIP("2.2.2.2"), Protocol: "TCP", Port: 443},
+			expEffective: "p1",
+			expNodes: []Node{{
+				Name: "node2", EIPv4: "10.6.1.21", Phase: string(egressv1.EgressNodeSucceeded),
+				Mark: "0x26000001", Ready: true,
+			}},
+			expMatched: map[string]bool{"p1": true, "p2": false, "cp1": true},
+		},
+		"port not matched": {
+			opts:         Options{Dest: net.ParseIP("1.1.1.1"), Protocol: "TCP", Port: 80},
+			expEffective: "cp1",
+			expNodes:     []Node{{Name: "node3", EIPv4: "10.6.2.21", Phase: "NotFound"}},
+			expMatched:   map[string]bool{"p1": false, "p2": false, "cp1": true},
+		},
+		"dest in the cluster": {
+			opts:       Options{Dest: net.ParseIP("10.244.1.1")},
+			expMatched: map[string]bool{"p1": false, "p2": false, "cp1": false},
+		},
+		"dest in custom ignore cidr": {
+			opts:       Options{Dest: net.ParseIP("8.8.8.8"), IgnoreCIDRs: []string{"8.8.8.0/24"}},
+			expMatched: map[string]bool{"p1": false, "p2": false, "cp1": false},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			c.opts.Namespace = "default"
+			c.opts.Pod = "nginx"
+			res, err := Trace(context.Background(), cli, c.opts)
+			assert.NoError(t, err)
+
+			assert.Equal(t, []string{"10.244.0.10"}, res.PodIPs)
+			assert.Equal(t, "node1", res.SourceNode.Name)
+			assert.True(t, res.SourceNode.Ready)
+			matched := make(map[string]bool)
+			for _, p := range res.Policies {
+				matched[p.Name] = p.Matched
+				assert.NotEmpty(t, p.Reasons)
+			}
+			assert.Equal(t, c.expMatched, matched)
+
+			if c.expEffective == "" {
+				assert.Nil(t, res.Effective)
+				return
+			}
+			assert.Equal(t, c.expEffective, res.Effective.Name)
+			assert.Equal(t, c.expNodes, res.Gateway.Nodes)
+		})
+	}
+
+	_, err := Trace(context.Background(), cli, Options{Namespace: "default", Pod: "none", Dest: net.ParseIP("1.1.1.1")})
+	assert.Error(t, err)
+}
@@ -248,14 +248,14 @@ func IPsDiffSet(ips1, ips2 []net.IP, sorted bool) []net.IP {
 // MergeIPRanges merges dispersed IP ranges.
 // For example, transport [172.18.40.1-172.18.40.3, 172.18.40.2-172.18.40.5]
 // to [172.18.40.1-172.18.40.5]. The overlapping part of two IP ranges will
-// be ignored.
+// be ignored. The ranges are merged without expanding them into IP slices.
 func MergeIPRanges(version constant.IPVersion, ipRanges []string) ([]string, error) {
-	ips, err := ParseIPRanges(version, ipRanges)
+	set, err := NewIPRangeSet(version, ipRanges)
 	if err != nil {
 		return nil, err
 	}
 
-	return ConvertIPsToIPRanges(version, ips)
+	return set.Ranges(), nil
 }
 
 // ConvertIPsToIPRanges converts the IP address slices of the specified
@@ -310,26 +310,12 @@ func ConvertIPsToIPRanges(version constant.IPVersion, ips []net.IP) ([]string, e
 
 // IsIPIncludedRange determines whether an IP address is included in the destination range
 func IsIPIncludedRange(version constant.IPVersion, ip string, ipRange []string) (bool, error) {
-	ips, err := MergeIPRanges(version, ipRange)
+	set, err := NewIPRangeSet(version, ipRange)
 	if err != nil {
 		return false, err
 	}
 
-	var result bool
-
-	for _, item := range ips {
-		result, err := IsIPRangeOverlap(version, ip, item)
-		if err != nil {
-			return false, err
-		}
-
-		if result {
-			return true, nil
-		}
-
-	}
-
-	return result, nil
+	return set.Overlaps(ip)
 }
 
 // IsIPRangeOverlap reports whether the IP address slices of specific IP
@@ -345,15 +331,11 @@ func IsIPRangeOverlap(version constant.IPVersion, ipRange1, ipRange2 string) (bo
 		return false, err
 	}
 
-	// Ignore the error returned here. The format of the IP range has been
-	// verified in IsIPRange above.
-	ips1, _ := ParseIPRange(version, ipRange1)
-	ips2, _ := ParseIPRange(version, ipRange2)
-	if len(ips1) > len(IPsDiffSet(ips1, ips2, false)) {
-		return true, nil
+	set, err := NewIPRangeSet(version, []string{ipRange1})
+	if err != nil {
+		return false, err
 	}
-
-	return false, nil
+	return set.Overlaps(ipRange2)
 }
 
 // ParseIPRange parses IP range as an IP address slices of the specified
@@ -0,0 +1,227 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/spidernet-io/egressgateway/pkg/constant"
+)
+
+// IPRangeSet is a sorted set of disjoint IP ranges of one IP version. The
+// size, the membership and the free IPs are computed with range arithmetic,
+// so a large pool such as an IPv6 /64 is never expanded into an IP slice.
+type IPRangeSet struct {
+	version constant.IPVersion
+	ranges  []bigRange
+}
+
+type bigRange struct {
+	start, end *big.Int
+}
+
+func (r bigRange) size() *big.Int {
+	res := new(big.Int).Sub(r.end, r.start)
+	return res.Add(res, big.NewInt(1))
+}
+
+// NewIPRangeSet parses the IP ranges of the IP version. An item can be a
+// single IP address, an address range in the form of 'start-end' or a CIDR.
+// The overlapping and adjacent ranges are merged.
+func NewIPRangeSet(version constant.IPVersion, ipRanges []string) (*IPRangeSet, error) {
+	if err := IsIPVersion(version); err != nil {
+		return nil, err
+	}
+	s := &IPRangeSet{version: version}
+	for _, item := range ipRanges {
+		r, err := parseBigRange(version, item)
+		if err != nil {
+			return nil, err
+		}
+		s.ranges = append(s.ranges, r)
+	}
+	s.merge()
+	return s, nil
+}
+
+func parseBigRange(version constant.IPVersion, ipRange string) (bigRange, error) {
+	if !strings.Contains(ipRange, "/") {
+		if err := IsIPRange(version, ipRange); err != nil {
+			return bigRange{}, err
+		}
+		arr := strings.Split(ipRange, "-")
+		return bigRange{
+			start: ipToInt(net.ParseIP(arr[0])),
+			end:   ipToInt(net.ParseIP(arr[len(arr)-1])),
+		}, nil
+	}
+
+	ip, ipNet, err := net.ParseCIDR(ipRange)
+	if err != nil || (version == constant.IPv4) != (ip.To4() != nil) {
+		return bigRange{}, fmt.Errorf("%w in IPv%d '%s'", ErrInvalidCIDRFormat, version, ipRange)
+	}
+	ones, bits := ipNet.Mask.Size()
+	start := ipToInt(ipNet.IP)
+	end := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	end.Add(end, start).Sub(end, big.NewInt(1))
+	return bigRange{start: start, end: end}, nil
+}
+
+func (s *IPRangeSet) merge() {
+	if len(s.ranges) == 0 {
+		return
+	}
+	sort.Slice(s.ranges, func(i, j int) bool {
+		return s.ranges[i].start.Cmp(s.ranges[j].start) < 0
+	})
+	res := []bigRange{s.ranges[0]}
+	for _, r := range s.ranges[1:] {
+		last := &res[len(res)-1]
+		next := new(big.Int).Add(last.end, big.NewInt(1))
+		if r.start.Cmp(next) <= 0 {
+			if r.end.Cmp(last.end) > 0 {
+				last.end = r.end
+			}
+			continue
+		}
+		res = append(res, r)
+	}
+	s.ranges = res
+}
+
+// Size returns the number of IP addresses in the set
+func (s *IPRangeSet) Size() *big.Int {
+	res := big.NewInt(0)
+	for _, r := range s.ranges {
+		res.Add(res, r.size())
+	}
+	return res
+}
+
+// Contains reports whether ip is in the set
+func (s *IPRangeSet) Contains(ip net.IP) bool {
+	if !s.isVersion(ip) {
+		return false
+	}
+	i := ipToInt(ip)
+	for _, r := range s.ranges {
+		if r.start.Cmp(i) <= 0 && i.Cmp(r.end) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether any IP address of ipRange is in the set
+func (s *IPRangeSet) Overlaps(ipRange string) (bool, error) {
+	other, err := parseBigRange(s.version, ipRange)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range s.ranges {
+		if r.start.Cmp(other.end) <= 0 && other.start.Cmp(r.end) <= 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Ranges returns the merged ranges in the form of 'start-end', a range of
+// one IP address is the address itself
+func (s *IPRangeSet) Ranges() []string {
+	var res []string
+	for _, r := range s.ranges {
+		if r.start.Cmp(r.end) == 0 {
+			res = append(res, s.intToIP(r.start).String())
+		} else {
+			res = append(res, fmt.Sprintf("%s-%s", s.intToIP(r.start), s.intToIP(r.end)))
+		}
+	}
+	return res
+}
+
+// CountIn returns the number of distinct IP addresses of ips in the set
+func (s *IPRangeSet) CountIn(ips []net.IP) int64 {
+	return int64(len(s.sortedIn(ips)))
+}
+
+// RandomFree picks an IP address of the set not in used at random, it
+// returns false when all the IP addresses are used
+func (s *IPRangeSet) RandomFree(rander *rand.Rand, used []net.IP) (net.IP, bool) {
+	usedIn := s.sortedIn(used)
+	free := s.Size()
+	free.Sub(free, big.NewInt(int64(len(usedIn))))
+	if free.Sign() <= 0 {
+		return nil, false
+	}
+
+	// the k-th free IP address in the order of the set
+	k := new(big.Int).Rand(rander, free)
+	for _, r := range s.ranges {
+		var inRange []*big.Int
+		for _, u := range usedIn {
+			if r.start.Cmp(u) <= 0 && u.Cmp(r.end) <= 0 {
+				inRange = append(inRange, u)
+			}
+		}
+		rangeFree := r.size()
+		rangeFree.Sub(rangeFree, big.NewInt(int64(len(inRange))))
+		if k.Cmp(rangeFree) >= 0 {
+			k.Sub(k, rangeFree)
+			continue
+		}
+		candidate := new(big.Int).Add(r.start, k)
+		for _, u := range inRange {
+			if u.Cmp(candidate) <= 0 {
+				candidate.Add(candidate, big.NewInt(1))
+			}
+		}
+		return s.intToIP(candidate), true
+	}
+	return nil, false
+}
+
+// sortedIn returns the distinct IP addresses of ips in the set in ascending order
+func (s *IPRangeSet) sortedIn(ips []net.IP) []*big.Int {
+	seen := make(map[string]struct{}, len(ips))
+	var res []*big.Int
+	for _, ip := range ips {
+		if !s.Contains(ip) {
+			continue
+		}
+		i := ipToInt(ip)
+		if _, ok := seen[i.String()]; ok {
+			continue
+		}
+		seen[i.String()] = struct{}{}
+		res = append(res, i)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Cmp(res[j]) < 0
+	})
+	return res
+}
+
+func (s *IPRangeSet) isVersion(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if s.version == constant.IPv4 {
+		return ip.To4() != nil
+	}
+	return ip.To4() == nil
+}
+
+func (s *IPRangeSet) intToIP(i *big.Int) net.IP {
+	size := net.IPv6len
+	if s.version == constant.IPv4 {
+		size = net.IPv4len
+	}
+	return net.IP(i.FillBytes(make([]byte, size)))
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package utils_test
+
+import (
+	"math/big"
+	"math/rand"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/spidernet-io/egressgateway/pkg/constant"
+	"github.com/spidernet-io/egressgateway/pkg/utils"
+)
+
+var _ = Describe("IPRangeSet", Label("IPRangeSet"), func() {
+	It("merges the ranges, IPs and CIDRs", func() {
+		set, err := utils.NewIPRangeSet(constant.IPv4, []string{"10.6.1.5-10.6.1.9", "10.6.1.1", "10.6.1.2-10.6.1.4", "10.6.2.0/30"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(set.Ranges()).To(Equal([]string{"10.6.1.1-10.6.1.9", "10.6.2.0-10.6.2.3"}))
+		Expect(set.Size().Int64()).To(Equal(int64(13)))
+		Expect(set.Contains(net.ParseIP("10.6.2.3"))).To(BeTrue())
+		Expect(set.Contains(net.ParseIP("10.6.1.10"))).To(BeFalse())
+		Expect(set.Contains(net.ParseIP("fd00::1"))).To(BeFalse())
+	})
+
+	It("rejects the invalid ranges", func() {
+		_, err := utils.NewIPRangeSet(constant.IPv4, []string{"10.6.1.9-10.6.1.1"})
+		Expect(err).To(HaveOccurred())
+		_, err = utils.NewIPRangeSet(constant.IPv4, []string{"fd00::/64"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("handles an IPv6 /64 without expanding it", func() {
+		set, err := utils.NewIPRangeSet(constant.IPv6, []string{"fd00::/64"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(set.Size()).To(Equal(new(big.Int).Lsh(big.NewInt(1), 64)))
+		Expect(set.Contains(net.ParseIP("fd00::ffff:ffff:ffff:ffff"))).To(BeTrue())
+		Expect(set.Contains(net.ParseIP("fd00:0:0:1::"))).To(BeFalse())
+
+		ip, ok := set.RandomFree(rand.New(rand.NewSource(1)), nil)
+		Expect(ok).To(BeTrue())
+		Expect(set.Contains(ip)).To(BeTrue())
+	})
+
+	It("picks the free IPs only", func() {
+		set, err := utils.NewIPRangeSet(constant.IPv4, []string{"10.6.1.1-10.6.1.3", "10.6.1.10"})
+		Expect(err).NotTo(HaveOccurred())
+		used := []net.IP{net.ParseIP("10.6.1.1"), net.ParseIP("10.6.1.3"), net.ParseIP("10.6.1.10"), net.ParseIP("10.6.1.3")}
+		Expect(set.CountIn(used)).To(Equal(int64(3)))
+
+		rander := rand.New(rand.NewSource(1))
+		for i := 0; i < 10; i++ {
+			ip, ok := set.RandomFree(rander, used)
+			Expect(ok).To(BeTrue())
+			Expect(ip.String()).To(Equal("10.6.1.2"))
+		}
+
+		_, ok := set.RandomFree(rander, append(used, net.ParseIP("10.6.1.2")))
+		Expect(ok).To(BeFalse())
+	})
+})
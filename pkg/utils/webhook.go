@@ -0,0 +1,18 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// Denied returns a response denying the request, reason is a CamelCase
+// reason of the denial, such as InvalidDestPorts, it labels the metric of
+// the denials
+func Denied(reason metav1.StatusReason, msg string) webhook.AdmissionResponse {
+	resp := webhook.Denied(msg)
+	resp.Result.Reason = reason
+	return resp
+}